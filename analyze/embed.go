@@ -25,6 +25,9 @@ type EmbeddingConfig struct {
 
 	// SkipExisting skips nodes that already have embeddings
 	SkipExisting bool
+
+	// Filter, if set, limits embedding to the nodes it accepts
+	Filter func(node *graph.Node) bool
 }
 
 // DefaultEmbeddingConfig returns sensible defaults
@@ -228,6 +231,9 @@ func EmbedGraph(
 		if config.SkipExisting && index.Has(node.ID) {
 			continue
 		}
+		if config.Filter != nil && !config.Filter(node) {
+			continue
+		}
 
 		// Only embed functions, methods, and types for now
 		switch node.Kind {
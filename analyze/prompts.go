@@ -48,6 +48,8 @@ Be brief but comprehensive. Use technical terms appropriately.`
 		userContent.WriteString(fmt.Sprintf("\n\nExisting documentation:\n%s", source.Node.DocString))
 	}
 
+	writeMembers(&userContent, source)
+
 	return []Message{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: userContent.String()},
@@ -98,12 +100,28 @@ Be brief but comprehensive. Use technical terms appropriately.`
 		userContent.WriteString("\n```")
 	}
 
+	writeMembers(&userContent, source)
+
 	return []Message{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: userContent.String()},
 	}
 }
 
+// writeMembers appends the fields and method set of a type, which the
+// type's own source may not show (Go methods, impl blocks).
+func writeMembers(sb *strings.Builder, source *SymbolSource) {
+	if len(source.Node.Fields) > 0 {
+		sb.WriteString(fmt.Sprintf("\n\nFields: `%s`", strings.Join(source.Node.Fields, "`, `")))
+	}
+	if len(source.Methods) > 0 {
+		sb.WriteString("\n\nMethods:")
+		for _, m := range source.Methods {
+			sb.WriteString(fmt.Sprintf("\n- `%s`", m))
+		}
+	}
+}
+
 // SummarizeModulePrompt generates a prompt for summarizing a module/directory.
 func SummarizeModulePrompt(modulePath string, sources []*SymbolSource) []Message {
 	systemPrompt := `You are a code documentation expert. Summarize code modules clearly and concisely.
@@ -230,6 +248,8 @@ func kindName(node *graph.Node) string {
 		return "package"
 	case graph.KindVariable:
 		return "variable"
+	case graph.KindConstant:
+		return "constant"
 	default:
 		return "code"
 	}
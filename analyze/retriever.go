@@ -42,6 +42,9 @@ type SearchConfig struct {
 
 	// FuzzyMatch if true, uses substring matching for graph search
 	FuzzyMatch bool
+
+	// Filter, if set, keeps only the results it accepts (e.g. one workspace module)
+	Filter func(node *graph.Node) bool
 }
 
 // DefaultSearchConfig returns sensible search defaults
@@ -99,12 +102,18 @@ func (r *Retriever) Search(ctx context.Context, query string, config SearchConfi
 	var vectorResults []graph.SearchResult
 	var graphResults []graphMatch
 
+	// Look further down the rankings when a filter will drop candidates
+	candidates := config.Limit * 2
+	if config.Filter != nil {
+		candidates = config.Limit * 10
+	}
+
 	// Vector search
 	if config.Mode == SearchModeHybrid || config.Mode == SearchModeVector {
 		if r.vectorIndex != nil && r.vectorIndex.Count() > 0 && r.llmClient != nil {
 			queryVec, err := EmbedQuery(ctx, r.llmClient, query)
 			if err == nil && len(queryVec) > 0 {
-				results, err := r.vectorIndex.Search(queryVec, config.Limit*2)
+				results, err := r.vectorIndex.Search(queryVec, candidates)
 				if err == nil {
 					vectorResults = results
 				}
@@ -114,11 +123,20 @@ func (r *Retriever) Search(ctx context.Context, query string, config SearchConfi
 
 	// Graph search (name matching)
 	if config.Mode == SearchModeHybrid || config.Mode == SearchModeGraph {
-		graphResults = r.graphSearch(query, config.Limit*2, config.FuzzyMatch)
+		graphResults = r.graphSearch(query, candidates, config.FuzzyMatch)
 	}
 
 	// Combine results using Reciprocal Rank Fusion
 	combined := r.rankFusion(vectorResults, graphResults, config)
+	if config.Filter != nil {
+		kept := combined[:0]
+		for _, result := range combined {
+			if config.Filter(result.Node) {
+				kept = append(kept, result)
+			}
+		}
+		combined = kept
+	}
 
 	// Apply limit
 	if len(combined) > config.Limit {
@@ -154,8 +172,9 @@ func (r *Retriever) graphSearch(query string, limit int, fuzzy bool) []graphMatc
 	var matches []graphMatch
 
 	for _, node := range r.graph.Nodes {
-		// Skip file nodes for search (usually not what users want)
-		if node.Kind == graph.KindFile || node.Kind == graph.KindPackage {
+		// Skip file nodes for search (usually not what users want), and
+		// external symbols, which have no source
+		if node.Kind == graph.KindFile || node.Kind == graph.KindPackage || node.Kind == graph.KindExternal {
 			continue
 		}
 
@@ -344,7 +363,7 @@ func (r *Retriever) expandContext(result *HybridResult, depth int) {
 
 	// Add code snippet if available
 	if r.projectRoot != "" {
-		source, err := ReadSymbolSource(r.projectRoot, result.Node)
+		source, err := ReadGraphSymbolSource(r.graph, r.projectRoot, result.Node)
 		if err == nil && source != nil {
 			// Truncate for readability
 			snippet := source.Source
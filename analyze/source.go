@@ -28,6 +28,29 @@ type SymbolSource struct {
 
 	// Context provides surrounding code for context
 	Context *SourceContext
+
+	// Methods is the method set of a type (signatures where known)
+	Methods []string
+}
+
+// AddMethodSet records the methods a type node defines in the graph.
+// Methods may live outside the type's source (Go, C++, Rust impl blocks),
+// so they are folded into the content hash as well.
+func (s *SymbolSource) AddMethodSet(g *graph.CodeGraph) {
+	if s.Node.Kind != graph.KindType {
+		return
+	}
+	for _, m := range g.GetMethods(s.Node.ID) {
+		if m.Signature != "" {
+			s.Methods = append(s.Methods, m.Signature)
+		} else {
+			s.Methods = append(s.Methods, m.Name)
+		}
+	}
+	if len(s.Methods) > 0 {
+		hash := sha256.Sum256([]byte(s.ContentHash + "\n" + strings.Join(s.Methods, "\n")))
+		s.ContentHash = hex.EncodeToString(hash[:])
+	}
 }
 
 // SourceContext provides surrounding code context for a symbol.
@@ -43,8 +66,25 @@ type SourceContext struct {
 }
 
 // ReadSymbolSource extracts the source code for a symbol from its file.
-// The projectRoot is used to resolve relative paths in the node.
+// The projectRoot is used to resolve relative paths in the node. Without
+// the graph to tell whether the file changed, the node's lines are used.
 func ReadSymbolSource(projectRoot string, node *graph.Node) (*SymbolSource, error) {
+	return readSymbolSource(projectRoot, node, "")
+}
+
+// ReadGraphSymbolSource is ReadSymbolSource for a node of g: while the file
+// still has the content g recorded, the node's exact byte range is used.
+func ReadGraphSymbolSource(g *graph.CodeGraph, projectRoot string, node *graph.Node) (*SymbolSource, error) {
+	var hash string
+	if g != nil && node != nil {
+		hash = g.Files[node.Path].Hash
+	}
+	return readSymbolSource(projectRoot, node, hash)
+}
+
+// readSymbolSource reads a node's source; hash is the file's content hash
+// at indexing time, empty when unknown.
+func readSymbolSource(projectRoot string, node *graph.Node, hash string) (*SymbolSource, error) {
 	if node == nil {
 		return nil, fmt.Errorf("node is nil")
 	}
@@ -64,11 +104,14 @@ func ReadSymbolSource(projectRoot string, node *graph.Node) (*SymbolSource, erro
 
 	lines := strings.Split(string(content), "\n")
 
-	// Extract source based on line numbers
+	// Extract source from the exact byte range, falling back to line numbers
+	// for graphs built before ranges were recorded or when the file changed
 	var source string
-	if node.Line > 0 && node.EndLine > 0 {
+	if exact, ok := byteRange(content, node, hash); ok {
+		source = exact
+	} else if node.Line > 0 && node.EndLine > 0 {
 		// Extract specific lines (1-indexed to 0-indexed)
-		startLine := node.Line - 1
+		startLine := firstLine(node) - 1
 		endLine := node.EndLine
 
 		if startLine < 0 {
@@ -107,8 +150,8 @@ func ReadSymbolSource(projectRoot string, node *graph.Node) (*SymbolSource, erro
 	}
 
 	// Compute content hash
-	hash := sha256.Sum256([]byte(source))
-	contentHash := hex.EncodeToString(hash[:])
+	sum := sha256.Sum256([]byte(source))
+	contentHash := hex.EncodeToString(sum[:])
 
 	// Detect language from file extension
 	language := detectLanguage(node.Path)
@@ -121,6 +164,28 @@ func ReadSymbolSource(projectRoot string, node *graph.Node) (*SymbolSource, erro
 	}, nil
 }
 
+// byteRange returns the recorded byte range of a node if the file content
+// still hashes to hash, the hash recorded when the node was indexed.
+func byteRange(content []byte, node *graph.Node, hash string) (string, bool) {
+	if hash == "" || node.EndByte <= node.StartByte || node.EndByte > len(content) {
+		return "", false
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != hash {
+		return "", false
+	}
+	return string(content[node.StartByte:node.EndByte]), true
+}
+
+// firstLine returns the first line of a node's definition, which precedes
+// the name line for decorated or annotated definitions.
+func firstLine(node *graph.Node) int {
+	if node.StartLine > 0 && node.StartLine < node.Line {
+		return node.StartLine
+	}
+	return node.Line
+}
+
 // ReadSymbolSourceWithContext extracts source code with surrounding context.
 func ReadSymbolSourceWithContext(projectRoot string, node *graph.Node, contextLines int) (*SymbolSource, error) {
 	source, err := ReadSymbolSource(projectRoot, node)
@@ -142,12 +207,12 @@ func ReadSymbolSourceWithContext(projectRoot string, node *graph.Node, contextLi
 	lines := strings.Split(string(content), "\n")
 
 	// Extract context before
-	if node.Line > 1 {
-		startContext := node.Line - 1 - contextLines
+	if start := firstLine(node); start > 1 {
+		startContext := start - 1 - contextLines
 		if startContext < 0 {
 			startContext = 0
 		}
-		endContext := node.Line - 1
+		endContext := start - 1
 		source.Context = &SourceContext{
 			Before: strings.Join(lines[startContext:endContext], "\n"),
 		}
@@ -0,0 +1,57 @@
+package analyze
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"codemap/graph"
+)
+
+func TestReadGraphSymbolSource(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "greet.go")
+	original := "package greet\n\n// Greet says hi.\nfunc Greet() string { return \"hi\" }\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	state, err := graph.HashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := graph.NewCodeGraph(root)
+	g.Files["greet.go"] = state
+	start := len("package greet\n\n// Greet says hi.\n")
+	node := &graph.Node{
+		Kind:      graph.KindFunction,
+		Name:      "Greet",
+		Path:      "greet.go",
+		Line:      4,
+		StartLine: 3,
+		EndLine:   4,
+		StartByte: start,
+		EndByte:   len(original) - 1,
+	}
+
+	source, err := ReadGraphSymbolSource(g, root, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "func Greet() string { return \"hi\" }"; source.Source != want {
+		t.Errorf("fresh graph: source = %q, want %q", source.Source, want)
+	}
+
+	// Shift the function down; the old range still contains "Greet"
+	stale := "package greet\n\n// Greet says hi\n// to everyone.\nfunc Greet() string { return \"hi\" }\n"
+	if err := os.WriteFile(path, []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	source, err = ReadGraphSymbolSource(g, root, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "// Greet says hi\n// to everyone."; source.Source != want {
+		t.Errorf("stale graph: source = %q, want the recorded lines %q", source.Source, want)
+	}
+}
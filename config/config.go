@@ -74,6 +74,20 @@ type CacheConfig struct {
 	TTLDays int `yaml:"ttl_days"`
 }
 
+// IgnoreConfig adjusts the directory names scanning always skips
+// (scanner.IgnoredDirs) and whether generated files are indexed. Path
+// patterns belong in .codemapignore.
+type IgnoreConfig struct {
+	// AddDirs are directory names to skip besides the defaults
+	AddDirs []string `yaml:"add_dirs"`
+
+	// RemoveDirs are default ignored names to scan after all (e.g. "vendor", "build")
+	RemoveDirs []string `yaml:"remove_dirs"`
+
+	// IncludeGenerated indexes generated files (protobuf stubs, "DO NOT EDIT" headers)
+	IncludeGenerated bool `yaml:"include_generated"`
+}
+
 // Config is the main configuration structure.
 type Config struct {
 	// LLM holds LLM integration settings
@@ -82,6 +96,9 @@ type Config struct {
 	// Cache holds caching settings
 	Cache CacheConfig `yaml:"cache"`
 
+	// Ignore adjusts which directories are scanned
+	Ignore IgnoreConfig `yaml:"ignore"`
+
 	// Debug enables verbose logging
 	Debug bool `yaml:"debug"`
 }
@@ -152,6 +169,35 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// LoadIgnore reads the ignore settings of the user config and of the
+// project config under root, without the LLM validation and environment
+// handling of Load: scanning works with any LLM configuration. Entries of
+// both files apply.
+func LoadIgnore(root string) IgnoreConfig {
+	var paths []string
+	if path, err := userConfigPath(); err == nil {
+		paths = append(paths, path)
+	}
+	paths = append(paths, filepath.Join(root, ".codemap", "config.yaml"))
+
+	var ignore IgnoreConfig
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var cfg struct {
+			Ignore IgnoreConfig `yaml:"ignore"`
+		}
+		if yaml.Unmarshal(data, &cfg) == nil {
+			ignore.AddDirs = append(ignore.AddDirs, cfg.Ignore.AddDirs...)
+			ignore.RemoveDirs = append(ignore.RemoveDirs, cfg.Ignore.RemoveDirs...)
+			ignore.IncludeGenerated = ignore.IncludeGenerated || cfg.Ignore.IncludeGenerated
+		}
+	}
+	return ignore
+}
+
 // LoadFromPath reads configuration from a specific file path.
 func LoadFromPath(path string) (*Config, error) {
 	cfg := DefaultConfig()
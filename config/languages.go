@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Language describes a tree-sitter language to analyze besides (or in place
+// of) the built-in ones:
+//
+//	languages:
+//	  elixir:
+//	    extensions: [.ex, .exs]
+//	    shebangs: [elixir]
+//	    grammar: grammars/libtree-sitter-elixir.so
+//	    queries:
+//	      symbols: queries/elixir.scm
+//	      calls: queries/elixir.calls.scm
+//
+// Relative paths resolve against the directory of the file defining the
+// language. Without a grammar, libtree-sitter-<name> is looked up in
+// LanguagesDir and then in the grammar directory; without queries,
+// <name>.scm, <name>.calls.scm and <name>.refs.scm in the project's
+// .codemap/queries and then LanguagesDir. A definition named after a
+// built-in language adds its extensions and shebangs to it and replaces
+// the grammar and queries it sets.
+type Language struct {
+	Name       string   `yaml:"-"`          // Key in the languages map, or the file name
+	Display    string   `yaml:"display"`    // Full name (default: Name)
+	Short      string   `yaml:"short"`      // Compact label (default: Display)
+	Extensions []string `yaml:"extensions"` // File extensions with the dot (".ex")
+	Shebangs   []string `yaml:"shebangs"`   // Interpreters named by #! lines ("elixir")
+	Grammar    string   `yaml:"grammar"`    // Grammar shared library
+	Symbol     string   `yaml:"symbol"`     // Language function the library exports (default: tree_sitter_<name>)
+	Queries    struct {
+		Symbols string `yaml:"symbols"` // Definitions and imports, as the built-in queries/*.scm
+		Calls   string `yaml:"calls"`   // Call sites (@call.name, @call.receiver, @call.args)
+		Refs    string `yaml:"refs"`    // Type references (@ref.type)
+	} `yaml:"queries"`
+
+	Dir string `yaml:"-"` // Directory of the defining file
+}
+
+// LanguagesDir returns the directory of per-language definitions,
+// ~/.config/codemap/languages (or under XDG_CONFIG_HOME).
+func LanguagesDir() (string, error) {
+	path, err := userConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "languages"), nil
+}
+
+// LoadLanguages reads the language definitions that apply to the project at
+// root: the languages section of the user config, the files of
+// LanguagesDir (elixir.yaml defines elixir) and the languages section of
+// the project config, a later definition replacing an earlier one of the
+// same name. Files that can't be parsed are reported in the error; the
+// definitions of the others are returned, ordered by name.
+func LoadLanguages(root string) ([]Language, error) {
+	byName := make(map[string]Language)
+	var errs []error
+
+	readSection := func(path string) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		var cfg struct {
+			Languages map[string]Language `yaml:"languages"`
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			errs = append(errs, fmt.Errorf("parsing %s: %w", path, err))
+			return
+		}
+		for name, lang := range cfg.Languages {
+			lang.Name, lang.Dir = name, filepath.Dir(path)
+			byName[name] = lang
+		}
+	}
+
+	if path, err := userConfigPath(); err == nil {
+		readSection(path)
+	}
+	if dir, err := LanguagesDir(); err == nil {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.yaml"))
+		more, _ := filepath.Glob(filepath.Join(dir, "*.yml"))
+		for _, path := range append(files, more...) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			var lang Language
+			if err := yaml.Unmarshal(data, &lang); err != nil {
+				errs = append(errs, fmt.Errorf("parsing %s: %w", path, err))
+				continue
+			}
+			lang.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			lang.Dir = dir
+			byName[lang.Name] = lang
+		}
+	}
+	readSection(filepath.Join(root, ".codemap", "config.yaml"))
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var langs []Language
+	for _, name := range names {
+		lang := byName[name]
+		if lang.Display == "" {
+			lang.Display = name
+		}
+		if lang.Short == "" {
+			lang.Short = lang.Display
+		}
+		for i, ext := range lang.Extensions {
+			if !strings.HasPrefix(ext, ".") {
+				lang.Extensions[i] = "." + ext
+			}
+		}
+		langs = append(langs, lang)
+	}
+	return langs, errors.Join(errs...)
+}
+
+// Path resolves a path of the definition against its directory.
+func (l *Language) Path(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	return filepath.Join(l.Dir, p)
+}
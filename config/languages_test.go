@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadLanguages(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	userDir := filepath.Join(config, "codemap")
+	langDir := filepath.Join(userDir, "languages")
+	root := t.TempDir()
+
+	writeFiles(t, userDir, map[string]string{
+		"config.yaml": `languages:
+  elixir:
+    extensions: [.ex]
+  zig:
+    display: Zig
+    extensions: [zig]
+`,
+		"languages/elixir.yaml": "extensions: [.ex, .exs]\nshebangs: [elixir]\n",
+		"languages/nim.yml":     "short: Nim\nextensions: [.nim]\n",
+		"languages/broken.yaml": "extensions: [\n",
+	})
+	writeFiles(t, root, map[string]string{
+		".codemap/config.yaml": `languages:
+  zig:
+    extensions: [.zig, .zon]
+    grammar: grammars/zig.so
+`,
+	})
+
+	langs, err := LoadLanguages(root)
+	if err == nil || !strings.Contains(err.Error(), "broken.yaml") {
+		t.Errorf("error = %v, want broken.yaml reported", err)
+	}
+
+	var names []string
+	byName := make(map[string]Language)
+	for _, l := range langs {
+		names = append(names, l.Name)
+		byName[l.Name] = l
+	}
+	if got := strings.Join(names, ","); got != "elixir,nim,zig" {
+		t.Fatalf("languages = %s, want elixir,nim,zig", got)
+	}
+
+	// The languages directory replaces the user config's definition
+	elixir := byName["elixir"]
+	if strings.Join(elixir.Extensions, ",") != ".ex,.exs" || elixir.Dir != langDir {
+		t.Errorf("elixir = %+v, want the definition of elixir.yaml", elixir)
+	}
+	if elixir.Display != "elixir" || elixir.Short != "elixir" {
+		t.Errorf("elixir names = %q/%q, want the language name", elixir.Display, elixir.Short)
+	}
+	if nim := byName["nim"]; nim.Display != "nim" || nim.Short != "Nim" {
+		t.Errorf("nim names = %q/%q, want nim/Nim", nim.Display, nim.Short)
+	}
+
+	// The project config replaces both, and its paths are its own
+	zig := byName["zig"]
+	if strings.Join(zig.Extensions, ",") != ".zig,.zon" || zig.Display != "zig" {
+		t.Errorf("zig = %+v, want the project definition", zig)
+	}
+	if got, want := zig.Path(zig.Grammar), filepath.Join(root, ".codemap", "grammars", "zig.so"); got != want {
+		t.Errorf("zig grammar = %s, want %s", got, want)
+	}
+}
+
+func TestLanguagePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	l := Language{Dir: filepath.FromSlash("/etc/codemap")}
+	abs := filepath.Join(t.TempDir(), "lib.so")
+	tests := map[string]string{
+		"":                "",
+		"lib.so":          filepath.Join(l.Dir, "lib.so"),
+		abs:               abs,
+		"~/grammars/a.so": filepath.Join(home, "grammars", "a.so"),
+	}
+	for p, want := range tests {
+		if got := l.Path(p); got != want {
+			t.Errorf("Path(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
@@ -6,17 +6,63 @@ import (
 	"strings"
 	"time"
 
-	ignore "github.com/sabhiram/go-gitignore"
+	"codemap/internal/parallel"
 )
 
 // Builder constructs a CodeGraph from a codebase.
 type Builder struct {
 	graph      *CodeGraph
 	rootPath   string
-	gitignore  *ignore.GitIgnore
+	ignore     PathMatcher
 	progress   func(msg string)
 	fileCount  int
 	errorCount int
+	workers    int // Files prepared concurrently by AddFiles (0 = GOMAXPROCS)
+
+	// Type hierarchy state, resolved by ResolveTypeHierarchy
+	pendingSupers []pendingSuper
+	goMethods     map[string]map[string]bool // "dir:Type" -> method names
+	goInterfaces  map[NodeID][]string        // interface node -> declared methods
+
+	pendingRefs    []pendingRef    // Type references, resolved by ResolveReferences
+	pendingMethods []pendingMethod // Method owners, resolved by ResolveMethods
+	pendingCalls   []pendingCall   // Calls left to other files, resolved by ResolveCallEdges
+}
+
+// pendingCall is a call edge whose callee lies outside the caller's file,
+// with what the scanner inferred about the call's receiver.
+type pendingCall struct {
+	edge            *Edge
+	receiverType    string
+	receiverPackage string
+	inference       string
+}
+
+// pendingMethod is a method awaiting a defines edge from its owning type.
+type pendingMethod struct {
+	method NodeID
+	path   string
+	owner  string
+	line   int
+}
+
+// pendingRef is a type reference awaiting resolution to a type node.
+type pendingRef struct {
+	from  NodeID
+	path  string
+	name  string
+	value bool // Variable/constant use rather than a type use
+	line  int
+}
+
+// pendingSuper is a declared supertype awaiting resolution to a type node.
+type pendingSuper struct {
+	from     NodeID // Subtype node (empty for impl blocks, resolved by fromName)
+	fromName string
+	path     string
+	name     string
+	kind     EdgeKind
+	line     int
 }
 
 // BuilderOption configures the graph builder.
@@ -29,26 +75,54 @@ func WithProgress(fn func(msg string)) BuilderOption {
 	}
 }
 
-// WithGitignore sets the gitignore matcher.
-func WithGitignore(gi *ignore.GitIgnore) BuilderOption {
+// PathMatcher reports whether a path relative to the project root is
+// ignored (scanner.IgnoreMatcher).
+type PathMatcher interface {
+	MatchesPath(relPath string) bool
+}
+
+// WithGitignore sets the ignore matcher.
+func WithGitignore(m PathMatcher) BuilderOption {
 	return func(b *Builder) {
-		b.gitignore = gi
+		b.ignore = m
 	}
 }
 
-// WithExistingGraph sets an existing graph for incremental updates.
+// WithWorkers sets how many files AddFiles prepares concurrently.
+func WithWorkers(n int) BuilderOption {
+	return func(b *Builder) {
+		b.workers = n
+	}
+}
+
+// WithExistingGraph sets an existing graph for incremental updates. The
+// methods of its Go types count toward the method sets that satisfy
+// interfaces, so the Go files declaring interfaces are the only ones that
+// need analyzing again for structural satisfaction (see GoInterfaceFiles).
 func WithExistingGraph(g *CodeGraph) BuilderOption {
 	return func(b *Builder) {
 		b.graph = g
+		for _, n := range g.Nodes {
+			if n.Owner == "" || (n.Kind != KindMethod && n.Kind != KindFunction) || filepath.Ext(n.Path) != ".go" {
+				continue
+			}
+			key := goTypeKey(n.Path, n.Owner)
+			if b.goMethods[key] == nil {
+				b.goMethods[key] = make(map[string]bool)
+			}
+			b.goMethods[key][n.Name] = true
+		}
 	}
 }
 
 // NewBuilder creates a new graph builder.
 func NewBuilder(rootPath string, opts ...BuilderOption) *Builder {
 	b := &Builder{
-		graph:    NewCodeGraph(rootPath),
-		rootPath: rootPath,
-		progress: func(msg string) {}, // Default: no-op
+		graph:        NewCodeGraph(rootPath),
+		rootPath:     rootPath,
+		progress:     func(msg string) {}, // Default: no-op
+		goMethods:    make(map[string]map[string]bool),
+		goInterfaces: make(map[NodeID][]string),
 	}
 
 	for _, opt := range opts {
@@ -60,12 +134,19 @@ func NewBuilder(rootPath string, opts ...BuilderOption) *Builder {
 
 // FileAnalysis represents the analysis result from scanner.
 type FileAnalysis struct {
-	Path      string
-	Language  string
-	Functions []FuncInfo
-	Types     []TypeInfo
-	Imports   []string
-	Calls     []CallInfo
+	Path          string
+	Language      string
+	Package       string // Fully qualified package, namespace or module
+	Functions     []FuncInfo
+	Types         []TypeInfo
+	Variables     []VarInfo
+	Imports       []string
+	ImportedFiles []string // Scanned files the imports resolve to
+	Size          int64    // Content size in bytes
+	Hash          string   // Hex SHA-256 of the content; recorded in CodeGraph.Files when set
+	Calls         []CallInfo
+	Impls         []ImplInfo
+	References    []RefInfo
 }
 
 // FuncInfo represents a function/method from scanner.
@@ -73,10 +154,15 @@ type FuncInfo struct {
 	Name       string
 	Signature  string
 	Receiver   string
+	Owner      string // Enclosing class/struct/trait or Go receiver type
 	IsExported bool
 	Line       int
+	StartLine  int // First line of the definition (decorators included)
 	EndLine    int
-	ParamCount int // Number of parameters (-1 if unknown/variadic)
+	StartByte  int // Byte offsets of the definition (EndByte exclusive)
+	EndByte    int
+	ParamCount int    // Number of parameters (-1 if unknown/variadic)
+	DocString  string // Doc comment or docstring
 }
 
 // TypeInfo represents a type definition from scanner.
@@ -85,6 +171,40 @@ type TypeInfo struct {
 	Kind       string
 	IsExported bool
 	Line       int
+	StartLine  int // First line of the definition
+	EndLine    int // Last line of the definition
+	StartByte  int // Byte offsets of the definition (EndByte exclusive)
+	EndByte    int
+	DocString  string   // Doc comment or docstring
+	Extends    []string // Declared superclasses / parent interfaces
+	Implements []string // Declared interfaces / protocols
+	Methods    []string // Method names (Go interfaces: the method set)
+	Fields     []string // Field and property names
+}
+
+// VarInfo represents a package-level variable or constant from scanner.
+type VarInfo struct {
+	Name       string
+	IsConst    bool
+	IsExported bool
+	Line       int
+	EndLine    int
+}
+
+// ImplInfo represents a conformance declared outside the type (Rust impl blocks).
+type ImplInfo struct {
+	Type  string
+	Trait string
+	Line  int
+}
+
+// RefInfo represents a type or variable reference from scanner.
+type RefInfo struct {
+	From     string // Enclosing function/type ("" for file scope)
+	FromLine int    // Definition line of From
+	Name     string // Referenced type or variable name
+	IsValue  bool   // Variable/constant use rather than a type use
+	Line     int
 }
 
 // CallInfo represents a function call from scanner.
@@ -95,6 +215,10 @@ type CallInfo struct {
 	CallLine   int
 	Args       int
 	Receiver   string
+
+	ReceiverType    string // Inferred static type of Receiver
+	ReceiverPackage string // Package Receiver names when it is an import alias, or that declares ReceiverType
+	Inference       string // How ReceiverType/ReceiverPackage were inferred
 }
 
 // AddFile adds a file's analysis to the graph.
@@ -102,9 +226,93 @@ func (b *Builder) AddFile(analysis *FileAnalysis) error {
 	if analysis == nil {
 		return nil
 	}
+	b.merge(prepareFile(analysis))
+	return nil
+}
 
+// AddFiles adds several analyses, preparing their nodes and edges on the
+// builder's worker pool. Results are merged in input order, so the graph is
+// identical to adding the files one by one.
+func (b *Builder) AddFiles(analyses []*FileAnalysis) {
+	fragments := make([]*fileFragment, len(analyses))
+	parallel.For(len(analyses), b.workers, func(i int) {
+		if analyses[i] != nil {
+			fragments[i] = prepareFile(analyses[i])
+		}
+	})
+	for _, f := range fragments {
+		if f != nil {
+			b.merge(f)
+		}
+	}
+}
+
+// fileFragment holds the nodes, edges and pending resolutions of one file.
+// Fragments are built independently and merged into the graph in order.
+type fileFragment struct {
+	path         string
+	state        FileState
+	nodes        []*Node
+	nodeIDs      map[NodeID]bool
+	edges        []*Edge
+	supers       []pendingSuper
+	refs         []pendingRef
+	methods      []pendingMethod
+	calls        []pendingCall
+	goMethods    map[string][]string // "dir:Type" -> method names
+	goInterfaces map[NodeID][]string
+}
+
+func (f *fileFragment) addNode(n *Node) {
+	f.nodes = append(f.nodes, n)
+	f.nodeIDs[n.ID] = true
+}
+
+func (f *fileFragment) addEdge(e *Edge) {
+	f.edges = append(f.edges, e)
+}
+
+// merge adds a prepared file to the graph and queues its pending resolutions.
+func (b *Builder) merge(f *fileFragment) {
 	b.fileCount++
-	b.progress(fmt.Sprintf("Processing %s", analysis.Path))
+	b.progress(fmt.Sprintf("Processing %s", f.path))
+
+	if f.state.Hash != "" {
+		b.graph.Files[f.path] = f.state
+	}
+	for _, n := range f.nodes {
+		b.graph.AddNode(n)
+	}
+	for _, e := range f.edges {
+		b.graph.AddEdge(e)
+	}
+	b.pendingSupers = append(b.pendingSupers, f.supers...)
+	b.pendingRefs = append(b.pendingRefs, f.refs...)
+	b.pendingMethods = append(b.pendingMethods, f.methods...)
+	b.pendingCalls = append(b.pendingCalls, f.calls...)
+	for key, methods := range f.goMethods {
+		if b.goMethods[key] == nil {
+			b.goMethods[key] = make(map[string]bool)
+		}
+		for _, m := range methods {
+			b.goMethods[key][m] = true
+		}
+	}
+	for id, methods := range f.goInterfaces {
+		b.goInterfaces[id] = methods
+	}
+}
+
+// prepareFile turns a file's analysis into graph nodes and edges without
+// touching the builder, so files can be prepared concurrently.
+func prepareFile(analysis *FileAnalysis) *fileFragment {
+	f := &fileFragment{
+		path:         analysis.Path,
+		state:        FileState{Size: analysis.Size, Hash: analysis.Hash},
+		nodeIDs:      make(map[NodeID]bool),
+		goMethods:    make(map[string][]string),
+		goInterfaces: make(map[NodeID][]string),
+	}
 
 	// Create file node
 	fileID := GenerateNodeID(analysis.Path, "")
@@ -113,30 +321,52 @@ func (b *Builder) AddFile(analysis *FileAnalysis) error {
 		Kind:    KindFile,
 		Name:    filepath.Base(analysis.Path),
 		Path:    analysis.Path,
-		Package: getPackageFromPath(analysis.Path),
+		Package: analysis.Package,
 	}
-	b.graph.AddNode(fileNode)
+	f.addNode(fileNode)
 
-	// Process functions
-	funcNodes := make(map[string]NodeID) // name -> nodeID for call resolution
-	for _, fn := range analysis.Functions {
-		funcID := GenerateNodeID(analysis.Path, fn.Name)
+	// Process functions. Node identity is the owner-qualified name
+	// (Reader.Close), so same-named methods of different types don't collide.
+	pkg := analysis.Package
+	keys := functionKeys(analysis.Functions)
+	symbols := make(map[string]NodeID)  // "name:line" -> nodeID for callers and references
+	funcNodes := make(map[string][]int) // name -> indexes into analysis.Functions
+	funcIDs := make([]NodeID, len(analysis.Functions))
+	for i, fn := range analysis.Functions {
+		funcID := GenerateNodeID(analysis.Path, keys[i])
 		funcNode := &Node{
-			ID:         funcID,
-			Kind:       kindFromFunc(fn),
-			Name:       fn.Name,
-			Path:       analysis.Path,
-			Line:       fn.Line,
-			EndLine:    fn.EndLine,
-			Signature:  fn.Signature,
-			Exported:   fn.IsExported,
-			ParamCount: fn.ParamCount,
-		}
-		b.graph.AddNode(funcNode)
-		funcNodes[fn.Name] = funcID
+			ID:            funcID,
+			Kind:          kindFromFunc(fn),
+			Name:          fn.Name,
+			QualifiedName: qualifiedName(pkg, keys[i]),
+			Owner:         fn.Owner,
+			Path:          analysis.Path,
+			Package:       pkg,
+			Line:          fn.Line,
+			StartLine:     fn.StartLine,
+			EndLine:       fn.EndLine,
+			StartByte:     fn.StartByte,
+			EndByte:       fn.EndByte,
+			Signature:     fn.Signature,
+			DocString:     fn.DocString,
+			Exported:      fn.IsExported,
+			ParamCount:    fn.ParamCount,
+		}
+		f.addNode(funcNode)
+		funcIDs[i] = funcID
+		funcNodes[fn.Name] = append(funcNodes[fn.Name], i)
+		symbols[symbolKey(fn.Name, fn.Line)] = funcID
+
+		if fn.Owner != "" {
+			f.methods = append(f.methods, pendingMethod{method: funcID, path: analysis.Path, owner: fn.Owner, line: fn.Line})
+		}
+		if analysis.Language == "go" && fn.Owner != "" {
+			key := goTypeKey(analysis.Path, fn.Owner)
+			f.goMethods[key] = append(f.goMethods[key], fn.Name)
+		}
 
 		// File contains function
-		b.graph.AddEdge(&Edge{
+		f.addEdge(&Edge{
 			From: fileID,
 			To:   funcID,
 			Kind: EdgeContains,
@@ -147,23 +377,89 @@ func (b *Builder) AddFile(analysis *FileAnalysis) error {
 	for _, t := range analysis.Types {
 		typeID := GenerateNodeID(analysis.Path, t.Name)
 		typeNode := &Node{
-			ID:       typeID,
-			Kind:     KindType,
-			Name:     t.Name,
-			Path:     analysis.Path,
-			Line:     t.Line,
-			Exported: t.IsExported,
+			ID:            typeID,
+			Kind:          KindType,
+			Name:          t.Name,
+			QualifiedName: qualifiedName(pkg, t.Name),
+			Path:          analysis.Path,
+			Package:       pkg,
+			Line:          t.Line,
+			StartLine:     t.StartLine,
+			EndLine:       t.EndLine,
+			StartByte:     t.StartByte,
+			EndByte:       t.EndByte,
+			DocString:     t.DocString,
+			Exported:      t.IsExported,
+			TypeKind:      t.Kind,
+			Fields:        t.Fields,
+		}
+		f.addNode(typeNode)
+		if key := symbolKey(t.Name, t.Line); symbols[key] == "" {
+			symbols[key] = typeID
+		}
+
+		for _, name := range t.Extends {
+			f.supers = append(f.supers, pendingSuper{from: typeID, path: analysis.Path, name: name, kind: EdgeExtends, line: t.Line})
+		}
+		for _, name := range t.Implements {
+			f.supers = append(f.supers, pendingSuper{from: typeID, path: analysis.Path, name: name, kind: EdgeImplements, line: t.Line})
+		}
+		if analysis.Language == "go" && t.Kind == "interface" {
+			f.goInterfaces[typeID] = t.Methods // Possibly none besides embedded interfaces
 		}
-		b.graph.AddNode(typeNode)
 
 		// File contains type
-		b.graph.AddEdge(&Edge{
+		f.addEdge(&Edge{
 			From: fileID,
 			To:   typeID,
 			Kind: EdgeContains,
 		})
 	}
 
+	// Process package-level variables and constants
+	for _, v := range analysis.Variables {
+		varID := GenerateNodeID(analysis.Path, v.Name)
+		if f.nodeIDs[varID] {
+			continue // Already defined as a function or type (e.g. const f = () => {})
+		}
+		kind := KindVariable
+		if v.IsConst {
+			kind = KindConstant
+		}
+		f.addNode(&Node{
+			ID:            varID,
+			Kind:          kind,
+			Name:          v.Name,
+			QualifiedName: qualifiedName(pkg, v.Name),
+			Path:          analysis.Path,
+			Package:       pkg,
+			Line:          v.Line,
+			EndLine:       v.EndLine,
+			Exported:      v.IsExported,
+		})
+
+		// File contains variable
+		f.addEdge(&Edge{
+			From: fileID,
+			To:   varID,
+			Kind: EdgeContains,
+		})
+	}
+
+	// Conformances declared outside the type (resolved with the hierarchy)
+	for _, impl := range analysis.Impls {
+		f.supers = append(f.supers, pendingSuper{fromName: impl.Type, path: analysis.Path, name: impl.Trait, kind: EdgeImplements, line: impl.Line})
+	}
+
+	// Type references (resolved once all types are known)
+	for _, ref := range analysis.References {
+		fromID := fileID
+		if id, ok := symbols[symbolKey(ref.From, ref.FromLine)]; ok && ref.From != "" {
+			fromID = id
+		}
+		f.refs = append(f.refs, pendingRef{from: fromID, path: analysis.Path, name: ref.Name, value: ref.IsValue, line: ref.Line})
+	}
+
 	// Process imports
 	for _, imp := range analysis.Imports {
 		impID := GenerateNodeID(imp, "")
@@ -173,43 +469,82 @@ func (b *Builder) AddFile(analysis *FileAnalysis) error {
 			Name: filepath.Base(imp),
 			Path: imp,
 		}
-		b.graph.AddNode(impNode)
+		f.addNode(impNode)
 
 		// File imports package
-		b.graph.AddEdge(&Edge{
+		f.addEdge(&Edge{
 			From: fileID,
 			To:   impID,
 			Kind: EdgeImports,
 		})
 	}
 
+	// File imports the scanned files its imports resolve to
+	for _, path := range analysis.ImportedFiles {
+		f.addEdge(&Edge{
+			From: fileID,
+			To:   GenerateNodeID(path, ""),
+			Kind: EdgeImports,
+		})
+	}
+
 	// Process calls (create edges between functions)
 	for _, call := range analysis.Calls {
-		// Find caller node
-		callerID, ok := funcNodes[call.CallerFunc]
+		// Find caller node by name and definition line
+		callerID, ok := symbols[symbolKey(call.CallerFunc, call.CallerLine)]
 		if !ok {
 			continue // Caller not found (might be global scope)
 		}
+		var caller FuncInfo
+		for _, i := range funcNodes[call.CallerFunc] {
+			if analysis.Functions[i].Line == call.CallerLine {
+				caller = analysis.Functions[i]
+			}
+		}
 
-		// Try to find callee node
-		// First, look in the same file
-		calleeID, ok := funcNodes[call.CalleeName]
-		if !ok {
-			// Create a placeholder node for the callee (will be resolved later)
-			calleeID = GenerateNodeID("", call.CalleeName) // Global lookup
+		// Try to find callee node in the same file. A receiver of known type
+		// only matches that type's methods; imported packages live elsewhere.
+		var calleeID NodeID
+		var resolution string
+		switch {
+		case call.ReceiverPackage != "":
+		case call.ReceiverType != "":
+			if i := pickMethod(analysis.Functions, funcNodes[call.CalleeName], call.ReceiverType, call.Args); i >= 0 {
+				calleeID, resolution = funcIDs[i], call.Inference
+			}
+		default:
+			if i := pickCallee(analysis.Functions, funcNodes[call.CalleeName], call, caller); i >= 0 {
+				calleeID, resolution = funcIDs[i], ResolutionSameFile
+				if owner := callOwner(call, caller); owner != "" && analysis.Functions[i].Owner == owner {
+					resolution = ResolutionReceiver
+				}
+			}
 		}
 
-		b.graph.AddEdge(&Edge{
-			From:     callerID,
-			To:       calleeID,
-			Kind:     EdgeCalls,
-			Line:     call.CallLine,
-			CallSite: call.CalleeName,
-			ArgCount: call.Args,
-		})
+		edge := &Edge{
+			From:       callerID,
+			To:         calleeID,
+			Kind:       EdgeCalls,
+			Line:       call.CallLine,
+			Weight:     ResolutionConfidence(resolution),
+			CallSite:   call.CalleeName,
+			ArgCount:   call.Args,
+			Resolution: resolution,
+		}
+		if calleeID == "" {
+			// Placeholder callee, resolved across files by ResolveCallEdges
+			edge.To = GenerateNodeID("", call.CalleeName)
+			f.calls = append(f.calls, pendingCall{
+				edge:            edge,
+				receiverType:    call.ReceiverType,
+				receiverPackage: call.ReceiverPackage,
+				inference:       call.Inference,
+			})
+		}
+		f.addEdge(edge)
 	}
 
-	return nil
+	return f
 }
 
 // Build finalizes the graph and returns it.
@@ -225,15 +560,36 @@ func (b *Builder) Build() *CodeGraph {
 }
 
 // ResolveCallEdges attempts to resolve placeholder callee nodes to actual nodes.
-// Call this after all files have been added.
+// Calls whose receiver type or package was inferred resolve to a method of
+// that type (or a supertype) or a function of that package. When the type
+// or package belongs to a package the caller's file imports from outside
+// the project, the callee is an external symbol node (see externalCallee).
+// Calls on a type or package of the graph that lacks the method or function
+// stay unresolved; calls on receivers outside it, and other calls, resolve
+// by name. Each resolved edge records its method and confidence. Call this
+// after all files have been added.
 func (b *Builder) ResolveCallEdges() {
 	// Build name lookup index
 	nameToNodes := make(map[string][]*Node)
-	for _, node := range b.graph.Nodes {
-		if node.Kind == KindFunction || node.Kind == KindMethod {
+	typeNames := make(map[string]bool)
+	packages := make(map[string]bool)
+	for _, node := range b.graph.SortedNodes() {
+		switch node.Kind {
+		case KindFunction, KindMethod:
 			nameToNodes[node.Name] = append(nameToNodes[node.Name], node)
+			packages[node.Package] = true
+		case KindType:
+			typeNames[node.Name] = true
+		case KindFile:
+			packages[node.Package] = true
 		}
 	}
+	imports := b.graph.importedPackages()
+	hints := make(map[*Edge]pendingCall, len(b.pendingCalls))
+	for _, p := range b.pendingCalls {
+		hints[p.edge] = p
+	}
+	supers := b.supertypeNames()
 
 	// Update edges
 	for _, edge := range b.graph.Edges {
@@ -246,47 +602,592 @@ func (b *Builder) ResolveCallEdges() {
 			continue
 		}
 
-		// Try to find by name
 		candidates := nameToNodes[edge.CallSite]
+		callerNode := b.graph.GetNode(edge.From)
+
+		// Typed receiver: the callee must belong to the inferred type or package
+		if p := hints[edge]; p.receiverPackage != "" || p.receiverType != "" {
+			var callee *Node
+			if p.receiverType != "" {
+				callee = pickTypeMethod(candidates, p.receiverType, supers, callerNode, edge.ArgCount)
+			} else {
+				callee = pickPackageFunc(candidates, p.receiverPackage, edge.ArgCount)
+			}
+			if callee == nil && callerNode != nil && !packages[p.receiverPackage] {
+				callee = b.externalCallee(edge, p, imports[callerNode.Path])
+			}
+			if callee != nil {
+				resolveCall(edge, callee, p.inference)
+				continue
+			}
+			if typeNames[p.receiverType] || (p.receiverPackage != "" && packages[p.receiverPackage]) {
+				continue // No such method or function: leave unresolved
+			}
+			// The receiver is not in the graph (a static call on an unknown
+			// name, a relative import): fall back to the name. A package's
+			// function is never in the caller's own file.
+			if p.receiverPackage != "" && callerNode != nil {
+				candidates = outsideFile(candidates, callerNode.Path)
+			}
+		}
+
+		// Try to find by name, preferring overloads that accept the arguments
+		if len(candidates) > 1 {
+			candidates = matchingArity(candidates, edge.ArgCount)
+		}
 		if len(candidates) == 1 {
-			edge.To = candidates[0].ID
+			resolveCall(edge, candidates[0], ResolutionName)
 		} else if len(candidates) > 1 {
 			// Ambiguous: use heuristics (same package preferred)
-			callerNode := b.graph.GetNode(edge.From)
 			if callerNode != nil {
 				for _, c := range candidates {
-					if c.Path == callerNode.Path || getPackageFromPath(c.Path) == getPackageFromPath(callerNode.Path) {
-						edge.To = c.ID
+					if c.Path == callerNode.Path || c.Package == callerNode.Package {
+						resolveCall(edge, c, ResolutionGuess)
 						break
 					}
 				}
 			}
 		}
 	}
+	b.pendingCalls = nil
 }
 
-// kindFromFunc determines the NodeKind based on function info.
-func kindFromFunc(fn FuncInfo) NodeKind {
-	if fn.Receiver != "" {
-		return KindMethod
+// outsideFile returns the nodes of candidates not defined in path.
+func outsideFile(candidates []*Node, path string) []*Node {
+	var outside []*Node
+	for _, c := range candidates {
+		if c.Path != path {
+			outside = append(outside, c)
+		}
 	}
-	return KindFunction
+	return outside
 }
 
-// getPackageFromPath extracts a package name from a file path.
-func getPackageFromPath(path string) string {
-	dir := filepath.Dir(path)
-	if dir == "." || dir == "" {
+// resolveCall points a call edge at callee and records how it was chosen.
+func resolveCall(edge *Edge, callee *Node, resolution string) {
+	edge.To = callee.ID
+	edge.Resolution = resolution
+	edge.Weight = ResolutionConfidence(resolution)
+}
+
+// pickPackageFunc returns the function of package pkg among candidates,
+// preferring package-level functions and matching arity.
+func pickPackageFunc(candidates []*Node, pkg string, argCount int) *Node {
+	var matched []*Node
+	for _, c := range candidates {
+		if c.Package == pkg {
+			matched = append(matched, c)
+		}
+	}
+	matched = matchingArity(matched, argCount)
+	for _, c := range matched {
+		if c.Owner == "" {
+			return c
+		}
+	}
+	if len(matched) > 0 {
+		return matched[0]
+	}
+	return nil
+}
+
+// pickTypeMethod returns the method of typ, or else of its nearest declared
+// supertype, among candidates. Same-named types in several packages are
+// told apart by preferring the caller's package, then matching arity.
+func pickTypeMethod(candidates []*Node, typ string, supers map[string][]string, caller *Node, argCount int) *Node {
+	seen := map[string]bool{typ: true}
+	for owners := []string{typ}; len(owners) > 0; {
+		var matched []*Node
+		var next []string
+		for _, owner := range owners {
+			for _, c := range candidates {
+				if c.Owner == owner {
+					matched = append(matched, c)
+				}
+			}
+			for _, s := range supers[owner] {
+				if !seen[s] {
+					seen[s] = true
+					next = append(next, s)
+				}
+			}
+		}
+		if len(matched) > 0 {
+			matched = matchingArity(matched, argCount)
+			if caller != nil {
+				for _, c := range matched {
+					if c.Package == caller.Package {
+						return c
+					}
+				}
+			}
+			return matched[0]
+		}
+		owners = next
+	}
+	return nil
+}
+
+// supertypeNames maps type names to the bare names of their declared
+// supertypes.
+func (b *Builder) supertypeNames() map[string][]string {
+	supers := make(map[string][]string)
+	for _, p := range b.pendingSupers {
+		name := p.fromName
+		if node := b.graph.GetNode(p.from); node != nil {
+			name = node.Name
+		}
+		if _, super := splitQualified(p.name); name != "" && super != "" {
+			supers[name] = append(supers[name], super)
+		}
+	}
+	return supers
+}
+
+// ResolveTypeHierarchy turns declared supertypes into extends/implements edges
+// and adds implements edges for Go types whose method set satisfies an interface.
+// Interfaces embedding one that isn't in the graph (io.Reader) have an
+// unknown method set, so nothing satisfies them structurally.
+// Call this after all files have been added.
+func (b *Builder) ResolveTypeHierarchy() {
+	seen := make(map[string]bool)
+	for _, edge := range b.graph.Edges {
+		if edge.Kind == EdgeExtends || edge.Kind == EdgeImplements {
+			seen[fmt.Sprintf("%s>%s", edge.From, edge.To)] = true
+		}
+	}
+	addEdge := func(from, to NodeID, kind EdgeKind, line int) {
+		key := fmt.Sprintf("%s>%s", from, to)
+		if from == to || seen[key] {
+			return
+		}
+		seen[key] = true
+		b.graph.AddEdge(&Edge{From: from, To: to, Kind: kind, Line: line})
+	}
+
+	// Declared supertypes
+	openInterfaces := make(map[NodeID]bool) // Go interfaces embedding an unknown one
+	for _, p := range b.pendingSupers {
+		from := b.graph.GetNode(p.from)
+		if from == nil {
+			from = b.resolveType(p.fromName, p.path)
+		}
+		to := b.resolveType(p.name, p.path)
+		if from != nil && to == nil && p.kind == EdgeExtends {
+			if _, ok := b.goInterfaces[from.ID]; ok {
+				openInterfaces[from.ID] = true
+			}
+		}
+		if from == nil || to == nil {
+			continue // External or unknown type
+		}
+		kind := p.kind
+		// Extends clauses that name an interface (C#, Swift, Kotlin) are implementations
+		if kind == EdgeExtends && isAbstractTypeKind(to.TypeKind) && !isAbstractTypeKind(from.TypeKind) {
+			kind = EdgeImplements
+		}
+		addEdge(from.ID, to.ID, kind, p.line)
+	}
+	b.pendingSupers = nil
+
+	// Go: structural satisfaction from method sets
+	var ifaces []*Node
+	ifaceMethods := make(map[NodeID][]string)
+	for id := range b.goInterfaces {
+		if iface := b.graph.GetNode(id); iface != nil {
+			if methods, known := b.interfaceMethodSet(id, openInterfaces, make(map[NodeID]bool)); known {
+				ifaces = append(ifaces, iface)
+				ifaceMethods[id] = methods
+			}
+		}
+	}
+	sortNodes(ifaces)
+	for _, node := range b.graph.SortedNodes() {
+		if node.Kind != KindType || node.TypeKind == "interface" || filepath.Ext(node.Path) != ".go" {
+			continue
+		}
+		methods := b.goMethods[goTypeKey(node.Path, node.Name)]
+		if len(methods) == 0 {
+			continue
+		}
+		for _, iface := range ifaces {
+			if required := ifaceMethods[iface.ID]; len(required) > 0 && hasAllMethods(methods, required) {
+				addEdge(node.ID, iface.ID, EdgeImplements, node.Line)
+			}
+		}
+	}
+}
+
+// ResolveMethods links each method to its owning type with a defines edge.
+// Owners are looked up in the method's file, then in its directory (Go
+// methods and C++ out-of-line definitions may live apart from the type).
+// Call this after all files have been added.
+func (b *Builder) ResolveMethods() {
+	for _, p := range b.pendingMethods {
+		var owner *Node
+		for _, n := range b.graph.GetNodesByName(p.owner) {
+			if n.Kind != KindType {
+				continue
+			}
+			if n.Path == p.path {
+				owner = n
+				break
+			}
+			if owner == nil && filepath.Dir(n.Path) == filepath.Dir(p.path) {
+				owner = n
+			}
+		}
+		if owner == nil {
+			continue // Owner outside the index (e.g. extension of a library type)
+		}
+		b.graph.AddEdge(&Edge{From: owner.ID, To: p.method, Kind: EdgeDefines, Line: p.line})
+	}
+	b.pendingMethods = nil
+}
+
+// ResolveReferences turns type and variable references into edges from the
+// enclosing symbol (or file) to the referenced type, variable or constant node.
+// References to unknown, builtin or local names are dropped.
+// Call this after all files have been added.
+func (b *Builder) ResolveReferences() {
+	seen := make(map[string]bool)
+	for _, p := range b.pendingRefs {
+		var to *Node
+		if p.value {
+			to = b.resolveNamed(p.name, p.path, KindVariable, KindConstant)
+		} else {
+			to = b.resolveType(p.name, p.path)
+		}
+		if to == nil || to.ID == p.from {
+			continue
+		}
+		key := fmt.Sprintf("%s>%s", p.from, to.ID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		b.graph.AddEdge(&Edge{From: p.from, To: to.ID, Kind: EdgeReferences, Line: p.line})
+	}
+	b.pendingRefs = nil
+}
+
+// resolveType finds the type node named name.
+func (b *Builder) resolveType(name, fromPath string) *Node {
+	return b.resolveNamed(name, fromPath, KindType)
+}
+
+// resolveNamed finds a node of one of kinds named name, preferring the same
+// file, then the same directory, then a unique match among files of the same
+// language. A qualified name (io.Reader, java.util.List) only matches nodes
+// the qualifier places (see qualifies).
+func (b *Builder) resolveNamed(name, fromPath string, kinds ...NodeKind) *Node {
+	qualifier, name := splitQualified(name)
+	var candidates []*Node
+	for _, n := range b.graph.GetNodesByName(name) {
+		if qualifier != "" && !b.qualifies(qualifier, n) {
+			continue
+		}
+		for _, k := range kinds {
+			if n.Kind == k {
+				candidates = append(candidates, n)
+				break
+			}
+		}
+	}
+	for _, c := range candidates {
+		if c.Path == fromPath {
+			return c
+		}
+	}
+	for _, c := range candidates {
+		if filepath.Dir(c.Path) == filepath.Dir(fromPath) {
+			return c
+		}
+	}
+	var sameLang []*Node
+	for _, c := range candidates {
+		if filepath.Ext(c.Path) == filepath.Ext(fromPath) {
+			sameLang = append(sameLang, c)
+		}
+	}
+	if len(sameLang) == 1 {
+		return sameLang[0]
+	}
+	return nil
+}
+
+// splitQualified splits a dotted type name into its qualifier and bare
+// name: "io.Reader" -> "io", "Reader".
+func splitQualified(name string) (qualifier, bare string) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// qualifies reports whether qualifier places n: it names n's package, in
+// full or by its trailing elements (io, java.util, models for app.models,
+// myio for example.com/app/myio or example.com/myio/v2), or a type
+// enclosing n in its file (Outer in Outer.Inner). Nodes without a package
+// use their directory.
+func (b *Builder) qualifies(qualifier string, n *Node) bool {
+	pkg := n.Package
+	if pkg == "" {
+		pkg = filepath.ToSlash(filepath.Dir(n.Path))
+	}
+	pkg = strings.NewReplacer("/", ".", `\`, ".", "::", ".").Replace(pkg)
+	if base, version := splitQualified(pkg); base != "" && len(version) > 1 && version[0] == 'v' && strings.Trim(version[1:], "0123456789") == "" {
+		pkg = base // Go major version suffix
+	}
+	if pkg == qualifier || strings.HasSuffix(pkg, "."+qualifier) {
+		return true
+	}
+	_, outer := splitQualified(qualifier)
+	for _, t := range b.graph.GetNodesByName(outer) {
+		if t.Kind == KindType && t.Path == n.Path && t.ID != n.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// interfaceMethodSet returns the methods of a Go interface including those
+// of embedded interfaces, and whether the set is known: false when it or
+// an interface it embeds is in open (embeds one outside the graph).
+func (b *Builder) interfaceMethodSet(id NodeID, open map[NodeID]bool, visited map[NodeID]bool) ([]string, bool) {
+	if visited[id] {
+		return nil, true
+	}
+	visited[id] = true
+	if open[id] {
+		return nil, false
+	}
+
+	methods := append([]string(nil), b.goInterfaces[id]...)
+	for _, edge := range b.graph.GetOutgoingEdges(id) {
+		if edge.Kind == EdgeExtends {
+			embedded, known := b.interfaceMethodSet(edge.To, open, visited)
+			if !known {
+				return nil, false
+			}
+			methods = append(methods, embedded...)
+		}
+	}
+	return methods, true
+}
+
+// isAbstractTypeKind reports whether a type kind can only be implemented.
+func isAbstractTypeKind(kind string) bool {
+	return kind == "interface" || kind == "trait" || kind == "protocol"
+}
+
+// hasAllMethods reports whether set contains every name in required.
+func hasAllMethods(set map[string]bool, required []string) bool {
+	for _, m := range required {
+		if !set[m] {
+			return false
+		}
+	}
+	return true
+}
+
+// goTypeKey identifies a Go type by package directory and name.
+func goTypeKey(path, typeName string) string {
+	return filepath.Dir(path) + ":" + typeName
+}
+
+// receiverVarName extracts the variable name from a Go receiver such as "(s *Server[T])".
+func receiverVarName(receiver string) string {
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(receiver), "()"))
+	if len(fields) < 2 {
 		return ""
 	}
-	return filepath.Base(dir)
+	return fields[0]
+}
+
+// symbolKey identifies a function or type within a file by name and definition line.
+func symbolKey(name string, line int) string {
+	return fmt.Sprintf("%s:%d", name, line)
+}
+
+// qualifiedName joins a package name and an in-file symbol key.
+func qualifiedName(pkg, key string) string {
+	if pkg == "" {
+		return key
+	}
+	return pkg + "." + key
+}
+
+// functionKeys returns the in-file identity of each function: Owner.Name,
+// with the parameter types appended for overloads (Java, C#, C++) and the
+// definition line as a last resort (redefinitions, multiple Go init funcs).
+func functionKeys(funcs []FuncInfo) []string {
+	keys := make([]string, len(funcs))
+	for i, fn := range funcs {
+		keys[i] = fn.Name
+		if fn.Owner != "" {
+			keys[i] = fn.Owner + "." + fn.Name
+		}
+	}
+	for _, disambiguate := range []func(i int) string{
+		func(i int) string { return "(" + paramTypes(funcs[i].Signature, funcs[i].Name) + ")" },
+		func(i int) string { return fmt.Sprintf("@%d", funcs[i].Line) },
+	} {
+		count := make(map[string]int)
+		for _, k := range keys {
+			count[k]++
+		}
+		for i := range keys {
+			if count[keys[i]] > 1 {
+				keys[i] += disambiguate(i)
+			}
+		}
+	}
+	return keys
+}
+
+// paramTypes extracts the comma-separated parameter types from a signature,
+// e.g. "void greet(String name, int times)" -> "String,int".
+func paramTypes(signature, name string) string {
+	start := strings.Index(signature, name+"(")
+	if start < 0 {
+		return ""
+	}
+	start += len(name) + 1
+
+	var params []string
+	depth, from := 0, start
+scan:
+	for i := start; i < len(signature); i++ {
+		switch signature[i] {
+		case '(', '<', '[', '{':
+			depth++
+		case ')', '>', ']', '}':
+			if depth == 0 {
+				params = append(params, signature[from:i])
+				break scan
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, signature[from:i])
+				from = i + 1
+			}
+		}
+	}
+
+	var types []string
+	for _, p := range params {
+		if i := strings.Index(p, "="); i >= 0 {
+			p = p[:i] // Default value
+		}
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if i := strings.Index(p, ":"); i >= 0 && !strings.Contains(p, "::") {
+			types = append(types, strings.TrimSpace(p[i+1:])) // name: Type
+			continue
+		}
+		fields := strings.Fields(p)
+		if len(fields) == 1 {
+			types = append(types, fields[0])
+			continue
+		}
+		// Type name: keep pointer/reference markers attached to the name
+		last := fields[len(fields)-1]
+		typ := strings.Join(fields[:len(fields)-1], " ") + strings.Map(func(r rune) rune {
+			if r == '*' || r == '&' {
+				return r
+			}
+			return -1
+		}, last)
+		types = append(types, typ)
+	}
+	return strings.Join(types, ",")
+}
+
+// pickCallee chooses among same-file functions named like the callee (given
+// as indexes into funcs), preferring methods of the receiver's type and then
+// a matching argument count. Returns -1 when there is no candidate.
+func pickCallee(funcs []FuncInfo, candidates []int, call CallInfo, caller FuncInfo) int {
+	owner := callOwner(call, caller)
+
+	best, bestScore := -1, -1
+	for _, i := range candidates {
+		fn := funcs[i]
+		score := 0
+		if fn.Owner == owner {
+			score += 2
+		}
+		if fn.ParamCount == call.Args || fn.ParamCount < 0 {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// callOwner returns the type a call's receiver refers to when it is the
+// caller's own (implicit, self/this or the Go receiver variable), else the
+// receiver text.
+func callOwner(call CallInfo, caller FuncInfo) string {
+	switch call.Receiver {
+	case "", "this", "self", "super", "base", "$this", "static", receiverVarName(caller.Receiver):
+		return caller.Owner
+	}
+	return call.Receiver
+}
+
+// pickMethod returns the index of the method of owner among candidates,
+// preferring one accepting args arguments, or -1.
+func pickMethod(funcs []FuncInfo, candidates []int, owner string, args int) int {
+	best := -1
+	for _, i := range candidates {
+		if funcs[i].Owner != owner {
+			continue
+		}
+		if funcs[i].ParamCount == args || funcs[i].ParamCount < 0 {
+			return i
+		}
+		if best < 0 {
+			best = i
+		}
+	}
+	return best
+}
+
+// matchingArity narrows call candidates to those accepting argCount
+// arguments, keeping all of them when none does.
+func matchingArity(candidates []*Node, argCount int) []*Node {
+	var matched []*Node
+	for _, c := range candidates {
+		if c.ParamCount == argCount || c.ParamCount < 0 {
+			matched = append(matched, c)
+		}
+	}
+	if len(matched) == 0 {
+		return candidates
+	}
+	return matched
+}
+
+// kindFromFunc determines the NodeKind based on function info.
+func kindFromFunc(fn FuncInfo) NodeKind {
+	if fn.Receiver != "" || fn.Owner != "" {
+		return KindMethod
+	}
+	return KindFunction
 }
 
 // FilterCallEdges removes call edges that violate import constraints.
 // This implements the ImportGraphFilter from the plan.
 func (b *Builder) FilterCallEdges() {
-	// Build file -> imported packages map
+	// Build file -> imported packages and files map
 	fileImports := make(map[NodeID]map[string]bool)
+	importedFiles := make(map[NodeID]map[string]bool)
 
 	for _, edge := range b.graph.Edges {
 		if edge.Kind != EdgeImports {
@@ -298,10 +1199,17 @@ func (b *Builder) FilterCallEdges() {
 			continue
 		}
 
+		if toNode.Kind == KindFile {
+			if _, ok := importedFiles[edge.From]; !ok {
+				importedFiles[edge.From] = make(map[string]bool)
+			}
+			importedFiles[edge.From][toNode.Path] = true
+			continue
+		}
 		if _, ok := fileImports[edge.From]; !ok {
 			fileImports[edge.From] = make(map[string]bool)
 		}
-		fileImports[edge.From][toNode.Name] = true
+		fileImports[edge.From][toNode.Path] = true
 	}
 
 	// Filter call edges
@@ -339,17 +1247,20 @@ func (b *Builder) FilterCallEdges() {
 			continue
 		}
 
-		// Check if callee's package is imported
-		calleePackage := getPackageFromPath(calleeNode.Path)
-		imports := fileImports[callerFileID]
-		if imports != nil && imports[calleePackage] {
+		// Same package? Go packages and namespaces span files
+		if calleeNode.Package != "" && calleeNode.Package == callerNode.Package {
+			validEdges = append(validEdges, edge)
+			continue
+		}
+
+		// Check if callee's file is imported (resolved imports)
+		if importedFiles[callerFileID][calleeNode.Path] {
 			validEdges = append(validEdges, edge)
 			continue
 		}
 
-		// Also check for standard library and external calls
-		// For now, keep edges where callee package looks like an import
-		if strings.Contains(calleePackage, "/") {
+		// Check if callee's package is imported
+		if importsPackage(fileImports[callerFileID], calleeNode.Package) {
 			validEdges = append(validEdges, edge)
 		}
 	}
@@ -357,3 +1268,17 @@ func (b *Builder) FilterCallEdges() {
 	b.graph.Edges = validEdges
 	b.graph.RebuildIndexes()
 }
+
+// importsPackage reports whether one of imports names pkg, a class or
+// member inside it (com.acme.Util for com.acme), or a parent package of it.
+func importsPackage(imports map[string]bool, pkg string) bool {
+	if pkg == "" {
+		return false
+	}
+	for imp := range imports {
+		if imp == pkg || strings.HasPrefix(imp, pkg+".") || strings.HasPrefix(pkg, imp+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,329 @@
+package graph
+
+import "testing"
+
+// buildGraph builds a graph from analyses the way an index run does.
+func buildGraph(files ...*FileAnalysis) *CodeGraph {
+	b := NewBuilder("/repo")
+	b.AddFiles(files)
+	b.ResolveMethods()
+	b.ResolveCallEdges()
+	b.FilterCallEdges()
+	b.ResolveTypeHierarchy()
+	b.ResolveReferences()
+	return b.Build()
+}
+
+// findEdge returns the edge of kind between the nodes named from and to.
+func findEdge(g *CodeGraph, from, to string, kind EdgeKind) *Edge {
+	for _, f := range g.GetNodesByName(from) {
+		for _, e := range g.GetOutgoingEdges(f.ID) {
+			if n := g.GetNode(e.To); e.Kind == kind && n != nil && n.Name == to {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func TestGoInterfaceEmbeddingUnknownInterface(t *testing.T) {
+	g := buildGraph(&FileAnalysis{
+		Path:     "door.go",
+		Language: "go",
+		Package:  "example.com/app",
+		Types: []TypeInfo{
+			{Name: "ReadCloser", Kind: "interface", Line: 3, Extends: []string{"io.Reader"}, Methods: []string{"Close"}},
+			{Name: "Closer", Kind: "interface", Line: 8, Methods: []string{"Close"}},
+			{Name: "Door", Kind: "struct", Line: 12},
+		},
+		Functions: []FuncInfo{{Name: "Close", Owner: "Door", Line: 14}},
+	})
+
+	if findEdge(g, "Door", "ReadCloser", EdgeImplements) != nil {
+		t.Error("Door implements ReadCloser, whose embedded io.Reader is unknown")
+	}
+	if findEdge(g, "Door", "Closer", EdgeImplements) == nil {
+		t.Error("Door doesn't implement Closer")
+	}
+}
+
+func TestQualifiedSupertypes(t *testing.T) {
+	g := buildGraph(
+		&FileAnalysis{
+			Path:     "myio/reader.go",
+			Language: "go",
+			Package:  "example.com/app/myio",
+			Types:    []TypeInfo{{Name: "Reader", Kind: "interface", Line: 3, Methods: []string{"Read"}}},
+		},
+		&FileAnalysis{
+			Path:     "app.go",
+			Language: "go",
+			Package:  "example.com/app",
+			Types: []TypeInfo{
+				{Name: "ReadCloser", Kind: "interface", Line: 3, Extends: []string{"io.Reader"}, Methods: []string{"Close"}},
+				{Name: "Source", Kind: "interface", Line: 8, Extends: []string{"myio.Reader"}},
+				{Name: "File", Kind: "struct", Line: 12},
+			},
+			Functions: []FuncInfo{
+				{Name: "Read", Owner: "File", Line: 14},
+				{Name: "Close", Owner: "File", Line: 16},
+			},
+		},
+	)
+
+	if findEdge(g, "ReadCloser", "Reader", EdgeExtends) != nil {
+		t.Error("io.Reader resolved to myio.Reader")
+	}
+	if findEdge(g, "File", "ReadCloser", EdgeImplements) != nil {
+		t.Error("File implements ReadCloser, whose embedded io.Reader is unknown")
+	}
+	if findEdge(g, "Source", "Reader", EdgeExtends) == nil {
+		t.Error("myio.Reader didn't resolve")
+	}
+	// Source is made only of the embedded interface
+	if findEdge(g, "File", "Source", EdgeImplements) == nil {
+		t.Error("File doesn't implement Source")
+	}
+}
+
+func TestQualifiedSupertypeOtherLanguages(t *testing.T) {
+	g := buildGraph(
+		&FileAnalysis{
+			Path:     "src/com/acme/Base.java",
+			Language: "java",
+			Package:  "com.acme",
+			Types: []TypeInfo{
+				{Name: "Base", Kind: "class", Line: 3},
+				{Name: "Outer", Kind: "class", Line: 5},
+				{Name: "Inner", Kind: "class", Line: 6},
+			},
+		},
+		&FileAnalysis{
+			Path:     "src/com/acme/app/App.java",
+			Language: "java",
+			Package:  "com.acme.app",
+			Types: []TypeInfo{
+				{Name: "App", Kind: "class", Line: 3, Extends: []string{"com.acme.Base"}},
+				{Name: "Job", Kind: "class", Line: 5, Extends: []string{"Outer.Inner"}},
+				{Name: "Task", Kind: "class", Line: 7, Extends: []string{"java.util.Base"}},
+			},
+		},
+	)
+
+	if findEdge(g, "App", "Base", EdgeExtends) == nil {
+		t.Error("com.acme.Base didn't resolve")
+	}
+	if findEdge(g, "Job", "Inner", EdgeExtends) == nil {
+		t.Error("nested Outer.Inner didn't resolve")
+	}
+	if findEdge(g, "Task", "Base", EdgeExtends) != nil {
+		t.Error("java.util.Base resolved to com.acme.Base")
+	}
+}
+
+func TestSameNameMethodDisambiguation(t *testing.T) {
+	files := []*FileAnalysis{
+		{
+			Path:      "store/file.go",
+			Language:  "go",
+			Package:   "example.com/app/store",
+			Types:     []TypeInfo{{Name: "File", Kind: "struct", Line: 3}},
+			Functions: []FuncInfo{{Name: "Close", Owner: "File", Line: 5}, {Name: "Flush", Owner: "File", Line: 7}},
+		},
+		{
+			Path:      "store/conn.go",
+			Language:  "go",
+			Package:   "example.com/app/store",
+			Types:     []TypeInfo{{Name: "Conn", Kind: "struct", Line: 3}},
+			Functions: []FuncInfo{{Name: "Close", Owner: "Conn", Line: 5}},
+		},
+		{
+			Path:     "store/pool.go",
+			Language: "go",
+			Package:  "example.com/app/store",
+			Functions: []FuncInfo{
+				{Name: "Release", Line: 3},
+				{Name: "Drain", Line: 7},
+				{Name: "Shutdown", Line: 11},
+			},
+			Calls: []CallInfo{
+				{CallerFunc: "Release", CallerLine: 3, CalleeName: "Close", CallLine: 4, Receiver: "c", ReceiverType: "Conn", Inference: ResolutionParam},
+				{CallerFunc: "Drain", CallerLine: 7, CalleeName: "Flush", CallLine: 8, Receiver: "c", ReceiverType: "Conn", Inference: ResolutionParam},
+				{CallerFunc: "Shutdown", CallerLine: 11, CalleeName: "Close", CallLine: 12, Receiver: "x"},
+			},
+		},
+	}
+	g := buildGraph(files...)
+
+	tests := []struct {
+		caller, owner, resolution string
+	}{
+		{"Release", "Conn", ResolutionParam},  // Typed receiver picks Conn.Close over File.Close
+		{"Drain", "", ""},                     // Conn has no Flush; File.Flush is not a candidate
+		{"Shutdown", "Conn", ResolutionGuess}, // Untyped: ambiguous, first in the package
+	}
+	for _, tt := range tests {
+		t.Run(tt.caller, func(t *testing.T) {
+			var calls []*Edge
+			for _, n := range g.GetNodesByName(tt.caller) {
+				for _, e := range g.GetOutgoingEdges(n.ID) {
+					if e.Kind == EdgeCalls {
+						calls = append(calls, e)
+					}
+				}
+			}
+			if tt.resolution == "" {
+				if len(calls) != 0 {
+					t.Fatalf("%s calls %s, want no call edge", tt.caller, calls[0].To)
+				}
+				return
+			}
+			if len(calls) != 1 {
+				t.Fatalf("%s has %d call edges, want 1", tt.caller, len(calls))
+			}
+			e := calls[0]
+			if callee := g.GetNode(e.To); callee.Owner != tt.owner {
+				t.Errorf("%s calls %s.%s, want %s.%s", tt.caller, callee.Owner, callee.Name, tt.owner, callee.Name)
+			}
+			if e.Resolution != tt.resolution {
+				t.Errorf("Resolution = %q, want %q", e.Resolution, tt.resolution)
+			}
+			if want := ResolutionConfidence(tt.resolution); e.Weight != want {
+				t.Errorf("Weight = %v, want %v", e.Weight, want)
+			}
+		})
+	}
+}
+
+func TestPackageHintFallback(t *testing.T) {
+	g := buildGraph(
+		&FileAnalysis{
+			Path:      "app/models.py",
+			Language:  "python",
+			Package:   "app.models",
+			Functions: []FuncInfo{{Name: "save", Line: 1, ParamCount: 1}},
+		},
+		&FileAnalysis{
+			Path:      "app/db.py",
+			Language:  "python",
+			Package:   "app.db",
+			Functions: []FuncInfo{{Name: "load", Line: 1, ParamCount: 1}},
+		},
+		&FileAnalysis{
+			Path:          "app/views.py",
+			Language:      "python",
+			Package:       "app.views",
+			ImportedFiles: []string{"app/db.py", "app/models.py"},
+			Functions: []FuncInfo{
+				{Name: "save", Line: 1, ParamCount: 1},
+				{Name: "create", Line: 4},
+				{Name: "fetch", Line: 8},
+			},
+			Calls: []CallInfo{
+				// from .models import ... left unqualified: fall back to the name
+				{CallerFunc: "create", CallerLine: 4, CalleeName: "save", CallLine: 5, Args: 1, Receiver: "models", ReceiverPackage: ".models", Inference: ResolutionImport},
+				// app.db is in the graph but has no fetch_all
+				{CallerFunc: "fetch", CallerLine: 8, CalleeName: "load", CallLine: 9, Args: 1, Receiver: "db", ReceiverPackage: "app.db", Inference: ResolutionImport},
+				{CallerFunc: "fetch", CallerLine: 8, CalleeName: "save", CallLine: 10, Args: 1, Receiver: "db", ReceiverPackage: "app.db", Inference: ResolutionImport},
+			},
+		},
+	)
+
+	e := findEdge(g, "create", "save", EdgeCalls)
+	if e == nil {
+		t.Fatal("create doesn't call save")
+	}
+	if callee := g.GetNode(e.To); callee.Path != "app/models.py" {
+		t.Errorf("create calls save in %s, want app/models.py", callee.Path)
+	}
+	if e.Resolution != ResolutionName || e.Weight != ResolutionConfidence(ResolutionName) {
+		t.Errorf("Resolution, Weight = %q, %v, want %q, %v", e.Resolution, e.Weight, ResolutionName, ResolutionConfidence(ResolutionName))
+	}
+	if e := findEdge(g, "fetch", "load", EdgeCalls); e == nil || e.Resolution != ResolutionImport {
+		t.Errorf("fetch doesn't call db.load by import: %+v", e)
+	}
+	if e := findEdge(g, "fetch", "save", EdgeCalls); e != nil {
+		t.Errorf("fetch calls %s, but app.db has no save", e.To)
+	}
+}
+
+func TestShellCallsIntoSourcedScripts(t *testing.T) {
+	g := buildGraph(
+		&FileAnalysis{
+			Path:      "lib/build.sh",
+			Language:  "bash",
+			Functions: []FuncInfo{{Name: "build_image", Line: 1}},
+		},
+		&FileAnalysis{
+			Path:      "lib/clean.sh",
+			Language:  "bash",
+			Functions: []FuncInfo{{Name: "clean", Line: 1}},
+		},
+		&FileAnalysis{
+			Path:          "deploy.sh",
+			Language:      "bash",
+			ImportedFiles: []string{"lib/build.sh"},
+			Functions:     []FuncInfo{{Name: "deploy", Line: 3}},
+			Calls: []CallInfo{
+				{CallerFunc: "deploy", CallerLine: 3, CalleeName: "build_image", CallLine: 4},
+				{CallerFunc: "deploy", CallerLine: 3, CalleeName: "clean", CallLine: 5},
+				{CallerFunc: "deploy", CallerLine: 3, CalleeName: "ls", CallLine: 6},
+			},
+		},
+	)
+
+	if findEdge(g, "deploy", "build_image", EdgeCalls) == nil {
+		t.Error("deploy doesn't call build_image of the sourced lib/build.sh")
+	}
+	if findEdge(g, "deploy", "clean", EdgeCalls) != nil {
+		t.Error("deploy calls clean of lib/clean.sh, which it doesn't source")
+	}
+	for _, e := range g.Edges {
+		if e.Kind == EdgeCalls && g.GetNode(e.To) == nil {
+			t.Errorf("unresolved call edge to %s kept", e.To)
+		}
+	}
+}
+
+func TestNodesCarryPackages(t *testing.T) {
+	g := buildGraph(
+		&FileAnalysis{
+			Path:      "a/util/strings.go",
+			Language:  "go",
+			Package:   "example.com/app/a/util",
+			Functions: []FuncInfo{{Name: "Trim", Line: 3}, {Name: "Pad", Line: 5}},
+			Types:     []TypeInfo{{Name: "Builder", Kind: "struct", Line: 7}},
+			Variables: []VarInfo{{Name: "Space", IsConst: true, Line: 9}},
+		},
+		&FileAnalysis{
+			Path:      "a/util/format.go",
+			Language:  "go",
+			Package:   "example.com/app/a/util",
+			Functions: []FuncInfo{{Name: "Format", Line: 3}},
+			Calls:     []CallInfo{{CallerFunc: "Format", CallerLine: 3, CalleeName: "Trim", CallLine: 4}},
+		},
+		&FileAnalysis{
+			Path:      "b/util/util.go",
+			Language:  "go",
+			Package:   "example.com/app/b/util",
+			Functions: []FuncInfo{{Name: "Render", Line: 3}},
+			Calls:     []CallInfo{{CallerFunc: "Render", CallerLine: 3, CalleeName: "Pad", CallLine: 4}},
+		},
+	)
+
+	for _, name := range []string{"strings.go", "Trim", "Builder", "Space"} {
+		nodes := g.GetNodesByName(name)
+		if len(nodes) != 1 || nodes[0].Package != "example.com/app/a/util" {
+			t.Errorf("%s: nodes %+v, want one in example.com/app/a/util", name, nodes)
+		}
+	}
+	if n := g.GetNodesByName("Trim"); len(n) == 1 && n[0].QualifiedName != "example.com/app/a/util.Trim" {
+		t.Errorf("Trim QualifiedName = %q", n[0].QualifiedName)
+	}
+	if findEdge(g, "Format", "Trim", EdgeCalls) == nil {
+		t.Error("Format doesn't call Trim of its own package in another file")
+	}
+	if findEdge(g, "Render", "Pad", EdgeCalls) != nil {
+		t.Error("Render calls Pad of another package named util without importing it")
+	}
+}
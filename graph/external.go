@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// externalCallee returns the external symbol node a call into an imported
+// package resolves to, creating it on first use: the package's function
+// (http.Get) or the method of one of its types (http.Client.Do). The
+// package must be one the caller's file imports, as importsPackage
+// matches them; relative imports never name external packages. New symbols
+// are contained by the node of the import. It returns nil for calls that
+// don't go to an imported package.
+func (b *Builder) externalCallee(edge *Edge, p pendingCall, imports map[string]bool) *Node {
+	pkg := p.receiverPackage
+	if pkg == "" || strings.HasPrefix(pkg, ".") || !importsPackage(imports, pkg) {
+		return nil
+	}
+	imp := pkg
+	if !imports[pkg] {
+		for _, candidate := range sortedSet(imports) {
+			if importsPackage(map[string]bool{candidate: true}, pkg) {
+				imp = candidate
+				break
+			}
+		}
+	}
+
+	n := &Node{
+		Kind:       KindExternal,
+		Name:       edge.CallSite,
+		Owner:      p.receiverType,
+		Path:       pkg,
+		Package:    pkg,
+		Exported:   true,
+		ParamCount: -1, // Unknown: any argument count matches
+	}
+	n.QualifiedName = pkg + "." + n.DisplayName()
+	n.ID = GenerateNodeID(pkg, n.DisplayName())
+	if existing := b.graph.GetNode(n.ID); existing != nil {
+		return existing
+	}
+	b.graph.AddNode(n)
+	b.graph.AddEdge(&Edge{From: GenerateNodeID(imp, ""), To: n.ID, Kind: EdgeContains})
+	return n
+}
+
+// importedPackages maps each file to the import paths of the packages it
+// imports.
+func (g *CodeGraph) importedPackages() map[string]map[string]bool {
+	imports := make(map[string]map[string]bool)
+	for _, e := range g.Edges {
+		if e.Kind != EdgeImports {
+			continue
+		}
+		from, to := g.Nodes[e.From], g.Nodes[e.To]
+		if from == nil || to == nil || from.Kind != KindFile || to.Kind != KindPackage {
+			continue
+		}
+		if imports[from.Path] == nil {
+			imports[from.Path] = make(map[string]bool)
+		}
+		imports[from.Path][to.Path] = true
+	}
+	return imports
+}
+
+// ExternalUsage is the part of an external package the project calls.
+type ExternalUsage struct {
+	Package    string        `json:"package"`              // Import path
+	Dependency string        `json:"dependency,omitempty"` // Declared dependency providing the package
+	Version    string        `json:"version,omitempty"`
+	Calls      int           `json:"calls"`
+	Files      []string      `json:"files"` // Calling files
+	Symbols    []SymbolUsage `json:"symbols"`
+}
+
+// SymbolUsage is one external function or method and where it is called.
+type SymbolUsage struct {
+	Name  string   `json:"name"` // Owner-qualified name within the package (Client.Do)
+	Calls int      `json:"calls"`
+	Files []string `json:"files"`
+}
+
+// Alias returns the name code usually refers to the package by: the last
+// element of its import path (http for net/http), skipping a Go major
+// version suffix, or the whole dotted Python module name.
+func (u *ExternalUsage) Alias() string {
+	base := path.Base(u.Package)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" && strings.Contains(u.Package, "/") {
+		return path.Base(path.Dir(u.Package)) // example.com/mod/v2 is package mod
+	}
+	return base
+}
+
+// ExternalUsage returns the usage surface of each external package: the
+// symbols called from the nodes keep accepts (all when keep is nil), with
+// their call counts and calling files. dependency, when not nil, names the
+// declared dependency an import path belongs to. Packages are ordered by
+// dependency and import path, symbols by name.
+func (g *CodeGraph) ExternalUsage(keep func(caller *Node) bool, dependency func(importPath string) string) []*ExternalUsage {
+	byPackage := make(map[string]*ExternalUsage)
+	symbols := make(map[string]map[string]*SymbolUsage)
+	files := make(map[*SymbolUsage]map[string]bool)
+	for _, e := range g.Edges {
+		if e.Kind != EdgeCalls {
+			continue
+		}
+		callee, caller := g.Nodes[e.To], g.Nodes[e.From]
+		if callee == nil || caller == nil || callee.Kind != KindExternal || keep != nil && !keep(caller) {
+			continue
+		}
+
+		u, ok := byPackage[callee.Package]
+		if !ok {
+			u = &ExternalUsage{Package: callee.Package, Version: callee.Version}
+			if dependency != nil {
+				u.Dependency = dependency(callee.Package)
+			}
+			byPackage[callee.Package] = u
+			symbols[callee.Package] = make(map[string]*SymbolUsage)
+		}
+		name := callee.DisplayName()
+		s, ok := symbols[callee.Package][name]
+		if !ok {
+			s = &SymbolUsage{Name: name}
+			symbols[callee.Package][name] = s
+			files[s] = make(map[string]bool)
+		}
+		u.Calls++
+		s.Calls++
+		files[s][caller.Path] = true
+	}
+
+	usage := make([]*ExternalUsage, 0, len(byPackage))
+	for pkg, u := range byPackage {
+		all := make(map[string]bool)
+		for _, s := range symbols[pkg] {
+			s.Files = sortedSet(files[s])
+			for f := range files[s] {
+				all[f] = true
+			}
+			u.Symbols = append(u.Symbols, *s)
+		}
+		sort.Slice(u.Symbols, func(i, j int) bool { return u.Symbols[i].Name < u.Symbols[j].Name })
+		u.Files = sortedSet(all)
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		if a.Dependency != b.Dependency {
+			return a.Dependency < b.Dependency
+		}
+		return a.Package < b.Package
+	})
+	return usage
+}
+
+// sortedSet returns the members of a set in order.
+func sortedSet(set map[string]bool) []string {
+	members := make([]string, 0, len(set))
+	for m := range set {
+		members = append(members, m)
+	}
+	sort.Strings(members)
+	return members
+}
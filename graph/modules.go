@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Module is a unit of a multi-module repository (a Go module, npm package,
+// Cargo crate or Gradle project) as recorded by SetModules.
+type Module struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"` // Directory relative to the project root ("." for the root)
+	Kind     string   `json:"kind"`
+	Deps     []string `json:"deps,omitempty"`     // External dependencies
+	Requires []string `json:"requires,omitempty"` // Names of the modules it depends on
+}
+
+// ModuleID returns the ID of a module's node.
+func ModuleID(name string) NodeID {
+	return GenerateNodeID("module", name)
+}
+
+// IsModule reports whether n is the node of a workspace module.
+func (n *Node) IsModule() bool {
+	return n.Kind == KindPackage && n.ID == ModuleID(n.Module)
+}
+
+// SetModules replaces the graph's modules. Each module becomes a package
+// node that contains its files and imports the modules it requires and
+// the package nodes of its external dependencies. Every node in a
+// module's files records the module's name in Node.Module; a file belongs
+// to the innermost module containing it.
+func (g *CodeGraph) SetModules(modules []Module) {
+	// Drop the previous module nodes and the dependency nodes only they used
+	stale := make(map[NodeID]bool)
+	for id, n := range g.Nodes {
+		if n.IsModule() {
+			stale[id] = true
+		}
+	}
+	deps := make(map[NodeID]bool)
+	for _, m := range g.Modules {
+		for _, dep := range m.Deps {
+			deps[GenerateNodeID(dep, "")] = true
+		}
+	}
+	if len(stale) > 0 {
+		var edges []*Edge
+		for _, e := range g.Edges {
+			if !stale[e.From] && !stale[e.To] {
+				edges = append(edges, e)
+			}
+		}
+		g.Edges = edges
+		g.RebuildIndexes()
+		for id := range deps {
+			if n := g.Nodes[id]; n != nil && n.Kind == KindPackage && len(g.edgesByFrom[id]) == 0 && len(g.edgesByTo[id]) == 0 {
+				stale[id] = true
+			}
+		}
+		for id := range stale {
+			delete(g.Nodes, id)
+		}
+		g.RebuildIndexes()
+	}
+	g.Modules = modules
+
+	// Assign nodes to the innermost module containing their file
+	for _, n := range g.Nodes {
+		if n.Kind == KindPackage || n.Kind == KindExternal {
+			continue // Imported packages and their symbols aren't files
+		}
+		n.Module = ""
+		best := -1
+		p := filepath.ToSlash(n.Path)
+		for i, m := range modules {
+			if inDir(m.Path, p) && (best < 0 || len(m.Path) > len(modules[best].Path)) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			n.Module = modules[best].Name
+		}
+	}
+
+	for _, m := range modules {
+		id := ModuleID(m.Name)
+		g.AddNode(&Node{ID: id, Kind: KindPackage, Name: m.Name, Path: m.Path, Package: m.Name, Module: m.Name, TypeKind: m.Kind})
+	}
+	nodes := g.SortedNodes()
+	for _, m := range modules {
+		id := ModuleID(m.Name)
+		for _, n := range nodes {
+			if n.Kind == KindFile && n.Module == m.Name {
+				g.AddEdge(&Edge{From: id, To: n.ID, Kind: EdgeContains})
+			}
+		}
+		for _, name := range m.Requires {
+			g.AddEdge(&Edge{From: id, To: ModuleID(name), Kind: EdgeImports})
+		}
+		for _, dep := range m.Deps {
+			depID := GenerateNodeID(dep, "")
+			g.AddNode(&Node{ID: depID, Kind: KindPackage, Name: path.Base(dep), Path: dep})
+			g.AddEdge(&Edge{From: id, To: depID, Kind: EdgeImports})
+		}
+	}
+	g.NodeCount = len(g.Nodes)
+	g.EdgeCount = len(g.Edges)
+}
+
+// SetPackageVersions records in Node.Version the version resolve returns
+// for the import path of each external package and external symbol node,
+// "" when it has none.
+func (g *CodeGraph) SetPackageVersions(resolve func(importPath string) string) {
+	for _, n := range g.Nodes {
+		switch {
+		case n.Kind == KindPackage && !n.IsModule():
+			n.Version = resolve(n.Path)
+		case n.Kind == KindExternal:
+			n.Version = resolve(n.Package)
+		}
+	}
+}
+
+// inDir reports whether the slash-separated path p is dir or inside it.
+func inDir(dir, p string) bool {
+	return dir == "." || p == dir || strings.HasPrefix(p, dir+"/")
+}
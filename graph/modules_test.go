@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetModules(t *testing.T) {
+	g := buildGraph(
+		&FileAnalysis{Path: "api/server.go", Language: "go", Functions: []FuncInfo{{Name: "Serve", Line: 3}}},
+		&FileAnalysis{Path: "api/routes.go", Language: "go"},
+		&FileAnalysis{Path: "web/index.ts", Language: "typescript"},
+		&FileAnalysis{Path: "main.go", Language: "go"},
+	)
+	// A package node left by the parser with no edges of its own
+	g.AddNode(&Node{ID: GenerateNodeID("unsafe", ""), Kind: KindPackage, Name: "unsafe", Path: "unsafe"})
+
+	g.SetModules([]Module{
+		{Name: "example.com/app", Path: ".", Kind: "go"},
+		{Name: "example.com/app/api", Path: "api", Kind: "go", Deps: []string{"github.com/gorilla/mux"}},
+		{Name: "web", Path: "web", Kind: "npm", Deps: []string{"react"}, Requires: []string{"example.com/app/api"}},
+	})
+	if n := g.GetNode(GenerateNodeID("api/server.go", "Serve")); n == nil || n.Module != "example.com/app/api" {
+		t.Errorf("Serve is in module %v, want the innermost, example.com/app/api", n)
+	}
+	var contained []string
+	for _, e := range g.GetOutgoingEdges(ModuleID("example.com/app/api")) {
+		if e.Kind == EdgeContains {
+			contained = append(contained, g.GetNode(e.To).Path)
+		}
+	}
+	if want := []string{"api/routes.go", "api/server.go"}; !reflect.DeepEqual(contained, want) {
+		t.Errorf("api contains %q, want %q in path order", contained, want)
+	}
+	if findEdge(g, "web", "example.com/app/api", EdgeImports) == nil || findEdge(g, "web", "react", EdgeImports) == nil {
+		t.Error("web should import the api module and react")
+	}
+
+	// Replacing the modules drops the dependency nodes only the old ones used
+	g.SetModules([]Module{{Name: "example.com/app", Path: ".", Kind: "go"}})
+	for _, id := range []NodeID{ModuleID("web"), GenerateNodeID("react", ""), GenerateNodeID("github.com/gorilla/mux", "")} {
+		if g.GetNode(id) != nil {
+			t.Errorf("%s survived SetModules", id)
+		}
+	}
+	if g.GetNode(GenerateNodeID("unsafe", "")) == nil {
+		t.Error("SetModules deleted a package node it didn't add")
+	}
+	if n := g.GetNode(GenerateNodeID("api/server.go", "Serve")); n.Module != "example.com/app" {
+		t.Errorf("Serve is in module %q, want example.com/app", n.Module)
+	}
+	if g.NodeCount != len(g.Nodes) || g.EdgeCount != len(g.Edges) {
+		t.Errorf("counts %d/%d, want %d/%d", g.NodeCount, g.EdgeCount, len(g.Nodes), len(g.Edges))
+	}
+}
@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -20,9 +21,12 @@ type PathResult struct {
 	Length int     `json:"length"`
 }
 
-// FindNodesByPattern searches for nodes matching a pattern in name or path.
+// FindNodesByPattern searches for nodes matching a pattern in name, qualified
+// name or path. Qualified patterns may use Go ("(*Reader).Close"), C++/Rust
+// ("Reader::Close") or Ruby/Java doc ("Reader#Close") notation.
 func (g *CodeGraph) FindNodesByPattern(pattern string, kinds []NodeKind) []*Node {
 	pattern = strings.ToLower(pattern)
+	qualified := normalizeQualified(pattern)
 	var results []*Node
 
 	kindSet := make(map[NodeKind]bool)
@@ -30,7 +34,7 @@ func (g *CodeGraph) FindNodesByPattern(pattern string, kinds []NodeKind) []*Node
 		kindSet[k] = true
 	}
 
-	for _, node := range g.Nodes {
+	for _, node := range g.SortedNodes() {
 		// Filter by kind if specified
 		if len(kinds) > 0 && !kindSet[node.Kind] {
 			continue
@@ -42,12 +46,27 @@ func (g *CodeGraph) FindNodesByPattern(pattern string, kinds []NodeKind) []*Node
 
 		if strings.Contains(nameLower, pattern) || strings.Contains(pathLower, pattern) {
 			results = append(results, node)
+			continue
+		}
+		if node.QualifiedName != "" && strings.Contains(qualified, ".") &&
+			strings.Contains(strings.ToLower(node.QualifiedName), qualified) {
+			results = append(results, node)
 		}
 	}
 
 	return results
 }
 
+// normalizeQualified rewrites the separators of a qualified symbol name to
+// the dotted form used by Node.QualifiedName.
+func normalizeQualified(name string) string {
+	name = strings.TrimPrefix(name, "(*")
+	name = strings.TrimPrefix(name, "(")
+	name = strings.Replace(name, ").", ".", 1)
+	name = strings.ReplaceAll(name, "::", ".")
+	return strings.ReplaceAll(name, "#", ".")
+}
+
 // FindPath finds the shortest path between two nodes using BFS.
 // Returns nil if no path exists.
 func (g *CodeGraph) FindPath(fromID, toID NodeID, maxDepth int) *PathResult {
@@ -176,7 +195,8 @@ func (g *CodeGraph) FindAllPaths(fromID, toID NodeID, maxDepth int) []*PathResul
 }
 
 // GetDependencyTree returns all nodes reachable from a starting node.
-func (g *CodeGraph) GetDependencyTree(startID NodeID, maxDepth int) map[int][]*Node {
+// If kinds are given, only edges of those kinds are followed.
+func (g *CodeGraph) GetDependencyTree(startID NodeID, maxDepth int, kinds ...EdgeKind) map[int][]*Node {
 	if maxDepth <= 0 {
 		maxDepth = 5
 	}
@@ -202,7 +222,7 @@ func (g *CodeGraph) GetDependencyTree(startID NodeID, maxDepth int) map[int][]*N
 			}
 
 			for _, edge := range g.GetOutgoingEdges(id) {
-				if !visited[edge.To] {
+				if !visited[edge.To] && edgeKindIn(edge.Kind, kinds) {
 					nextLevel = append(nextLevel, edge.To)
 				}
 			}
@@ -218,7 +238,8 @@ func (g *CodeGraph) GetDependencyTree(startID NodeID, maxDepth int) map[int][]*N
 }
 
 // GetReverseTree returns all nodes that transitively depend on the starting node.
-func (g *CodeGraph) GetReverseTree(startID NodeID, maxDepth int) map[int][]*Node {
+// If kinds are given, only edges of those kinds are followed.
+func (g *CodeGraph) GetReverseTree(startID NodeID, maxDepth int, kinds ...EdgeKind) map[int][]*Node {
 	if maxDepth <= 0 {
 		maxDepth = 5
 	}
@@ -244,7 +265,7 @@ func (g *CodeGraph) GetReverseTree(startID NodeID, maxDepth int) map[int][]*Node
 			}
 
 			for _, edge := range g.GetIncomingEdges(id) {
-				if !visited[edge.From] {
+				if !visited[edge.From] && edgeKindIn(edge.Kind, kinds) {
 					nextLevel = append(nextLevel, edge.From)
 				}
 			}
@@ -259,6 +280,109 @@ func (g *CodeGraph) GetReverseTree(startID NodeID, maxDepth int) map[int][]*Node
 	return levels
 }
 
+// FindTypesByName returns type nodes named exactly name, falling back to a
+// substring match when there is no exact match.
+func (g *CodeGraph) FindTypesByName(name string) []*Node {
+	var results []*Node
+	for _, n := range g.GetNodesByName(name) {
+		if n.Kind == KindType {
+			results = append(results, n)
+		}
+	}
+	if len(results) == 0 {
+		results = g.FindNodesByPattern(name, []NodeKind{KindType})
+	}
+	return results
+}
+
+// edgeKindIn reports whether kind is in kinds; an empty filter matches all kinds.
+func edgeKindIn(kind EdgeKind, kinds []EdgeKind) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// HierarchyLink is a type reached while walking the type hierarchy.
+type HierarchyLink struct {
+	Node  *Node    `json:"node"`
+	Kind  EdgeKind `json:"kind"`  // Extends or implements edge that led here
+	Depth int      `json:"depth"` // Distance from the queried type
+}
+
+// HierarchyResult holds the supertypes and subtypes of a type.
+type HierarchyResult struct {
+	Type       *Node           `json:"type"`
+	Supertypes []HierarchyLink `json:"supertypes"`
+	Subtypes   []HierarchyLink `json:"subtypes"`
+}
+
+// GetTypeHierarchy returns the transitive supertypes and subtypes of a type
+// following extends and implements edges.
+func (g *CodeGraph) GetTypeHierarchy(id NodeID, maxDepth int) *HierarchyResult {
+	node := g.GetNode(id)
+	if node == nil {
+		return nil
+	}
+	if maxDepth <= 0 {
+		maxDepth = 5
+	}
+
+	walk := func(up bool) []HierarchyLink {
+		var links []HierarchyLink
+		visited := map[NodeID]bool{id: true}
+		level := []NodeID{id}
+		for depth := 1; depth <= maxDepth && len(level) > 0; depth++ {
+			var next []NodeID
+			for _, cur := range level {
+				edges := g.GetIncomingEdges(cur)
+				if up {
+					edges = g.GetOutgoingEdges(cur)
+				}
+				for _, edge := range edges {
+					if edge.Kind != EdgeExtends && edge.Kind != EdgeImplements {
+						continue
+					}
+					other := edge.From
+					if up {
+						other = edge.To
+					}
+					if visited[other] {
+						continue
+					}
+					visited[other] = true
+					if n := g.GetNode(other); n != nil {
+						links = append(links, HierarchyLink{Node: n, Kind: edge.Kind, Depth: depth})
+						next = append(next, other)
+					}
+				}
+			}
+			level = next
+		}
+		sort.SliceStable(links, func(i, j int) bool {
+			if links[i].Depth != links[j].Depth {
+				return links[i].Depth < links[j].Depth
+			}
+			if links[i].Node.Path != links[j].Node.Path {
+				return links[i].Node.Path < links[j].Node.Path
+			}
+			return links[i].Node.Line < links[j].Node.Line
+		})
+		return links
+	}
+
+	return &HierarchyResult{
+		Type:       node,
+		Supertypes: walk(true),
+		Subtypes:   walk(false),
+	}
+}
+
 // Stats returns statistics about the graph.
 type Stats struct {
 	TotalNodes      int            `json:"total_nodes"`
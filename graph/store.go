@@ -2,10 +2,15 @@ package graph
 
 import (
 	"compress/gzip"
+	"crypto/sha256"
 	"encoding/gob"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -28,6 +33,8 @@ func EnsureDir(rootPath string) error {
 }
 
 // SaveBinary writes the graph to disk using gob encoding with gzip compression.
+// The file is replaced atomically, so concurrent readers (the MCP server
+// while a watcher updates the index) never see a partial graph.
 func (g *CodeGraph) SaveBinary(path string) error {
 	// Ensure parent directory exists
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
@@ -39,23 +46,41 @@ func (g *CodeGraph) SaveBinary(path string) error {
 	g.NodeCount = len(g.Nodes)
 	g.EdgeCount = len(g.Edges)
 
-	// Create file
-	f, err := os.Create(path)
+	return writeAtomic(path, func(w io.Writer) error {
+		// Wrap with gzip for compression
+		gz := gzip.NewWriter(w)
+
+		// Encode with gob
+		enc := gob.NewEncoder(gz)
+		if err := enc.Encode(g); err != nil {
+			return fmt.Errorf("encode graph: %w", err)
+		}
+		return gz.Close()
+	})
+}
+
+// writeAtomic writes a file through a temporary file in the same directory
+// that is renamed over path once write succeeds.
+func writeAtomic(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
 	if err != nil {
 		return fmt.Errorf("create file: %w", err)
 	}
-	defer f.Close()
-
-	// Wrap with gzip for compression
-	gz := gzip.NewWriter(f)
-	defer gz.Close()
+	tmp := f.Name()
 
-	// Encode with gob
-	enc := gob.NewEncoder(gz)
-	if err := enc.Encode(g); err != nil {
-		return fmt.Errorf("encode graph: %w", err)
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace file: %w", err)
 	}
-
 	return nil
 }
 
@@ -81,6 +106,11 @@ func LoadBinary(path string) (*CodeGraph, error) {
 		return nil, fmt.Errorf("decode graph: %w", err)
 	}
 
+	// Graphs saved before file states were recorded have none
+	if g.Files == nil {
+		g.Files = make(map[string]FileState)
+	}
+
 	// Rebuild in-memory indexes
 	g.RebuildIndexes()
 
@@ -93,87 +123,122 @@ func Exists(path string) bool {
 	return err == nil
 }
 
-// IsStale checks if the graph needs to be rebuilt.
-// Returns true if any source file has been modified after the graph was indexed.
-func IsStale(g *CodeGraph, rootPath string) (bool, error) {
-	if g == nil || g.LastIndexed == 0 {
-		return true, nil
-	}
+// FileChanges classifies the current source files of a project against the
+// file states recorded in its graph. Paths are relative to the project root.
+type FileChanges struct {
+	Added     []string `json:"added"`
+	Changed   []string `json:"changed"`
+	Removed   []string `json:"removed"`
+	Unchanged []string `json:"unchanged"`
+}
 
-	indexTime := time.Unix(g.LastIndexed, 0)
+// Empty reports whether no file was added, changed or removed.
+func (c *FileChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Changed) == 0 && len(c.Removed) == 0
+}
 
-	// Check each file in the graph
-	for path := range g.nodesByPath {
-		fullPath := filepath.Join(rootPath, path)
-		info, err := os.Stat(fullPath)
-		if err != nil {
-			if os.IsNotExist(err) {
-				// File was deleted - graph is stale
-				return true, nil
+// DiffFiles compares the source files at paths (relative to rootPath) with
+// the file states recorded in g. Files whose size matches are hashed, so
+// the result depends on content only: checkouts, touches, clock skew and
+// copied trees don't mark files changed, and edits that keep an old
+// modification time aren't missed. With no graph every file is added.
+func DiffFiles(g *CodeGraph, rootPath string, paths []string) *FileChanges {
+	return diffFiles(g, rootPath, paths, func(string) bool { return true })
+}
+
+// DiffTouched is DiffFiles limited to the paths a file watcher reported as
+// touched; a touched directory covers every file under it. paths are the
+// touched files that still exist as source files, so recorded files that
+// are touched but missing from paths are removed.
+func DiffTouched(g *CodeGraph, rootPath string, touched, paths []string) *FileChanges {
+	return diffFiles(g, rootPath, paths, func(path string) bool {
+		for _, t := range touched {
+			if path == t || strings.HasPrefix(path, t+string(filepath.Separator)) {
+				return true
 			}
-			continue // Skip files we can't stat
-		}
-		if info.ModTime().After(indexTime) {
-			return true, nil
 		}
-	}
-
-	return false, nil
+		return false
+	})
 }
 
-// GetModifiedFiles returns a list of files that have been modified since the graph was indexed.
-// Only considers paths that look like actual source files (contain a file extension).
-func GetModifiedFiles(g *CodeGraph, rootPath string) ([]string, error) {
-	if g == nil || g.LastIndexed == 0 {
-		return nil, nil
+// diffFiles classifies paths against g's recorded files; recorded files
+// missing from paths are removed when covered says they were looked at.
+func diffFiles(g *CodeGraph, rootPath string, paths []string, covered func(path string) bool) *FileChanges {
+	changes := &FileChanges{Added: []string{}, Changed: []string{}, Removed: []string{}, Unchanged: []string{}}
+	var recorded map[string]FileState
+	if g != nil {
+		recorded = g.Files
 	}
 
-	indexTime := time.Unix(g.LastIndexed, 0)
-	var modified []string
-
-	for path := range g.nodesByPath {
-		// Skip package paths (no extension) - only check actual file paths
-		ext := filepath.Ext(path)
-		if ext == "" {
+	current := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if current[path] {
 			continue
 		}
-		fullPath := filepath.Join(rootPath, path)
-		info, err := os.Stat(fullPath)
-		if err != nil {
-			if os.IsNotExist(err) {
-				modified = append(modified, path)
-			}
-			continue
+		current[path] = true
+		state, ok := recorded[path]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, path)
+		case fileChanged(filepath.Join(rootPath, path), state):
+			changes.Changed = append(changes.Changed, path)
+		default:
+			changes.Unchanged = append(changes.Unchanged, path)
 		}
-		if info.ModTime().After(indexTime) {
-			modified = append(modified, path)
+	}
+	for path := range recorded {
+		if !current[path] && covered(path) {
+			changes.Removed = append(changes.Removed, path)
 		}
 	}
 
-	return modified, nil
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Unchanged)
+	return changes
 }
 
-// GetDeletedFiles returns files that were in the graph but no longer exist.
-// Only considers paths that look like actual source files (contain a file extension).
-func GetDeletedFiles(g *CodeGraph, rootPath string) []string {
-	if g == nil {
-		return nil
+// IsStale checks if the graph needs to be rebuilt: true when it records no
+// file states or any recorded file was changed or deleted. Files added
+// since indexing are not seen; use DiffFiles with the current file list.
+func IsStale(g *CodeGraph, rootPath string) (bool, error) {
+	if g == nil || len(g.Files) == 0 {
+		return true, nil
 	}
-
-	var deleted []string
-	for path := range g.nodesByPath {
-		// Skip package paths (no extension) - only check actual file paths
-		ext := filepath.Ext(path)
-		if ext == "" {
-			continue
-		}
-		fullPath := filepath.Join(rootPath, path)
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-			deleted = append(deleted, path)
+	for path, state := range g.Files {
+		if fileChanged(filepath.Join(rootPath, path), state) {
+			return true, nil
 		}
 	}
+	return false, nil
+}
+
+// HashFile returns the size and content hash of a file.
+func HashFile(path string) (FileState, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FileState{}, err
+	}
+	defer f.Close()
 
-	return deleted
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return FileState{}, err
+	}
+	return FileState{Size: n, Hash: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// fileChanged reports whether the file at path no longer matches state.
+// The size is compared first, so only files of the same size are hashed.
+func fileChanged(path string, state FileState) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != state.Size {
+		return true
+	}
+	current, err := HashFile(path)
+	return err != nil || current.Hash != state.Hash
 }
 
 // IsFileInGraph checks if a file path is already in the graph.
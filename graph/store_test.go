@@ -0,0 +1,161 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// indexedTree writes files under a temporary root and returns it with a
+// graph recording their states, as an index run does.
+func indexedTree(t *testing.T, files map[string]string) (string, *CodeGraph) {
+	t.Helper()
+	root := t.TempDir()
+	g := NewCodeGraph(root)
+	for path, content := range files {
+		abs := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		state, err := HashFile(abs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Files[path] = state
+	}
+	return root, g
+}
+
+func TestDiffFilesByContent(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, path string) // Changes the file at path
+		want   string                          // Added, Changed, Removed or Unchanged
+	}{
+		{
+			name: "same size, modification time kept",
+			modify: func(t *testing.T, path string) {
+				info, _ := os.Stat(path)
+				writeFile(t, path, "package a // v2\n")
+				if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "Changed",
+		},
+		{
+			name: "touched, same content",
+			modify: func(t *testing.T, path string) {
+				if err := os.Chtimes(path, past, past); err != nil {
+					t.Fatal(err)
+				}
+				writeFile(t, path, "package a // v1\n")
+			},
+			want: "Unchanged",
+		},
+		{
+			name:   "size changed",
+			modify: func(t *testing.T, path string) { writeFile(t, path, "package a\n") },
+			want:   "Changed",
+		},
+		{
+			name:   "untouched",
+			modify: func(t *testing.T, path string) {},
+			want:   "Unchanged",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, g := indexedTree(t, map[string]string{"a.go": "package a // v1\n"})
+			tt.modify(t, filepath.Join(root, "a.go"))
+
+			changes := DiffFiles(g, root, []string{"a.go"})
+			got := map[string][]string{
+				"Added":     changes.Added,
+				"Changed":   changes.Changed,
+				"Removed":   changes.Removed,
+				"Unchanged": changes.Unchanged,
+			}
+			for kind, paths := range got {
+				want := []string{}
+				if kind == tt.want {
+					want = []string{"a.go"}
+				}
+				if !reflect.DeepEqual(paths, want) {
+					t.Errorf("%s = %v, want %v", kind, paths, want)
+				}
+			}
+			if stale, _ := IsStale(g, root); stale != (tt.want == "Changed") {
+				t.Errorf("IsStale = %v, want %v", stale, tt.want == "Changed")
+			}
+		})
+	}
+}
+
+func TestDiffFilesAddedAndRemoved(t *testing.T) {
+	root, g := indexedTree(t, map[string]string{
+		"a.go":     "package a\n",
+		"b/b.go":   "package b\n",
+		"c/c.go":   "package c\n",
+		"c/old.go": "package c\n",
+	})
+	writeFile(t, filepath.Join(root, "d.go"), "package d\n")
+
+	changes := DiffFiles(g, root, []string{"a.go", "c/c.go", "d.go"})
+	want := &FileChanges{
+		Added:     []string{"d.go"},
+		Changed:   []string{},
+		Removed:   []string{"b/b.go", "c/old.go"},
+		Unchanged: []string{"a.go", "c/c.go"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("DiffFiles = %+v, want %+v", changes, want)
+	}
+
+	// A watcher that saw only c/ leaves b/b.go alone
+	changes = DiffTouched(g, root, []string{"c"}, []string{"c/c.go"})
+	want = &FileChanges{
+		Added:     []string{},
+		Changed:   []string{},
+		Removed:   []string{"c/old.go"},
+		Unchanged: []string{"c/c.go"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("DiffTouched = %+v, want %+v", changes, want)
+	}
+
+	if changes := DiffFiles(nil, root, []string{"a.go"}); !reflect.DeepEqual(changes.Added, []string{"a.go"}) {
+		t.Errorf("without a graph, Added = %v, want [a.go]", changes.Added)
+	}
+}
+
+func TestFileStatesSurviveSave(t *testing.T) {
+	root, g := indexedTree(t, map[string]string{"a.go": "package a\n"})
+	path := filepath.Join(root, DefaultGraphDir, DefaultGraphFile)
+	if err := g.SaveBinary(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBinary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Files, g.Files) {
+		t.Errorf("loaded Files = %v, want %v", loaded.Files, g.Files)
+	}
+	if stale, _ := IsStale(loaded, root); stale {
+		t.Error("freshly saved graph is stale")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"path/filepath"
+	"sort"
 )
 
 // NodeKind represents the type of a code entity in the graph.
@@ -20,6 +22,7 @@ const (
 	KindType
 	KindVariable
 	KindConstant
+	KindExternal // Function or method of an external package the code calls
 )
 
 func (k NodeKind) String() string {
@@ -38,6 +41,8 @@ func (k NodeKind) String() string {
 		return "variable"
 	case KindConstant:
 		return "constant"
+	case KindExternal:
+		return "external"
 	default:
 		return "unknown"
 	}
@@ -90,17 +95,35 @@ func GenerateNodeID(path, symbol string) NodeID {
 
 // Node represents a code entity in the knowledge graph.
 type Node struct {
-	ID         NodeID   `json:"id"`
-	Kind       NodeKind `json:"kind"`
-	Name       string   `json:"name"`
-	Path       string   `json:"path"`                  // File path relative to project root
-	Line       int      `json:"line,omitempty"`        // Line number (1-indexed)
-	EndLine    int      `json:"end_line,omitempty"`    // End line number
-	Signature  string   `json:"signature,omitempty"`   // Function/method signature
-	DocString  string   `json:"doc,omitempty"`         // Documentation comment
-	Exported   bool     `json:"exported,omitempty"`    // Is publicly visible
-	Package    string   `json:"package,omitempty"`     // Package/module name
-	ParamCount int      `json:"param_count,omitempty"` // For functions: parameter count (-1 = variadic)
+	ID            NodeID   `json:"id"`
+	Kind          NodeKind `json:"kind"`
+	Name          string   `json:"name"`
+	QualifiedName string   `json:"qualified_name,omitempty"` // package.Owner.Name, plus parameter types for overloads
+	Owner         string   `json:"owner,omitempty"`          // For methods: enclosing class/struct or Go receiver type
+	Path          string   `json:"path"`                     // File path relative to project root
+	Line          int      `json:"line,omitempty"`           // Line number (1-indexed)
+	StartLine     int      `json:"start_line,omitempty"`     // First line of the definition (decorators included)
+	EndLine       int      `json:"end_line,omitempty"`       // End line number
+	StartByte     int      `json:"start_byte,omitempty"`     // Byte offset where the definition starts
+	EndByte       int      `json:"end_byte,omitempty"`       // Byte offset where the definition ends (exclusive)
+	Signature     string   `json:"signature,omitempty"`      // Function/method signature
+	DocString     string   `json:"doc,omitempty"`            // Documentation comment
+	Exported      bool     `json:"exported,omitempty"`       // Is publicly visible
+	Package       string   `json:"package,omitempty"`        // Package/module name
+	Module        string   `json:"module,omitempty"`         // Workspace module the file belongs to (see SetModules)
+	Version       string   `json:"version,omitempty"`        // For external packages: version the lockfiles resolve (see SetPackageVersions)
+	ParamCount    int      `json:"param_count,omitempty"`    // For functions: parameter count (-1 = variadic)
+	TypeKind      string   `json:"type_kind,omitempty"`      // For types: struct, class, interface, trait, ...
+	Fields        []string `json:"fields,omitempty"`         // For types: field and property names
+}
+
+// DisplayName returns the owner-qualified name of a node (Reader.Close),
+// or its plain name for free functions and other symbols.
+func (n *Node) DisplayName() string {
+	if n.Owner != "" {
+		return n.Owner + "." + n.Name
+	}
+	return n.Name
 }
 
 // Edge represents a relationship between two nodes.
@@ -109,16 +132,67 @@ type Edge struct {
 	To       NodeID   `json:"to"`
 	Kind     EdgeKind `json:"kind"`
 	Line     int      `json:"line,omitempty"`      // Line where the reference occurs
-	Weight   float64  `json:"weight,omitempty"`    // Relationship strength (0-1)
+	Weight   float64  `json:"weight,omitempty"`    // Relationship strength (0-1); for calls, resolution confidence
 	CallSite string   `json:"callsite,omitempty"`  // For calls: the call expression text
 	ArgCount int      `json:"arg_count,omitempty"` // For calls: number of arguments
+
+	Resolution string `json:"resolution,omitempty"` // For calls: how the callee was chosen (Resolution*)
+}
+
+// Call resolution methods recorded in Edge.Resolution. The receiver-based
+// ones follow the scanner's inference of the receiver's type or package.
+const (
+	ResolutionReceiver = "receiver"  // Method of the caller's own type (self, this, Go receiver)
+	ResolutionParam    = "param"     // Method of a parameter's declared type
+	ResolutionLocal    = "local"     // Method of a typed or constructed local
+	ResolutionField    = "field"     // Method of a field's declared type
+	ResolutionStatic   = "static"    // Method called on a type name
+	ResolutionImport   = "import"    // Function of an imported package
+	ResolutionSameFile = "same_file" // Name defined in the caller's file
+	ResolutionName     = "name"      // Only function of that name in the graph
+	ResolutionGuess    = "heuristic" // Ambiguous name, picked by file or package
+
+	ResolutionTyped    = "typed"    // Static callee from the type checker (--precise)
+	ResolutionDispatch = "dispatch" // Dynamic call target found by call graph analysis (--precise)
+	ResolutionIndex    = "index"    // Reference recorded by an imported SCIP/LSIF index
+)
+
+// resolutionConfidence is the Edge.Weight given to each resolution method.
+var resolutionConfidence = map[string]float64{
+	ResolutionTyped:    1,
+	ResolutionIndex:    1,
+	ResolutionReceiver: 0.95,
+	ResolutionParam:    0.9,
+	ResolutionLocal:    0.9,
+	ResolutionImport:   0.9,
+	ResolutionField:    0.85,
+	ResolutionStatic:   0.85,
+	ResolutionDispatch: 0.8,
+	ResolutionSameFile: 0.7,
+	ResolutionName:     0.5,
+	ResolutionGuess:    0.3,
+}
+
+// ResolutionConfidence returns the confidence (0-1) of a call resolution method.
+func ResolutionConfidence(method string) float64 {
+	return resolutionConfidence[method]
+}
+
+// FileState records the content of an indexed file, so incremental indexing
+// can tell whether it changed without trusting modification times.
+type FileState struct {
+	Size int64  `json:"size"`
+	Hash string `json:"hash"` // Hex SHA-256 of the content
 }
 
 // CodeGraph is the main knowledge graph structure with indexed lookups.
 type CodeGraph struct {
 	// Core storage
-	Nodes map[NodeID]*Node `json:"nodes"`
-	Edges []*Edge          `json:"edges"`
+	Nodes map[NodeID]*Node     `json:"nodes"`
+	Edges []*Edge              `json:"edges"`
+	Files map[string]FileState `json:"files"` // Indexed source file -> content state
+
+	Modules []Module `json:"modules,omitempty"` // Workspace modules in path order
 
 	// Indexes for fast lookup (rebuilt on load)
 	nodesByPath map[string][]*Node // path -> nodes in that file
@@ -139,6 +213,7 @@ func NewCodeGraph(rootPath string) *CodeGraph {
 	return &CodeGraph{
 		Nodes:       make(map[NodeID]*Node),
 		Edges:       make([]*Edge, 0),
+		Files:       make(map[string]FileState),
 		nodesByPath: make(map[string][]*Node),
 		nodesByName: make(map[string][]*Node),
 		edgesByFrom: make(map[NodeID][]*Edge),
@@ -222,6 +297,62 @@ func (g *CodeGraph) GetCallees(id NodeID) []*Node {
 	return callees
 }
 
+// GetReferences returns the reference edges pointing to the given node.
+func (g *CodeGraph) GetReferences(id NodeID) []*Edge {
+	var refs []*Edge
+	for _, edge := range g.edgesByTo[id] {
+		if edge.Kind == EdgeReferences {
+			refs = append(refs, edge)
+		}
+	}
+	return refs
+}
+
+// GetMethods returns the method nodes defined by a type, in source order.
+func (g *CodeGraph) GetMethods(id NodeID) []*Node {
+	var methods []*Node
+	for _, edge := range g.edgesByFrom[id] {
+		if edge.Kind != EdgeDefines {
+			continue
+		}
+		if n := g.Nodes[edge.To]; n != nil {
+			methods = append(methods, n)
+		}
+	}
+	sort.SliceStable(methods, func(i, j int) bool {
+		if methods[i].Path != methods[j].Path {
+			return methods[i].Path < methods[j].Path
+		}
+		return methods[i].Line < methods[j].Line
+	})
+	return methods
+}
+
+// SortedNodes returns all nodes ordered by path, line and ID, for callers
+// whose output must not depend on map iteration order.
+func (g *CodeGraph) SortedNodes() []*Node {
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes = append(nodes, n)
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// sortNodes orders nodes by path, line and ID.
+func sortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.ID < b.ID
+	})
+}
+
 // RebuildIndexes rebuilds the in-memory indexes from Nodes and Edges.
 // Call this after loading from disk.
 func (g *CodeGraph) RebuildIndexes() {
@@ -230,7 +361,7 @@ func (g *CodeGraph) RebuildIndexes() {
 	g.edgesByFrom = make(map[NodeID][]*Edge)
 	g.edgesByTo = make(map[NodeID][]*Edge)
 
-	for _, n := range g.Nodes {
+	for _, n := range g.SortedNodes() {
 		g.nodesByPath[n.Path] = append(g.nodesByPath[n.Path], n)
 		g.nodesByName[n.Name] = append(g.nodesByName[n.Name], n)
 	}
@@ -269,6 +400,7 @@ func (g *CodeGraph) RemoveNodesForPath(path string) {
 
 	// Remove from nodesByPath
 	delete(g.nodesByPath, path)
+	delete(g.Files, path)
 
 	// Remove edges involving these nodes
 	var newEdges []*Edge
@@ -289,3 +421,103 @@ func (g *CodeGraph) RemoveNodesForPath(path string) {
 		g.edgesByTo[e.To] = append(g.edgesByTo[e.To], e)
 	}
 }
+
+// DependentFiles returns the indexed files outside paths with nodes whose
+// edges lead into paths: their calls, references and type relations were
+// resolved against the nodes of paths and must be resolved again when those
+// files change.
+func (g *CodeGraph) DependentFiles(paths map[string]bool) []string {
+	dependents := make(map[string]bool)
+	for _, e := range g.Edges {
+		from, to := g.Nodes[e.From], g.Nodes[e.To]
+		if from == nil || to == nil || !paths[to.Path] || paths[from.Path] {
+			continue
+		}
+		if _, indexed := g.Files[from.Path]; indexed {
+			dependents[from.Path] = true
+		}
+	}
+	return sortedSet(dependents)
+}
+
+// GoInterfaceFiles returns the Go files declaring interfaces. The graph
+// doesn't record their method sets, so incremental updates analyze them
+// again to tell which types satisfy them.
+func (g *CodeGraph) GoInterfaceFiles() []string {
+	files := make(map[string]bool)
+	for _, n := range g.Nodes {
+		if n.Kind == KindType && n.TypeKind == "interface" && filepath.Ext(n.Path) == ".go" {
+			files[n.Path] = true
+		}
+	}
+	return sortedSet(files)
+}
+
+// DetachFiles removes the nodes of paths, as RemoveNodesForPath does, and
+// returns the edges that files outside paths made to or from them: calls
+// and references into paths, and defines edges from their types to methods
+// declared elsewhere. Once the files are analyzed again, RestoreEdges puts
+// these back.
+func (g *CodeGraph) DetachFiles(paths map[string]bool) []*Edge {
+	var detached []*Edge
+	for _, e := range g.Edges {
+		from, to := g.Nodes[e.From], g.Nodes[e.To]
+		if from == nil || to == nil || (!paths[from.Path] && !paths[to.Path]) {
+			continue
+		}
+		// The file a defines edge comes from declares the method
+		maker := from
+		if e.Kind == EdgeDefines {
+			maker = to
+		}
+		if !paths[maker.Path] {
+			detached = append(detached, e)
+		}
+	}
+	for _, path := range sortedSet(paths) {
+		g.RemoveNodesForPath(path)
+	}
+	return detached
+}
+
+// RestoreEdges adds back the edges DetachFiles returned whose nodes exist
+// again and that the graph doesn't have yet.
+func (g *CodeGraph) RestoreEdges(edges []*Edge) {
+	for _, e := range edges {
+		if g.Nodes[e.From] == nil || g.Nodes[e.To] == nil {
+			continue
+		}
+		duplicate := false
+		for _, existing := range g.edgesByFrom[e.From] {
+			if existing.To == e.To && existing.Kind == e.Kind && existing.Line == e.Line {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			g.AddEdge(e)
+		}
+	}
+}
+
+// ReplaceEdges drops the edges of the given kinds (all when none are given)
+// made from nodes in the given files and adds edges in their place. Used by
+// precise sources (the type-checked Go call graph, imported SCIP/LSIF
+// indexes), whose edges supersede the name-based ones for the files they
+// cover. Edges into external symbols are kept: precise sources only link
+// nodes of the project.
+func (g *CodeGraph) ReplaceEdges(paths map[string]bool, edges []*Edge, kinds ...EdgeKind) {
+	var newEdges []*Edge
+	for _, edge := range g.Edges {
+		if edgeKindIn(edge.Kind, kinds) {
+			from, to := g.Nodes[edge.From], g.Nodes[edge.To]
+			if from != nil && paths[from.Path] && (to == nil || to.Kind != KindExternal) {
+				continue
+			}
+		}
+		newEdges = append(newEdges, edge)
+	}
+	g.Edges = append(newEdges, edges...)
+	g.EdgeCount = len(g.Edges)
+	g.RebuildIndexes()
+}
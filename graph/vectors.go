@@ -4,6 +4,7 @@ import (
 	"compress/gzip"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
@@ -210,37 +211,31 @@ func (idx *InMemoryVectorIndex) Save(path string) error {
 		return fmt.Errorf("creating directory: %w", err)
 	}
 
-	file, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
-	}
-	defer file.Close()
+	return writeAtomic(path, func(w io.Writer) error {
+		// Use gzip compression
+		gzWriter := gzip.NewWriter(w)
+		encoder := gob.NewEncoder(gzWriter)
 
-	// Use gzip compression
-	gzWriter := gzip.NewWriter(file)
-	defer gzWriter.Close()
-
-	encoder := gob.NewEncoder(gzWriter)
-
-	// Encode dimension first
-	if err := encoder.Encode(idx.dimension); err != nil {
-		return fmt.Errorf("encoding dimension: %w", err)
-	}
+		// Encode dimension first
+		if err := encoder.Encode(idx.dimension); err != nil {
+			return fmt.Errorf("encoding dimension: %w", err)
+		}
 
-	// Encode vector count
-	count := len(idx.vectors)
-	if err := encoder.Encode(count); err != nil {
-		return fmt.Errorf("encoding count: %w", err)
-	}
+		// Encode vector count
+		count := len(idx.vectors)
+		if err := encoder.Encode(count); err != nil {
+			return fmt.Errorf("encoding count: %w", err)
+		}
 
-	// Encode each vector entry
-	for _, entry := range idx.vectors {
-		if err := encoder.Encode(entry); err != nil {
-			return fmt.Errorf("encoding vector entry: %w", err)
+		// Encode each vector entry
+		for _, entry := range idx.vectors {
+			if err := encoder.Encode(entry); err != nil {
+				return fmt.Errorf("encoding vector entry: %w", err)
+			}
 		}
-	}
 
-	return nil
+		return gzWriter.Close()
+	})
 }
 
 // LoadVectorIndex loads a vector index from disk
@@ -0,0 +1,42 @@
+// Package parallel runs loop bodies concurrently on a bounded number of
+// goroutines.
+package parallel
+
+import (
+	"runtime"
+	"sync"
+)
+
+// For calls fn for every index in [0, n) on up to workers goroutines
+// (GOMAXPROCS when workers <= 0) and returns when all calls are done.
+func For(n, workers int, fn func(i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
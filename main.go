@@ -6,17 +6,21 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
 	"time"
 
 	"codemap/analyze"
 	"codemap/cache"
 	"codemap/config"
 	"codemap/graph"
+	"codemap/precise"
 	"codemap/render"
 	"codemap/scanner"
-
-	ignore "github.com/sabhiram/go-gitignore"
+	"codemap/watch"
 )
 
 func main() {
@@ -28,10 +32,16 @@ func main() {
 	jsonMode := flag.Bool("json", false, "Output JSON (for Python renderer compatibility)")
 	debugMode := flag.Bool("debug", false, "Show debug info (gitignore loading, paths, etc.)")
 	helpMode := flag.Bool("help", false, "Show help")
+	workers := flag.Int("workers", 0, "Files analyzed in parallel (default: number of CPUs)")
+	moduleName := flag.String("module", "", "Limit to one workspace module (name or directory); --index then updates only its files, after a first full index")
+	includeGenerated := flag.Bool("include-generated", false, "Analyze generated files (protobuf stubs, \"DO NOT EDIT\" headers) too")
 
 	// New flags for enhanced analysis
 	detailLevel := flag.Int("detail", 0, "Detail level: 0=names, 1=signatures, 2=full (use with --deps)")
 	apiMode := flag.Bool("api", false, "Show public API surface only (compact view, use with --deps)")
+	inventoryMode := flag.Bool("deps-inventory", false, "List the package versions the lockfiles pin, with their dependency tree")
+	sbomMode := flag.Bool("sbom", false, "Write the inventory as a CycloneDX SBOM (use with --deps-inventory)")
+	usageMode := flag.Bool("deps-usage", false, "Show which functions and methods of each external dependency the code calls (uses the graph index)")
 
 	// Graph/RAG mode flags
 	indexMode := flag.Bool("index", false, "Build knowledge graph index")
@@ -41,6 +51,11 @@ func main() {
 	queryDepth := flag.Int("depth", 5, "Query: max traversal depth")
 	forceReindex := flag.Bool("force", false, "Force rebuild index even if up-to-date")
 	graphOutput := flag.String("output", "", "Output path for graph file (default: .codemap/graph.gob)")
+	preciseMode := flag.Bool("precise", false, "Index Go call graphs from type-checked source (use with --index)")
+	importSCIP := flag.String("import-scip", "", "Merge a SCIP or LSIF index into the knowledge graph")
+	exportFormat := flag.String("export", "", "Export the knowledge graph in a format for other tools (scip)")
+	hierarchyType := flag.String("hierarchy", "", "Show supertypes and subtypes of a type (uses the graph index)")
+	refsType := flag.String("refs", "", "Find symbols that reference a type (uses the graph index)")
 
 	// LLM analysis flags
 	explainMode := flag.Bool("explain", false, "Explain a symbol using LLM")
@@ -58,27 +73,48 @@ func main() {
 
 	flag.Parse()
 
+	// "codemap watch [options] [path]" runs the watch daemon
+	watchMode := flag.Arg(0) == "watch"
+	if watchMode {
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+
 	if *helpMode {
 		fmt.Println("codemap - Generate a brain map of your codebase for LLM context")
 		fmt.Println()
 		fmt.Println("Usage: codemap [options] [path]")
+		fmt.Println("       codemap watch [options] [path]")
 		fmt.Println()
 		fmt.Println("Modes:")
 		fmt.Println("  (default)          Tree view with token estimates and file sizes")
 		fmt.Println("  --deps             Dependency flow map (functions, types & imports)")
+		fmt.Println("  --deps-inventory   Package versions the lockfiles pin, as a dependency tree")
+		fmt.Println("  --deps-usage       External APIs the code calls, per dependency (uses the index)")
 		fmt.Println("  --skyline          City skyline visualization")
 		fmt.Println("  --diff             Only show files changed vs a branch")
 		fmt.Println("  --index            Build knowledge graph index (.codemap/graph.gob)")
+		fmt.Println("  watch              Keep the graph index and embeddings fresh as files change")
+		fmt.Println("  --import-scip <f>  Merge a SCIP or LSIF index into the graph index")
+		fmt.Println("  --export scip      Write the graph index as a SCIP index")
 		fmt.Println("  --query            Query the knowledge graph")
+		fmt.Println("  --hierarchy <type> Show supertypes and subtypes of a type")
+		fmt.Println("  --refs <type>      Find symbols that use a type")
 		fmt.Println()
 		fmt.Println("Options:")
 		fmt.Println("  --help             Show this help message")
 		fmt.Println("  --json             Output JSON (for programmatic use)")
+		fmt.Println("  --workers <n>      Files analyzed in parallel (default: number of CPUs)")
+		fmt.Println("  --include-generated  Analyze generated files too (skipped by --deps, --index)")
+		fmt.Println("  --module <name>    Limit any mode to one workspace module (go.mod, package.json,")
+		fmt.Println("                     Cargo.toml or Gradle project; name or directory)")
 		fmt.Println()
 		fmt.Println("Dependency mode (--deps):")
 		fmt.Println("  --detail <level>   Detail level: 0=names, 1=signatures, 2=full")
 		fmt.Println("  --api              Show public API surface only (compact view)")
 		fmt.Println()
+		fmt.Println("Inventory mode (--deps-inventory):")
+		fmt.Println("  --sbom             Write a CycloneDX 1.5 SBOM (JSON) instead")
+		fmt.Println()
 		fmt.Println("Diff mode (--diff):")
 		fmt.Println("  --ref <branch>     Branch to compare against (default: main)")
 		fmt.Println()
@@ -88,12 +124,23 @@ func main() {
 		fmt.Println("Index mode (--index):")
 		fmt.Println("  --force            Force rebuild even if index is up-to-date")
 		fmt.Println("  --output <path>    Output path for graph file (default: .codemap/graph.gob)")
+		fmt.Println("  --precise          Go call edges from go/packages + VTA (offline, module cache)")
+		fmt.Println()
+		fmt.Println("Watch mode (watch):")
+		fmt.Println("  --precise          Also refresh the precise Go call graph on every update")
+		fmt.Println("  --model <name>     Embedding model for vectors.gob updates (overrides config)")
+		fmt.Println()
+		fmt.Println("Export mode (--export <format>):")
+		fmt.Println("  --output <path>    File to write (default: index.scip)")
 		fmt.Println()
 		fmt.Println("Query mode (--query):")
 		fmt.Println("  --from <symbol>    Find outgoing edges from symbol")
 		fmt.Println("  --to <symbol>      Find incoming edges to symbol")
 		fmt.Println("  --depth <n>        Max traversal depth (default: 5)")
 		fmt.Println()
+		fmt.Println("Hierarchy mode (--hierarchy <type>):")
+		fmt.Println("  --depth <n>        Max levels up and down (default: 5)")
+		fmt.Println()
 		fmt.Println("Explain mode (--explain):")
 		fmt.Println("  --symbol <name>    Symbol name to explain")
 		fmt.Println("  --model <name>     LLM model to use (overrides config)")
@@ -115,18 +162,28 @@ func main() {
 		fmt.Println("  codemap .                              # Tree with tokens")
 		fmt.Println("  codemap --deps .                       # Dependencies")
 		fmt.Println("  codemap --index .                      # Build graph index")
+		fmt.Println("  codemap watch .                        # Update the index as files change")
+		fmt.Println("  codemap --import-scip index.scip .     # Add precise refs from scip-go etc.")
+		fmt.Println("  codemap --export scip .                # Write index.scip for code search")
 		fmt.Println("  codemap --query --from main .          # Find what main calls")
 		fmt.Println("  codemap --query --to Scanner .         # Find what calls Scanner")
 		fmt.Println("  codemap --query --from A --to B .      # Find path from A to B")
+		fmt.Println("  codemap --query --from Reader.Close .  # Methods by owner (also (*Reader).Close, Reader::Close)")
+		fmt.Println("  codemap --hierarchy Reader .           # Types implementing Reader")
+		fmt.Println("  codemap --refs Config .                # Who uses the Config type")
 		fmt.Println("  codemap --explain --symbol main .      # Explain main function")
 		fmt.Println("  codemap --summarize src/              # Summarize directory")
 		fmt.Println("  codemap --embed .                      # Generate embeddings")
 		fmt.Println("  codemap --search --q \"parse config\" . # Semantic search")
 		fmt.Println("  codemap --skyline --animate .          # Animated skyline")
+		fmt.Println("  codemap --deps --module api .          # Dependencies of one module in a monorepo")
+		fmt.Println("  codemap --deps-inventory --sbom . > bom.json  # SBOM from go.sum, package-lock.json, ...")
+		fmt.Println("  codemap --deps-usage .                 # Which http.Client, axios, ... calls the code makes")
 		fmt.Println()
 		fmt.Println("Output notes:")
 		fmt.Println("  ⭐️  = Top 5 largest source files")
 		fmt.Println("  [!] = Large file (>8k tokens) - may need chunking for LLMs")
+		fmt.Println("  [generated], [vendored], [minified], [binary] = Not analyzed, no tokens counted")
 		os.Exit(0)
 	}
 
@@ -141,18 +198,33 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Load .gitignore if it exists
+	// Register configured languages and query overrides
+	if err := scanner.RegisterLanguages(absRoot); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Language configuration: %v\n", err)
+	}
+
+	// Load ignore rules: .gitignore files, git excludes and .codemapignore
 	gitignore := scanner.LoadGitignore(root)
+	if *includeGenerated {
+		gitignore.IncludeGenerated = true
+	}
 
 	if *debugMode {
 		fmt.Fprintf(os.Stderr, "[debug] Root path: %s\n", root)
 		fmt.Fprintf(os.Stderr, "[debug] Absolute path: %s\n", absRoot)
-		gitignorePath := filepath.Join(root, ".gitignore")
-		if gitignore != nil {
-			fmt.Fprintf(os.Stderr, "[debug] Loaded .gitignore from: %s\n", gitignorePath)
-		} else {
-			fmt.Fprintf(os.Stderr, "[debug] No .gitignore found at: %s\n", gitignorePath)
+		sources := gitignore.Sources()
+		if len(sources) == 0 {
+			fmt.Fprintf(os.Stderr, "[debug] No ignore files found for: %s\n", root)
 		}
+		for _, source := range sources {
+			fmt.Fprintf(os.Stderr, "[debug] Loaded ignore file: %s\n", source)
+		}
+	}
+
+	// Resolve --module among the workspace's modules
+	var scope *moduleScope
+	if *moduleName != "" {
+		scope = newModuleScope(root, gitignore, *moduleName)
 	}
 
 	// Get changed files if --diff is specified
@@ -171,39 +243,85 @@ func main() {
 		}
 	}
 
+	// Handle watch mode
+	if watchMode {
+		runWatchMode(absRoot, root, gitignore, scope, *jsonMode, *graphOutput, *workers, *preciseMode, *llmModel)
+		return
+	}
+
 	// Handle --index mode
 	if *indexMode {
-		runIndexMode(absRoot, root, gitignore, *forceReindex, *jsonMode, *graphOutput)
+		runIndexMode(absRoot, root, gitignore, scope, *forceReindex, *jsonMode, *graphOutput, *workers, *preciseMode)
+		return
+	}
+
+	// Handle --import-scip mode
+	if *importSCIP != "" {
+		runImportSCIPMode(absRoot, *importSCIP, *graphOutput, scope, *jsonMode)
+		return
+	}
+
+	// Handle --export mode
+	if *exportFormat != "" {
+		runExportMode(absRoot, *exportFormat, *graphOutput, scope, *jsonMode)
 		return
 	}
 
 	// Handle --query mode
 	if *queryMode {
-		runQueryMode(absRoot, *queryFrom, *queryTo, *queryDepth, *jsonMode)
+		runQueryMode(absRoot, *queryFrom, *queryTo, *queryDepth, scope, *jsonMode)
+		return
+	}
+
+	// Handle --hierarchy mode
+	if *hierarchyType != "" {
+		runHierarchyMode(absRoot, *hierarchyType, *queryDepth, scope, *jsonMode)
+		return
+	}
+
+	// Handle --refs mode
+	if *refsType != "" {
+		runRefsMode(absRoot, *refsType, scope, *jsonMode)
 		return
 	}
 
 	// Handle --explain mode
 	if *explainMode {
-		runExplainMode(absRoot, *explainSymbol, *llmModel, *noCache, *jsonMode)
+		runExplainMode(absRoot, *explainSymbol, *llmModel, scope, *noCache, *jsonMode)
 		return
 	}
 
 	// Handle --summarize mode
 	if *summarizeMode {
-		runSummarizeMode(root, *llmModel, *noCache, *jsonMode)
+		target := root
+		if scope != nil {
+			target = filepath.Join(root, scope.mod.Path)
+		}
+		runSummarizeMode(target, *llmModel, *noCache, *jsonMode)
 		return
 	}
 
 	// Handle --embed mode
 	if *embedMode {
-		runEmbedMode(absRoot, *llmModel, *forceReindex, *jsonMode)
+		runEmbedMode(absRoot, *llmModel, scope, *forceReindex, *jsonMode)
 		return
 	}
 
 	// Handle --search mode
 	if *searchMode {
-		runSearchMode(absRoot, *searchQuery, *searchLimit, *searchExpand, *llmModel, *jsonMode)
+		runSearchMode(absRoot, *searchQuery, *searchLimit, *searchExpand, *llmModel, scope, *jsonMode)
+		return
+	}
+
+	// Handle --deps-usage mode
+	if *usageMode {
+		runUsageMode(absRoot, gitignore, scope, *jsonMode)
+		return
+	}
+
+	// Handle --deps-inventory mode
+	if *inventoryMode {
+		runInventoryMode(absRoot, gitignore, scope, *jsonMode, *sbomMode)
 		return
 	}
 
@@ -213,7 +331,7 @@ func main() {
 		if diffInfo != nil {
 			changedFiles = diffInfo.Changed
 		}
-		runDepsMode(absRoot, root, gitignore, *jsonMode, *diffRef, changedFiles, *detailLevel, *apiMode)
+		runDepsMode(absRoot, root, gitignore, scope, *jsonMode, *diffRef, changedFiles, *detailLevel, *apiMode, *workers)
 		return
 	}
 
@@ -228,6 +346,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error walking tree: %v\n", err)
 		os.Exit(1)
 	}
+	files = scope.filterFiles(files)
 
 	// Filter to changed files if --diff specified (with diff info annotations)
 	var impact []scanner.ImpactInfo
@@ -257,7 +376,7 @@ func main() {
 	}
 }
 
-func runDepsMode(absRoot, root string, gitignore *ignore.GitIgnore, jsonMode bool, diffRef string, changedFiles map[string]bool, detailLevel int, apiMode bool) {
+func runDepsMode(absRoot, root string, gitignore *scanner.IgnoreMatcher, scope *moduleScope, jsonMode bool, diffRef string, changedFiles map[string]bool, detailLevel int, apiMode bool, workers int) {
 	loader := scanner.NewGrammarLoader()
 
 	// Check if grammars are available
@@ -275,7 +394,7 @@ func runDepsMode(absRoot, root string, gitignore *ignore.GitIgnore, jsonMode boo
 		os.Exit(1)
 	}
 
-	analyses, err := scanner.ScanForDeps(root, gitignore, loader, scanner.DetailLevel(detailLevel))
+	analyses, err := scanner.ScanForDepsWithWorkers(root, gitignore, loader, scanner.DetailLevel(detailLevel), workers)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning for deps: %v\n", err)
 		os.Exit(1)
@@ -286,11 +405,28 @@ func runDepsMode(absRoot, root string, gitignore *ignore.GitIgnore, jsonMode boo
 		analyses = scanner.FilterAnalysisToChanged(analyses, changedFiles)
 	}
 
+	workspace := scanner.DiscoverWorkspace(root, gitignore)
+	dependencies := scanner.ReadDependencies(absRoot)
+	for lang, deps := range dependencies {
+		var external []scanner.Dependency // Modules of the workspace aren't external
+		for _, dep := range deps {
+			if !workspace.HasModule(dep.Name) && scope.contains(dep.Manifest) {
+				external = append(external, dep)
+			}
+		}
+		dependencies[lang] = external
+	}
+	if scope != nil {
+		analyses = scope.filterAnalyses(analyses)
+	}
+
 	depsProject := scanner.DepsProject{
 		Root:         absRoot,
 		Mode:         "deps",
 		Files:        analyses,
-		ExternalDeps: scanner.ReadExternalDeps(absRoot),
+		ExternalDeps: scanner.DependencyNames(dependencies),
+		Dependencies: dependencies,
+		Modules:      workspace.Modules,
 		DiffRef:      diffRef,
 		DetailLevel:  detailLevel,
 	}
@@ -305,7 +441,7 @@ func runDepsMode(absRoot, root string, gitignore *ignore.GitIgnore, jsonMode boo
 	}
 }
 
-func runIndexMode(absRoot, root string, gitignore *ignore.GitIgnore, forceReindex, jsonMode bool, graphOutput string) {
+func runIndexMode(absRoot, root string, gitignore *scanner.IgnoreMatcher, scope *moduleScope, forceReindex, jsonMode bool, graphOutput string, workers int, preciseGo bool) {
 	graphPath := graphOutput
 	if graphPath == "" {
 		graphPath = graph.GraphPath(absRoot)
@@ -318,174 +454,98 @@ func runIndexMode(absRoot, root string, gitignore *ignore.GitIgnore, forceReinde
 		os.Exit(1)
 	}
 
-	// Check if we can do an incremental update
-	var existingGraph *graph.CodeGraph
-	var modifiedFiles, deletedFiles []string
-	isIncremental := false
-
-	if !forceReindex && graph.Exists(graphPath) {
-		existing, err := graph.LoadBinary(graphPath)
-		if err == nil {
-			stale, _ := graph.IsStale(existing, absRoot)
-			if !stale {
-				stats := existing.GetStats()
-				if jsonMode {
-					json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
-						"status":     "up-to-date",
-						"path":       graphPath,
-						"nodes":      stats.TotalNodes,
-						"edges":      stats.TotalEdges,
-						"indexed_at": time.Unix(existing.LastIndexed, 0).Format(time.RFC3339),
-					})
-				} else {
-					fmt.Printf("✓ Index is up-to-date (%d nodes, %d edges)\n", stats.TotalNodes, stats.TotalEdges)
-					fmt.Printf("  Path: %s\n", graphPath)
-					fmt.Printf("  Last indexed: %s\n", time.Unix(existing.LastIndexed, 0).Format(time.RFC3339))
-				}
-				return
+	// A watcher may keep this index fresh; writing it here would race with it
+	if status := watch.Running(absRoot); status != nil && status.Maintains(graphPath) {
+		if jsonMode {
+			json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"status":  "watching",
+				"path":    graphPath,
+				"watcher": status,
+			})
+		} else {
+			fmt.Printf("✓ Index is kept fresh by codemap watch (pid %d)\n", status.PID)
+			fmt.Printf("  Path: %s\n", graphPath)
+			if !status.Updated.IsZero() {
+				fmt.Printf("  Last updated: %s\n", status.Updated.Format(time.RFC3339))
+			}
+			if !status.Fresh() {
+				fmt.Println("  Update pending")
 			}
-			// Graph is stale - use incremental update
-			existingGraph = existing
-			modifiedFiles, _ = graph.GetModifiedFiles(existing, absRoot)
-			deletedFiles = graph.GetDeletedFiles(existing, absRoot)
-			isIncremental = true
 		}
+		return
 	}
 
-	start := time.Now()
-
-	// Scan all files to get current state
-	analyses, err := scanner.ScanForDeps(root, gitignore, loader, scanner.DetailFull)
+	// Compare the current source files with the file states the previous
+	// index recorded; an incremental update needs those states
+	paths, err := scanner.SourceFiles(root, gitignore, loader)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
 		os.Exit(1)
 	}
-
-	// Build set of files that need processing
-	filesToProcess := make(map[string]bool)
-	if isIncremental {
-		// Remove deleted files from graph
-		for _, path := range deletedFiles {
-			existingGraph.RemoveNodesForPath(path)
-			if !jsonMode {
-				fmt.Fprintf(os.Stderr, "  Removed %s\n", path)
-			}
-		}
-
-		// Mark modified files for reprocessing
-		for _, path := range modifiedFiles {
-			existingGraph.RemoveNodesForPath(path)
-			filesToProcess[path] = true
+	var recorded, existingGraph *graph.CodeGraph
+	if graph.Exists(graphPath) {
+		if existing, err := graph.LoadBinary(graphPath); err == nil {
+			recorded = existing
 		}
+	}
+	changes := graph.DiffFiles(recorded, absRoot, paths)
 
-		// Find new files (not in existing graph)
-		for _, a := range analyses {
-			if !existingGraph.IsFileInGraph(a.Path) {
-				filesToProcess[a.Path] = true
-			}
+	// With --module, --force re-analyzes the module's files and keeps the
+	// rest of the index; the first index covers every module
+	if recorded != nil && len(recorded.Files) > 0 && (!forceReindex || scope != nil) {
+		if forceReindex {
+			changes.Changed = append(changes.Changed, changes.Unchanged...)
+			sort.Strings(changes.Changed)
+			changes.Unchanged = nil
 		}
-
-		// If no files need updating, index is up-to-date
-		if len(filesToProcess) == 0 {
-			stats := existingGraph.GetStats()
+		changes = scope.filterChanges(changes)
+		if changes.Empty() {
+			stats := recorded.GetStats()
 			if jsonMode {
 				json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
 					"status":     "up-to-date",
 					"path":       graphPath,
 					"nodes":      stats.TotalNodes,
 					"edges":      stats.TotalEdges,
-					"indexed_at": time.Unix(existingGraph.LastIndexed, 0).Format(time.RFC3339),
+					"indexed_at": time.Unix(recorded.LastIndexed, 0).Format(time.RFC3339),
+					"changes":    changes,
 				})
 			} else {
 				fmt.Printf("✓ Index is up-to-date (%d nodes, %d edges)\n", stats.TotalNodes, stats.TotalEdges)
 				fmt.Printf("  Path: %s\n", graphPath)
+				fmt.Printf("  Last indexed: %s\n", time.Unix(recorded.LastIndexed, 0).Format(time.RFC3339))
 			}
 			return
 		}
+		existingGraph = recorded
+	}
+	isIncremental := existingGraph != nil
 
+	start := time.Now()
+	if isIncremental {
 		if !jsonMode {
-			fmt.Fprintf(os.Stderr, "Incremental update: %d files\n", len(filesToProcess))
+			fmt.Fprintf(os.Stderr, "Incremental update: %d added, %d changed, %d removed, %d unchanged\n",
+				len(changes.Added), len(changes.Changed), len(changes.Removed), len(changes.Unchanged))
 		}
 	} else {
 		fmt.Fprintf(os.Stderr, "Building knowledge graph index...\n")
-		// Full rebuild - process all files
-		for _, a := range analyses {
-			filesToProcess[a.Path] = true
-		}
-	}
-
-	// Create builder (with existing graph for incremental, new for full rebuild)
-	var builder *graph.Builder
-	if isIncremental {
-		builder = graph.NewBuilder(absRoot, graph.WithProgress(func(msg string) {
-			if !jsonMode {
-				fmt.Fprintf(os.Stderr, "  %s\n", msg)
-			}
-		}), graph.WithGitignore(gitignore), graph.WithExistingGraph(existingGraph))
-	} else {
-		builder = graph.NewBuilder(absRoot, graph.WithProgress(func(msg string) {
-			if !jsonMode {
-				fmt.Fprintf(os.Stderr, "  %s\n", msg)
-			}
-		}), graph.WithGitignore(gitignore))
 	}
 
-	// Process files that need updating
-	for _, a := range analyses {
-		if !filesToProcess[a.Path] {
-			continue
-		}
-
-		fa := &graph.FileAnalysis{
-			Path:     a.Path,
-			Language: a.Language,
-			Imports:  a.Imports,
-		}
-
-		// Convert functions
-		for _, f := range a.Functions {
-			fa.Functions = append(fa.Functions, graph.FuncInfo{
-				Name:       f.Name,
-				Signature:  f.Signature,
-				Receiver:   f.Receiver,
-				IsExported: f.IsExported,
-				Line:       f.Line,
-				ParamCount: f.ParamCount,
-			})
-		}
-
-		// Convert types
-		for _, t := range a.Types {
-			fa.Types = append(fa.Types, graph.TypeInfo{
-				Name:       t.Name,
-				Kind:       string(t.Kind),
-				IsExported: t.IsExported,
-				Line:       t.Line,
-			})
-		}
-
-		// Extract calls
-		callAnalysis, err := loader.ExtractCalls(filepath.Join(absRoot, a.Path))
-		if err == nil && callAnalysis != nil {
-			for _, c := range callAnalysis.Calls {
-				fa.Calls = append(fa.Calls, graph.CallInfo{
-					CallerFunc: c.CallerFunc,
-					CallerLine: c.CallerLine,
-					CalleeName: c.CalleeName,
-					CallLine:   c.CallLine,
-					Args:       c.Args,
-					Receiver:   c.Receiver,
-				})
-			}
+	codeGraph, filesUpdated, err := updateGraph(absRoot, root, gitignore, loader, existingGraph, changes, workers, func(msg string) {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "  %s\n", msg)
 		}
-
-		builder.AddFile(fa)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error scanning: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Finalize graph
-	builder.ResolveCallEdges()
-	builder.FilterCallEdges()
-	codeGraph := builder.Build()
+	// Replace heuristic Go call edges with the type-checked call graph
+	var preciseStats *precise.Stats
+	if preciseGo {
+		preciseStats = preciseCallGraph(codeGraph, absRoot, jsonMode)
+	}
 
 	// Save to disk
 	if err := codeGraph.SaveBinary(graphPath); err != nil {
@@ -507,18 +567,20 @@ func runIndexMode(absRoot, root string, gitignore *ignore.GitIgnore, forceReinde
 		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
 			"status":        status,
 			"incremental":   isIncremental,
-			"files_updated": len(filesToProcess),
+			"files_updated": filesUpdated,
 			"path":          graphPath,
 			"nodes":         stats.TotalNodes,
 			"edges":         stats.TotalEdges,
 			"files":         stats.FileCount,
 			"functions":     stats.FunctionCount,
 			"elapsed_ms":    elapsed.Milliseconds(),
+			"precise":       preciseStats,
+			"changes":       changes,
 		})
 	} else {
 		fmt.Printf("\n✓ %s in %v\n", statusMsg, elapsed.Round(time.Millisecond))
 		if isIncremental {
-			fmt.Printf("  Updated: %d files\n", len(filesToProcess))
+			fmt.Printf("  Updated: %d files\n", filesUpdated)
 		}
 		fmt.Printf("  Path: %s\n", graphPath)
 		fmt.Printf("  Nodes: %d (files: %d, functions: %d)\n", stats.TotalNodes, stats.FileCount, stats.FunctionCount)
@@ -526,7 +588,597 @@ func runIndexMode(absRoot, root string, gitignore *ignore.GitIgnore, forceReinde
 	}
 }
 
-func runQueryMode(absRoot, fromSymbol, toSymbol string, maxDepth int, jsonMode bool) {
+// updateGraph analyzes the files changes added or changed and merges them
+// into existing, after dropping the nodes of changed and removed files. The
+// files with edges into those are analyzed again so their calls and
+// references resolve against the new nodes, and edges other files made to
+// re-analyzed ones are kept. Without an existing graph every source file is
+// analyzed into a new one. Returns the graph and the number of files
+// analyzed.
+func updateGraph(absRoot, root string, gitignore *scanner.IgnoreMatcher, loader *scanner.GrammarLoader, existing *graph.CodeGraph, changes *graph.FileChanges, workers int, progress func(msg string)) (*graph.CodeGraph, int, error) {
+	var only map[string]bool
+	var detached []*graph.Edge
+	if existing != nil {
+		only = make(map[string]bool)
+		stale := make(map[string]bool)
+		goChanged := false
+		for _, path := range changes.Removed {
+			stale[path] = true
+			goChanged = goChanged || filepath.Ext(path) == ".go"
+			progress("Removed " + path)
+		}
+		for _, path := range changes.Changed {
+			stale[path] = true
+			goChanged = goChanged || filepath.Ext(path) == ".go"
+		}
+		for _, path := range changes.Added {
+			only[path] = true
+			goChanged = goChanged || filepath.Ext(path) == ".go"
+		}
+
+		// Files resolved against the stale ones are analyzed again, and so
+		// are Go interfaces when method sets may have changed
+		again := existing.DependentFiles(stale)
+		if goChanged {
+			again = append(again, existing.GoInterfaceFiles()...)
+		}
+		for _, path := range again {
+			if !stale[path] {
+				stale[path] = true
+				only[path] = true
+			}
+		}
+		for _, path := range changes.Changed {
+			only[path] = true
+		}
+		detached = existing.DetachFiles(stale)
+	}
+
+	analyses, err := scanner.ScanForDepsIn(root, gitignore, loader, scanner.DetailFull, workers, only)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Create builder (with existing graph for incremental, new for full rebuild)
+	opts := []graph.BuilderOption{graph.WithProgress(progress), graph.WithGitignore(gitignore), graph.WithWorkers(workers)}
+	if existing != nil {
+		opts = append(opts, graph.WithExistingGraph(existing))
+	}
+	builder := graph.NewBuilder(absRoot, opts...)
+
+	files := make([]*graph.FileAnalysis, 0, len(analyses))
+	for _, a := range analyses {
+		files = append(files, graphAnalysis(a))
+	}
+	builder.AddFiles(files)
+
+	// Finalize graph
+	builder.ResolveMethods()
+	builder.ResolveCallEdges()
+	builder.FilterCallEdges()
+	builder.ResolveTypeHierarchy()
+	builder.ResolveReferences()
+	codeGraph := builder.Build()
+	codeGraph.RestoreEdges(detached)
+
+	// Module nodes and membership follow the manifests as they are now
+	codeGraph.SetModules(graphModules(scanner.DiscoverWorkspace(root, gitignore)))
+
+	// Package nodes carry the versions the lockfiles pin
+	inventory := scanner.ReadInventory(absRoot, gitignore)
+	codeGraph.SetPackageVersions(func(importPath string) string {
+		if p, _ := inventory.Resolve(importPath); p != nil {
+			return p.Version
+		}
+		return ""
+	})
+	return codeGraph, len(files), nil
+}
+
+// graphAnalysis converts a scanner analysis into the builder's form.
+func graphAnalysis(a scanner.FileAnalysis) *graph.FileAnalysis {
+	fa := &graph.FileAnalysis{
+		Path:          a.Path,
+		Language:      a.Language,
+		Package:       a.Package,
+		Imports:       a.Imports,
+		ImportedFiles: a.ImportedFiles,
+		Size:          a.Size,
+		Hash:          a.Hash,
+	}
+
+	// Convert functions
+	for _, f := range a.Functions {
+		fa.Functions = append(fa.Functions, graph.FuncInfo{
+			Name:       f.Name,
+			Signature:  f.Signature,
+			Receiver:   f.Receiver,
+			Owner:      f.Owner,
+			IsExported: f.IsExported,
+			Line:       f.Line,
+			StartLine:  f.StartLine,
+			EndLine:    f.EndLine,
+			StartByte:  f.StartByte,
+			EndByte:    f.EndByte,
+			ParamCount: f.ParamCount,
+			DocString:  f.Doc,
+		})
+	}
+
+	// Convert types
+	for _, t := range a.Types {
+		fa.Types = append(fa.Types, graph.TypeInfo{
+			Name:       t.Name,
+			Kind:       string(t.Kind),
+			IsExported: t.IsExported,
+			Line:       t.Line,
+			StartLine:  t.StartLine,
+			EndLine:    t.EndLine,
+			StartByte:  t.StartByte,
+			EndByte:    t.EndByte,
+			DocString:  t.Doc,
+			Extends:    t.Extends,
+			Implements: t.Implements,
+			Methods:    t.Methods,
+			Fields:     t.Fields,
+		})
+	}
+	for _, impl := range a.Impls {
+		fa.Impls = append(fa.Impls, graph.ImplInfo{Type: impl.Type, Trait: impl.Trait, Line: impl.Line})
+	}
+	for _, ref := range a.References {
+		fa.References = append(fa.References, graph.RefInfo{From: ref.From, FromLine: ref.FromLine, Name: ref.Name, IsValue: ref.Kind == "value", Line: ref.Line})
+	}
+
+	// Convert variables and constants
+	for _, v := range a.Variables {
+		fa.Variables = append(fa.Variables, graph.VarInfo{
+			Name:       v.Name,
+			IsConst:    v.Kind == "const",
+			IsExported: v.IsExported,
+			Line:       v.Line,
+			EndLine:    v.EndLine,
+		})
+	}
+
+	// Convert calls
+	for _, c := range a.Calls {
+		fa.Calls = append(fa.Calls, graph.CallInfo{
+			CallerFunc: c.CallerFunc,
+			CallerLine: c.CallerLine,
+			CalleeName: c.CalleeName,
+			CallLine:   c.CallLine,
+			Args:       c.Args,
+			Receiver:   c.Receiver,
+
+			ReceiverType:    c.ReceiverType,
+			ReceiverPackage: c.ReceiverPackage,
+			Inference:       c.Inference,
+		})
+	}
+
+	return fa
+}
+
+// preciseCallGraph replaces the heuristic Go call edges of g with the
+// type-checked call graph, keeping them when it can't be built.
+func preciseCallGraph(g *graph.CodeGraph, absRoot string, quiet bool) *precise.Stats {
+	stats, err := precise.GoCallGraph(g, absRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Precise Go call graph unavailable, keeping heuristic edges: %v\n", err)
+		return nil
+	}
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "  Precise call graph: %d edges from %d Go files (%d packages, %d skipped; %d modules skipped)\n",
+			stats.Edges, stats.Files, stats.Packages, stats.Skipped, stats.SkippedModules)
+	}
+	return stats
+}
+
+// runWatchMode keeps the graph index, and the vector index when one
+// exists, fresh until interrupted: it brings them up to date, then
+// watches the project and updates them for the files each burst of
+// changes touches. The watch lock tells --index and the MCP server that
+// the index on disk is maintained.
+func runWatchMode(absRoot, root string, gitignore *scanner.IgnoreMatcher, scope *moduleScope, jsonMode bool, graphOutput string, workers int, preciseGo bool, modelOverride string) {
+	loader := scanner.NewGrammarLoader()
+	if !loader.HasGrammars() {
+		fmt.Fprintln(os.Stderr, "⚠️  No tree-sitter grammars found. Index requires --deps mode grammars.")
+		os.Exit(1)
+	}
+
+	graphPath := graphOutput
+	if graphPath == "" {
+		graphPath = graph.GraphPath(absRoot)
+	}
+	if abs, err := filepath.Abs(graphPath); err == nil {
+		graphPath = abs // The lock names it for other working directories
+	}
+
+	lock, err := watch.Acquire(absRoot, graphPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting watch: %v\n", err)
+		os.Exit(1)
+	}
+	defer lock.Release()
+	fail := func(format string, args ...interface{}) {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+		lock.Release()
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logf := func(format string, args ...interface{}) {
+		if !jsonMode {
+			fmt.Fprintf(os.Stderr, "[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+		}
+	}
+
+	var codeGraph *graph.CodeGraph
+	if graph.Exists(graphPath) {
+		if existing, err := graph.LoadBinary(graphPath); err == nil && len(existing.Files) > 0 {
+			codeGraph = existing
+		}
+	}
+	vectors := newVectorUpdater(absRoot, modelOverride, logf)
+
+	// update applies changes to the graph and vectors and records the result
+	update := func(changes *graph.FileChanges) {
+		start := time.Now()
+		stale := vectors.staleNodes(codeGraph, changes)
+
+		updated, files, err := updateGraph(absRoot, root, gitignore, loader, codeGraph, changes, workers, func(string) {})
+		if err != nil {
+			logf("⚠️  Update failed: %v", err)
+			return
+		}
+		codeGraph = updated
+		if preciseGo {
+			preciseCallGraph(codeGraph, absRoot, true)
+		}
+		if err := codeGraph.SaveBinary(graphPath); err != nil {
+			logf("⚠️  Error saving index: %v", err)
+			return
+		}
+		embedded := vectors.update(ctx, codeGraph, stale)
+
+		stats := codeGraph.GetStats()
+		lock.Update(func(s *watch.Status) {
+			s.Updated = time.Now()
+			s.Nodes = stats.TotalNodes
+			s.Edges = stats.TotalEdges
+			s.Vectors = vectors.count()
+		})
+		if jsonMode {
+			json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+				"status":        "updated",
+				"time":          time.Now().Format(time.RFC3339),
+				"files_updated": files,
+				"added":         changes.Added,
+				"changed":       changes.Changed,
+				"removed":       changes.Removed,
+				"nodes":         stats.TotalNodes,
+				"edges":         stats.TotalEdges,
+				"embedded":      embedded,
+				"elapsed_ms":    time.Since(start).Milliseconds(),
+			})
+			return
+		}
+		logf("Updated %d added, %d changed, %d removed in %v (%d nodes, %d edges)",
+			len(changes.Added), len(changes.Changed), len(changes.Removed),
+			time.Since(start).Round(time.Millisecond), stats.TotalNodes, stats.TotalEdges)
+		for _, path := range append(append(append([]string{}, changes.Added...), changes.Changed...), changes.Removed...) {
+			logf("  %s", path)
+		}
+		if embedded > 0 {
+			logf("  Embedded %d symbols", embedded)
+		}
+	}
+
+	// rescan compares every source file with the graph
+	rescan := func() *graph.FileChanges {
+		paths, err := scanner.SourceFiles(root, gitignore, loader)
+		if err != nil {
+			logf("⚠️  Scan failed: %v", err)
+			return nil
+		}
+		if codeGraph == nil {
+			return graph.DiffFiles(nil, absRoot, paths) // The first index covers every module
+		}
+		return scope.filterChanges(graph.DiffFiles(codeGraph, absRoot, paths))
+	}
+
+	// Bring the index up to date before watching
+	lock.Update(func(s *watch.Status) { s.Pending = true })
+	if changes := rescan(); changes != nil && (codeGraph == nil || !changes.Empty()) {
+		update(changes)
+	}
+	if codeGraph == nil {
+		fail("Error: could not build the index")
+	}
+	lock.Update(func(s *watch.Status) {
+		s.Pending = false
+		if s.Updated.IsZero() {
+			s.Updated = time.Unix(codeGraph.LastIndexed, 0)
+		}
+		s.Nodes = len(codeGraph.Nodes)
+		s.Edges = len(codeGraph.Edges)
+		s.Vectors = vectors.count()
+	})
+
+	w, err := watch.New(absRoot, watch.Options{
+		Ignore:    gitignore,
+		OnPending: func() { lock.Update(func(s *watch.Status) { s.Pending = true }) },
+		Reload: func() *scanner.IgnoreMatcher {
+			reloaded := scanner.LoadGitignore(root)
+			reloaded.IncludeGenerated = gitignore.IncludeGenerated
+			gitignore = reloaded
+			return gitignore
+		},
+	})
+	if err != nil {
+		fail("Error starting watch: %v", err)
+	}
+	defer w.Close()
+	logf("Watching %s (%d nodes, %d edges)", absRoot, len(codeGraph.Nodes), len(codeGraph.Edges))
+
+	w.Run(ctx, func(touched []string) {
+		var changes *graph.FileChanges
+		if touched == nil {
+			logf("Events were dropped or ignore rules changed; rescanning")
+			changes = rescan()
+		} else {
+			var paths []string
+			for _, rel := range touched {
+				info, err := os.Stat(filepath.Join(absRoot, rel))
+				lang := scanner.DetectLanguage(filepath.Join(absRoot, rel))
+				if err == nil && info.Mode().IsRegular() && lang != "" && loader.LoadLanguage(lang) == nil && !gitignore.SkipsAnalysis(filepath.Join(absRoot, rel), rel) {
+					paths = append(paths, rel)
+				}
+			}
+			changes = scope.filterChanges(graph.DiffTouched(codeGraph, absRoot, touched, paths))
+		}
+		if changes != nil && !changes.Empty() {
+			update(changes)
+		}
+		lock.Update(func(s *watch.Status) { s.Pending = false })
+	})
+	logf("Stopped watching")
+}
+
+// vectorUpdater re-embeds the symbols of updated files when the project
+// has a vector index. Its methods do nothing when it has none or no LLM
+// client could be created.
+type vectorUpdater struct {
+	path   string
+	index  *graph.InMemoryVectorIndex
+	client analyze.LLMClient
+	logf   func(format string, args ...interface{})
+}
+
+func newVectorUpdater(absRoot, modelOverride string, logf func(format string, args ...interface{})) *vectorUpdater {
+	u := &vectorUpdater{path: graph.VectorIndexPath(absRoot), logf: logf}
+	if !graph.VectorIndexExists(absRoot) {
+		return u
+	}
+
+	index, err := graph.LoadVectorIndex(u.path)
+	if err != nil {
+		logf("⚠️  Could not load vectors, embeddings will not be updated: %v", err)
+		return u
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	if modelOverride != "" {
+		cfg.LLM.EmbeddingModel = modelOverride
+	}
+	client, err := analyze.NewClient(cfg)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = client.Ping(ctx)
+		cancel()
+	}
+	if err != nil {
+		logf("⚠️  LLM provider unavailable, embeddings will not be updated: %v", err)
+		return u
+	}
+	u.index, u.client = index, client
+	return u
+}
+
+// staleNodes returns the nodes of the files changes changes or removes,
+// whose vectors no longer match their code. Call it before the update.
+func (u *vectorUpdater) staleNodes(g *graph.CodeGraph, changes *graph.FileChanges) []graph.NodeID {
+	if u.index == nil || g == nil {
+		return nil
+	}
+	var ids []graph.NodeID
+	for _, path := range append(append([]string{}, changes.Changed...), changes.Removed...) {
+		for _, n := range g.GetNodesByPath(path) {
+			ids = append(ids, n.ID)
+		}
+	}
+	return ids
+}
+
+// update drops the stale vectors, embeds the symbols that lack one and
+// saves the index. Returns the number of symbols embedded.
+func (u *vectorUpdater) update(ctx context.Context, g *graph.CodeGraph, stale []graph.NodeID) int {
+	if u.index == nil {
+		return 0
+	}
+	for _, id := range stale {
+		u.index.Remove(id)
+	}
+
+	embedCtx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+	stats, err := analyze.EmbedGraph(embedCtx, u.client, g, u.index, analyze.DefaultEmbeddingConfig())
+	if err != nil {
+		u.logf("⚠️  Embedding failed: %v", err)
+	}
+	if err := u.index.Save(u.path); err != nil {
+		u.logf("⚠️  Error saving vectors: %v", err)
+	}
+	if stats == nil {
+		return 0
+	}
+	return stats.Embedded
+}
+
+func (u *vectorUpdater) count() int {
+	if u.index == nil {
+		return 0
+	}
+	return u.index.Count()
+}
+
+func runImportSCIPMode(absRoot, indexFile, graphOutput string, scope *moduleScope, jsonMode bool) {
+	graphPath := graphOutput
+	if graphPath == "" {
+		graphPath = graph.GraphPath(absRoot)
+	}
+
+	if !graph.Exists(graphPath) {
+		fmt.Fprintln(os.Stderr, "No index found. Run 'codemap --index' first.")
+		os.Exit(1)
+	}
+
+	codeGraph, err := graph.LoadBinary(graphPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading index: %v\n", err)
+		os.Exit(1)
+	}
+
+	idx, err := precise.ReadIndex(indexFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", indexFile, err)
+		os.Exit(1)
+	}
+
+	idx.Documents = scope.filterDocuments(idx.Documents)
+	stats := precise.MergeIndex(codeGraph, absRoot, idx)
+	if err := codeGraph.SaveBinary(graphPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving index: %v\n", err)
+		os.Exit(1)
+	}
+
+	if jsonMode {
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"status": "merged",
+			"path":   graphPath,
+			"index":  indexFile,
+			"merged": stats,
+		})
+		return
+	}
+	fmt.Printf("✓ Merged %s into %s\n", indexFile, graphPath)
+	fmt.Printf("  Documents: %d\n", stats.Documents)
+	fmt.Printf("  Symbols: %d matched, %d new\n", stats.Symbols, stats.NewNodes)
+	fmt.Printf("  Edges: %d calls, %d references, %d implements\n", stats.Calls, stats.References, stats.Implements)
+}
+
+func runInventoryMode(absRoot string, gitignore *scanner.IgnoreMatcher, scope *moduleScope, jsonMode, sbom bool) {
+	inventory := scanner.ReadInventory(absRoot, gitignore)
+	if scope != nil {
+		var lockfiles []*scanner.Lockfile
+		for _, l := range inventory.Lockfiles {
+			if scope.contains(l.Path) {
+				lockfiles = append(lockfiles, l)
+			}
+		}
+		inventory.Lockfiles = lockfiles
+	}
+
+	switch {
+	case sbom:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(inventory.SBOM())
+	case jsonMode:
+		json.NewEncoder(os.Stdout).Encode(inventory)
+	default:
+		render.Inventory(inventory)
+	}
+}
+
+// runUsageMode handles --deps-usage: the external functions and methods the
+// indexed code calls, grouped by the dependency providing them.
+func runUsageMode(absRoot string, gitignore *scanner.IgnoreMatcher, scope *moduleScope, jsonMode bool) {
+	graphPath := graph.GraphPath(absRoot)
+
+	if !graph.Exists(graphPath) {
+		fmt.Fprintln(os.Stderr, "No index found. Run 'codemap --index' first.")
+		os.Exit(1)
+	}
+
+	codeGraph, err := graph.LoadBinary(graphPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading index: %v\n", err)
+		os.Exit(1)
+	}
+
+	usage := codeGraph.ExternalUsage(scope.nodeFilter(), scanner.DependencyResolver(absRoot, gitignore))
+	if jsonMode {
+		json.NewEncoder(os.Stdout).Encode(usage)
+		return
+	}
+	render.ExternalUsage(filepath.Base(absRoot), usage)
+}
+
+func runExportMode(absRoot, format, output string, scope *moduleScope, jsonMode bool) {
+	if format != "scip" {
+		fmt.Fprintf(os.Stderr, "Unsupported export format %q (supported: scip)\n", format)
+		os.Exit(1)
+	}
+	if output == "" {
+		output = "index.scip"
+	}
+
+	graphPath := graph.GraphPath(absRoot)
+	if !graph.Exists(graphPath) {
+		fmt.Fprintln(os.Stderr, "No index found. Run 'codemap --index' first.")
+		os.Exit(1)
+	}
+
+	codeGraph, err := graph.LoadBinary(graphPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading index: %v\n", err)
+		os.Exit(1)
+	}
+
+	idx := precise.ExportIndex(codeGraph, absRoot)
+	idx.Documents = scope.filterDocuments(idx.Documents)
+	if err := os.WriteFile(output, precise.EncodeSCIP(idx), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", output, err)
+		os.Exit(1)
+	}
+
+	symbols, occurrences := 0, 0
+	for _, doc := range idx.Documents {
+		symbols += len(doc.Symbols)
+		occurrences += len(doc.Occurrences)
+	}
+	if jsonMode {
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"status":      "exported",
+			"format":      format,
+			"path":        output,
+			"documents":   len(idx.Documents),
+			"symbols":     symbols,
+			"occurrences": occurrences,
+		})
+		return
+	}
+	fmt.Printf("✓ Exported %s\n", output)
+	fmt.Printf("  Documents: %d, symbols: %d, occurrences: %d\n", len(idx.Documents), symbols, occurrences)
+}
+
+func runQueryMode(absRoot, fromSymbol, toSymbol string, maxDepth int, scope *moduleScope, jsonMode bool) {
 	graphPath := graph.GraphPath(absRoot)
 
 	if !graph.Exists(graphPath) {
@@ -565,8 +1217,8 @@ func runQueryMode(absRoot, fromSymbol, toSymbol string, maxDepth int, jsonMode b
 
 	// Path query: from A to B
 	if fromSymbol != "" && toSymbol != "" {
-		fromNodes := codeGraph.FindNodesByPattern(fromSymbol, nil)
-		toNodes := codeGraph.FindNodesByPattern(toSymbol, nil)
+		fromNodes := scope.filterNodes(codeGraph.FindNodesByPattern(fromSymbol, nil))
+		toNodes := scope.filterNodes(codeGraph.FindNodesByPattern(toSymbol, nil))
 
 		if len(fromNodes) == 0 {
 			fmt.Fprintf(os.Stderr, "No nodes found matching '%s'\n", fromSymbol)
@@ -589,7 +1241,7 @@ func runQueryMode(absRoot, fromSymbol, toSymbol string, maxDepth int, jsonMode b
 		} else {
 			fmt.Printf("Path from %s to %s (length: %d):\n\n", fromSymbol, toSymbol, path.Length)
 			for i, node := range path.Path {
-				fmt.Printf("  %d. %s [%s] %s:%d\n", i+1, node.Name, node.Kind, node.Path, node.Line)
+				fmt.Printf("  %d. %s [%s] %s:%d\n", i+1, node.DisplayName(), node.Kind, node.Path, node.Line)
 				if i < len(path.Edges) {
 					fmt.Printf("     └─ %s ──>\n", path.Edges[i].Kind)
 				}
@@ -600,7 +1252,7 @@ func runQueryMode(absRoot, fromSymbol, toSymbol string, maxDepth int, jsonMode b
 
 	// From query: what does X call?
 	if fromSymbol != "" {
-		nodes := codeGraph.FindNodesByPattern(fromSymbol, nil)
+		nodes := scope.filterNodes(codeGraph.FindNodesByPattern(fromSymbol, nil))
 		if len(nodes) == 0 {
 			fmt.Fprintf(os.Stderr, "No nodes found matching '%s'\n", fromSymbol)
 			os.Exit(1)
@@ -631,11 +1283,11 @@ func runQueryMode(absRoot, fromSymbol, toSymbol string, maxDepth int, jsonMode b
 		} else {
 			fmt.Printf("Outgoing edges from '%s':\n\n", fromSymbol)
 			for _, r := range results {
-				fmt.Printf("%s [%s] %s:%d\n", r.From.Name, r.From.Kind, r.From.Path, r.From.Line)
+				fmt.Printf("%s [%s] %s:%d\n", r.From.DisplayName(), r.From.Kind, r.From.Path, r.From.Line)
 				for i, e := range r.Edges {
 					target := r.To[i]
 					if target != nil {
-						fmt.Printf("  └─ %s ──> %s [%s] %s:%d\n", e.Kind, target.Name, target.Kind, target.Path, target.Line)
+						fmt.Printf("  └─ %s ──> %s [%s] %s:%d\n", e.Kind, target.DisplayName(), target.Kind, target.Path, target.Line)
 					}
 				}
 				fmt.Println()
@@ -646,7 +1298,7 @@ func runQueryMode(absRoot, fromSymbol, toSymbol string, maxDepth int, jsonMode b
 
 	// To query: what calls X?
 	if toSymbol != "" {
-		nodes := codeGraph.FindNodesByPattern(toSymbol, nil)
+		nodes := scope.filterNodes(codeGraph.FindNodesByPattern(toSymbol, nil))
 		if len(nodes) == 0 {
 			fmt.Fprintf(os.Stderr, "No nodes found matching '%s'\n", toSymbol)
 			os.Exit(1)
@@ -677,11 +1329,11 @@ func runQueryMode(absRoot, fromSymbol, toSymbol string, maxDepth int, jsonMode b
 		} else {
 			fmt.Printf("Incoming edges to '%s':\n\n", toSymbol)
 			for _, r := range results {
-				fmt.Printf("%s [%s] %s:%d\n", r.To.Name, r.To.Kind, r.To.Path, r.To.Line)
+				fmt.Printf("%s [%s] %s:%d\n", r.To.DisplayName(), r.To.Kind, r.To.Path, r.To.Line)
 				for i, e := range r.Edges {
 					caller := r.Callers[i]
 					if caller != nil {
-						fmt.Printf("  <── %s ── %s [%s] %s:%d\n", e.Kind, caller.Name, caller.Kind, caller.Path, caller.Line)
+						fmt.Printf("  <── %s ── %s [%s] %s:%d\n", e.Kind, caller.DisplayName(), caller.Kind, caller.Path, caller.Line)
 					}
 				}
 				fmt.Println()
@@ -690,8 +1342,112 @@ func runQueryMode(absRoot, fromSymbol, toSymbol string, maxDepth int, jsonMode b
 	}
 }
 
+// runHierarchyMode handles the --hierarchy command: supertypes and subtypes of a type.
+func runHierarchyMode(absRoot, typeName string, maxDepth int, scope *moduleScope, jsonMode bool) {
+	graphPath := graph.GraphPath(absRoot)
+
+	if !graph.Exists(graphPath) {
+		fmt.Fprintln(os.Stderr, "No index found. Run 'codemap --index' first.")
+		os.Exit(1)
+	}
+
+	codeGraph, err := graph.LoadBinary(graphPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading index: %v\n", err)
+		os.Exit(1)
+	}
+
+	types := scope.filterNodes(codeGraph.FindTypesByName(typeName))
+	if len(types) == 0 {
+		fmt.Fprintf(os.Stderr, "No types found matching '%s'\n", typeName)
+		os.Exit(1)
+	}
+
+	var results []*graph.HierarchyResult
+	for _, t := range types {
+		results = append(results, codeGraph.GetTypeHierarchy(t.ID, maxDepth))
+	}
+
+	if jsonMode {
+		json.NewEncoder(os.Stdout).Encode(results)
+		return
+	}
+
+	for _, r := range results {
+		fmt.Printf("%s [%s] %s:%d\n", r.Type.Name, r.Type.TypeKind, r.Type.Path, r.Type.Line)
+		if len(r.Supertypes) == 0 && len(r.Subtypes) == 0 {
+			fmt.Println("  (no known supertypes or subtypes)")
+		}
+		for _, l := range r.Supertypes {
+			fmt.Printf("  %s└─ %s ──> %s [%s] %s:%d\n", strings.Repeat("  ", l.Depth-1), l.Kind, l.Node.Name, l.Node.TypeKind, l.Node.Path, l.Node.Line)
+		}
+		for _, l := range r.Subtypes {
+			fmt.Printf("  %s<── %s ── %s [%s] %s:%d\n", strings.Repeat("  ", l.Depth-1), l.Kind, l.Node.Name, l.Node.TypeKind, l.Node.Path, l.Node.Line)
+		}
+		fmt.Println()
+	}
+}
+
+// runRefsMode handles the --refs command: symbols that reference a type.
+func runRefsMode(absRoot, typeName string, scope *moduleScope, jsonMode bool) {
+	graphPath := graph.GraphPath(absRoot)
+
+	if !graph.Exists(graphPath) {
+		fmt.Fprintln(os.Stderr, "No index found. Run 'codemap --index' first.")
+		os.Exit(1)
+	}
+
+	codeGraph, err := graph.LoadBinary(graphPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading index: %v\n", err)
+		os.Exit(1)
+	}
+
+	types := scope.filterNodes(codeGraph.FindTypesByName(typeName))
+	if len(types) == 0 {
+		fmt.Fprintf(os.Stderr, "No types found matching '%s'\n", typeName)
+		os.Exit(1)
+	}
+
+	type refResult struct {
+		Type  *graph.Node   `json:"type"`
+		Edges []*graph.Edge `json:"edges"`
+		From  []*graph.Node `json:"referrers"`
+	}
+
+	var results []refResult
+	for _, t := range types {
+		r := refResult{Type: t}
+		for _, e := range codeGraph.GetReferences(t.ID) {
+			if from := codeGraph.GetNode(e.From); from != nil {
+				r.Edges = append(r.Edges, e)
+				r.From = append(r.From, from)
+			}
+		}
+		results = append(results, r)
+	}
+
+	if jsonMode {
+		json.NewEncoder(os.Stdout).Encode(results)
+		return
+	}
+
+	fmt.Printf("References to '%s':\n\n", typeName)
+	for _, r := range results {
+		fmt.Printf("%s [%s] %s:%d\n", r.Type.Name, r.Type.TypeKind, r.Type.Path, r.Type.Line)
+		if len(r.Edges) == 0 {
+			fmt.Println("  (no references)")
+		}
+		for i, e := range r.Edges {
+			from := r.From[i]
+			fmt.Printf("  <── %s [%s] %s:%d\n", from.DisplayName(), from.Kind, from.Path, e.Line)
+		}
+		fmt.Println()
+	}
+}
+
 // runExplainMode handles the --explain command for LLM-powered symbol explanation.
-func runExplainMode(absRoot, symbol, modelOverride string, noCache, jsonMode bool) {
+func runExplainMode(absRoot, symbol, modelOverride string, scope *moduleScope, noCache, jsonMode bool) {
 	if symbol == "" {
 		fmt.Fprintln(os.Stderr, "Error: --symbol is required with --explain")
 		fmt.Fprintln(os.Stderr, "Usage: codemap --explain --symbol <name> [path]")
@@ -712,7 +1468,7 @@ func runExplainMode(absRoot, symbol, modelOverride string, noCache, jsonMode boo
 	}
 
 	// Find matching nodes
-	nodes := codeGraph.FindNodesByPattern(symbol, nil)
+	nodes := scope.filterNodes(codeGraph.FindNodesByPattern(symbol, nil))
 	if len(nodes) == 0 {
 		fmt.Fprintf(os.Stderr, "No symbols found matching '%s'\n", symbol)
 		os.Exit(1)
@@ -755,11 +1511,12 @@ func runExplainMode(absRoot, symbol, modelOverride string, noCache, jsonMode boo
 	}
 
 	// Read source code
-	source, err := analyze.ReadSymbolSource(absRoot, node)
+	source, err := analyze.ReadGraphSymbolSource(codeGraph, absRoot, node)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading source: %v\n", err)
 		os.Exit(1)
 	}
+	source.AddMethodSet(codeGraph)
 
 	// Initialize cache
 	cacheOpts := cache.Options{
@@ -1042,7 +1799,7 @@ func runSummarizeMode(targetPath, modelOverride string, noCache, jsonMode bool)
 }
 
 // runEmbedMode handles the --embed command for generating embeddings.
-func runEmbedMode(absRoot, modelOverride string, forceRebuild, jsonMode bool) {
+func runEmbedMode(absRoot, modelOverride string, scope *moduleScope, forceRebuild, jsonMode bool) {
 	// Load graph
 	graphPath := graph.GraphPath(absRoot)
 	if !graph.Exists(graphPath) {
@@ -1104,6 +1861,7 @@ func runEmbedMode(absRoot, modelOverride string, forceRebuild, jsonMode bool) {
 	// Configure embedding
 	embedConfig := analyze.DefaultEmbeddingConfig()
 	embedConfig.SkipExisting = !forceRebuild
+	embedConfig.Filter = scope.nodeFilter()
 	embedConfig.ProgressFn = func(completed, total int) {
 		if !jsonMode {
 			fmt.Fprintf(os.Stderr, "\rEmbedding: %d/%d", completed, total)
@@ -1158,7 +1916,7 @@ func runEmbedMode(absRoot, modelOverride string, forceRebuild, jsonMode bool) {
 }
 
 // runSearchMode handles the --search command for semantic/hybrid search.
-func runSearchMode(absRoot, query string, limit int, expandContext bool, modelOverride string, jsonMode bool) {
+func runSearchMode(absRoot, query string, limit int, expandContext bool, modelOverride string, scope *moduleScope, jsonMode bool) {
 	if query == "" {
 		fmt.Fprintln(os.Stderr, "Error: --q is required with --search")
 		fmt.Fprintln(os.Stderr, "Usage: codemap --search --q \"your query\" [path]")
@@ -1214,6 +1972,7 @@ func runSearchMode(absRoot, query string, limit int, expandContext bool, modelOv
 	searchConfig := analyze.DefaultSearchConfig()
 	searchConfig.Limit = limit
 	searchConfig.ExpandContext = expandContext
+	searchConfig.Filter = scope.nodeFilter()
 
 	// Determine search mode based on available resources
 	if client == nil || vectorIndex == nil || vectorIndex.Count() == 0 {
@@ -1351,3 +2110,125 @@ func findProjectRoot(startPath string) string {
 		current = parent
 	}
 }
+
+// moduleScope limits a mode to one workspace module (--module). A nil
+// scope covers the whole project; its methods then return their input.
+type moduleScope struct {
+	workspace *scanner.Workspace
+	mod       *scanner.Module
+}
+
+// newModuleScope finds the named module under root, exiting with the list
+// of modules when there is no such module.
+func newModuleScope(root string, gitignore *scanner.IgnoreMatcher, name string) *moduleScope {
+	workspace := scanner.DiscoverWorkspace(root, gitignore)
+	mod := workspace.Lookup(name)
+	if mod == nil {
+		fmt.Fprintf(os.Stderr, "No module %q found\n", name)
+		if names := workspace.Names(); len(names) > 0 {
+			fmt.Fprintf(os.Stderr, "Modules: %s\n", strings.Join(names, ", "))
+		} else {
+			fmt.Fprintln(os.Stderr, "No go.mod, package.json, Cargo.toml or Gradle build files found")
+		}
+		os.Exit(1)
+	}
+	return &moduleScope{workspace: workspace, mod: mod}
+}
+
+// contains reports whether a root-relative path belongs to the module and
+// not to a module nested in it.
+func (s *moduleScope) contains(rel string) bool {
+	return s == nil || s.workspace.Contains(s.mod, rel)
+}
+
+func (s *moduleScope) filterFiles(files []scanner.FileInfo) []scanner.FileInfo {
+	if s == nil {
+		return files
+	}
+	var kept []scanner.FileInfo
+	for _, f := range files {
+		if s.contains(f.Path) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+func (s *moduleScope) filterAnalyses(analyses []scanner.FileAnalysis) []scanner.FileAnalysis {
+	if s == nil {
+		return analyses
+	}
+	var kept []scanner.FileAnalysis
+	for _, a := range analyses {
+		if s.contains(a.Path) {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+func (s *moduleScope) filterNodes(nodes []*graph.Node) []*graph.Node {
+	if s == nil {
+		return nodes
+	}
+	var kept []*graph.Node
+	for _, n := range nodes {
+		if s.contains(n.Path) {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+func (s *moduleScope) filterDocuments(docs []precise.Document) []precise.Document {
+	if s == nil {
+		return docs
+	}
+	var kept []precise.Document
+	for _, doc := range docs {
+		if s.contains(doc.Path) {
+			kept = append(kept, doc)
+		}
+	}
+	return kept
+}
+
+// filterChanges keeps the changes to the module's files, so an update
+// leaves the rest of the index as it is.
+func (s *moduleScope) filterChanges(changes *graph.FileChanges) *graph.FileChanges {
+	if s == nil || changes == nil {
+		return changes
+	}
+	keep := func(paths []string) []string {
+		kept := []string{}
+		for _, p := range paths {
+			if s.contains(p) {
+				kept = append(kept, p)
+			}
+		}
+		return kept
+	}
+	return &graph.FileChanges{
+		Added:     keep(changes.Added),
+		Changed:   keep(changes.Changed),
+		Removed:   keep(changes.Removed),
+		Unchanged: keep(changes.Unchanged),
+	}
+}
+
+// nodeFilter returns a filter for search and embedding, nil without a scope.
+func (s *moduleScope) nodeFilter() func(n *graph.Node) bool {
+	if s == nil {
+		return nil
+	}
+	return func(n *graph.Node) bool { return s.contains(n.Path) }
+}
+
+// graphModules converts discovered modules for the graph.
+func graphModules(workspace *scanner.Workspace) []graph.Module {
+	modules := make([]graph.Module, 0, len(workspace.Modules))
+	for _, m := range workspace.Modules {
+		modules = append(modules, graph.Module{Name: m.Name, Path: m.Path, Kind: m.Kind, Deps: m.Deps, Requires: m.Requires})
+	}
+	return modules
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"codemap/graph"
+	"codemap/scanner"
+)
+
+// indexer indexes a temporary Go project with updateGraph, fully and then
+// incrementally as its files change.
+type indexer struct {
+	t      *testing.T
+	root   string
+	ignore *scanner.IgnoreMatcher
+	loader *scanner.GrammarLoader
+	graph  *graph.CodeGraph
+}
+
+func newIndexer(t *testing.T, files map[string]string) *indexer {
+	t.Helper()
+	loader := scanner.NewGrammarLoader()
+	if err := loader.LoadLanguage("go"); err != nil {
+		t.Skipf("grammar for go not available: %v", err)
+	}
+	ix := &indexer{t: t, root: t.TempDir(), loader: loader}
+	ix.write(files)
+	ix.ignore = scanner.LoadGitignore(ix.root)
+	ix.update()
+	return ix
+}
+
+func (ix *indexer) write(files map[string]string) {
+	ix.t.Helper()
+	for name, content := range files {
+		path := filepath.Join(ix.root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			ix.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			ix.t.Fatal(err)
+		}
+	}
+}
+
+// update indexes the changes since the last update, as the index and watch
+// modes do.
+func (ix *indexer) update() {
+	ix.t.Helper()
+	paths, err := scanner.SourceFiles(ix.root, ix.ignore, ix.loader)
+	if err != nil {
+		ix.t.Fatal(err)
+	}
+	changes := graph.DiffFiles(ix.graph, ix.root, paths)
+	g, _, err := updateGraph(ix.root, ix.root, ix.ignore, ix.loader, ix.graph, changes, 1, func(string) {})
+	if err != nil {
+		ix.t.Fatal(err)
+	}
+	ix.graph = g
+}
+
+// edge returns the edge of kind between the nodes named from and to.
+func (ix *indexer) edge(from, to string, kind graph.EdgeKind) *graph.Edge {
+	for _, f := range ix.graph.GetNodesByName(from) {
+		for _, e := range ix.graph.GetOutgoingEdges(f.ID) {
+			if n := ix.graph.GetNode(e.To); e.Kind == kind && n != nil && n.Name == to {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func TestIncrementalUpdateKeepsInboundEdges(t *testing.T) {
+	ix := newIndexer(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"a.go": `package app
+
+func Caller() int {
+	var s Store
+	return Helper() + s.Size()
+}
+`,
+		"b.go": `package app
+
+// Helper helps.
+func Helper() int { return 1 }
+`,
+		"store.go": `package app
+
+type Store struct{}
+
+type Sizer interface{ Size() int }
+`,
+		"size.go": `package app
+
+func (s Store) Size() int { return 0 }
+`,
+	})
+
+	check := func(when string) {
+		t.Helper()
+		if ix.edge("Caller", "Helper", graph.EdgeCalls) == nil {
+			t.Errorf("%s: Caller -> Helper call lost", when)
+		}
+		if ix.edge("Caller", "Size", graph.EdgeCalls) == nil {
+			t.Errorf("%s: Caller -> Store.Size call lost", when)
+		}
+		if ix.edge("Store", "Size", graph.EdgeDefines) == nil {
+			t.Errorf("%s: Store -> Size defines edge lost", when)
+		}
+		if ix.edge("Store", "Sizer", graph.EdgeImplements) == nil {
+			t.Errorf("%s: Store -> Sizer implements edge lost", when)
+		}
+	}
+	check("full index")
+
+	ix.write(map[string]string{"b.go": "package app\n\n// Helper helps.\nfunc Helper() int { return 1 }\n\n// More to come.\n"})
+	ix.update()
+	check("after changing b.go")
+
+	ix.write(map[string]string{"store.go": "package app\n\n// Store stores.\ntype Store struct{}\n\ntype Sizer interface{ Size() int }\n"})
+	ix.update()
+	check("after changing store.go")
+
+	// Helper moves to another file; the unchanged caller follows it
+	ix.write(map[string]string{
+		"b.go":       "package app\n",
+		"helpers.go": "package app\n\nfunc Helper() int { return 2 }\n",
+	})
+	ix.update()
+	check("after moving Helper")
+	if e := ix.edge("Caller", "Helper", graph.EdgeCalls); e != nil {
+		if callee := ix.graph.GetNode(e.To); callee.Path != "helpers.go" {
+			t.Errorf("Caller calls Helper in %s, want helpers.go", callee.Path)
+		}
+	}
+
+	// A method added in a new file makes Store satisfy another interface
+	ix.write(map[string]string{
+		"store.go": "package app\n\n// Store stores.\ntype Store struct{}\n\ntype Sizer interface{ Size() int }\n\ntype Resetter interface{ Reset() }\n",
+	})
+	ix.update()
+	if ix.edge("Store", "Resetter", graph.EdgeImplements) != nil {
+		t.Error("Store implements Resetter before having Reset")
+	}
+	ix.write(map[string]string{"reset.go": "package app\n\nfunc (s Store) Reset() {}\n"})
+	ix.update()
+	if ix.edge("Store", "Resetter", graph.EdgeImplements) == nil {
+		t.Error("Store doesn't implement Resetter after adding Reset")
+	}
+	check("after adding Reset")
+}
+
+func TestPreciseCallGraphKeepsExternalUsage(t *testing.T) {
+	ix := newIndexer(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"a.go": `package app
+
+import "strings"
+
+func Shout(s string) string {
+	return strings.ToUpper(Trim(s))
+}
+
+func Trim(s string) string { return strings.TrimSpace(s) }
+`,
+	})
+	before := ix.graph.ExternalUsage(nil, nil)
+	if len(before) != 1 || before[0].Package != "strings" || before[0].Calls != 2 {
+		t.Fatalf("external usage before --precise = %+v, want two calls into strings", before)
+	}
+
+	stats := preciseCallGraph(ix.graph, ix.root, true)
+	if stats == nil || stats.Files != 1 {
+		t.Fatalf("precise stats = %+v, want a.go covered", stats)
+	}
+	if e := ix.edge("Shout", "Trim", graph.EdgeCalls); e == nil || e.Resolution != graph.ResolutionTyped {
+		t.Errorf("Shout -> Trim = %+v, want a typed call", e)
+	}
+	after := ix.graph.ExternalUsage(nil, scanner.DependencyResolver(ix.root, ix.ignore))
+	if len(after) != 1 || after[0].Package != "strings" || after[0].Calls != 2 {
+		t.Errorf("external usage after --precise = %+v, want two calls into strings", after)
+	}
+}
+
+func TestParallelIndexingIsDeterministic(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"store/store.go": `package store
+
+// Store keeps items.
+type Store struct{ items []string }
+
+type Sizer interface{ Size() int }
+
+func (s *Store) Size() int { return len(s.items) }
+`,
+	}
+	for i := 0; i < 24; i++ {
+		files[fmt.Sprintf("pkg%d/file.go", i%6)+fmt.Sprint(i)] = fmt.Sprintf(`package pkg%d
+
+import "example.com/app/store"
+
+// Run%d runs.
+func Run%d(s *store.Store) int {
+	return helper%d() + s.Size()
+}
+
+func helper%d() int { return %d }
+`, i%6, i, i, i, i, i)
+		files[fmt.Sprintf("py/mod%d.py", i)] = fmt.Sprintf("from py.mod%d import run\n\n\nclass Job%d:\n    def run(self):\n        return run()\n", (i+1)%24, i)
+	}
+	files["py/__init__.py"] = ""
+
+	ix := newIndexer(t, files)
+	index := func(workers int) *graph.CodeGraph {
+		paths, err := scanner.SourceFiles(ix.root, ix.ignore, ix.loader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, _, err := updateGraph(ix.root, ix.root, ix.ignore, ix.loader, nil, graph.DiffFiles(nil, ix.root, paths), workers, func(string) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	nodes := func(g *graph.CodeGraph) []graph.Node {
+		var all []graph.Node
+		for _, n := range g.SortedNodes() {
+			all = append(all, *n)
+		}
+		return all
+	}
+	edges := func(g *graph.CodeGraph) []string {
+		var all []string
+		for _, e := range g.Edges {
+			all = append(all, fmt.Sprintf("%+v", *e))
+		}
+		sort.Strings(all)
+		return all
+	}
+
+	serial := index(1)
+	if len(serial.Edges) < 100 {
+		t.Fatalf("serial index has %d edges, want a larger graph", len(serial.Edges))
+	}
+	for _, workers := range []int{4, 16} {
+		g := index(workers)
+		if !reflect.DeepEqual(nodes(g), nodes(serial)) {
+			t.Errorf("workers=%d: nodes differ from workers=1", workers)
+		}
+		if !reflect.DeepEqual(edges(g), edges(serial)) {
+			t.Errorf("workers=%d: edges differ from workers=1", workers)
+		}
+		if !reflect.DeepEqual(g.Files, serial.Files) {
+			t.Errorf("workers=%d: file states differ from workers=1", workers)
+		}
+	}
+}
+
+func TestForcedIndexOfModuleKeepsOthers(t *testing.T) {
+	ix := newIndexer(t, map[string]string{
+		"go.mod":         "module example.com/app\n",
+		"main.go":        "package main\n\nfunc main() {}\n",
+		"tools/go.mod":   "module example.com/tools\n",
+		"tools/gen.go":   "package tools\n\nfunc Gen() {}\n",
+		"tools/check.go": "package tools\n\nfunc Check() { Gen() }\n",
+	})
+	graphPath := filepath.Join(ix.root, ".codemap", "graph.gob")
+	if err := graph.EnsureDir(ix.root); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.graph.SaveBinary(graphPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Capture the JSON report
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	scope := newModuleScope(ix.root, ix.ignore, "example.com/tools")
+	runIndexMode(ix.root, ix.root, ix.ignore, scope, true, true, graphPath, 1, false)
+	os.Stdout = stdout
+	w.Close()
+
+	var report struct {
+		Incremental bool               `json:"incremental"`
+		Changes     *graph.FileChanges `json:"changes"`
+	}
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Incremental {
+		t.Error("--force --module rebuilt the whole index")
+	}
+	if want := []string{"tools/check.go", "tools/gen.go"}; !reflect.DeepEqual(report.Changes.Changed, want) {
+		t.Errorf("re-analyzed %q, want %q", report.Changes.Changed, want)
+	}
+	g, err := graph.LoadBinary(graphPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.GetNodesByName("main")) == 0 || len(g.GetNodesByName("Gen")) == 0 {
+		t.Error("index lost the nodes of a module")
+	}
+}
@@ -4,12 +4,14 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"codemap/analyze"
@@ -18,6 +20,7 @@ import (
 	"codemap/graph"
 	"codemap/render"
 	"codemap/scanner"
+	"codemap/watch"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -33,6 +36,16 @@ type DepsInput struct {
 	Mode   string `json:"mode,omitempty" jsonschema:"Output mode: deps (default) shows dependency flow, api shows API surface (exported functions/types)"`
 }
 
+type ExternalUsageInput struct {
+	Path    string `json:"path" jsonschema:"Path to the project directory"`
+	Package string `json:"package,omitempty" jsonschema:"Only show packages whose import path or dependency contains this (case-insensitive)"`
+}
+
+type InventoryInput struct {
+	Path   string `json:"path" jsonschema:"Path to the project directory to analyze"`
+	Format string `json:"format,omitempty" jsonschema:"Output format: text (default) shows the dependency tree, json the locked packages, cyclonedx a CycloneDX 1.5 SBOM"`
+}
+
 type DiffInput struct {
 	Path string `json:"path" jsonschema:"Path to the project directory to analyze"`
 	Ref  string `json:"ref,omitempty" jsonschema:"Git branch/ref to compare against (default: main)"`
@@ -56,7 +69,7 @@ type ListProjectsInput struct {
 type SymbolInput struct {
 	Path string `json:"path" jsonschema:"Path to the project directory"`
 	Name string `json:"name" jsonschema:"Symbol name to search (substring match, case-insensitive)"`
-	Kind string `json:"kind,omitempty" jsonschema:"Filter by symbol type: function, type, or all (default: all)"`
+	Kind string `json:"kind,omitempty" jsonschema:"Filter by symbol type: function, type, variable, or all (default: all)"`
 	File string `json:"file,omitempty" jsonschema:"Filter to specific file path (substring match)"`
 }
 
@@ -79,6 +92,17 @@ type CalleesInput struct {
 	Depth  int    `json:"depth,omitempty" jsonschema:"Depth of callee chain (default: 1, max: 5)"`
 }
 
+type TypeHierarchyInput struct {
+	Path  string `json:"path" jsonschema:"Path to the project directory"`
+	Type  string `json:"type" jsonschema:"Type name (class, interface, trait, struct)"`
+	Depth int    `json:"depth,omitempty" jsonschema:"Levels to walk up and down (default: 5)"`
+}
+
+type ReferencesInput struct {
+	Path string `json:"path" jsonschema:"Path to the project directory"`
+	Type string `json:"type" jsonschema:"Type name to find references to"`
+}
+
 type ExplainSymbolInput struct {
 	Path    string `json:"path" jsonschema:"Path to the project directory"`
 	Symbol  string `json:"symbol" jsonschema:"Symbol name to explain (function, type, method)"`
@@ -118,6 +142,12 @@ func main() {
 		Description: "Get the dependency flow of a project. Shows external dependencies by language, internal import chains between files, hub files (most-imported), and function counts. Use detail=1 for function signatures, detail=2 for full type information.",
 	}, handleGetDependencies)
 
+	// Tool: get_dependency_inventory - Locked package versions
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_dependency_inventory",
+		Description: "Get the exact package versions a project ships, from its lockfiles (go.sum, package-lock.json, pnpm-lock.yaml, yarn.lock, Cargo.lock, poetry.lock, uv.lock, Gemfile.lock). Shows the transitive dependency tree under each direct dependency and marks development-only packages. Use format=json for the package list or format=cyclonedx for an SBOM.",
+	}, handleGetDependencyInventory)
+
 	// Tool: get_diff - Get changed files with impact analysis
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "get_diff",
@@ -151,7 +181,7 @@ func main() {
 	// Tool: get_symbol - Search for symbols by name
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "get_symbol",
-		Description: "Search for functions and types by name. Returns matching symbols with file location (path:line). Use this to find specific code elements without browsing files. Supports filtering by kind (function/type) and file path.",
+		Description: "Search for functions, types and package-level variables/constants by name. Returns matching symbols with file location (path:line); types also list their fields and methods. Use this to find specific code elements without browsing files. Supports filtering by kind (function/type/variable) and file path.",
 	}, handleGetSymbol)
 
 	// Tool: trace_path - Find path between two symbols
@@ -172,6 +202,24 @@ func main() {
 		Description: "Find all functions called by a specific symbol. Requires a pre-built index (run 'codemap --index' first). Returns the callee chain showing what the source symbol calls.",
 	}, handleGetCallees)
 
+	// Tool: get_type_hierarchy - Supertypes and subtypes of a type
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_type_hierarchy",
+		Description: "Show the type hierarchy of a class, interface or trait: its supertypes (extends/implements) and the types that extend or implement it. Go interfaces include structurally satisfying types. Requires a pre-built index (run 'codemap --index' first).",
+	}, handleGetTypeHierarchy)
+
+	// Tool: find_references - Find what uses a type
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "find_references",
+		Description: "Find all functions, methods and types that use a type in their parameters, return values, fields or local declarations. Requires a pre-built index (run 'codemap --index' first). Returns each referencing symbol with the line of its first use.",
+	}, handleFindReferences)
+
+	// Tool: get_external_usage - External APIs the code calls
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_external_usage",
+		Description: "Show which functions and methods of external packages the code calls (http.Client.Do, requests.get, ...), grouped by the dependency providing them, with call counts and calling files. Use it to judge the impact of upgrading or replacing a dependency. Requires a pre-built index (run 'codemap --index' first).",
+	}, handleGetExternalUsage)
+
 	// Tool: explain_symbol - LLM-powered code explanation
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "explain_symbol",
@@ -217,9 +265,32 @@ func validatePath(path string) (string, error) {
 		return "", fmt.Errorf("path does not exist: %s", absPath)
 	}
 
+	registerLanguages(absPath)
 	return absPath, nil
 }
 
+// registered is the project whose configured languages are registered.
+// The language registry is global, so it is replaced only when a tool
+// names another project, not on every call.
+var registered struct {
+	sync.Mutex
+	root string
+}
+
+// registerLanguages registers the languages and query overrides
+// configured for the project at root, unless they already are.
+func registerLanguages(root string) {
+	registered.Lock()
+	defer registered.Unlock()
+	if registered.root == root {
+		return
+	}
+	registered.root = root
+	if err := scanner.RegisterLanguages(root); err != nil {
+		log.Printf("Language configuration: %v", err)
+	}
+}
+
 func textResult(text string) *mcp.CallToolResult {
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
@@ -270,6 +341,16 @@ func handleGetStructure(ctx context.Context, req *mcp.CallToolRequest, input Pat
 			stats.TotalNodes, stats.FunctionCount, stats.NodesByKind["type"]))
 		sb.WriteString(fmt.Sprintf("  Edges: %d (calls: %d, imports: %d)\n",
 			stats.TotalEdges, stats.EdgesByKind["calls"], stats.EdgesByKind["imports"]))
+		w := watch.Running(absRoot)
+		if w != nil && !w.Maintains(graph.GraphPath(absRoot)) {
+			w = nil // It keeps another graph file fresh
+		}
+		if w != nil && w.Fresh() {
+			sb.WriteString(fmt.Sprintf("  Kept fresh by codemap watch (pid %d, updated %s)\n",
+				w.PID, w.Updated.Format(time.RFC3339)))
+		} else if w != nil {
+			sb.WriteString(fmt.Sprintf("  codemap watch (pid %d) is applying changes; results may lag\n", w.PID))
+		}
 		sb.WriteString("\n  Use trace_path, get_callers, get_callees for call graph queries.\n")
 		output = sb.String()
 	}
@@ -293,11 +374,13 @@ func handleGetDependencies(ctx context.Context, req *mcp.CallToolRequest, input
 		return errorResult("Scan error: " + err.Error()), nil, nil
 	}
 
+	dependencies := scanner.ReadDependencies(absRoot)
 	depsProject := scanner.DepsProject{
 		Root:         absRoot,
 		Mode:         "deps",
 		Files:        analyses,
-		ExternalDeps: scanner.ReadExternalDeps(absRoot),
+		ExternalDeps: scanner.DependencyNames(dependencies),
+		Dependencies: dependencies,
 		DetailLevel:  input.Detail,
 	}
 
@@ -316,6 +399,34 @@ func handleGetDependencies(ctx context.Context, req *mcp.CallToolRequest, input
 	return textResult(output), nil, nil
 }
 
+func handleGetDependencyInventory(ctx context.Context, req *mcp.CallToolRequest, input InventoryInput) (*mcp.CallToolResult, any, error) {
+	absRoot, err := validatePath(input.Path)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	inventory := scanner.ReadInventory(absRoot, scanner.LoadGitignore(absRoot))
+	switch input.Format {
+	case "", "text":
+		return textResult(captureOutput(func() {
+			render.Inventory(inventory)
+		})), nil, nil
+	case "json":
+		data, err := json.MarshalIndent(inventory, "", "  ")
+		if err != nil {
+			return errorResult("Encoding error: " + err.Error()), nil, nil
+		}
+		return textResult(string(data)), nil, nil
+	case "cyclonedx":
+		data, err := json.MarshalIndent(inventory.SBOM(), "", "  ")
+		if err != nil {
+			return errorResult("Encoding error: " + err.Error()), nil, nil
+		}
+		return textResult(string(data)), nil, nil
+	}
+	return errorResult("Unknown format " + input.Format + " (use text, json or cyclonedx)"), nil, nil
+}
+
 func handleGetDiff(ctx context.Context, req *mcp.CallToolRequest, input DiffInput) (*mcp.CallToolResult, any, error) {
 	ref := input.Ref
 	if ref == "" {
@@ -408,10 +519,12 @@ Available tools:
   get_diff           - Changed files vs branch
   find_file          - Search by filename
   get_importers      - Find what imports a file
-  get_symbol         - Search for functions/types by name
+  get_symbol         - Search for functions/types/variables by name
   trace_path         - Find call path between symbols (requires index)
   get_callers        - Find what calls a symbol (requires index)
   get_callees        - Find what a symbol calls (requires index)
+  get_type_hierarchy - Supertypes and subtypes of a type (requires index)
+  find_references    - Find what uses a type (requires index)
   explain_symbol     - LLM-powered code explanation (requires index + LLM)
   summarize_module   - LLM-powered module summary (requires LLM)
   semantic_search    - Hybrid semantic/graph search (requires index)`, cwd, home)), nil, nil
@@ -481,7 +594,7 @@ func getProjectStats(path string) string {
 	// Count files by language
 	langCounts := make(map[string]int)
 	for _, f := range files {
-		lang := scanner.DetectLanguage(f.Path)
+		lang := scanner.DetectLanguage(filepath.Join(path, f.Path))
 		if lang != "" {
 			langCounts[lang]++
 		}
@@ -503,7 +616,7 @@ func getProjectStats(path string) string {
 		isGit = " [git]"
 	}
 
-	if info, ok := scanner.LangDisplay[primaryLang]; ok {
+	if info := scanner.LanguageInfo(primaryLang); info.Full != "" {
 		return fmt.Sprintf("(%d files, %s%s)", len(files), info.Full, isGit)
 	}
 	return fmt.Sprintf("(%d files%s)", len(files), isGit)
@@ -524,26 +637,21 @@ func handleGetImporters(ctx context.Context, req *mcp.CallToolRequest, input Imp
 		return errorResult("Scan error: " + err.Error()), nil, nil
 	}
 
-	targetBase := filepath.Base(input.File)
-	targetNoExt := strings.TrimSuffix(targetBase, filepath.Ext(targetBase))
-	
-	// Resolve target directory absolutely to compare with file paths
-	targetDir := filepath.Join(absRoot, filepath.Dir(input.File))
+	// Match the file by its path from the root, or by a trailing part of it
+	target := filepath.Clean(input.File)
+	if filepath.IsAbs(target) {
+		if rel, err := filepath.Rel(absRoot, target); err == nil {
+			target = rel
+		}
+	}
+	isTarget := func(path string) bool {
+		return path == target || strings.HasSuffix(path, string(filepath.Separator)+target)
+	}
 
 	var importers []string
 	for _, a := range analyses {
-		// Skip files in the same directory only for Go (implicit package imports)
-		// For other languages (TS, JS, Python), importing sibling files is common and significant
-		if a.Language == "go" && filepath.Dir(a.Path) == targetDir {
-			continue
-		}
-		for _, imp := range a.Imports {
-			impBase := filepath.Base(imp)
-			impNoExt := strings.TrimSuffix(impBase, filepath.Ext(impBase))
-			// Match by filename, name without ext, full path, or package/directory
-			if impBase == targetBase || impNoExt == targetNoExt ||
-				strings.HasSuffix(imp, input.File) ||
-				strings.HasSuffix(imp, targetDir) || imp == targetDir {
+		for _, imp := range a.ImportedFiles {
+			if isTarget(imp) {
 				importers = append(importers, a.Path)
 				break
 			}
@@ -600,11 +708,15 @@ func handleGetSymbol(ctx context.Context, req *mcp.CallToolRequest, input Symbol
 
 	funcCount := 0
 	typeCount := 0
+	varCount := 0
 
 	for _, m := range matches {
-		if m.Kind == "function" {
+		switch m.Kind {
+		case "function":
 			funcCount++
-		} else {
+		case "variable":
+			varCount++
+		default:
 			typeCount++
 		}
 
@@ -618,6 +730,15 @@ func handleGetSymbol(ctx context.Context, req *mcp.CallToolRequest, input Symbol
 			}
 		} else {
 			sb.WriteString(fmt.Sprintf("  ├─ %s %s\n", m.TypeKind, m.Name))
+			if len(m.Fields) > 0 {
+				sb.WriteString(fmt.Sprintf("  ├─ fields: %s\n", strings.Join(m.Fields, ", ")))
+			}
+			if len(m.Methods) > 0 {
+				sb.WriteString(fmt.Sprintf("  ├─ methods: %s\n", strings.Join(m.Methods, ", ")))
+			}
+		}
+		if m.Package != "" {
+			sb.WriteString(fmt.Sprintf("  ├─ package: %s\n", m.Package))
 		}
 
 		if m.Exported {
@@ -630,20 +751,23 @@ func handleGetSymbol(ctx context.Context, req *mcp.CallToolRequest, input Symbol
 
 	sb.WriteString("───────────────────────────────────\n")
 	sb.WriteString(fmt.Sprintf("Matches: %d", len(matches)))
-	if funcCount > 0 && typeCount > 0 {
-		sb.WriteString(fmt.Sprintf(" (%d functions, %d types)", funcCount, typeCount))
-	} else if funcCount > 0 {
-		funcWord := "functions"
-		if funcCount == 1 {
-			funcWord = "function"
+	var counts []string
+	for _, c := range []struct {
+		n         int
+		one, many string
+	}{
+		{funcCount, "function", "functions"},
+		{typeCount, "type", "types"},
+		{varCount, "variable", "variables"},
+	} {
+		if c.n == 1 {
+			counts = append(counts, fmt.Sprintf("1 %s", c.one))
+		} else if c.n > 1 {
+			counts = append(counts, fmt.Sprintf("%d %s", c.n, c.many))
 		}
-		sb.WriteString(fmt.Sprintf(" (%d %s)", funcCount, funcWord))
-	} else if typeCount > 0 {
-		typeWord := "types"
-		if typeCount == 1 {
-			typeWord = "type"
-		}
-		sb.WriteString(fmt.Sprintf(" (%d %s)", typeCount, typeWord))
+	}
+	if len(counts) > 0 {
+		sb.WriteString(fmt.Sprintf(" (%s)", strings.Join(counts, ", ")))
 	}
 	sb.WriteString("\n")
 
@@ -742,7 +866,7 @@ func handleTracePath(ctx context.Context, req *mcp.CallToolRequest, input TraceP
 		if i == len(result.Path)-1 {
 			prefix = "└─"
 		}
-		sb.WriteString(fmt.Sprintf("%s %s (%s:%d)\n", prefix, node.Name, node.Path, node.Line))
+		sb.WriteString(fmt.Sprintf("%s %s (%s:%d)\n", prefix, node.DisplayName(), node.Path, node.Line))
 		if i < len(result.Edges) {
 			edge := result.Edges[i]
 			sb.WriteString(fmt.Sprintf("   │ %s\n", edge.Kind.String()))
@@ -782,12 +906,15 @@ func handleGetCallers(ctx context.Context, req *mcp.CallToolRequest, input Calle
 
 	totalCallers := 0
 	for _, node := range nodes {
-		callerTree := g.GetReverseTree(node.ID, depth)
+		callerTree := g.GetReverseTree(node.ID, depth, graph.EdgeCalls)
 		if len(callerTree) <= 1 { // Only has the node itself
 			continue
 		}
 
-		sb.WriteString(fmt.Sprintf("Target: %s (%s:%d)\n", node.Name, node.Path, node.Line))
+		sb.WriteString(fmt.Sprintf("Target: %s (%s:%d)\n", node.DisplayName(), node.Path, node.Line))
+		if node.Package != "" {
+			sb.WriteString(fmt.Sprintf("Package: %s\n", node.Package))
+		}
 
 		for level := 1; level <= depth; level++ {
 			callers := callerTree[level]
@@ -796,7 +923,7 @@ func handleGetCallers(ctx context.Context, req *mcp.CallToolRequest, input Calle
 			}
 			for _, caller := range callers {
 				indent := strings.Repeat("  ", level-1)
-				sb.WriteString(fmt.Sprintf("%s├─ %s (%s:%d)\n", indent, caller.Name, caller.Path, caller.Line))
+				sb.WriteString(fmt.Sprintf("%s├─ %s (%s:%d)\n", indent, caller.DisplayName(), caller.Path, caller.Line))
 				totalCallers++
 			}
 		}
@@ -841,12 +968,15 @@ func handleGetCallees(ctx context.Context, req *mcp.CallToolRequest, input Calle
 
 	totalCallees := 0
 	for _, node := range nodes {
-		calleeTree := g.GetDependencyTree(node.ID, depth)
+		calleeTree := g.GetDependencyTree(node.ID, depth, graph.EdgeCalls)
 		if len(calleeTree) <= 1 { // Only has the node itself
 			continue
 		}
 
-		sb.WriteString(fmt.Sprintf("Source: %s (%s:%d)\n", node.Name, node.Path, node.Line))
+		sb.WriteString(fmt.Sprintf("Source: %s (%s:%d)\n", node.DisplayName(), node.Path, node.Line))
+		if node.Package != "" {
+			sb.WriteString(fmt.Sprintf("Package: %s\n", node.Package))
+		}
 
 		for level := 1; level <= depth; level++ {
 			callees := calleeTree[level]
@@ -855,7 +985,7 @@ func handleGetCallees(ctx context.Context, req *mcp.CallToolRequest, input Calle
 			}
 			for _, callee := range callees {
 				indent := strings.Repeat("  ", level-1)
-				sb.WriteString(fmt.Sprintf("%s├─ %s (%s:%d)\n", indent, callee.Name, callee.Path, callee.Line))
+				sb.WriteString(fmt.Sprintf("%s├─ %s (%s:%d)\n", indent, callee.DisplayName(), callee.Path, callee.Line))
 				totalCallees++
 			}
 		}
@@ -870,6 +1000,130 @@ func handleGetCallees(ctx context.Context, req *mcp.CallToolRequest, input Calle
 	return textResult(sb.String()), nil, nil
 }
 
+func handleFindReferences(ctx context.Context, req *mcp.CallToolRequest, input ReferencesInput) (*mcp.CallToolResult, any, error) {
+	absRoot, err := validatePath(input.Path)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	g, err := loadGraph(absRoot)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	types := g.FindTypesByName(input.Type)
+	if len(types) == 0 {
+		return errorResult(fmt.Sprintf("No type found matching '%s'", input.Type)), nil, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("=== References to '%s' ===\n\n", input.Type))
+
+	total := 0
+	for _, t := range types {
+		refs := g.GetReferences(t.ID)
+		if len(refs) == 0 {
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("Type: %s (%s:%d)\n", t.Name, t.Path, t.Line))
+		if t.Package != "" {
+			sb.WriteString(fmt.Sprintf("Package: %s\n", t.Package))
+		}
+		for _, e := range refs {
+			if from := g.GetNode(e.From); from != nil {
+				sb.WriteString(fmt.Sprintf("├─ %s [%s] (%s:%d)\n", from.DisplayName(), from.Kind, from.Path, e.Line))
+				total++
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if total == 0 {
+		return textResult(fmt.Sprintf("No references found for '%s'", input.Type)), nil, nil
+	}
+
+	sb.WriteString(fmt.Sprintf("───────────────────────────────────\nTotal references: %d\n", total))
+	return textResult(sb.String()), nil, nil
+}
+
+func handleGetExternalUsage(ctx context.Context, req *mcp.CallToolRequest, input ExternalUsageInput) (*mcp.CallToolResult, any, error) {
+	absRoot, err := validatePath(input.Path)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	g, err := loadGraph(absRoot)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	usage := g.ExternalUsage(nil, scanner.DependencyResolver(absRoot, scanner.LoadGitignore(absRoot)))
+	if input.Package != "" {
+		filter := strings.ToLower(input.Package)
+		var kept []*graph.ExternalUsage
+		for _, u := range usage {
+			if strings.Contains(strings.ToLower(u.Package), filter) || strings.Contains(strings.ToLower(u.Dependency), filter) {
+				kept = append(kept, u)
+			}
+		}
+		if len(kept) == 0 {
+			return textResult(fmt.Sprintf("No calls into packages matching '%s'", input.Package)), nil, nil
+		}
+		usage = kept
+	}
+
+	return textResult(captureOutput(func() {
+		render.ExternalUsage(filepath.Base(absRoot), usage)
+	})), nil, nil
+}
+
+func handleGetTypeHierarchy(ctx context.Context, req *mcp.CallToolRequest, input TypeHierarchyInput) (*mcp.CallToolResult, any, error) {
+	absRoot, err := validatePath(input.Path)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	g, err := loadGraph(absRoot)
+	if err != nil {
+		return errorResult(err.Error()), nil, nil
+	}
+
+	types := g.FindTypesByName(input.Type)
+	if len(types) == 0 {
+		return errorResult(fmt.Sprintf("No type found matching '%s'", input.Type)), nil, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("=== Type hierarchy of '%s' ===\n\n", input.Type))
+
+	for _, t := range types {
+		h := g.GetTypeHierarchy(t.ID, input.Depth)
+		sb.WriteString(fmt.Sprintf("%s [%s] (%s:%d)\n", t.Name, t.TypeKind, t.Path, t.Line))
+
+		if len(h.Supertypes) > 0 {
+			sb.WriteString("  Supertypes:\n")
+			for _, l := range h.Supertypes {
+				indent := strings.Repeat("  ", l.Depth)
+				sb.WriteString(fmt.Sprintf("  %s├─ %s %s (%s:%d)\n", indent, l.Kind, l.Node.Name, l.Node.Path, l.Node.Line))
+			}
+		}
+		if len(h.Subtypes) > 0 {
+			sb.WriteString("  Subtypes:\n")
+			for _, l := range h.Subtypes {
+				indent := strings.Repeat("  ", l.Depth)
+				sb.WriteString(fmt.Sprintf("  %s├─ %s (%s, %s:%d)\n", indent, l.Node.Name, l.Kind, l.Node.Path, l.Node.Line))
+			}
+		}
+		if len(h.Supertypes) == 0 && len(h.Subtypes) == 0 {
+			sb.WriteString("  No known supertypes or subtypes\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	return textResult(sb.String()), nil, nil
+}
+
 func handleExplainSymbol(ctx context.Context, req *mcp.CallToolRequest, input ExplainSymbolInput) (*mcp.CallToolResult, any, error) {
 	absRoot, err := validatePath(input.Path)
 	if err != nil {
@@ -920,10 +1174,11 @@ func handleExplainSymbol(ctx context.Context, req *mcp.CallToolRequest, input Ex
 	}
 
 	// Read source code
-	source, err := analyze.ReadSymbolSource(absRoot, node)
+	source, err := analyze.ReadGraphSymbolSource(g, absRoot, node)
 	if err != nil {
 		return errorResult(fmt.Sprintf("Error reading source: %v", err)), nil, nil
 	}
+	source.AddMethodSet(g)
 
 	// Initialize cache
 	cacheOpts := cache.Options{
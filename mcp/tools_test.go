@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 
+	"codemap/graph"
+
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -87,6 +89,21 @@ func TestMCPTools(t *testing.T) {
 		}
 	})
 
+	t.Run("get_symbol_type_members", func(t *testing.T) {
+		input := SymbolInput{Path: testDataPath, Name: "Service", Kind: "type"}
+		result, _, err := handleGetSymbol(ctx, nil, input)
+		if err != nil {
+			t.Fatalf("handleGetSymbol failed: %v", err)
+		}
+		text := result.Content[0].(*mcp.TextContent).Text
+		if !strings.Contains(text, "fields: users") {
+			t.Errorf("Expected Service fields, got:\n%s", text)
+		}
+		if !strings.Contains(text, "AddUser") || !strings.Contains(text, "ProcessAll") {
+			t.Errorf("Expected Service methods, got:\n%s", text)
+		}
+	})
+
     // Graph dependent tests
     // Check if graph.gob exists
     graphPath := filepath.Join(testDataPath, ".codemap", "graph.gob")
@@ -258,6 +275,73 @@ func TestMCPTools(t *testing.T) {
         })
     }
 
+	t.Run("get_dependency_inventory", func(t *testing.T) {
+		dir := t.TempDir()
+		files := map[string]string{
+			"package.json": `{"dependencies":{"chalk":"^4.1.0"}}`,
+			"package-lock.json": `{"lockfileVersion":3,"packages":{
+"":{"dependencies":{"chalk":"^4.1.0"}},
+"node_modules/chalk":{"version":"4.1.2","dependencies":{"ansi-styles":"^4.1.0"}},
+"node_modules/ansi-styles":{"version":"4.3.0"}}}`,
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+		}
+
+		result, _, err := handleGetDependencyInventory(ctx, nil, InventoryInput{Path: dir})
+		if err != nil {
+			t.Fatalf("handleGetDependencyInventory failed: %v", err)
+		}
+		text := result.Content[0].(*mcp.TextContent).Text
+		if !strings.Contains(text, "chalk") || !strings.Contains(text, "4.3.0") {
+			t.Errorf("Expected the tree to show chalk and ansi-styles 4.3.0, got:\n%s", text)
+		}
+
+		result, _, err = handleGetDependencyInventory(ctx, nil, InventoryInput{Path: dir, Format: "cyclonedx"})
+		if err != nil {
+			t.Fatalf("handleGetDependencyInventory failed: %v", err)
+		}
+		text = result.Content[0].(*mcp.TextContent).Text
+		if !strings.Contains(text, `"pkg:npm/ansi-styles@4.3.0"`) {
+			t.Errorf("Expected the SBOM to list ansi-styles by purl, got:\n%s", text)
+		}
+	})
+
+	t.Run("get_external_usage", func(t *testing.T) {
+		dir := t.TempDir()
+		builder := graph.NewBuilder(dir)
+		builder.AddFile(&graph.FileAnalysis{
+			Path:      "client.go",
+			Language:  "go",
+			Package:   "example.com/app",
+			Functions: []graph.FuncInfo{{Name: "fetch", Line: 3, ParamCount: 1}},
+			Imports:   []string{"net/http"},
+			Calls: []graph.CallInfo{
+				{CallerFunc: "fetch", CallerLine: 3, CalleeName: "Do", CallLine: 4, Args: 1, Receiver: "c", ReceiverType: "Client", ReceiverPackage: "net/http", Inference: "param"},
+				{CallerFunc: "fetch", CallerLine: 3, CalleeName: "NewRequest", CallLine: 5, Args: 3, Receiver: "http", ReceiverPackage: "net/http", Inference: "import"},
+			},
+		})
+		builder.ResolveCallEdges()
+		builder.FilterCallEdges()
+		if err := graph.EnsureDir(dir); err != nil {
+			t.Fatalf("EnsureDir failed: %v", err)
+		}
+		if err := builder.Build().SaveBinary(graph.GraphPath(dir)); err != nil {
+			t.Fatalf("SaveBinary failed: %v", err)
+		}
+
+		result, _, err := handleGetExternalUsage(ctx, nil, ExternalUsageInput{Path: dir})
+		if err != nil {
+			t.Fatalf("handleGetExternalUsage failed: %v", err)
+		}
+		text := result.Content[0].(*mcp.TextContent).Text
+		if !strings.Contains(text, "http.Client.Do") || !strings.Contains(text, "http.NewRequest") {
+			t.Errorf("Expected http.Client.Do and http.NewRequest, got:\n%s", text)
+		}
+	})
+
     // Git Diff Test
     t.Run("get_diff", func(t *testing.T) {
         // Create temp git repo
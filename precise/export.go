@@ -0,0 +1,205 @@
+package precise
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codemap/graph"
+	"codemap/scanner"
+)
+
+// ExportIndex converts g into a SCIP index of the project at root. Every
+// file becomes a document; functions, methods, types and variables become
+// symbols with a definition occurrence, and call and reference edges become
+// reference occurrences at their line. Implements and extends edges are
+// recorded as implementation relationships. Columns are found by looking
+// for the symbol's name on its line in the file under root.
+func ExportIndex(g *graph.CodeGraph, root string) *Index {
+	symbols := exportSymbols(g, filepath.Base(root))
+
+	byPath := make(map[string][]*graph.Node)
+	var paths []string
+	for _, n := range g.SortedNodes() {
+		if n.Kind == graph.KindFile {
+			paths = append(paths, n.Path)
+		}
+		byPath[n.Path] = append(byPath[n.Path], n)
+	}
+	sort.Strings(paths)
+
+	idx := &Index{
+		ProjectRoot: "file://" + filepath.ToSlash(root),
+		ToolName:    "codemap",
+	}
+	for _, path := range paths {
+		doc := Document{Path: filepath.ToSlash(path), Language: scanner.DetectLanguage(path)}
+		lines := sourceLines(filepath.Join(root, path))
+		seen := make(map[string]bool)
+		addOccurrence := func(occ Occurrence) {
+			key := fmt.Sprintf("%s@%d:%d", occ.Symbol, occ.Range.StartLine, occ.Range.StartChar)
+			if !seen[key] {
+				seen[key] = true
+				doc.Occurrences = append(doc.Occurrences, occ)
+			}
+		}
+
+		for _, n := range byPath[path] {
+			symbol := symbols[n.ID]
+			if symbol == "" {
+				continue
+			}
+			occ := Occurrence{Range: nameRange(lines, n.Line, n.Name), Symbol: symbol, Roles: RoleDefinition}
+			if n.EndLine > 0 {
+				start := n.StartLine
+				if start == 0 {
+					start = n.Line
+				}
+				occ.Enclosing = &Range{StartLine: start - 1, EndLine: n.EndLine - 1, EndChar: lineLength(lines, n.EndLine)}
+			}
+			addOccurrence(occ)
+
+			info := SymbolInfo{Symbol: symbol, DisplayName: n.Name, Signature: n.Signature}
+			if n.DocString != "" {
+				info.Documentation = []string{n.DocString}
+			}
+			for _, e := range g.GetOutgoingEdges(n.ID) {
+				if target := symbols[e.To]; target != "" && (e.Kind == graph.EdgeImplements || e.Kind == graph.EdgeExtends) {
+					info.Relationships = append(info.Relationships, Relationship{Symbol: target, IsImplementation: true})
+				}
+			}
+			doc.Symbols = append(doc.Symbols, info)
+		}
+
+		for _, n := range byPath[path] {
+			for _, e := range g.GetOutgoingEdges(n.ID) {
+				if (e.Kind != graph.EdgeCalls && e.Kind != graph.EdgeReferences) || e.Line == 0 {
+					continue
+				}
+				target := g.GetNode(e.To)
+				if target == nil || symbols[target.ID] == "" {
+					continue
+				}
+				addOccurrence(Occurrence{Range: nameRange(lines, e.Line, target.Name), Symbol: symbols[target.ID]})
+			}
+		}
+
+		sort.SliceStable(doc.Occurrences, func(i, j int) bool {
+			a, b := doc.Occurrences[i].Range, doc.Occurrences[j].Range
+			if a.StartLine != b.StartLine {
+				return a.StartLine < b.StartLine
+			}
+			return a.StartChar < b.StartChar
+		})
+		idx.Documents = append(idx.Documents, doc)
+	}
+	return idx
+}
+
+// exportSymbols assigns a SCIP symbol to every function, method, type,
+// variable and constant node: "codemap . <project> . <package>/<Owner>#<name>().".
+// Functions that would share a symbol (overloads, redefinitions) get +1,
+// +2, ... disambiguators; other redefinitions get their line in the name.
+func exportSymbols(g *graph.CodeGraph, project string) map[graph.NodeID]string {
+	prefix := "codemap . " + escapeHeader(project) + " . "
+	symbols := make(map[graph.NodeID]string)
+	count := make(map[string]int)
+	for _, n := range g.SortedNodes() {
+		var suffix string
+		switch n.Kind {
+		case graph.KindFunction, graph.KindMethod:
+			suffix = "()."
+		case graph.KindType:
+			suffix = "#"
+		case graph.KindVariable, graph.KindConstant:
+			suffix = "."
+		default:
+			continue
+		}
+
+		ns := n.Package
+		if ns == "" {
+			ns = filepath.ToSlash(n.Path)
+		}
+		descriptors := escapeName(ns) + "/"
+		if n.Owner != "" && n.Kind != graph.KindType {
+			descriptors += escapeName(n.Owner) + "#"
+		}
+
+		symbol := prefix + descriptors + escapeName(n.Name) + suffix
+		dup := count[symbol]
+		count[symbol]++
+		switch {
+		case dup > 0 && suffix == "().":
+			symbol = fmt.Sprintf("%s%s%s(+%d).", prefix, descriptors, escapeName(n.Name), dup)
+		case dup > 0:
+			symbol = prefix + descriptors + escapeName(fmt.Sprintf("%s@%d", n.Name, n.Line)) + suffix
+		}
+		symbols[n.ID] = symbol
+	}
+	return symbols
+}
+
+// escapeName returns a SCIP descriptor name, backquoted unless it is a
+// simple identifier.
+func escapeName(name string) string {
+	for _, r := range name {
+		if !(r == '_' || r == '+' || r == '-' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+	}
+	return name
+}
+
+// escapeHeader escapes a symbol header field: spaces are doubled and an
+// empty field is ".".
+func escapeHeader(s string) string {
+	if s == "" {
+		return "."
+	}
+	return strings.ReplaceAll(s, " ", "  ")
+}
+
+// sourceLines returns the lines of a file, or nil if it can't be read.
+func sourceLines(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// nameRange locates name on a 1-indexed line, falling back to the start of
+// the line when the name isn't found there.
+func nameRange(lines []string, line int, name string) Range {
+	r := Range{StartLine: line - 1, EndLine: line - 1, EndChar: len(name)}
+	if line < 1 || line > len(lines) || name == "" {
+		return r
+	}
+	text := lines[line-1]
+	for from := 0; ; {
+		i := strings.Index(text[from:], name)
+		if i < 0 {
+			return r
+		}
+		start, end := from+i, from+i+len(name)
+		if (start == 0 || !isIdentByte(text[start-1])) && (end == len(text) || !isIdentByte(text[end])) {
+			r.StartChar, r.EndChar = start, end
+			return r
+		}
+		from = end
+	}
+}
+
+func lineLength(lines []string, line int) int {
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	return len(lines[line-1])
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
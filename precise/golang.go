@@ -0,0 +1,287 @@
+// Package precise replaces codemap's name-based call and reference edges
+// with precise ones. Go modules are loaded with go/packages and analysed
+// with VTA (seeded by CHA), which resolves interface dispatch, method
+// values and closures that tree-sitter heuristics miss. SCIP and LSIF
+// indexes produced by other toolchains are merged in the same way.
+package precise
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codemap/graph"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Stats summarizes a precise indexing run.
+type Stats struct {
+	Modules        int `json:"modules"`         // go.mod files loaded
+	Packages       int `json:"packages"`        // Packages analysed (test variants included)
+	Skipped        int `json:"skipped"`         // Packages left to the heuristic graph due to load or type errors
+	SkippedModules int `json:"skipped_modules"` // Modules left to the heuristic graph because SSA or VTA failed
+	Files          int `json:"files"`           // Go files whose call edges were replaced
+	Edges          int `json:"edges"`           // Call edges added
+}
+
+// loadMode is what SSA construction needs: syntax and types for the module's
+// packages and their dependencies. Dependencies are type-checked from source
+// rather than export data, which needs no build of the module and keeps the
+// loader independent of the toolchain's export format.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesSizes |
+	packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule
+
+// GoCallGraph loads every Go module under root and replaces the call edges
+// of the files it type-checks with edges from a VTA call graph. Function
+// nodes are matched by file and line of their name, so the graph must have
+// been built from the same sources. Packages that fail to load or type-check
+// keep their heuristic edges, as do modules whose SSA form or call graph
+// can't be built. No network access is made: modules must be vendored or
+// present in the module cache.
+func GoCallGraph(g *graph.CodeGraph, root string) (*Stats, error) {
+	modules, err := findModules(root)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{}
+	funcs := functionNodes(g)
+	covered := make(map[string]bool)
+	var calls []*graph.Edge
+	for _, dir := range modules {
+		modCalls, err := moduleCalls(root, dir, funcs, covered, stats)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", dir, err)
+		}
+		calls = append(calls, modCalls...)
+	}
+
+	sort.SliceStable(calls, func(i, j int) bool {
+		a, b := calls[i], calls[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.To < b.To
+	})
+	g.ReplaceEdges(covered, calls, graph.EdgeCalls)
+
+	stats.Files = len(covered)
+	stats.Edges = len(calls)
+	return stats, nil
+}
+
+// moduleCalls builds the call graph of the module in dir and returns its
+// edges between graph nodes. Files of well-typed packages are added to
+// covered.
+func moduleCalls(root, dir string, funcs map[string]*graph.Node, covered map[string]bool, stats *Stats) ([]*graph.Edge, error) {
+	cfg := &packages.Config{
+		Mode:  loadMode,
+		Dir:   dir,
+		Tests: true,
+		Env:   offlineEnv(dir),
+	}
+	initial, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	prog, pkgs := ssautil.Packages(initial, ssa.InstantiateGenerics)
+	cg, err := buildCallGraph(prog)
+	if err != nil {
+		// Code the SSA builder or VTA can't handle; the module keeps its
+		// heuristic edges like a package that fails to type-check
+		stats.SkippedModules++
+		return nil, nil
+	}
+	stats.Modules++
+	for i, pkg := range pkgs {
+		if pkg == nil || len(initial[i].Errors) > 0 {
+			stats.Skipped++
+			continue
+		}
+		stats.Packages++
+		for _, file := range initial[i].GoFiles {
+			if rel, ok := relPath(root, file); ok {
+				covered[rel] = true
+			}
+		}
+	}
+
+	// declared maps an SSA function to the graph node of the declaration
+	// holding its code: closures belong to their enclosing function, and
+	// wrappers and generic instances to the method or function they wrap.
+	declared := func(fn *ssa.Function) *graph.Node {
+		for fn.Parent() != nil {
+			fn = fn.Parent()
+		}
+		if origin := fn.Origin(); origin != nil {
+			fn = origin
+		}
+		obj := fn.Object()
+		if obj == nil {
+			return nil // Package initializers and other synthetic code
+		}
+		rel, ok := relPath(root, prog.Fset.Position(obj.Pos()).Filename)
+		if !ok {
+			return nil
+		}
+		return funcs[funcKey(rel, prog.Fset.Position(obj.Pos()).Line, obj.Name())]
+	}
+
+	seen := make(map[string]bool)
+	var calls []*graph.Edge
+	err = callgraph.GraphVisitEdges(cg, func(e *callgraph.Edge) error {
+		if e.Site == nil || e.Callee.Func.Parent() != nil {
+			return nil // Calling a closure; its calls belong to its enclosing function
+		}
+		caller, callee := declared(e.Caller.Func), declared(e.Callee.Func)
+		if caller == nil || callee == nil || !covered[caller.Path] {
+			return nil
+		}
+
+		line := prog.Fset.Position(e.Site.Pos()).Line
+		key := fmt.Sprintf("%s>%s@%d", caller.ID, callee.ID, line)
+		if seen[key] {
+			return nil // Same call seen through another package variant
+		}
+		seen[key] = true
+
+		resolution := graph.ResolutionDispatch
+		if e.Site.Common().StaticCallee() != nil {
+			resolution = graph.ResolutionTyped
+		}
+		calls = append(calls, &graph.Edge{
+			From:       caller.ID,
+			To:         callee.ID,
+			Kind:       graph.EdgeCalls,
+			Line:       line,
+			Weight:     graph.ResolutionConfidence(resolution),
+			CallSite:   callee.Name,
+			ArgCount:   argCount(e.Site.Common()),
+			Resolution: resolution,
+		})
+		return nil
+	})
+	return calls, err
+}
+
+// buildCallGraph builds the SSA form of prog and its VTA call graph. Both
+// panic on code they don't support, which is returned as an error. It is a
+// variable so tests can make it fail.
+var buildCallGraph = func(prog *ssa.Program) (cg *callgraph.Graph, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("building call graph: %v", r)
+		}
+	}()
+	prog.Build()
+	cg = vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog))
+	cg.DeleteSyntheticNodes()
+	return cg, nil
+}
+
+// argCount returns the number of arguments passed at a call site, not
+// counting the receiver of a static method call.
+func argCount(call *ssa.CallCommon) int {
+	n := len(call.Args)
+	if !call.IsInvoke() && call.Signature().Recv() != nil {
+		n--
+	}
+	return n
+}
+
+// functionNodes indexes the graph's Go functions and methods by file, line
+// and name.
+func functionNodes(g *graph.CodeGraph) map[string]*graph.Node {
+	funcs := make(map[string]*graph.Node)
+	for _, n := range g.Nodes {
+		if (n.Kind == graph.KindFunction || n.Kind == graph.KindMethod) && strings.HasSuffix(n.Path, ".go") {
+			funcs[funcKey(n.Path, n.Line, n.Name)] = n
+		}
+	}
+	return funcs
+}
+
+func funcKey(path string, line int, name string) string {
+	return fmt.Sprintf("%s:%d:%s", path, line, name)
+}
+
+// relPath returns file relative to root, or false if it lies outside.
+func relPath(root, file string) (string, bool) {
+	if file == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// findModules returns the directories under root holding a go.mod,
+// skipping vendored, test-data and hidden directories, or root itself when
+// it lies inside a module.
+func findModules(root string) ([]string, error) {
+	for dir := filepath.Dir(root); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return []string{root}, nil
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	var modules []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "go.mod" {
+			modules = append(modules, filepath.Dir(path))
+		}
+		return nil
+	})
+	return modules, err
+}
+
+// offlineEnv is the go command environment for loading the module in dir
+// without touching the network or the module's files: no proxy or
+// toolchain downloads, and a read-only module graph (vendor/ when present).
+func offlineEnv(dir string) []string {
+	mod := "-mod=readonly"
+	if _, err := os.Stat(filepath.Join(dir, "vendor", "modules.txt")); err == nil {
+		mod = "-mod=vendor"
+	}
+	var flags []string
+	for _, f := range strings.Fields(os.Getenv("GOFLAGS")) {
+		if !strings.HasPrefix(f, "-mod=") {
+			flags = append(flags, f)
+		}
+	}
+	flags = append(flags, mod)
+
+	return append(os.Environ(),
+		"GOPROXY=off",
+		"GOTOOLCHAIN=local",
+		"GOFLAGS="+strings.Join(flags, " "),
+	)
+}
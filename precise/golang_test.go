@@ -0,0 +1,87 @@
+package precise
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"codemap/graph"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// callModule writes a module whose main calls run, and the graph the
+// parser would build for it, with a heuristic edge between them.
+func callModule(t *testing.T) (string, *graph.CodeGraph) {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {\n\trun()\n}\n\nfunc run() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := graph.NewBuilder(root)
+	b.AddFiles([]*graph.FileAnalysis{{
+		Path:     "main.go",
+		Language: "go",
+		Package:  "example.com/app",
+		Functions: []graph.FuncInfo{
+			{Name: "main", Line: 3, StartLine: 3, EndLine: 5},
+			{Name: "run", Line: 7, StartLine: 7, EndLine: 7},
+		},
+		Calls: []graph.CallInfo{{CallerFunc: "main", CallerLine: 3, CalleeName: "run", CallLine: 4}},
+	}})
+	b.ResolveCallEdges()
+	return root, b.Build()
+}
+
+func TestGoCallGraph(t *testing.T) {
+	root, g := callModule(t)
+	stats, err := GoCallGraph(g, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Modules != 1 || stats.Packages == 0 || stats.SkippedModules != 0 || stats.Files != 1 {
+		t.Errorf("stats = %+v, want one module and file analysed", *stats)
+	}
+	e := edgeBetween(g, "main", "run", graph.EdgeCalls)
+	if e == nil || e.Resolution != graph.ResolutionTyped {
+		t.Errorf("main -> run = %+v, want a typed call edge", e)
+	}
+}
+
+func TestGoCallGraphSkipsModuleOnPanic(t *testing.T) {
+	// A panic in the SSA builder becomes an error
+	if _, err := buildCallGraph(nil); err == nil {
+		t.Fatal("buildCallGraph(nil) succeeded, want the recovered panic")
+	}
+
+	build := buildCallGraph
+	t.Cleanup(func() { buildCallGraph = build })
+	buildCallGraph = func(*ssa.Program) (*callgraph.Graph, error) {
+		return nil, errors.New("building call graph: unsupported construct")
+	}
+
+	root, g := callModule(t)
+	heuristic := edgeBetween(g, "main", "run", graph.EdgeCalls)
+	if heuristic == nil {
+		t.Fatal("no heuristic main -> run edge")
+	}
+	stats, err := GoCallGraph(g, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Stats{SkippedModules: 1}); *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+	if e := edgeBetween(g, "main", "run", graph.EdgeCalls); e == nil || e.Resolution != heuristic.Resolution {
+		t.Errorf("main -> run = %+v, want the heuristic edge %+v", e, heuristic)
+	}
+}
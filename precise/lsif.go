@@ -0,0 +1,227 @@
+package precise
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// lsifElement is a vertex or edge of an LSIF dump. Only the properties
+// codemap reads are declared.
+type lsifElement struct {
+	ID    json.RawMessage   `json:"id"`
+	Type  string            `json:"type"` // vertex or edge
+	Label string            `json:"label"`
+	OutV  json.RawMessage   `json:"outV"`
+	InV   json.RawMessage   `json:"inV"`
+	InVs  []json.RawMessage `json:"inVs"`
+
+	Property    string `json:"property"`    // item edges: definitions, references
+	ProjectRoot string `json:"projectRoot"` // metaData
+	URI         string `json:"uri"`         // document
+	LanguageID  string `json:"languageId"`  // document
+	Identifier  string `json:"identifier"`  // moniker
+	Scheme      string `json:"scheme"`      // moniker
+
+	Start *lsifPosition `json:"start"` // range
+	End   *lsifPosition `json:"end"`
+	Tag   *struct {
+		Type      string `json:"type"` // definition, declaration, reference
+		Text      string `json:"text"`
+		Kind      int    `json:"kind"` // LSP SymbolKind
+		FullRange *struct {
+			Start lsifPosition `json:"start"`
+			End   lsifPosition `json:"end"`
+		} `json:"fullRange"`
+	} `json:"tag"`
+}
+
+type lsifPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// lsifKinds maps LSP SymbolKind values to SymbolInfo kinds.
+var lsifKinds = map[int]string{
+	5:  SymbolType,     // Class
+	6:  SymbolMethod,   // Method
+	9:  SymbolMethod,   // Constructor
+	10: SymbolType,     // Enum
+	11: SymbolType,     // Interface
+	12: SymbolFunction, // Function
+	13: SymbolVariable, // Variable
+	14: SymbolVariable, // Constant
+	23: SymbolType,     // Struct
+}
+
+// decodeLSIF converts an LSIF dump (JSON lines or a JSON array) into an
+// Index. Each range's symbol is the moniker of its result set when it has
+// one, else the result set's ID; ranges listed as definitions of a result
+// set are definition occurrences.
+func decodeLSIF(data []byte) (*Index, error) {
+	elements, err := readLSIF(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LSIF dump: %w", err)
+	}
+
+	idx := &Index{}
+	vertices := make(map[string]*lsifElement)
+	docOf := make(map[string]string)     // range -> document
+	next := make(map[string]string)      // range/resultSet -> resultSet
+	monikers := make(map[string]string)  // resultSet -> moniker vertex
+	definitions := make(map[string]bool) // ranges that define their result set
+	var docs []string
+
+	for i := range elements {
+		e := &elements[i]
+		id := lsifID(e.ID)
+		if e.Type == "vertex" {
+			vertices[id] = e
+			switch e.Label {
+			case "metaData":
+				idx.ProjectRoot = e.ProjectRoot
+			case "document":
+				docs = append(docs, id)
+			}
+			continue
+		}
+
+		out := lsifID(e.OutV)
+		switch e.Label {
+		case "contains":
+			for _, in := range e.InVs {
+				docOf[lsifID(in)] = out
+			}
+		case "next":
+			next[out] = lsifID(e.InV)
+		case "moniker":
+			monikers[out] = lsifID(e.InV)
+		case "item":
+			result := vertices[out]
+			if result == nil {
+				continue
+			}
+			if result.Label == "definitionResult" || e.Property == "definitions" {
+				for _, in := range e.InVs {
+					definitions[lsifID(in)] = true
+				}
+			}
+		}
+	}
+
+	// symbolOf follows a range's next chain to its outermost result set
+	symbolOf := func(rangeID string) string {
+		set, seen := rangeID, map[string]bool{}
+		for next[set] != "" && !seen[set] {
+			seen[set] = true
+			set = next[set]
+		}
+		if set == rangeID {
+			return ""
+		}
+		if m := vertices[monikers[set]]; m != nil && m.Identifier != "" {
+			return m.Scheme + " " + m.Identifier
+		}
+		return "lsif:" + set
+	}
+
+	byDoc := make(map[string]int) // Document vertex -> index in idx.Documents
+	for _, id := range docs {
+		v := vertices[id]
+		path := v.URI
+		if rel, err := relativeURI(idx.ProjectRoot, v.URI); err == nil {
+			path = rel
+		} else if u, err := url.Parse(v.URI); err == nil && u.Path != "" {
+			path = u.Path // Absolute; placed under the graph root when merging
+		}
+		byDoc[id] = len(idx.Documents)
+		idx.Documents = append(idx.Documents, Document{Path: path, Language: v.LanguageID})
+	}
+
+	var rangeIDs []string
+	for id := range docOf {
+		rangeIDs = append(rangeIDs, id)
+	}
+	sort.Strings(rangeIDs)
+	for _, id := range rangeIDs {
+		v := vertices[id]
+		i, ok := byDoc[docOf[id]]
+		if v == nil || v.Label != "range" || v.Start == nil || v.End == nil || !ok {
+			continue
+		}
+		doc := &idx.Documents[i]
+		symbol := symbolOf(id)
+		if symbol == "" {
+			continue
+		}
+		occ := Occurrence{
+			Range:  Range{StartLine: v.Start.Line, StartChar: v.Start.Character, EndLine: v.End.Line, EndChar: v.End.Character},
+			Symbol: symbol,
+		}
+		if definitions[id] || (v.Tag != nil && v.Tag.Type == "definition") {
+			occ.Roles |= RoleDefinition
+			info := SymbolInfo{Symbol: symbol}
+			if v.Tag != nil {
+				info.DisplayName = v.Tag.Text
+				info.Kind = lsifKinds[v.Tag.Kind]
+				if fr := v.Tag.FullRange; fr != nil {
+					occ.Enclosing = &Range{StartLine: fr.Start.Line, StartChar: fr.Start.Character, EndLine: fr.End.Line, EndChar: fr.End.Character}
+				}
+			}
+			doc.Symbols = append(doc.Symbols, info)
+		}
+		doc.Occurrences = append(doc.Occurrences, occ)
+	}
+	for i := range idx.Documents {
+		occs := idx.Documents[i].Occurrences
+		sort.SliceStable(occs, func(a, b int) bool {
+			return occs[a].Range.StartLine < occs[b].Range.StartLine
+		})
+	}
+	return idx, nil
+}
+
+// readLSIF decodes the elements of an LSIF dump.
+func readLSIF(data []byte) ([]lsifElement, error) {
+	var elements []lsifElement
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err := json.Unmarshal(data, &elements)
+		return elements, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var e lsifElement
+		if err := dec.Decode(&e); err == io.EOF {
+			return elements, nil
+		} else if err != nil {
+			return nil, err
+		}
+		elements = append(elements, e)
+	}
+}
+
+// lsifID normalizes a vertex ID, which LSIF allows to be a number or a string.
+func lsifID(raw json.RawMessage) string {
+	return strings.Trim(string(raw), `"`)
+}
+
+// relativeURI returns the path of a file URI relative to the root URI.
+func relativeURI(root, uri string) (string, error) {
+	r, err := url.Parse(root)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	rootPath := strings.TrimSuffix(r.Path, "/") + "/"
+	if root == "" || !strings.HasPrefix(u.Path, rootPath) {
+		return "", fmt.Errorf("%s is outside %s", uri, root)
+	}
+	return strings.TrimPrefix(u.Path, rootPath), nil
+}
@@ -0,0 +1,377 @@
+package precise
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codemap/graph"
+	"codemap/scanner"
+)
+
+// MergeStats summarizes an index import.
+type MergeStats struct {
+	Documents  int `json:"documents"`  // Indexed files under the graph root
+	Symbols    int `json:"symbols"`    // Definitions matched to existing nodes
+	NewNodes   int `json:"new_nodes"`  // Definitions the graph lacked, added as nodes
+	Calls      int `json:"calls"`      // Call edges from the index
+	References int `json:"references"` // Reference edges from the index
+	Implements int `json:"implements"` // Implementation edges added
+}
+
+// ReadIndex loads a SCIP index (protobuf) or an LSIF dump (JSON lines or
+// a JSON array) from path.
+func ReadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return decodeLSIF(data)
+	}
+	return decodeSCIP(data)
+}
+
+// symbolDesc is what codemap needs to know about an indexed symbol to match
+// it to a graph node or create one.
+type symbolDesc struct {
+	name    string // Empty when the index doesn't say (LSIF without tags)
+	owner   string // Enclosing type of a method
+	pkg     string
+	kind    graph.NodeKind
+	hasKind bool
+}
+
+// merger carries the state of one MergeIndex call.
+type merger struct {
+	g       *graph.CodeGraph
+	infos   map[string]SymbolInfo
+	symbols map[string]*graph.Node // Indexed symbol -> node defining it
+	stats   *MergeStats
+}
+
+// MergeIndex merges the definitions and references of idx into g.
+// Definitions are matched to the graph's nodes by file, line and name;
+// definitions the graph lacks become new nodes. References become edges
+// from the innermost enclosing function or type (or the file): calls when
+// both ends are functions, references otherwise. For every indexed file
+// these edges replace the graph's heuristic calls and references, and the
+// index's signatures replace the parsed ones. Implementation relationships
+// between indexed types add implements edges.
+func MergeIndex(g *graph.CodeGraph, root string, idx *Index) *MergeStats {
+	m := &merger{
+		g:       g,
+		infos:   make(map[string]SymbolInfo),
+		symbols: make(map[string]*graph.Node),
+		stats:   &MergeStats{},
+	}
+
+	// Document paths are relative to the indexed project root, which is the
+	// graph root unless the index names another directory that exists here
+	base := root
+	if u, err := url.Parse(idx.ProjectRoot); err == nil && u.Path != "" {
+		if info, err := os.Stat(u.Path); err == nil && info.IsDir() {
+			base = filepath.FromSlash(u.Path)
+		}
+	}
+	type placedDoc struct {
+		path string
+		doc  *Document
+	}
+	var docs []placedDoc
+	covered := make(map[string]bool)
+	for i := range idx.Documents {
+		doc := &idx.Documents[i]
+		abs := filepath.FromSlash(doc.Path)
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(base, abs)
+		}
+		path, ok := relPath(root, abs)
+		if !ok {
+			continue
+		}
+		docs = append(docs, placedDoc{path, doc})
+		covered[path] = true
+		for _, info := range doc.Symbols {
+			m.infos[info.Symbol] = info
+		}
+	}
+	m.stats.Documents = len(docs)
+
+	// Definitions first, so references in any file can bind to them
+	for _, d := range docs {
+		for _, occ := range d.doc.Occurrences {
+			if !occ.IsDefinition() || strings.HasPrefix(occ.Symbol, "local ") || m.symbols[occ.Symbol] != nil {
+				continue
+			}
+			if node := m.define(d.path, strings.ToLower(d.doc.Language), occ); node != nil {
+				m.symbols[occ.Symbol] = node
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var edges []*graph.Edge
+	for _, d := range docs {
+		for _, occ := range d.doc.Occurrences {
+			if occ.IsDefinition() || occ.Roles&RoleImport != 0 {
+				continue
+			}
+			target := m.symbols[occ.Symbol]
+			if target == nil {
+				continue
+			}
+			edge := m.reference(d.path, occ, target)
+			if edge == nil {
+				continue
+			}
+			key := fmt.Sprintf("%s>%s@%d/%d", edge.From, edge.To, edge.Line, edge.Kind)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			edges = append(edges, edge)
+			if edge.Kind == graph.EdgeCalls {
+				m.stats.Calls++
+			} else {
+				m.stats.References++
+			}
+		}
+	}
+	g.ReplaceEdges(covered, edges, graph.EdgeCalls, graph.EdgeReferences)
+
+	m.addImplementations()
+	return m.stats
+}
+
+// define binds a definition occurrence to the graph node it defines,
+// creating the node when the graph lacks it. Returns nil for symbols the
+// graph doesn't model (namespaces, parameters, fields).
+func (m *merger) define(path, lang string, occ Occurrence) *graph.Node {
+	info := m.infos[occ.Symbol]
+	desc, ok := describe(occ.Symbol, info)
+	if !ok {
+		return nil
+	}
+
+	line := occ.Range.StartLine + 1
+	var node *graph.Node
+	for _, n := range m.g.GetNodesByPath(path) {
+		if n.Kind != graph.KindFile && n.Line == line && (desc.name == "" || n.Name == desc.name) {
+			node = n
+			break
+		}
+	}
+	if node != nil {
+		m.stats.Symbols++
+	} else {
+		if desc.name == "" || !desc.hasKind {
+			return nil
+		}
+		node = m.addNode(path, lang, line, desc)
+	}
+
+	if info.Signature != "" {
+		node.Signature = info.Signature
+	}
+	if node.DocString == "" && len(info.Documentation) > 0 {
+		node.DocString = strings.Join(info.Documentation, "\n\n")
+	}
+	if occ.Enclosing != nil && node.EndLine == 0 {
+		node.StartLine = occ.Enclosing.StartLine + 1
+		node.EndLine = occ.Enclosing.EndLine + 1
+	}
+	return node
+}
+
+// addNode adds a node for an indexed definition, contained in its file
+// and defined by its owner type when the graph has them.
+func (m *merger) addNode(path, lang string, line int, desc symbolDesc) *graph.Node {
+	key := desc.name
+	if desc.owner != "" {
+		key = desc.owner + "." + desc.name
+	}
+	id := graph.GenerateNodeID(path, key)
+	if n := m.g.GetNode(id); n != nil {
+		return n
+	}
+
+	qualified := key
+	if desc.pkg != "" {
+		qualified = desc.pkg + "." + key
+	}
+	node := &graph.Node{
+		ID:            id,
+		Kind:          desc.kind,
+		Name:          desc.name,
+		QualifiedName: qualified,
+		Owner:         desc.owner,
+		Path:          path,
+		Line:          line,
+		Package:       desc.pkg,
+		Exported:      scanner.IsExportedName(desc.name, lang),
+		ParamCount:    -1,
+	}
+	m.g.AddNode(node)
+	m.stats.NewNodes++
+
+	fileID := graph.GenerateNodeID(path, "")
+	if m.g.GetNode(fileID) == nil {
+		m.g.AddNode(&graph.Node{ID: fileID, Kind: graph.KindFile, Name: filepath.Base(path), Path: path, Package: desc.pkg})
+	}
+	m.g.AddEdge(&graph.Edge{From: fileID, To: id, Kind: graph.EdgeContains})
+
+	if desc.owner != "" {
+		for _, n := range m.g.GetNodesByName(desc.owner) {
+			if n.Kind == graph.KindType && filepath.Dir(n.Path) == filepath.Dir(path) {
+				m.g.AddEdge(&graph.Edge{From: n.ID, To: id, Kind: graph.EdgeDefines, Line: line})
+				break
+			}
+		}
+	}
+	return node
+}
+
+// reference turns a reference occurrence into an edge from its innermost
+// enclosing symbol (or file) to target. Returns nil for self references.
+func (m *merger) reference(path string, occ Occurrence, target *graph.Node) *graph.Edge {
+	line := occ.Range.StartLine + 1
+	from := m.enclosing(path, line)
+
+	kind := graph.EdgeReferences
+	if isCallable(target) && from != nil && isCallable(from) {
+		kind = graph.EdgeCalls
+	}
+	if from == nil {
+		from = m.g.GetNode(graph.GenerateNodeID(path, ""))
+	}
+	if from == nil || (from.ID == target.ID && kind == graph.EdgeReferences) {
+		return nil
+	}
+
+	edge := &graph.Edge{From: from.ID, To: target.ID, Kind: kind, Line: line}
+	if kind == graph.EdgeCalls {
+		edge.CallSite = target.Name
+		edge.Resolution = graph.ResolutionIndex
+		edge.Weight = graph.ResolutionConfidence(graph.ResolutionIndex)
+	}
+	return edge
+}
+
+// enclosing returns the innermost function, method or type of path whose
+// extent contains line.
+func (m *merger) enclosing(path string, line int) *graph.Node {
+	var best *graph.Node
+	bestSpan := 0
+	for _, n := range m.g.GetNodesByPath(path) {
+		if n.EndLine == 0 || (!isCallable(n) && n.Kind != graph.KindType) {
+			continue
+		}
+		start := n.StartLine
+		if start == 0 {
+			start = n.Line
+		}
+		if line < start || line > n.EndLine {
+			continue
+		}
+		if span := n.EndLine - start; best == nil || span < bestSpan {
+			best, bestSpan = n, span
+		}
+	}
+	return best
+}
+
+// addImplementations adds implements edges for the implementation
+// relationships between indexed symbols that the graph lacks.
+func (m *merger) addImplementations() {
+	symbols := make([]string, 0, len(m.infos))
+	for s := range m.infos {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+
+	for _, s := range symbols {
+		from := m.symbols[s]
+		if from == nil || from.Kind != graph.KindType {
+			continue
+		}
+		for _, rel := range m.infos[s].Relationships {
+			to := m.symbols[rel.Symbol]
+			if !rel.IsImplementation || to == nil || to.Kind != graph.KindType || hasEdge(m.g, from.ID, to.ID, graph.EdgeImplements) {
+				continue
+			}
+			m.g.AddEdge(&graph.Edge{From: from.ID, To: to.ID, Kind: graph.EdgeImplements})
+			m.stats.Implements++
+		}
+	}
+}
+
+// describe derives a symbol's name, owner, package and kind from its SCIP
+// descriptors, or from the display name and kind an LSIF dump records.
+func describe(symbol string, info SymbolInfo) (symbolDesc, bool) {
+	descs := parseSymbol(symbol)
+	if len(descs) == 0 {
+		desc := symbolDesc{name: info.DisplayName, hasKind: true}
+		switch info.Kind {
+		case SymbolType:
+			desc.kind = graph.KindType
+		case SymbolFunction:
+			desc.kind = graph.KindFunction
+		case SymbolMethod:
+			desc.kind = graph.KindMethod
+		case SymbolVariable:
+			desc.kind = graph.KindVariable
+		default:
+			desc.hasKind = false
+		}
+		return desc, true
+	}
+
+	last := descs[len(descs)-1]
+	desc := symbolDesc{name: last.name, hasKind: true}
+	var namespaces []string
+	for _, d := range descs[:len(descs)-1] {
+		switch d.suffix {
+		case '/':
+			namespaces = append(namespaces, d.name)
+		case '#':
+			desc.owner = d.name
+		}
+	}
+	desc.pkg = strings.Join(namespaces, ".")
+
+	switch last.suffix {
+	case '#':
+		desc.kind, desc.owner = graph.KindType, ""
+	case '(':
+		desc.kind = graph.KindFunction
+		if desc.owner != "" {
+			desc.kind = graph.KindMethod
+		}
+	case '.':
+		if desc.owner != "" {
+			return desc, false // Field or enum member
+		}
+		desc.kind = graph.KindVariable
+	default:
+		return desc, false // Namespace, parameter, type parameter, meta, macro
+	}
+	return desc, true
+}
+
+func isCallable(n *graph.Node) bool {
+	return n.Kind == graph.KindFunction || n.Kind == graph.KindMethod
+}
+
+func hasEdge(g *graph.CodeGraph, from, to graph.NodeID, kind graph.EdgeKind) bool {
+	for _, e := range g.GetOutgoingEdges(from) {
+		if e.To == to && e.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package precise
+
+import (
+	"path/filepath"
+	"testing"
+
+	"codemap/graph"
+)
+
+// shapesGraph builds the graph of the Go package indexed by the fixtures
+// in testdata: shapes.scip (written by gen_scip.go) and shapes.lsif. The
+// parser missed format, and guessed Describe's call to Area by name.
+func shapesGraph() *graph.CodeGraph {
+	b := graph.NewBuilder("/repo")
+	b.AddFiles([]*graph.FileAnalysis{
+		{
+			Path:     "shapes/shape.go",
+			Language: "go",
+			Package:  "example.com/app/shapes",
+			Types:    []graph.TypeInfo{{Name: "Shape", Kind: "interface", Line: 3, StartLine: 3, EndLine: 5, Methods: []string{"Perimeter"}}},
+		},
+		{
+			Path:     "shapes/square.go",
+			Language: "go",
+			Package:  "example.com/app/shapes",
+			Types:    []graph.TypeInfo{{Name: "Square", Kind: "struct", Line: 3, StartLine: 3, EndLine: 5}},
+			Functions: []graph.FuncInfo{
+				{Name: "Area", Owner: "Square", Line: 7, StartLine: 7, EndLine: 9, ParamCount: 0},
+				{Name: "Describe", Line: 11, StartLine: 11, EndLine: 14, ParamCount: 1},
+			},
+			Calls: []graph.CallInfo{
+				{CallerFunc: "Describe", CallerLine: 11, CalleeName: "Area", CallLine: 12, Receiver: "s"},
+			},
+		},
+	})
+	b.ResolveMethods()
+	b.ResolveCallEdges()
+	b.FilterCallEdges()
+	b.ResolveTypeHierarchy()
+	b.ResolveReferences()
+	return b.Build()
+}
+
+// edgeBetween returns the edge of kind between the nodes named from and to.
+func edgeBetween(g *graph.CodeGraph, from, to string, kind graph.EdgeKind) *graph.Edge {
+	for _, f := range g.GetNodesByName(from) {
+		for _, e := range g.GetOutgoingEdges(f.ID) {
+			if n := g.GetNode(e.To); e.Kind == kind && n != nil && n.Name == to {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func TestMergeIndexFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		pkg     string // Package of the added node
+		want    MergeStats
+	}{
+		// SCIP records Square's implementation of Shape; LSIF has no
+		// relationships, and its monikers no packages
+		{"shapes.scip", "example.com/app/shapes", MergeStats{Documents: 2, Symbols: 4, NewNodes: 1, Calls: 2, References: 1, Implements: 1}},
+		{"shapes.lsif", "", MergeStats{Documents: 2, Symbols: 4, NewNodes: 1, Calls: 2, References: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			idx, err := ReadIndex(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := shapesGraph()
+			nodes := g.NodeCount
+
+			stats := MergeIndex(g, "/repo", idx)
+			if *stats != tt.want {
+				t.Errorf("stats = %+v, want %+v", *stats, tt.want)
+			}
+
+			// Matched definitions keep their nodes
+			if g.NodeCount != nodes+1 {
+				t.Errorf("%d nodes, want %d", g.NodeCount, nodes+1)
+			}
+			format := g.GetNode(graph.GenerateNodeID("shapes/square.go", "format"))
+			if format == nil {
+				t.Fatal("format wasn't added")
+			}
+			if format.Kind != graph.KindFunction || format.Line != 16 || format.Package != tt.pkg {
+				t.Errorf("format = %+v", format)
+			}
+			if format.StartLine != 16 || format.EndLine != 18 {
+				t.Errorf("format spans %d-%d, want 16-18", format.StartLine, format.EndLine)
+			}
+
+			area := edgeBetween(g, "Describe", "Area", graph.EdgeCalls)
+			if area == nil {
+				t.Fatal("Describe doesn't call Area")
+			}
+			if area.Resolution != graph.ResolutionIndex || area.Weight != graph.ResolutionConfidence(graph.ResolutionIndex) || area.Line != 12 {
+				t.Errorf("Describe -> Area = %+v, want an index call on line 12", area)
+			}
+			var calls int
+			for _, e := range g.GetOutgoingEdges(area.From) {
+				if e.Kind == graph.EdgeCalls {
+					calls++
+				}
+			}
+			if calls != 2 {
+				t.Errorf("Describe has %d calls, want Area and format", calls)
+			}
+			if edgeBetween(g, "Describe", "format", graph.EdgeCalls) == nil {
+				t.Error("Describe doesn't call format")
+			}
+			if e := edgeBetween(g, "Describe", "Square", graph.EdgeReferences); e == nil || e.Line != 11 {
+				t.Errorf("Describe -> Square reference = %+v, want line 11", e)
+			}
+
+			implements := edgeBetween(g, "Square", "Shape", graph.EdgeImplements)
+			if (implements != nil) != (tt.want.Implements > 0) {
+				t.Errorf("Square implements Shape: %v, want %v", implements != nil, tt.want.Implements > 0)
+			}
+		})
+	}
+}
+
+func TestMergeIndexKeepsExternalCalls(t *testing.T) {
+	idx, err := ReadIndex(filepath.Join("testdata", "shapes.scip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := shapesGraph()
+	describe := g.GetNodesByName("Describe")[0]
+	sprintf := &graph.Node{ID: graph.GenerateNodeID("fmt", "Sprintf"), Kind: graph.KindExternal, Name: "Sprintf", Path: "fmt", Package: "fmt"}
+	g.AddNode(sprintf)
+	g.AddEdge(&graph.Edge{From: describe.ID, To: sprintf.ID, Kind: graph.EdgeCalls, Line: 13})
+
+	MergeIndex(g, "/repo", idx)
+	if edgeBetween(g, "Describe", "Sprintf", graph.EdgeCalls) == nil {
+		t.Fatal("Describe -> fmt.Sprintf dropped by the merge")
+	}
+	usage := g.ExternalUsage(nil, nil)
+	if len(usage) != 1 || usage[0].Package != "fmt" || usage[0].Calls != 1 {
+		t.Errorf("external usage = %+v, want one call into fmt", usage)
+	}
+}
+
+func TestMergeIndexSignatures(t *testing.T) {
+	idx, err := ReadIndex(filepath.Join("testdata", "shapes.scip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := shapesGraph()
+	MergeIndex(g, "/repo", idx)
+
+	for name, want := range map[string]string{
+		"Area":     "func (s Square) Area() float64",
+		"Describe": "func Describe(s Square) string",
+	} {
+		for _, n := range g.GetNodesByName(name) {
+			if n.Signature != want {
+				t.Errorf("%s signature = %q, want %q", name, n.Signature, want)
+			}
+		}
+	}
+	if shape := g.GetNodesByName("Shape"); len(shape) != 1 || shape[0].DocString != "Shape has an area." {
+		t.Errorf("Shape doc not merged: %+v", shape)
+	}
+}
+
+func TestDecodeLSIFFixture(t *testing.T) {
+	idx, err := ReadIndex(filepath.Join("testdata", "shapes.lsif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.ProjectRoot != "file:///repo" || len(idx.Documents) != 2 {
+		t.Fatalf("index = %+v", idx)
+	}
+	if shape := idx.Documents[0]; shape.Path != "shapes/shape.go" || len(shape.Occurrences) != 1 || !shape.Occurrences[0].IsDefinition() {
+		t.Errorf("shapes/shape.go = %+v, want the definition of Shape", shape)
+	}
+	doc := idx.Documents[1]
+	if doc.Path != "shapes/square.go" || doc.Language != "go" {
+		t.Errorf("document = %s (%s), want shapes/square.go (go)", doc.Path, doc.Language)
+	}
+
+	var defs, refs int
+	for _, occ := range doc.Occurrences {
+		if occ.IsDefinition() {
+			defs++
+		} else {
+			refs++
+		}
+	}
+	// Square is a definition through its definitionResult, the rest by tag;
+	// the range without a result set is dropped
+	if defs != 4 || refs != 3 {
+		t.Errorf("%d definitions and %d references, want 4 and 3", defs, refs)
+	}
+	for _, occ := range doc.Occurrences {
+		if occ.Range.StartLine == 11 && occ.Symbol != "gomod example.com/app/shapes:Square.Area" {
+			t.Errorf("Area reference has symbol %q, want the moniker", occ.Symbol)
+		}
+		if occ.Range.StartLine == 15 && occ.Symbol != "lsif:14" {
+			t.Errorf("format has symbol %q, want its result set", occ.Symbol)
+		}
+	}
+}
@@ -0,0 +1,492 @@
+package precise
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Index is the language-neutral subset of a SCIP index that codemap merges
+// into its graph. LSIF dumps are converted to the same form.
+type Index struct {
+	ProjectRoot string // URI of the indexed project root (file:///...)
+	ToolName    string // Indexer that produced the index
+	ToolVersion string
+	Documents   []Document
+}
+
+// Document holds the occurrences and symbols of one source file.
+type Document struct {
+	Path        string // Relative to ProjectRoot
+	Language    string
+	Occurrences []Occurrence
+	Symbols     []SymbolInfo
+}
+
+// Occurrence is a use or definition of a symbol at a source range.
+type Occurrence struct {
+	Range     Range
+	Symbol    string
+	Roles     int32
+	Enclosing *Range // Full extent of the definition, when the indexer records it
+}
+
+// IsDefinition reports whether the occurrence defines its symbol.
+func (o Occurrence) IsDefinition() bool {
+	return o.Roles&RoleDefinition != 0
+}
+
+// Symbol roles of an occurrence (SCIP SymbolRole bit flags).
+const (
+	RoleDefinition  int32 = 0x1
+	RoleImport      int32 = 0x2
+	RoleWriteAccess int32 = 0x4
+	RoleReadAccess  int32 = 0x8
+)
+
+// Range is a 0-indexed source span; EndChar is exclusive.
+type Range struct {
+	StartLine, StartChar int
+	EndLine, EndChar     int
+}
+
+// SymbolInfo is the metadata an indexer records for a symbol it defines.
+type SymbolInfo struct {
+	Symbol        string
+	DisplayName   string
+	Documentation []string
+	Signature     string
+	Kind          string // Symbol* kind when the index states it (LSIF); SCIP kinds come from the symbol's descriptors
+	Relationships []Relationship
+}
+
+// Symbol kinds recorded in SymbolInfo.Kind.
+const (
+	SymbolType     = "type"
+	SymbolFunction = "function"
+	SymbolMethod   = "method"
+	SymbolVariable = "variable"
+)
+
+// Relationship links a symbol to another, such as the interface it implements.
+type Relationship struct {
+	Symbol           string
+	IsReference      bool
+	IsImplementation bool
+	IsTypeDefinition bool
+	IsDefinition     bool
+}
+
+// decodeSCIP parses a SCIP protobuf index. Only the fields codemap uses are
+// decoded; unknown fields are skipped.
+func decodeSCIP(data []byte) (*Index, error) {
+	idx := &Index{}
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1: // metadata
+			return decodeMessage(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				switch num {
+				case 2: // tool_info
+					return decodeMessage(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+						switch num {
+						case 1:
+							idx.ToolName = string(v)
+						case 2:
+							idx.ToolVersion = string(v)
+						}
+						return nil
+					})
+				case 3:
+					idx.ProjectRoot = string(v)
+				}
+				return nil
+			})
+		case 2: // documents
+			doc, err := decodeDocument(v)
+			if err != nil {
+				return err
+			}
+			idx.Documents = append(idx.Documents, doc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCIP index: %w", err)
+	}
+	return idx, nil
+}
+
+func decodeDocument(data []byte) (Document, error) {
+	var doc Document
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			doc.Path = string(v)
+		case 2:
+			occ, err := decodeOccurrence(v)
+			if err != nil {
+				return err
+			}
+			doc.Occurrences = append(doc.Occurrences, occ)
+		case 3:
+			info, err := decodeSymbolInfo(v)
+			if err != nil {
+				return err
+			}
+			doc.Symbols = append(doc.Symbols, info)
+		case 4:
+			doc.Language = string(v)
+		}
+		return nil
+	})
+	return doc, err
+}
+
+func decodeOccurrence(data []byte) (Occurrence, error) {
+	var occ Occurrence
+	var rng, enclosing []int
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			return appendInts(&rng, typ, v)
+		case 2:
+			occ.Symbol = string(v)
+		case 3:
+			occ.Roles = int32(decodeVarint(v))
+		case 7:
+			return appendInts(&enclosing, typ, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return occ, err
+	}
+	r, ok := parseRange(rng)
+	if !ok {
+		return occ, fmt.Errorf("occurrence of %q has malformed range %v", occ.Symbol, rng)
+	}
+	occ.Range = r
+	if r, ok := parseRange(enclosing); ok {
+		occ.Enclosing = &r
+	}
+	return occ, nil
+}
+
+func decodeSymbolInfo(data []byte) (SymbolInfo, error) {
+	var info SymbolInfo
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			info.Symbol = string(v)
+		case 3:
+			info.Documentation = append(info.Documentation, string(v))
+		case 4:
+			rel, err := decodeRelationship(v)
+			if err != nil {
+				return err
+			}
+			info.Relationships = append(info.Relationships, rel)
+		case 6:
+			info.DisplayName = string(v)
+		case 7: // signature_documentation is a Document; its text is field 5
+			return decodeMessage(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				if num == 5 && typ == protowire.BytesType {
+					info.Signature = string(v)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return info, err
+}
+
+func decodeRelationship(data []byte) (Relationship, error) {
+	var rel Relationship
+	err := decodeMessage(data, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1:
+			rel.Symbol = string(v)
+		case 2:
+			rel.IsReference = decodeVarint(v) != 0
+		case 3:
+			rel.IsImplementation = decodeVarint(v) != 0
+		case 4:
+			rel.IsTypeDefinition = decodeVarint(v) != 0
+		case 5:
+			rel.IsDefinition = decodeVarint(v) != 0
+		}
+		return nil
+	})
+	return rel, err
+}
+
+// decodeMessage calls fn for each field of a protobuf message. Length-
+// delimited values are passed as their contents and varints in their
+// encoded form (see decodeVarint).
+func decodeMessage(data []byte, fn func(num protowire.Number, typ protowire.Type, v []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var v []byte
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n >= 0 {
+				v = data[:n]
+			}
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if err := fn(num, typ, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeVarint returns the value of an encoded varint, or 0.
+func decodeVarint(v []byte) uint64 {
+	x, n := protowire.ConsumeVarint(v)
+	if n < 0 {
+		return 0
+	}
+	return x
+}
+
+// appendInts appends a repeated int32 field, packed or not.
+func appendInts(dst *[]int, typ protowire.Type, v []byte) error {
+	if typ == protowire.VarintType {
+		*dst = append(*dst, int(int32(decodeVarint(v))))
+		return nil
+	}
+	for len(v) > 0 {
+		x, n := protowire.ConsumeVarint(v)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		*dst = append(*dst, int(int32(x)))
+		v = v[n:]
+	}
+	return nil
+}
+
+// parseRange converts a SCIP range: [startLine, startChar, endChar] on one
+// line, or [startLine, startChar, endLine, endChar].
+func parseRange(r []int) (Range, bool) {
+	switch len(r) {
+	case 3:
+		return Range{StartLine: r[0], StartChar: r[1], EndLine: r[0], EndChar: r[2]}, true
+	case 4:
+		return Range{StartLine: r[0], StartChar: r[1], EndLine: r[2], EndChar: r[3]}, true
+	}
+	return Range{}, false
+}
+
+// EncodeSCIP serializes idx as a SCIP protobuf index with UTF-8 positions.
+func EncodeSCIP(idx *Index) []byte {
+	var tool []byte
+	tool = appendString(tool, 1, idx.ToolName)
+	tool = appendString(tool, 2, idx.ToolVersion)
+
+	var meta []byte
+	meta = appendMessage(meta, 2, tool)
+	meta = appendString(meta, 3, idx.ProjectRoot)
+	meta = appendVarint(meta, 4, 1) // TextEncoding UTF8
+
+	var b []byte
+	b = appendMessage(b, 1, meta)
+	for _, doc := range idx.Documents {
+		b = appendMessage(b, 2, encodeDocument(doc))
+	}
+	return b
+}
+
+func encodeDocument(doc Document) []byte {
+	var b []byte
+	b = appendString(b, 1, doc.Path)
+	for _, occ := range doc.Occurrences {
+		var o []byte
+		o = appendRange(o, 1, occ.Range)
+		o = appendString(o, 2, occ.Symbol)
+		o = appendVarint(o, 3, uint64(occ.Roles))
+		if occ.Enclosing != nil {
+			o = appendRange(o, 7, *occ.Enclosing)
+		}
+		b = appendMessage(b, 2, o)
+	}
+	for _, info := range doc.Symbols {
+		var s []byte
+		s = appendString(s, 1, info.Symbol)
+		for _, d := range info.Documentation {
+			s = appendString(s, 3, d)
+		}
+		for _, rel := range info.Relationships {
+			var r []byte
+			r = appendString(r, 1, rel.Symbol)
+			r = appendBool(r, 2, rel.IsReference)
+			r = appendBool(r, 3, rel.IsImplementation)
+			r = appendBool(r, 4, rel.IsTypeDefinition)
+			r = appendBool(r, 5, rel.IsDefinition)
+			s = appendMessage(s, 4, r)
+		}
+		s = appendString(s, 6, info.DisplayName)
+		if info.Signature != "" {
+			var sig []byte
+			sig = appendString(sig, 4, doc.Language)
+			sig = appendString(sig, 5, info.Signature)
+			s = appendMessage(s, 7, sig)
+		}
+		b = appendMessage(b, 3, s)
+	}
+	b = appendString(b, 4, doc.Language)
+	return b
+}
+
+// The scalar append helpers omit zero values, as proto3 encoders do.
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendVarint(b, num, 1)
+}
+
+// appendRange appends a packed SCIP range, in its three-element form when
+// the range is on one line.
+func appendRange(b []byte, num protowire.Number, r Range) []byte {
+	ints := []int{r.StartLine, r.StartChar, r.EndLine, r.EndChar}
+	if r.StartLine == r.EndLine {
+		ints = []int{r.StartLine, r.StartChar, r.EndChar}
+	}
+	var packed []byte
+	for _, x := range ints {
+		packed = protowire.AppendVarint(packed, uint64(int64(x)))
+	}
+	return appendMessage(b, num, packed)
+}
+
+// descriptor is one component of a SCIP symbol's path, such as the
+// namespace `shapes/`, the type `Square#` or the method `Area().`.
+type descriptor struct {
+	name   string
+	suffix byte // '/' namespace, '#' type, '.' term, '(' method, ':' meta, '!' macro, '[' type parameter, ')' parameter
+}
+
+// parseSymbol splits a global SCIP symbol ("<scheme> <manager> <package>
+// <version> <descriptors>") into its descriptors. Local symbols and
+// malformed strings yield nil.
+func parseSymbol(symbol string) []descriptor {
+	if strings.HasPrefix(symbol, "local ") {
+		return nil
+	}
+	// Skip the four space-separated header fields; a double space escapes a space
+	rest := symbol
+	for field := 0; field < 4; field++ {
+		i := 0
+		for ; i < len(rest); i++ {
+			if rest[i] == ' ' {
+				if i+1 < len(rest) && rest[i+1] == ' ' {
+					i++
+					continue
+				}
+				break
+			}
+		}
+		if i >= len(rest) {
+			return nil
+		}
+		rest = rest[i+1:]
+	}
+
+	var descs []descriptor
+	for len(rest) > 0 {
+		var name string
+		switch rest[0] {
+		case '[', '(': // Type parameter [name] or parameter (name)
+			suffix, closer := byte('['), byte(']')
+			if rest[0] == '(' {
+				suffix, closer = ')', ')'
+			}
+			end := strings.IndexByte(rest, closer)
+			if end < 0 {
+				return nil
+			}
+			descs = append(descs, descriptor{name: rest[1:end], suffix: suffix})
+			rest = rest[end+1:]
+			continue
+		case '`':
+			end := 1
+			for end < len(rest) {
+				if rest[end] == '`' {
+					if end+1 < len(rest) && rest[end+1] == '`' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(rest) {
+				return nil
+			}
+			name = strings.ReplaceAll(rest[1:end], "``", "`")
+			rest = rest[end+1:]
+		default:
+			end := strings.IndexAny(rest, "/#.(:![")
+			if end <= 0 {
+				return nil
+			}
+			name, rest = rest[:end], rest[end:]
+		}
+		if rest == "" {
+			return nil
+		}
+
+		suffix := rest[0]
+		switch suffix {
+		case '(': // Method: name(disambiguator).
+			end := strings.Index(rest, ").")
+			if end < 0 {
+				return nil
+			}
+			rest = rest[end+2:]
+		case '/', '#', '.', ':', '!':
+			rest = rest[1:]
+		default:
+			return nil
+		}
+		descs = append(descs, descriptor{name: name, suffix: suffix})
+	}
+	return descs
+}
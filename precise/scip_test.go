@@ -0,0 +1,126 @@
+package precise
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSCIPRoundTrip(t *testing.T) {
+	idx := &Index{
+		ProjectRoot: "file:///repo",
+		ToolName:    "codemap",
+		ToolVersion: "1.2.0",
+		Documents: []Document{
+			{
+				Path:     "shapes/square.go",
+				Language: "go",
+				Occurrences: []Occurrence{
+					{
+						Range:     Range{StartLine: 4, StartChar: 5, EndLine: 4, EndChar: 11},
+						Symbol:    "scip-go gomod example.com/app v1 `example.com/app/shapes`/Square#",
+						Roles:     RoleDefinition,
+						Enclosing: &Range{StartLine: 4, StartChar: 0, EndLine: 6, EndChar: 1},
+					},
+					{
+						Range:  Range{StartLine: 9, StartChar: 8, EndLine: 9, EndChar: 12},
+						Symbol: "scip-go gomod example.com/app v1 `example.com/app/shapes`/Square#Area().",
+						Roles:  RoleReadAccess,
+					},
+				},
+				Symbols: []SymbolInfo{
+					{
+						Symbol:        "scip-go gomod example.com/app v1 `example.com/app/shapes`/Square#",
+						DisplayName:   "Square",
+						Documentation: []string{"Square is a shape.", "It has four sides."},
+						Signature:     "type Square struct",
+						Relationships: []Relationship{
+							{Symbol: "scip-go gomod example.com/app v1 `example.com/app/shapes`/Shape#", IsImplementation: true},
+							{Symbol: "scip-go gomod example.com/app v1 `example.com/app/shapes`/Polygon#", IsReference: true, IsTypeDefinition: true, IsDefinition: true},
+						},
+					},
+				},
+			},
+			{Path: "main.go", Language: "go"},
+		},
+	}
+
+	got, err := decodeSCIP(EncodeSCIP(idx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, idx) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", got, idx)
+	}
+}
+
+func TestDecodeSCIPMalformed(t *testing.T) {
+	data := EncodeSCIP(&Index{
+		ToolName: "codemap",
+		Documents: []Document{{
+			Path:        "main.go",
+			Occurrences: []Occurrence{{Range: Range{StartLine: 1, EndLine: 1, EndChar: 4}, Symbol: "local 1"}},
+		}},
+	})
+	if _, err := decodeSCIP(data[:len(data)-3]); err == nil {
+		t.Error("truncated index decoded without error")
+	}
+
+	// An occurrence range with two elements
+	occ := appendMessage(nil, 1, []byte{1, 2})
+	doc := appendMessage(nil, 2, occ)
+	if _, err := decodeSCIP(appendMessage(nil, 2, doc)); err == nil {
+		t.Error("malformed range decoded without error")
+	}
+}
+
+func TestParseSymbol(t *testing.T) {
+	tests := []struct {
+		name, symbol string
+		want         []descriptor
+	}{
+		{
+			"go type and method",
+			"scip-go gomod example.com/app v1.0.0 `example.com/app/shapes`/Square#Area().",
+			[]descriptor{{"example.com/app/shapes", '/'}, {"Square", '#'}, {"Area", '('}},
+		},
+		{
+			"escaped backtick",
+			"scip-ts npm app 1.0.0 src/`odd``name`.",
+			[]descriptor{{"src", '/'}, {"odd`name", '.'}},
+		},
+		{
+			"method disambiguator",
+			"semanticdb maven com.acme:app 1.0 com/acme/Job#run(+1).",
+			[]descriptor{{"com", '/'}, {"acme", '/'}, {"Job", '#'}, {"run", '('}},
+		},
+		{
+			"escaped space in package",
+			"scip-python python my  package 0.1 app/views/render().",
+			[]descriptor{{"app", '/'}, {"views", '/'}, {"render", '('}},
+		},
+		{
+			"parameter and type parameter",
+			"scip-go gomod example.com/app v1 pkg/Map#[K]Get().(key)",
+			[]descriptor{{"pkg", '/'}, {"Map", '#'}, {"K", '['}, {"Get", '('}, {"key", ')'}},
+		},
+		{
+			"term, meta and macro",
+			"rust-analyzer cargo app 0.1.0 config/Config#`<impl>`:DEFAULT.log!",
+			[]descriptor{{"config", '/'}, {"Config", '#'}, {"<impl>", ':'}, {"DEFAULT", '.'}, {"log", '!'}},
+		},
+		{"local symbol", "local 42", nil},
+		{"missing header fields", "scip-go gomod example.com/app", nil},
+		{"no descriptor suffix", "scip-go gomod example.com/app v1 Square", nil},
+		{"unterminated backtick", "scip-go gomod example.com/app v1 `shapes/Square#", nil},
+		{"unterminated method", "scip-go gomod example.com/app v1 Square#Area(", nil},
+		{"unterminated parameter", "scip-go gomod example.com/app v1 Area().(x", nil},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSymbol(tt.symbol); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSymbol(%q) = %v, want %v", tt.symbol, got, tt.want)
+			}
+		})
+	}
+}
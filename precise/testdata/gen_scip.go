@@ -0,0 +1,69 @@
+//go:build ignore
+
+// Writes shapes.scip, the SCIP fixture of the merge tests. Run it from this
+// directory with `go run gen_scip.go`.
+package main
+
+import (
+	"log"
+	"os"
+
+	"codemap/precise"
+)
+
+const pkg = "scip-go gomod example.com/app v1.0.0 `example.com/app/shapes`/"
+
+func main() {
+	idx := &precise.Index{
+		ProjectRoot: "file:///repo",
+		ToolName:    "scip-go",
+		ToolVersion: "0.1.0",
+		Documents: []precise.Document{
+			{
+				Path:     "shapes/shape.go",
+				Language: "go",
+				Occurrences: []precise.Occurrence{
+					{Range: precise.Range{StartLine: 2, StartChar: 5, EndLine: 2, EndChar: 10}, Symbol: pkg + "Shape#", Roles: precise.RoleDefinition},
+				},
+				Symbols: []precise.SymbolInfo{
+					{Symbol: pkg + "Shape#", DisplayName: "Shape", Documentation: []string{"Shape has an area."}},
+				},
+			},
+			{
+				Path:     "shapes/square.go",
+				Language: "go",
+				Occurrences: []precise.Occurrence{
+					{Range: precise.Range{StartLine: 0, StartChar: 8, EndLine: 0, EndChar: 14}, Symbol: "scip-go gomod example.com/app v1.0.0 `example.com/app/shapes`/", Roles: precise.RoleDefinition},
+					{Range: precise.Range{StartLine: 2, StartChar: 5, EndLine: 2, EndChar: 11}, Symbol: pkg + "Square#", Roles: precise.RoleDefinition},
+					{Range: precise.Range{StartLine: 3, StartChar: 1, EndLine: 3, EndChar: 5}, Symbol: pkg + "Square#Side.", Roles: precise.RoleDefinition},
+					{Range: precise.Range{StartLine: 6, StartChar: 16, EndLine: 6, EndChar: 20}, Symbol: pkg + "Square#Area().", Roles: precise.RoleDefinition},
+					{Range: precise.Range{StartLine: 10, StartChar: 5, EndLine: 10, EndChar: 13}, Symbol: pkg + "Describe().", Roles: precise.RoleDefinition},
+					{Range: precise.Range{StartLine: 10, StartChar: 16, EndLine: 10, EndChar: 22}, Symbol: pkg + "Square#", Roles: precise.RoleReadAccess},
+					{Range: precise.Range{StartLine: 11, StartChar: 10, EndLine: 11, EndChar: 14}, Symbol: pkg + "Square#Area().", Roles: precise.RoleReadAccess},
+					{Range: precise.Range{StartLine: 12, StartChar: 8, EndLine: 12, EndChar: 14}, Symbol: pkg + "format().", Roles: precise.RoleReadAccess},
+					{Range: precise.Range{StartLine: 11, StartChar: 2, EndLine: 11, EndChar: 3}, Symbol: "local 0", Roles: precise.RoleReadAccess},
+					{
+						Range:     precise.Range{StartLine: 15, StartChar: 5, EndLine: 15, EndChar: 11},
+						Symbol:    pkg + "format().",
+						Roles:     precise.RoleDefinition,
+						Enclosing: &precise.Range{StartLine: 15, EndLine: 17, EndChar: 1},
+					},
+				},
+				Symbols: []precise.SymbolInfo{
+					{
+						Symbol:        pkg + "Square#",
+						DisplayName:   "Square",
+						Relationships: []precise.Relationship{{Symbol: pkg + "Shape#", IsImplementation: true}},
+					},
+					{Symbol: pkg + "Square#Area().", DisplayName: "Area", Signature: "func (s Square) Area() float64"},
+					{Symbol: pkg + "Describe().", DisplayName: "Describe", Signature: "func Describe(s Square) string"},
+					{Symbol: pkg + "format().", DisplayName: "format", Signature: "func format(area float64) string"},
+				},
+			},
+			{Path: "../elsewhere/other.go", Language: "go"},
+		},
+	}
+	if err := os.WriteFile("shapes.scip", precise.EncodeSCIP(idx), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
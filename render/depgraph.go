@@ -10,129 +10,14 @@ import (
 	"codemap/scanner"
 )
 
-// Language compatibility groups
-var langGroups = map[string]string{
-	"python":     "python",
-	"go":         "go",
-	"javascript": "js",
-	"typescript": "js",
-	"rust":       "rust",
-	"ruby":       "ruby",
-	"c":          "c",
-	"cpp":        "c",
-	"java":       "java",
-	"swift":      "swift",
-	"bash":       "bash",
-	"kotlin":     "kotlin",
-	"c_sharp":    "c_sharp",
-	"php":        "php",
-	"dart":       "dart",
-	"r":          "r",
-}
-
-// Standard library names to filter out
-var stdlibNames = map[string]bool{
-	// Go stdlib
-	"errors": true, "fmt": true, "io": true, "os": true, "path": true, "sync": true, "time": true, "context": true, "http": true,
-	"net": true, "bytes": true, "strings": true, "strconv": true, "sort": true, "flag": true, "log": true, "bufio": true,
-	"encoding": true, "testing": true, "runtime": true, "unsafe": true, "reflect": true, "regexp": true,
-	// Python stdlib
-	"logging": true, "typing": true, "collections": true, "datetime": true, "json": true, "sys": true, "re": true,
-	"pathlib": true, "hashlib": true, "base64": true, "asyncio": true, "enum": true, "functools": true, "random": true,
-	"math": true, "copy": true, "itertools": true, "contextlib": true,
-	// JS/TS common
-	"fs": true, "util": true, "events": true, "stream": true, "crypto": true, "https": true,
-	"react": true, "filepath": true, "embed": true,
-}
-
-// normalizeImport normalizes an import string
-func normalizeImport(imp, lang string) string {
-	imp = strings.Trim(imp, "\"'")
-	if strings.Contains(imp, "/") {
-		parts := strings.Split(imp, "/")
-		imp = parts[len(parts)-1]
-	}
-	if strings.Contains(imp, ".") && !strings.HasPrefix(imp, ".") {
-		parts := strings.Split(imp, ".")
-		imp = parts[len(parts)-1]
-	}
-	// Remove file extensions
-	extPattern := regexp.MustCompile(`\.(py|go|js|ts|jsx|tsx|rb|rs|c|h|cpp|hpp|java|swift)$`)
-	imp = extPattern.ReplaceAllString(imp, "")
-	return strings.ToLower(imp)
-}
-
-// findInternalDeps finds which files import which other files
+// findInternalDeps maps each file to the scanned files its imports resolve to.
 func findInternalDeps(files []scanner.FileAnalysis) map[string][]string {
-	// Build lookup: name -> list of (path, language_group)
-	type fileInfo struct {
-		path      string
-		langGroup string
-	}
-	nameToInfos := make(map[string][]fileInfo)
-
-	for _, f := range files {
-		langGroup := langGroups[f.Language]
-		if langGroup == "" {
-			langGroup = f.Language
-		}
-		basename := filepath.Base(f.Path)
-		extPattern := regexp.MustCompile(`\.[^.]+$`)
-		name := strings.ToLower(extPattern.ReplaceAllString(basename, ""))
-		nameToInfos[name] = append(nameToInfos[name], fileInfo{f.Path, langGroup})
-	}
-
 	deps := make(map[string][]string)
-
 	for _, f := range files {
-		srcLang := f.Language
-		srcGroup := langGroups[srcLang]
-		if srcGroup == "" {
-			srcGroup = srcLang
-		}
-
-		for _, imp := range f.Imports {
-			// Skip stdlib-looking imports
-			if !strings.Contains(imp, "/") && !strings.Contains(imp, ".") {
-				if stdlibNames[strings.ToLower(imp)] {
-					continue
-				}
-			}
-
-			norm := normalizeImport(imp, srcLang)
-			if stdlibNames[norm] {
-				continue
-			}
-
-			if infos, ok := nameToInfos[norm]; ok {
-				srcBasename := filepath.Base(f.Path)
-				for _, info := range infos {
-					if info.path == f.Path {
-						continue // Skip self
-					}
-					if srcGroup != info.langGroup {
-						continue // Skip cross-language
-					}
-					targetName := filepath.Base(info.path)
-					if targetName == srcBasename {
-						continue // Skip same-basename
-					}
-					// Check if already added
-					found := false
-					for _, d := range deps[f.Path] {
-						if d == targetName {
-							found = true
-							break
-						}
-					}
-					if !found {
-						deps[f.Path] = append(deps[f.Path], targetName)
-					}
-				}
-			}
+		if len(f.ImportedFiles) > 0 {
+			deps[f.Path] = f.ImportedFiles
 		}
 	}
-
 	return deps
 }
 
@@ -191,12 +76,17 @@ func Depgraph(project scanner.DepsProject) {
 		}
 	}
 
-	// Group by top-level system
+	// Group by workspace module in multi-module repositories, otherwise by
+	// top-level directory
+	workspace := &scanner.Workspace{Modules: project.Modules}
+	byModule := len(project.Modules) > 1
 	systems := make(map[string][]scanner.FileAnalysis)
 	for _, f := range files {
 		parts := strings.Split(strings.ReplaceAll(f.Path, "\\", "/"), "/")
 		system := "."
-		if len(parts) > 1 {
+		if m := workspace.ModuleOf(f.Path); byModule && m != nil {
+			system = m.Name
+		} else if len(parts) > 1 {
 			system = parts[0]
 		}
 		systems[system] = append(systems[system], f)
@@ -240,7 +130,7 @@ func Depgraph(project scanner.DepsProject) {
 
 	for _, lang := range langOrder {
 		if names, ok := extByLang[lang]; ok {
-			label := scanner.LangDisplay[lang].Short
+			label := scanner.LanguageInfo(lang).Short
 			if label == "" {
 				label = strings.Title(lang)
 			}
@@ -285,6 +175,19 @@ func Depgraph(project scanner.DepsProject) {
 	fmt.Printf("╰%s╯\n", strings.Repeat("─", innerWidth))
 	fmt.Println()
 
+	// Module dependencies
+	if byModule {
+		fmt.Printf("Modules %s\n", strings.Repeat("═", 52))
+		for _, m := range project.Modules {
+			line := fmt.Sprintf("  %s %s(%s, %s)%s", m.Name, Dim, m.Kind, m.Path, Reset)
+			if len(m.Requires) > 0 {
+				line += " ───▶ " + strings.Join(m.Requires, ", ")
+			}
+			fmt.Println(line)
+		}
+		fmt.Println()
+	}
+
 	// Sort systems
 	var systemNames []string
 	for name := range systems {
@@ -295,7 +198,10 @@ func Depgraph(project scanner.DepsProject) {
 	// Render each system
 	for _, system := range systemNames {
 		sysFiles := systems[system]
-		systemName := getSystemName(system)
+		systemName := system
+		if !byModule || workspace.Lookup(system) == nil {
+			systemName = getSystemName(system)
+		}
 
 		// Check if system has content
 		hasContent := false
@@ -333,25 +239,17 @@ func Depgraph(project scanner.DepsProject) {
 
 			if len(targets) == 1 {
 				t := targets[0]
-				tName := extPattern.ReplaceAllString(t, "")
+				tName := extPattern.ReplaceAllString(filepath.Base(t), "")
 
 				// Check for sub-deps
-				var tPath string
-				for _, ff := range files {
-					if filepath.Base(ff.Path) == t {
-						tPath = ff.Path
-						break
-					}
-				}
-
-				subTargets := internalDeps[tPath]
+				subTargets := internalDeps[t]
 				if len(subTargets) > 0 {
 					var subNames []string
 					for i, s := range subTargets {
 						if i >= 3 {
 							break
 						}
-						subNames = append(subNames, extPattern.ReplaceAllString(s, ""))
+						subNames = append(subNames, extPattern.ReplaceAllString(filepath.Base(s), ""))
 					}
 					chain := fmt.Sprintf("%s ───▶ %s ───▶ %s", nameNoExt, tName, strings.Join(subNames, ", "))
 					if len(subTargets) > 3 {
@@ -364,7 +262,7 @@ func Depgraph(project scanner.DepsProject) {
 			} else {
 				var targetStrs []string
 				for _, t := range targets {
-					targetStrs = append(targetStrs, extPattern.ReplaceAllString(t, ""))
+					targetStrs = append(targetStrs, extPattern.ReplaceAllString(filepath.Base(t), ""))
 				}
 
 				if len(targets) <= 4 {
@@ -420,7 +318,7 @@ func Depgraph(project scanner.DepsProject) {
 			fmt.Println(strings.Repeat("─", 61))
 			var hubStrs []string
 			for _, h := range hubs {
-				hubStrs = append(hubStrs, fmt.Sprintf("%s (%d←)", extPattern.ReplaceAllString(h.name, ""), h.count))
+				hubStrs = append(hubStrs, fmt.Sprintf("%s (%d←)", extPattern.ReplaceAllString(filepath.Base(h.name), ""), h.count))
 			}
 			fmt.Printf("HUBS: %s\n", strings.Join(hubStrs, ", "))
 		}
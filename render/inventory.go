@@ -0,0 +1,88 @@
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"codemap/scanner"
+)
+
+// Inventory renders the locked packages of each lockfile as a dependency
+// tree rooted at the direct dependencies. A package whose dependencies
+// were already shown is marked (*); packages no direct dependency leads to
+// are listed after the tree.
+func Inventory(inv *scanner.Inventory) {
+	fmt.Println()
+	fmt.Printf("=== Dependency Inventory: %s ===\n", filepath.Base(inv.Root))
+	if len(inv.Lockfiles) == 0 {
+		fmt.Println()
+		fmt.Println("  No lockfiles found (go.sum, package-lock.json, pnpm-lock.yaml, yarn.lock,")
+		fmt.Println("  Cargo.lock, poetry.lock, uv.lock, Gemfile.lock).")
+		return
+	}
+
+	for _, l := range inv.Lockfiles {
+		direct := l.Direct()
+		fmt.Println()
+		fmt.Printf("%s%s%s %s(%s, %d packages, %d direct)%s\n", Bold, l.Path, Reset, Dim, l.Ecosystem, len(l.Packages), len(direct), Reset)
+
+		shown := make(map[string]bool)
+		for i, p := range direct {
+			printLocked(l, p, "", i == len(direct)-1, shown, nil)
+		}
+
+		var rest []string
+		for _, p := range l.Packages {
+			if !shown[p.Key()] {
+				rest = append(rest, lockedLabel(p))
+			}
+		}
+		if len(rest) > 0 {
+			fmt.Printf("%s  Not reached from direct dependencies:%s\n", Dim, Reset)
+			for _, label := range rest {
+				fmt.Printf("    %s\n", label)
+			}
+		}
+	}
+	fmt.Println()
+}
+
+// printLocked prints a package and, the first time it is shown, its
+// dependencies. path holds the packages above it to cut cycles.
+func printLocked(l *scanner.Lockfile, p *scanner.LockedPackage, prefix string, last bool, shown map[string]bool, path []string) {
+	branch, indent := "├── ", "│   "
+	if last {
+		branch, indent = "└── ", "    "
+	}
+	label := lockedLabel(p)
+	expand := !shown[p.Key()]
+	for _, key := range path {
+		if key == p.Key() {
+			expand = false
+		}
+	}
+	if !expand && len(p.Dependencies) > 0 {
+		label += Dim + " (*)" + Reset
+	}
+	fmt.Printf("%s%s%s\n", prefix, branch, label)
+	if !expand {
+		return
+	}
+	shown[p.Key()] = true
+
+	path = append(path, p.Key())
+	for i, key := range p.Dependencies {
+		if dep := l.Lookup(key); dep != nil {
+			printLocked(l, dep, prefix+indent, i == len(p.Dependencies)-1, shown, path)
+		}
+	}
+}
+
+// lockedLabel formats a package as "name version", with a dev tag.
+func lockedLabel(p *scanner.LockedPackage) string {
+	label := p.Name + " " + Dim + p.Version + Reset
+	if p.Dev {
+		label += Yellow + " [dev]" + Reset
+	}
+	return label
+}
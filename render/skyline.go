@@ -78,6 +78,9 @@ type extAgg struct {
 func filterCodeFiles(files []scanner.FileInfo) []scanner.FileInfo {
 	var result []scanner.FileInfo
 	for _, f := range files {
+		if f.Class != "" {
+			continue // Generated and vendored code would dwarf the project's own
+		}
 		if codeExtensions[strings.ToLower(f.Ext)] || codeFilenames[filepath.Base(f.Path)] {
 			result = append(result, f)
 		}
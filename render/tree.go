@@ -24,8 +24,8 @@ func getTopLargeFiles(files []scanner.FileInfo) map[string]bool {
 	var sourceFiles []scanner.FileInfo
 	for _, f := range files {
 		ext := strings.ToLower(f.Ext)
-		// Skip if no extension (likely binary) or if it's an asset
-		if ext == "" || IsAssetExtension(ext) {
+		// Skip if no extension (likely binary), an asset or generated/vendored
+		if ext == "" || IsAssetExtension(ext) || f.Class != "" {
 			continue
 		}
 		sourceFiles = append(sourceFiles, f)
@@ -354,6 +354,8 @@ func printTreeNode(node *treeNode, prefix string, isLast bool, topLarge map[stri
 				prefix = "⭐️ "
 				prefixWidth = 3
 				color = Bold + color
+			} else if f.file.Class != "" {
+				color = Dim
 			}
 
 			// Warning for large files (>8k tokens)
@@ -380,6 +382,11 @@ func printTreeNode(node *treeNode, prefix string, isLast bool, topLarge map[stri
 				}
 				suffixWidth = len(suffix)
 			}
+			// Generated, vendored, minified and binary files are marked, not hidden
+			if f.file.Class != "" {
+				suffix += " [" + f.file.Class + "]"
+				suffixWidth = len(suffix)
+			}
 
 			display := prefix + displayName + suffix + tokenWarning
 			colored := fmt.Sprintf("%s%s%s%s%s%s%s%s", color, prefix, displayName, Reset, Dim, suffix, Reset+Red+tokenWarning+Reset, "")
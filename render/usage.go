@@ -0,0 +1,42 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"codemap/graph"
+)
+
+// ExternalUsage renders the usage surface of external packages, grouped by
+// the dependency providing them: each package's called functions and
+// methods with their call counts and calling files.
+func ExternalUsage(projectName string, usage []*graph.ExternalUsage) {
+	fmt.Println()
+	fmt.Printf("=== External API Usage: %s ===\n", projectName)
+	if len(usage) == 0 {
+		fmt.Println()
+		fmt.Println("  No calls into external packages found (run 'codemap --index' to refresh).")
+		return
+	}
+
+	for i, u := range usage {
+		if i == 0 || u.Dependency != usage[i-1].Dependency {
+			fmt.Println()
+			if u.Dependency == "" {
+				fmt.Printf("%sStandard library and undeclared packages%s\n", Bold, Reset)
+			} else {
+				fmt.Printf("%s%s%s\n", Bold, u.Dependency, Reset)
+			}
+		}
+
+		label := u.Package
+		if u.Version != "" {
+			label += " " + Dim + u.Version + Reset
+		}
+		fmt.Printf("  %s %s(%d calls, %d files)%s\n", label, Dim, u.Calls, len(u.Files), Reset)
+		for _, s := range u.Symbols {
+			fmt.Printf("    %s%s.%s%s %s×%d%s  %s%s%s\n", Cyan, u.Alias(), s.Name, Reset, Dim, s.Calls, Reset, Dim, strings.Join(s.Files, ", "), Reset)
+		}
+	}
+	fmt.Println()
+}
@@ -1,7 +1,6 @@
 package scanner
 
 import (
-	"os"
 	"strings"
 
 	tree_sitter "github.com/tree-sitter/go-tree-sitter"
@@ -15,17 +14,19 @@ type CallInfo struct {
 	CallLine   int    `json:"call_line"`          // Line where the call occurs
 	Args       int    `json:"args"`               // Number of arguments
 	Receiver   string `json:"receiver,omitempty"` // Object/receiver for method calls
-}
 
-// FileCallAnalysis contains all calls found in a file.
-type FileCallAnalysis struct {
-	Path     string     `json:"path"`
-	Language string     `json:"language"`
-	Calls    []CallInfo `json:"calls"`
+	// Inferred target of Receiver: its static type, or the package an
+	// import alias names (the import path, qualified like FileAnalysis.Package
+	// once resolved in the tree), and the Infer* method that found it. A type
+	// of an imported package comes with that package.
+	ReceiverType    string `json:"receiver_type,omitempty"`
+	ReceiverPackage string `json:"receiver_package,omitempty"`
+	Inference       string `json:"inference,omitempty"`
 }
 
 // callQueryPatterns maps languages to their call expression query patterns.
-// These are simpler inline patterns for common languages.
+// Every language in LangDisplay has an entry; receivers are captured where
+// the grammar exposes them.
 var callQueryPatterns = map[string]string{
 	"go": `
 ; Function calls
@@ -95,6 +96,7 @@ var callQueryPatterns = map[string]string{
 	"java": `
 ; Method invocations
 (method_invocation
+  !object
   name: (identifier) @call.name
   arguments: (argument_list) @call.args)
 
@@ -104,82 +106,187 @@ var callQueryPatterns = map[string]string{
   name: (identifier) @call.name
   arguments: (argument_list) @call.args)
 `,
-}
+	"ruby": `
+; Function calls: foo(1) or foo 1
+(call
+  !receiver
+  method: (identifier) @call.name
+  arguments: (argument_list)? @call.args)
 
-// callConfigs caches compiled call queries per language.
-var callConfigs = make(map[string]*tree_sitter.Query)
+; Method calls: obj.foo(1), Foo.bar
+(call
+  receiver: (_) @call.receiver
+  method: (identifier) @call.name
+  arguments: (argument_list)? @call.args)
+`,
+	"c": `
+; Function calls
+(call_expression
+  function: (identifier) @call.name
+  arguments: (argument_list) @call.args)
 
-// ExtractCalls analyzes a file and extracts all function/method calls.
-func (l *GrammarLoader) ExtractCalls(filePath string) (*FileCallAnalysis, error) {
-	lang := DetectLanguage(filePath)
-	if lang == "" {
-		return nil, nil
-	}
+; Calls through struct members: s->fn(x), s.fn(x)
+(call_expression
+  function: (field_expression
+    argument: (_) @call.receiver
+    field: (field_identifier) @call.name)
+  arguments: (argument_list) @call.args)
+`,
+	"cpp": `
+; Function calls
+(call_expression
+  function: (identifier) @call.name
+  arguments: (argument_list) @call.args)
 
-	// Check if we have a call query for this language first
-	if _, ok := callQueryPatterns[lang]; !ok {
-		return nil, nil // No call extraction support for this language
-	}
+; Method calls: obj.fn(x), ptr->fn(x)
+(call_expression
+  function: (field_expression
+    argument: (_) @call.receiver
+    field: (field_identifier) @call.name)
+  arguments: (argument_list) @call.args)
 
-	if err := l.LoadLanguage(lang); err != nil {
-		return nil, nil
-	}
+; Qualified calls: ns::fn(x), Class::fn(x)
+(call_expression
+  function: (qualified_identifier
+    scope: (_) @call.receiver
+    name: (identifier) @call.name)
+  arguments: (argument_list) @call.args)
+`,
+	"kotlin": `
+; Function calls
+(call_expression
+  (simple_identifier) @call.name
+  (call_suffix
+    (value_arguments) @call.args))
 
-	config := l.configs[lang]
-	if config == nil || config.Language == nil {
-		return nil, nil
-	}
+; Method calls
+(call_expression
+  (navigation_expression
+    .
+    (_) @call.receiver
+    (navigation_suffix
+      (simple_identifier) @call.name))
+  (call_suffix
+    (value_arguments) @call.args))
+`,
+	"c_sharp": `
+; Method calls
+(invocation_expression
+  function: (identifier) @call.name
+  arguments: (argument_list) @call.args)
 
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
+; Member calls: obj.Method(x), Type.Method(x)
+(invocation_expression
+  function: (member_access_expression
+    expression: (_) @call.receiver
+    name: (identifier) @call.name)
+  arguments: (argument_list) @call.args)
+`,
+	"php": `
+; Function calls
+(function_call_expression
+  function: (name) @call.name
+  arguments: (arguments) @call.args)
 
-	// Get or compile the call query
-	callQuery, err := l.getCallQuery(lang, config.Language)
-	if err != nil || callQuery == nil {
-		return nil, nil // No call query for this language
-	}
+; Method calls: $obj->method()
+(member_call_expression
+  object: (_) @call.receiver
+  name: (name) @call.name
+  arguments: (arguments) @call.args)
 
-	parser := tree_sitter.NewParser()
-	defer parser.Close()
-	parser.SetLanguage(config.Language)
+; Static calls: Foo::method()
+(scoped_call_expression
+  scope: (_) @call.receiver
+  name: (name) @call.name
+  arguments: (arguments) @call.args)
+`,
+	"swift": `
+; Function calls
+(call_expression
+  (simple_identifier) @call.name
+  (call_suffix
+    (value_arguments) @call.args))
 
-	tree := parser.Parse(content, nil)
-	defer tree.Close()
+; Method calls
+(call_expression
+  (navigation_expression
+    .
+    (_) @call.receiver
+    (navigation_suffix
+      (simple_identifier) @call.name))
+  (call_suffix
+    (value_arguments) @call.args))
+`,
+	"dart": `
+; Function calls: the callee is followed by an argument selector
+((identifier) @call.name
+  .
+  (selector
+    (argument_part
+      (arguments) @call.args)))
+
+; Method calls: obj.method(x); the receiver is the sibling before the selectors
+((_) @call.receiver
+  .
+  (selector
+    (unconditional_assignable_selector
+      (identifier) @call.name))
+  .
+  (selector
+    (argument_part
+      (arguments) @call.args)))
+`,
+	"bash": `
+; Commands; builtins and paths are dropped (see functionCommands)
+(command
+  name: (command_name
+    (word) @call.name))
+`,
+	"r": `
+; Function calls
+(call
+  function: (identifier) @call.name
+  arguments: (arguments) @call.args)
+`,
+}
 
+// extractCalls runs the call query over a parsed file, attributes each
+// call to the innermost function range containing it and infers what the
+// receiver of each method call refers to.
+func extractCalls(query *tree_sitter.Query, root *tree_sitter.Node, content []byte, funcRanges []funcRange, in *inferrer) []CallInfo {
 	cursor := tree_sitter.NewQueryCursor()
 	defer cursor.Close()
 
-	analysis := &FileCallAnalysis{Path: filePath, Language: lang}
-
-	// First, build a map of line ranges to function names
-	funcRanges := l.extractFunctionRanges(tree.RootNode(), content, config.Query)
-
-	// Extract calls
-	matches := cursor.Matches(callQuery, tree.RootNode(), content)
+	var calls []CallInfo
+	matches := cursor.Matches(query, root, content)
 
 	var currentCall *CallInfo
+	var receiver *tree_sitter.Node
 	lastMatchID := uint(0xFFFFFFFF) // Use max value to ensure first match triggers initialization
 
+	finish := func() {
+		if currentCall == nil || currentCall.CalleeName == "" {
+			return
+		}
+		// Find containing function
+		currentCall.CallerFunc, currentCall.CallerLine = findContainingFunction(currentCall.CallLine, funcRanges)
+		if receiver != nil {
+			currentCall.ReceiverType, currentCall.ReceiverPackage, currentCall.Inference = in.receiver(receiver)
+		}
+		calls = append(calls, *currentCall)
+	}
+
 	for match := matches.Next(); match != nil; match = matches.Next() {
 		// New match = new call
 		if lastMatchID != match.Id() {
-			if currentCall != nil && currentCall.CalleeName != "" {
-				// Find containing function
-				currentCall.CallerFunc = findContainingFunction(currentCall.CallLine, funcRanges)
-				analysis.Calls = append(analysis.Calls, *currentCall)
-			}
+			finish()
 			currentCall = &CallInfo{}
+			receiver = nil
 			lastMatchID = match.Id()
 		}
 
-		if currentCall == nil {
-			currentCall = &CallInfo{}
-		}
-
 		for _, capture := range match.Captures {
-			captureName := callQuery.CaptureNames()[capture.Index]
+			captureName := query.CaptureNames()[capture.Index]
 			text := capture.Node.Utf8Text(content)
 			line := int(capture.Node.StartPosition().Row) + 1
 
@@ -188,7 +295,12 @@ func (l *GrammarLoader) ExtractCalls(filePath string) (*FileCallAnalysis, error)
 				currentCall.CalleeName = text
 				currentCall.CallLine = line
 			case "call.receiver":
+				if capture.Node.Kind() == "selector" {
+					break // Dart: a.b.c() continues a chain; b is no expression of its own
+				}
 				currentCall.Receiver = text
+				node := capture.Node
+				receiver = &node
 			case "call.args":
 				// Count arguments by counting commas + 1 (if not empty)
 				currentCall.Args = countArgs(text)
@@ -197,83 +309,79 @@ func (l *GrammarLoader) ExtractCalls(filePath string) (*FileCallAnalysis, error)
 	}
 
 	// Don't forget last call
-	if currentCall != nil && currentCall.CalleeName != "" {
-		currentCall.CallerFunc = findContainingFunction(currentCall.CallLine, funcRanges)
-		analysis.Calls = append(analysis.Calls, *currentCall)
+	finish()
+
+	return calls
+}
+
+// functionCommands keeps the shell commands that may call a function: those
+// naming a function of the file, and any other plain name but a builtin, as
+// it may be a function of a sourced script. The bash call query matches
+// every command; commands naming no function in the graph, such as ls or
+// git, are dropped when call edges are resolved.
+func functionCommands(calls []CallInfo, funcs []FuncInfo) []CallInfo {
+	defined := make(map[string]bool, len(funcs))
+	for _, fn := range funcs {
+		defined[fn.Name] = true
 	}
+	var kept []CallInfo
+	for _, call := range calls {
+		name := call.CalleeName
+		if defined[name] || (!shellBuiltins[name] && !strings.ContainsAny(name, "/$=`\"'")) {
+			kept = append(kept, call)
+		}
+	}
+	return kept
+}
 
-	return analysis, nil
+// shellBuiltins are the bash builtins and keywords a command can name. A
+// function may shadow one, which functionCommands keeps as a defined name.
+var shellBuiltins = map[string]bool{
+	".": true, ":": true, "[": true, "[[": true, "alias": true, "bg": true,
+	"bind": true, "break": true, "builtin": true, "caller": true, "cd": true,
+	"command": true, "compgen": true, "complete": true, "continue": true,
+	"declare": true, "dirs": true, "disown": true, "echo": true, "enable": true,
+	"eval": true, "exec": true, "exit": true, "export": true, "false": true,
+	"fc": true, "fg": true, "getopts": true, "hash": true, "help": true,
+	"history": true, "jobs": true, "kill": true, "let": true, "local": true,
+	"logout": true, "mapfile": true, "popd": true, "printf": true,
+	"pushd": true, "pwd": true, "read": true, "readarray": true,
+	"readonly": true, "return": true, "set": true, "shift": true,
+	"shopt": true, "source": true, "suspend": true, "test": true,
+	"time": true, "times": true, "trap": true, "true": true, "type": true,
+	"typeset": true, "ulimit": true, "umask": true, "unalias": true,
+	"unset": true, "wait": true,
 }
 
 // funcRange represents a function's line range for caller detection.
 type funcRange struct {
 	name      string
+	line      int // Line of the name, matching FuncInfo.Line
 	startLine int
 	endLine   int
 }
 
-// extractFunctionRanges builds a list of function ranges from the AST.
-func (l *GrammarLoader) extractFunctionRanges(root *tree_sitter.Node, content []byte, query *tree_sitter.Query) []funcRange {
-	var ranges []funcRange
-
-	cursor := tree_sitter.NewQueryCursor()
-	defer cursor.Close()
-
-	matches := cursor.Matches(query, root, content)
-
-	var currentFunc *funcRange
-
-	for match := matches.Next(); match != nil; match = matches.Next() {
-		for _, capture := range match.Captures {
-			captureName := query.CaptureNames()[capture.Index]
-
-			if strings.HasPrefix(captureName, "func.name") || captureName == "function" || captureName == "method" {
-				// Get the function node (parent of name)
-				funcNode := capture.Node.Parent()
-				if funcNode != nil {
-					currentFunc = &funcRange{
-						name:      capture.Node.Utf8Text(content),
-						startLine: int(funcNode.StartPosition().Row) + 1,
-						endLine:   int(funcNode.EndPosition().Row) + 1,
-					}
-					ranges = append(ranges, *currentFunc)
-				}
-			}
-		}
-	}
-
-	return ranges
-}
-
-// getCallQuery returns the compiled call query for a language.
-func (l *GrammarLoader) getCallQuery(lang string, tsLang *tree_sitter.Language) (*tree_sitter.Query, error) {
-	if q, ok := callConfigs[lang]; ok {
-		return q, nil
-	}
-
-	pattern, ok := callQueryPatterns[lang]
-	if !ok {
-		return nil, nil // No call query for this language
+// newFuncRange records the extent of the function named by name.
+func newFuncRange(name *tree_sitter.Node, content []byte) funcRange {
+	rng := definitionRange(name)
+	return funcRange{
+		name:      name.Utf8Text(content),
+		line:      int(name.StartPosition().Row) + 1,
+		startLine: rng.StartLine,
+		endLine:   rng.EndLine,
 	}
-
-	query, err := tree_sitter.NewQuery(tsLang, pattern)
-	if err != nil {
-		return nil, err
-	}
-
-	callConfigs[lang] = query
-	return query, nil
 }
 
-// findContainingFunction finds which function contains a given line.
-func findContainingFunction(line int, ranges []funcRange) string {
+// findContainingFunction finds which function contains a given line and
+// returns its name and definition line.
+func findContainingFunction(line int, ranges []funcRange) (string, int) {
 	for i := len(ranges) - 1; i >= 0; i-- {
 		r := ranges[i]
 		if line >= r.startLine && line <= r.endLine {
-			return r.name
+			return r.name, r.line
 		}
 	}
-	return "" // Global scope
+	return "", 0 // Global scope
 }
 
 // countArgs counts the number of arguments in an argument list.
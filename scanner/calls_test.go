@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+func TestBuiltinCallQueriesCompile(t *testing.T) {
+	loader := NewGrammarLoader()
+	for lang, pattern := range callQueryPatterns {
+		t.Run(lang, func(t *testing.T) {
+			config, err := loader.languageConfig(lang)
+			if err != nil {
+				t.Skipf("grammar for %s not available: %v", lang, err)
+			}
+			query, qerr := tree_sitter.NewQuery(config.Language, pattern)
+			if qerr != nil {
+				t.Fatalf("%d:%d: %s", qerr.Row+1, qerr.Column+1, describeQueryError(qerr))
+			}
+			query.Close()
+		})
+	}
+}
+
+func TestFunctionCommands(t *testing.T) {
+	funcs := []FuncInfo{{Name: "main"}, {Name: "deploy"}}
+	calls := []CallInfo{
+		{CallerFunc: "main", CalleeName: "echo"},
+		{CallerFunc: "main", CalleeName: "deploy"},
+		{CallerFunc: "deploy", CalleeName: "cd"},
+		{CallerFunc: "deploy", CalleeName: "ls"},
+		{CallerFunc: "deploy", CalleeName: "build_image"}, // Maybe from a sourced script
+		{CallerFunc: "deploy", CalleeName: "./build.sh"},
+		{CallerFunc: "deploy", CalleeName: "$CC"},
+		{CallerFunc: "", CalleeName: "main"},
+	}
+	got := functionCommands(calls, funcs)
+	want := []CallInfo{
+		{CallerFunc: "main", CalleeName: "deploy"},
+		{CallerFunc: "deploy", CalleeName: "ls"},
+		{CallerFunc: "deploy", CalleeName: "build_image"},
+		{CallerFunc: "", CalleeName: "main"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("functionCommands = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnalyzeFileCalls(t *testing.T) {
+	src := `package app
+
+import "strings"
+
+var banner = strings.Repeat("=", 3)
+
+type Server struct{ name string }
+
+func (s *Server) Start(port int) error {
+	s.log("start")
+	return listen(port, s.name)
+}
+
+func (s *Server) log(msg string) {}
+
+func listen(port int, host string) error {
+	run := func() { s := &Server{}; s.log("x") }
+	run()
+	return nil
+}
+`
+	type call struct {
+		caller     string
+		callerLine int
+		callee     string
+		line, args int
+		receiver   string
+	}
+	want := []call{
+		{"", 0, "Repeat", 5, 2, "strings"},
+		{"Start", 9, "log", 10, 1, "s"},
+		{"Start", 9, "listen", 11, 2, ""},
+		{"listen", 16, "log", 17, 1, "s"}, // In a closure: the enclosing declaration
+		{"listen", 16, "run", 18, 0, ""},
+	}
+
+	full := analyzeSource(t, "server.go", src, DetailFull)
+	var got []call
+	for _, c := range full.Calls {
+		got = append(got, call{c.CallerFunc, c.CallerLine, c.CalleeName, c.CallLine, c.Args, c.Receiver})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("calls:\n got %+v\nwant %+v", got, want)
+	}
+	if len(full.Functions) != 3 || len(full.Types) != 1 {
+		t.Errorf("symbols from the same parse: %d functions, %d types, want 3 and 1", len(full.Functions), len(full.Types))
+	}
+	if len(full.References) == 0 {
+		t.Error("no type references at DetailFull")
+	}
+
+	if sig := analyzeSource(t, "server.go", src, DetailSignature); sig.Calls != nil || sig.References != nil {
+		t.Errorf("DetailSignature extracted %d calls and %d references, want none", len(sig.Calls), len(sig.References))
+	}
+}
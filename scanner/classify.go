@@ -0,0 +1,258 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// File classes recorded in FileInfo.Class. Source files have no class.
+const (
+	ClassGenerated = "generated" // Written by a tool: protobuf stubs, lockfiles, "DO NOT EDIT" headers
+	ClassVendored  = "vendored"  // Third-party code checked into the project
+	ClassMinified  = "minified"  // Bundled or minified JavaScript and CSS
+	ClassBinary    = "binary"    // Contains NUL bytes
+)
+
+// sniffSize is how much of a file classification reads, as git does to
+// tell binary files apart.
+const sniffSize = 8000
+
+// minifiedLineLength is the average line length above which a script or
+// stylesheet is considered minified (GitHub linguist's threshold).
+const minifiedLineLength = 110
+
+// Path patterns of vendored and generated files, after GitHub linguist's
+// vendor.yml and generated.rb.
+var (
+	vendoredPaths = regexp.MustCompile(`(^|/)(vendor|third[-_]party|node_modules|bower_components|jspm_packages|Godeps/_workspace|\.yarn/(releases|plugins|sdks))/`)
+
+	generatedPaths = regexp.MustCompile(`(\.pb\.go|\.pb\.gw\.go|_pb2\.pyi?|_pb2_grpc\.py|\.pb\.(cc|h)|_grpc\.pb\.go|\.g\.dart|\.freezed\.dart|\.designer\.cs|\.Designer\.cs|_generated\.go)$` +
+		`|(^|/)zz_generated[^/]*\.go$` +
+		`|(^|/)(package-lock\.json|npm-shrinkwrap\.json|yarn\.lock|pnpm-lock\.yaml|go\.sum|go\.work\.sum|Cargo\.lock|poetry\.lock|Pipfile\.lock|uv\.lock|Gemfile\.lock|composer\.lock|Podfile\.lock|Package\.resolved|gradle\.lockfile)$`)
+
+	minifiedPaths = regexp.MustCompile(`[.-]min\.(js|mjs|css)$`)
+
+	minifiableExts = map[string]bool{".js": true, ".mjs": true, ".cjs": true, ".css": true}
+
+	// Header comments of generated files: Go's "Code generated ... DO NOT
+	// EDIT.", Facebook's "@generated" and the many "auto-generated, do not
+	// edit" variants
+	generatedHeader = regexp.MustCompile(`(?i)code generated .*do not edit|@generated\b|(auto-?generated|generated (by|from|with)).*do not (edit|modify)|do not (edit|modify).*(auto-?generated|generated (by|from|with))`)
+)
+
+// headerLines is how many lines are searched for a generated-file header.
+const headerLines = 20
+
+// Classify returns the class of the file at absPath (relPath relative to
+// the scanned root), or "" for a regular source file. linguist-generated
+// and linguist-vendored attributes in .gitattributes files decide first;
+// then come path patterns and finally the file's first bytes: NUL bytes,
+// a generated-file header or minified lines.
+func (m *IgnoreMatcher) Classify(absPath, relPath string) string {
+	rel := filepath.ToSlash(relPath)
+	generated, vendored := m.linguistAttributes(rel)
+	if generated == attrSet {
+		return ClassGenerated
+	}
+	if vendored == attrSet {
+		return ClassVendored
+	}
+
+	p := rel
+	if m != nil {
+		p = m.topPath(rel) // Vendored directories above the root count too
+	}
+	if vendored == attrUnspecified && vendoredPaths.MatchString(p) {
+		return ClassVendored
+	}
+	if generated == attrUnspecified && generatedPaths.MatchString(p) {
+		return ClassGenerated
+	}
+	if minifiedPaths.MatchString(p) {
+		return ClassMinified
+	}
+
+	head, err := readHead(absPath)
+	if err != nil || len(head) == 0 {
+		return ""
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return ClassBinary
+	}
+	if generated == attrUnspecified && hasGeneratedHeader(head) {
+		return ClassGenerated
+	}
+	if minifiableExts[strings.ToLower(path.Ext(p))] && averageLineLength(head) > minifiedLineLength {
+		return ClassMinified
+	}
+	return ""
+}
+
+// SkipsAnalysis reports whether the file at absPath is left out of
+// dependency scans and the graph: every classified file except generated
+// code when IncludeGenerated is set.
+func (m *IgnoreMatcher) SkipsAnalysis(absPath, relPath string) bool {
+	switch m.Classify(absPath, relPath) {
+	case "":
+		return false
+	case ClassGenerated:
+		return m == nil || !m.IncludeGenerated
+	}
+	return true
+}
+
+// readHead returns the first sniffSize bytes of a file.
+func readHead(absPath string) ([]byte, error) {
+	f, err := os.Open(absPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, head)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = nil
+	}
+	return head[:n], err
+}
+
+// hasGeneratedHeader looks for a generated-file comment in the first lines.
+func hasGeneratedHeader(head []byte) bool {
+	lines := bufio.NewScanner(bytes.NewReader(head))
+	lines.Buffer(make([]byte, 0, len(head)), len(head))
+	for i := 0; i < headerLines && lines.Scan(); i++ {
+		if generatedHeader.Match(lines.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// averageLineLength returns the mean length of the lines in data.
+func averageLineLength(data []byte) int {
+	return len(data) / (bytes.Count(data, []byte{'\n'}) + 1)
+}
+
+// Values of a gitattributes attribute for a path.
+const (
+	attrUnspecified = iota
+	attrSet         // "attr" or "attr=true"
+	attrUnset       // "-attr" or "attr=false"
+)
+
+// attributeRule is a .gitattributes line that sets linguist attributes.
+type attributeRule struct {
+	pattern   ignorePattern
+	generated int
+	vendored  int
+}
+
+// linguistAttributes returns the linguist-generated and linguist-vendored
+// attributes of the root-relative path rel. As in git, deeper
+// .gitattributes files override shallower ones, .git/info/attributes
+// overrides them all, and within a file the last matching line wins.
+func (m *IgnoreMatcher) linguistAttributes(rel string) (generated, vendored int) {
+	if m == nil || m.attributes == nil {
+		return attrUnspecified, attrUnspecified
+	}
+	p := m.topPath(rel)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dirs := []string{""}
+	if parent := path.Dir(p); parent != "." {
+		parts := strings.Split(parent, "/")
+		for i := range parts {
+			dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+		}
+	}
+	var rules []attributeRule
+	for _, dir := range dirs {
+		rules = append(rules, m.dirAttributes(dir)...)
+	}
+	rules = append(rules, m.infoAttributes...)
+
+	for _, rule := range rules {
+		if !matchPatterns([]ignorePattern{rule.pattern}, p, false, false) {
+			continue
+		}
+		if rule.generated != attrUnspecified {
+			generated = rule.generated
+		}
+		if rule.vendored != attrUnspecified {
+			vendored = rule.vendored
+		}
+	}
+	return generated, vendored
+}
+
+// dirAttributes loads the linguist rules of a top-relative directory's
+// .gitattributes. Callers hold m.mu.
+func (m *IgnoreMatcher) dirAttributes(dir string) []attributeRule {
+	if rules, ok := m.attributes[dir]; ok {
+		return rules
+	}
+	rules := readAttributesFile(filepath.Join(m.top, filepath.FromSlash(dir), ".gitattributes"), dir)
+	m.attributes[dir] = rules
+	return rules
+}
+
+// readAttributesFile parses the lines of a gitattributes file that set
+// linguist-generated or linguist-vendored.
+func readAttributesFile(file, base string) []attributeRule {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []attributeRule
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "!") {
+			continue
+		}
+		rule := attributeRule{}
+		for _, attr := range fields[1:] {
+			name, state := parseAttribute(attr)
+			switch name {
+			case "linguist-generated":
+				rule.generated = state
+			case "linguist-vendored":
+				rule.vendored = state
+			}
+		}
+		if rule.generated == attrUnspecified && rule.vendored == attrUnspecified {
+			continue
+		}
+		pattern, ok := parseIgnoreLine(fields[0], base)
+		if !ok || pattern.dirOnly {
+			continue // Attributes don't apply to directories
+		}
+		rule.pattern = pattern
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// parseAttribute splits a gitattributes attribute into its name and state.
+func parseAttribute(attr string) (string, int) {
+	switch {
+	case strings.HasPrefix(attr, "-"):
+		return attr[1:], attrUnset
+	case strings.HasPrefix(attr, "!"):
+		return attr[1:], attrUnspecified
+	}
+	name, value, hasValue := strings.Cut(attr, "=")
+	if hasValue && (value == "false" || value == "0") {
+		return name, attrUnset
+	}
+	return name, attrSet
+}
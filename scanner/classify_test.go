@@ -0,0 +1,159 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	isolateGit(t)
+	minified := "var a=1;" + strings.Repeat("function f(){return a+1};", 20) + "\n"
+	top := t.TempDir()
+	writeTree(t, top, map[string]string{
+		".git/HEAD":                      "ref: refs/heads/main\n",
+		".gitattributes":                 "gen/*.go linguist-generated\nvendor/keep/** -linguist-vendored\n*.pb.go -linguist-generated\nthird/** linguist-vendored=false\n",
+		"api/.gitattributes":             "schema.go linguist-generated=true\nclient.go linguist-vendored\n",
+		"main.go":                        "package main\n",
+		"gen/models.go":                  "package gen\n",
+		"api/schema.go":                  "package api\n",
+		"api/client.go":                  "package api\n",
+		"api/api.pb.go":                  "package api\n",
+		"api/api_grpc.pb.go":             "package api\n",
+		"proto/user_pb2.py":              "import x\n",
+		"k8s/zz_generated.deepcopy.go":   "package k8s\n",
+		"web/package-lock.json":          "{}\n",
+		"go.sum":                         "example.com/x v1.0.0 h1:abc=\n",
+		"vendor/lib/lib.go":              "package lib\n",
+		"vendor/keep/keep.go":            "package keep\n",
+		"third_party/x/x.c":              "int x;\n",
+		"third/x/x.c":                    "int x;\n",
+		"web/node_modules/a/index.js":    "module.exports = 1\n",
+		"web/.yarn/releases/yarn.cjs":    "x\n",
+		"static/app.min.js":              "x\n",
+		"static/site-min.css":            "x\n",
+		"static/bundle.js":               minified,
+		"static/app.js":                  "const a = 1;\nconst b = 2;\n",
+		"static/data.txt":                minified,
+		"assets/logo.png":                "\x89PNG\r\n\x1a\n\x00\x00",
+		"db/models.go":                   "// Code generated by sqlc. DO NOT EDIT.\n\npackage db\n",
+		"ui/theme.ts":                    "/**\n * @generated\n */\nexport {}\n",
+		"ui/icons.ts":                    "// This file is auto-generated, do not modify.\nexport {}\n",
+		"ui/late.ts":                     strings.Repeat("//\n", headerLines) + "// Code generated by x. DO NOT EDIT.\n",
+		"api/doc.go":                     "// Package api is generated from the spec; edit the spec instead.\npackage api\n",
+		"empty.go":                       "",
+		"scripts/generated_by_design.py": "print(1)\n",
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", ""},
+		{"empty.go", ""},
+		{"scripts/generated_by_design.py", ""},
+
+		// .gitattributes: deeper files and later lines override
+		{"gen/models.go", ClassGenerated},
+		{"api/schema.go", ClassGenerated},
+		{"api/client.go", ClassVendored},
+		{"api/api.pb.go", ""}, // -linguist-generated beats the path pattern
+		{"vendor/keep/keep.go", ""},
+		{"third/x/x.c", ""},
+
+		// Path patterns
+		{"api/api_grpc.pb.go", ""}, // Also matches *.pb.go above
+		{"proto/user_pb2.py", ClassGenerated},
+		{"k8s/zz_generated.deepcopy.go", ClassGenerated},
+		{"web/package-lock.json", ClassGenerated},
+		{"go.sum", ClassGenerated},
+		{"vendor/lib/lib.go", ClassVendored},
+		{"third_party/x/x.c", ClassVendored},
+		{"web/node_modules/a/index.js", ClassVendored},
+		{"web/.yarn/releases/yarn.cjs", ClassVendored},
+		{"static/app.min.js", ClassMinified},
+		{"static/site-min.css", ClassMinified},
+
+		// Contents
+		{"static/bundle.js", ClassMinified},
+		{"static/app.js", ""},
+		{"static/data.txt", ""}, // Long lines, but not a script
+		{"assets/logo.png", ClassBinary},
+		{"db/models.go", ClassGenerated},
+		{"ui/theme.ts", ClassGenerated},
+		{"ui/icons.ts", ClassGenerated},
+		{"ui/late.ts", ""}, // Header below the first lines
+		{"api/doc.go", ""},
+	}
+	m := NewIgnoreMatcher(top, nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			abs := filepath.Join(top, filepath.FromSlash(tt.path))
+			if got := m.Classify(abs, tt.path); got != tt.want {
+				t.Errorf("Classify(%s) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	// Vendored directories above the scanned root count too
+	sub := NewIgnoreMatcher(filepath.Join(top, "vendor", "lib"), nil, nil)
+	if got := sub.Classify(filepath.Join(top, "vendor", "lib", "lib.go"), "lib.go"); got != ClassVendored {
+		t.Errorf("from vendor/lib, Classify(lib.go) = %q, want %q", got, ClassVendored)
+	}
+}
+
+func TestSkipsAnalysis(t *testing.T) {
+	isolateGit(t)
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"main.go":           "package main\n",
+		"db/models.go":      "// Code generated by sqlc. DO NOT EDIT.\n\npackage db\n",
+		"vendor/lib/lib.go": "package lib\n",
+	})
+
+	tests := []struct {
+		path                  string
+		skipped, withIncluded bool // Without and with IncludeGenerated
+	}{
+		{"main.go", false, false},
+		{"db/models.go", true, false},
+		{"vendor/lib/lib.go", true, true},
+	}
+	m := NewIgnoreMatcher(root, nil, nil)
+	for _, tt := range tests {
+		abs := filepath.Join(root, filepath.FromSlash(tt.path))
+		m.IncludeGenerated = false
+		if got := m.SkipsAnalysis(abs, tt.path); got != tt.skipped {
+			t.Errorf("SkipsAnalysis(%s) = %v, want %v", tt.path, got, tt.skipped)
+		}
+		m.IncludeGenerated = true
+		if got := m.SkipsAnalysis(abs, tt.path); got != tt.withIncluded {
+			t.Errorf("with IncludeGenerated, SkipsAnalysis(%s) = %v, want %v", tt.path, got, tt.withIncluded)
+		}
+	}
+
+	var none *IgnoreMatcher
+	if !none.SkipsAnalysis(filepath.Join(root, "db", "models.go"), "db/models.go") {
+		t.Error("nil matcher keeps generated files")
+	}
+}
+
+func TestParseAttribute(t *testing.T) {
+	tests := []struct {
+		attr  string
+		name  string
+		state int
+	}{
+		{"linguist-generated", "linguist-generated", attrSet},
+		{"linguist-generated=true", "linguist-generated", attrSet},
+		{"-linguist-generated", "linguist-generated", attrUnset},
+		{"linguist-vendored=false", "linguist-vendored", attrUnset},
+		{"linguist-vendored=0", "linguist-vendored", attrUnset},
+		{"!linguist-vendored", "linguist-vendored", attrUnspecified},
+	}
+	for _, tt := range tests {
+		if name, state := parseAttribute(tt.attr); name != tt.name || state != tt.state {
+			t.Errorf("parseAttribute(%q) = %q, %d, want %q, %d", tt.attr, name, state, tt.name, tt.state)
+		}
+	}
+}
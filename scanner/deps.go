@@ -1,139 +1,219 @@
 package scanner
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Dependency scopes. Ecosystems without a notion of scope declare every
+// dependency as a runtime one.
+const (
+	ScopeRuntime  = "runtime"
+	ScopeDev      = "dev"      // Development only: devDependencies, require-dev, dev groups
+	ScopeTest     = "test"     // Test only: Maven test scope, testImplementation
+	ScopeBuild    = "build"    // Build tools and compile-only dependencies
+	ScopeOptional = "optional" // Optional features and extras
+	ScopePeer     = "peer"     // npm peerDependencies
 )
 
-// ReadExternalDeps reads manifest files (go.mod, requirements.txt, package.json)
-func ReadExternalDeps(root string) map[string][]string {
-	deps := make(map[string][]string)
+// Dependency is a dependency declared in a manifest file.
+type Dependency struct {
+	Name     string `json:"name"`
+	Version  string `json:"version,omitempty"` // Declared version or constraint, as written
+	Scope    string `json:"scope"`
+	Manifest string `json:"manifest"` // Manifest path relative to the scanned root
+}
+
+// manifestParser parses a manifest's content into its dependencies.
+type manifestParser struct {
+	lang  string
+	parse func(content string) []Dependency
+}
+
+// manifests maps manifest file names to their parsers.
+var manifests = map[string]manifestParser{
+	"go.mod":           {"go", parseGoMod},
+	"requirements.txt": {"python", parseRequirements},
+	"pyproject.toml":   {"python", parsePyproject},
+	"Pipfile":          {"python", parsePipfile},
+	"package.json":     {"javascript", parsePackageJson},
+	"Podfile":          {"swift", parsePodfile},
+	"Package.swift":    {"swift", parsePackageSwift},
+	"Cargo.toml":       {"rust", parseCargoToml},
+	"Gemfile":          {"ruby", parseGemfile},
+	"pom.xml":          {"java", parsePom},
+	"build.gradle":     {"java", parseGradle},
+	"build.gradle.kts": {"kotlin", parseGradle},
+	"composer.json":    {"php", parseComposer},
+	"pubspec.yaml":     {"dart", parsePubspec},
+	"DESCRIPTION":      {"r", parseDescription},
+}
+
+// manifestFor returns the parser of a manifest file name.
+func manifestFor(name string) (manifestParser, bool) {
+	if strings.HasSuffix(name, ".csproj") {
+		return manifestParser{"c_sharp", parseCsproj}, true
+	}
+	p, ok := manifests[name]
+	return p, ok
+}
+
+// ReadDependencies parses the manifest files under root (go.mod,
+// package.json, Cargo.toml, pyproject.toml, pom.xml, ...) and returns the
+// dependencies they declare, by language.
+func ReadDependencies(root string) map[string][]Dependency {
+	deps := make(map[string][]Dependency)
+	ignore := LoadGitignore(root)
 
 	// Walk tree to find all manifest files
 	filepath.Walk(root, func(path string, info os.FileInfo, _ error) error {
 		if info == nil {
 			return nil
 		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
 		if info.IsDir() {
-			if IgnoredDirs[info.Name()] {
+			if ignore.Match(rel, true) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		switch info.Name() {
-		case "go.mod":
-			if c, err := os.ReadFile(path); err == nil {
-				deps["go"] = append(deps["go"], parseGoMod(string(c))...)
-			}
-		case "requirements.txt":
-			if c, err := os.ReadFile(path); err == nil {
-				deps["python"] = append(deps["python"], parseRequirements(string(c))...)
-			}
-		case "package.json":
-			if c, err := os.ReadFile(path); err == nil {
-				deps["javascript"] = append(deps["javascript"], parsePackageJson(string(c))...)
-			}
-		case "Podfile":
-			if c, err := os.ReadFile(path); err == nil {
-				deps["swift"] = append(deps["swift"], parsePodfile(string(c))...)
-			}
-		case "Package.swift":
-			if c, err := os.ReadFile(path); err == nil {
-				deps["swift"] = append(deps["swift"], parsePackageSwift(string(c))...)
+		parser, ok := manifestFor(info.Name())
+		if !ok {
+			return nil
+		}
+		c, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, dep := range parser.parse(string(c)) {
+			dep.Manifest = filepath.ToSlash(rel)
+			if dep.Scope == "" {
+				dep.Scope = ScopeRuntime
 			}
+			deps[parser.lang] = append(deps[parser.lang], dep)
 		}
 		return nil
 	})
-
-	for k, v := range deps {
-		deps[k] = dedupe(v)
-	}
 	return deps
 }
 
-func parseGoMod(c string) (deps []string) {
-	inReq := false
-	for _, line := range strings.Split(c, "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "require (") {
-			inReq = true
-		} else if inReq && line == ")" {
-			inReq = false
-		} else if inReq && line != "" && !strings.HasPrefix(line, "//") {
-			if parts := strings.Fields(line); len(parts) >= 1 {
-				deps = append(deps, parts[0])
-			}
+// DependencyNames returns the sorted, distinct names of the dependencies
+// of each language.
+func DependencyNames(deps map[string][]Dependency) map[string][]string {
+	names := make(map[string][]string)
+	for lang, list := range deps {
+		for _, dep := range list {
+			names[lang] = append(names[lang], dep.Name)
 		}
+		sort.Strings(names[lang])
+		names[lang] = dedupe(names[lang])
+	}
+	return names
+}
+
+func parseGoMod(c string) (deps []Dependency) {
+	f, err := modfile.ParseLax("go.mod", []byte(c), nil)
+	if err != nil {
+		return nil
+	}
+	for _, req := range f.Require {
+		deps = append(deps, Dependency{Name: req.Mod.Path, Version: req.Mod.Version})
 	}
 	return
 }
 
-func parseRequirements(c string) (deps []string) {
+// pep508Name matches the distribution name at the start of a PEP 508
+// requirement.
+var pep508Name = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*`)
+
+// parsePEP508 splits a requirement ("requests[socks] >=2.8; python_version
+// < '3.12'") into its name and version specifier.
+func parsePEP508(req string) (name, version string, ok bool) {
+	m := pep508Name.FindStringSubmatchIndex(req)
+	if m == nil {
+		return "", "", false
+	}
+	name = req[m[2]:m[3]]
+	version = req[m[1]:]
+	if i := strings.Index(version, ";"); i != -1 {
+		version = version[:i] // Environment markers
+	}
+	version = strings.TrimSpace(version)
+	version = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(version, "("), ")"))
+	return name, version, true
+}
+
+func parseRequirements(c string) (deps []Dependency) {
 	for _, line := range strings.Split(c, "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+		if i := strings.Index(line, " #"); i != -1 {
+			line = line[:i]
 		}
-		for _, sep := range []string{"==", ">=", "<=", "~=", "<", ">", "[", ";", "#"} {
-			if i := strings.Index(line, sep); i != -1 {
-				line = line[:i]
-			}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue // Comments and options such as -r other.txt
 		}
-		if line != "" {
-			deps = append(deps, line)
+		if name, version, ok := parsePEP508(line); ok {
+			deps = append(deps, Dependency{Name: name, Version: version})
 		}
 	}
 	return
 }
 
-func parsePackageJson(c string) (deps []string) {
-	inDeps := false
-	for _, line := range strings.Split(c, "\n") {
-		if strings.Contains(line, `"dependencies"`) || strings.Contains(line, `"devDependencies"`) {
-			inDeps = true
-		} else if inDeps && strings.Contains(line, "}") {
-			inDeps = false
-		} else if inDeps && strings.Contains(line, ":") {
-			parts := strings.SplitN(line, ":", 2)
-			name := strings.Trim(strings.TrimSpace(parts[0]), `"`)
-			if name != "" {
-				deps = append(deps, name)
-			}
-		}
+func parsePackageJson(c string) (deps []Dependency) {
+	var manifest struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if json.Unmarshal([]byte(c), &manifest) != nil {
+		return nil
 	}
+	deps = append(deps, versionMap(manifest.Dependencies, ScopeRuntime)...)
+	deps = append(deps, versionMap(manifest.DevDependencies, ScopeDev)...)
+	deps = append(deps, versionMap(manifest.PeerDependencies, ScopePeer)...)
+	deps = append(deps, versionMap(manifest.OptionalDependencies, ScopeOptional)...)
 	return
 }
 
-func parsePodfile(c string) (deps []string) {
-	// Parse Podfile: pod 'Name' or pod 'Name', '~> 1.0'
+// versionMap turns a name -> version map into dependencies of one scope,
+// sorted by name.
+func versionMap(versions map[string]string, scope string) []Dependency {
+	var deps []Dependency
+	for name, version := range versions {
+		deps = append(deps, Dependency{Name: name, Version: version, Scope: scope})
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps
+}
+
+// podLine matches pod 'Name' and pod 'Name', '~> 1.0'
+var podLine = regexp.MustCompile(`^pod\s+['"]([^'"]+)['"]\s*(?:,\s*['"]([^'"]+)['"])?`)
+
+func parsePodfile(c string) (deps []Dependency) {
 	for _, line := range strings.Split(c, "\n") {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "pod ") {
-			// Extract pod name from: pod 'Name' or pod 'Name', ...
-			line = strings.TrimPrefix(line, "pod ")
-			line = strings.Trim(line, "'\"")
-			if i := strings.Index(line, "'"); i != -1 {
-				line = line[:i]
-			}
-			if i := strings.Index(line, "\""); i != -1 {
-				line = line[:i]
-			}
-			if i := strings.Index(line, ","); i != -1 {
-				line = line[:i]
-			}
-			line = strings.Trim(line, "'\" ")
-			if line != "" {
-				deps = append(deps, line)
-			}
+		if m := podLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			deps = append(deps, Dependency{Name: m[1], Version: m[2]})
 		}
 	}
 	return
 }
 
-func parsePackageSwift(c string) (deps []string) {
+// swiftPackageVersion matches the requirement following a package URL:
+// from: "1.0.0", exact: "1.2.3", .upToNextMajor(from: "1.0.0"), "1.0.0"..<"2.0.0"
+var swiftPackageVersion = regexp.MustCompile(`(?:from|exact|branch|revision):\s*"([^"]+)"|"([^"]+)"\s*(\.\.[.<]\s*"[^"]+")`)
+
+func parsePackageSwift(c string) (deps []Dependency) {
 	// Parse Package.swift: .package(url: "...", ...) or .package(name: "Name", ...)
-	// Look for package names in .product(name: "Name", package: "Package")
 	for _, line := range strings.Split(c, "\n") {
 		// Match .package(url: "https://github.com/user/repo", ...)
 		if strings.Contains(line, ".package(") && strings.Contains(line, "url:") {
@@ -149,7 +229,14 @@ func parsePackageSwift(c string) (deps []string) {
 						name := parts[len(parts)-1]
 						name = strings.TrimSuffix(name, ".git")
 						if name != "" {
-							deps = append(deps, name)
+							dep := Dependency{Name: name}
+							if m := swiftPackageVersion.FindStringSubmatch(rest[j:]); m != nil {
+								dep.Version = m[1]
+								if m[2] != "" {
+									dep.Version = m[2] + strings.ReplaceAll(m[3], `"`, "")
+								}
+							}
+							deps = append(deps, dep)
 						}
 					}
 				}
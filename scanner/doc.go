@@ -0,0 +1,204 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// docWrapperKinds are nodes that wrap a definition without owning its doc
+// comment (the comment sits before the wrapper, not the inner definition).
+var docWrapperKinds = map[string]bool{
+	"type_declaration":     true, // Go: type X struct{} wraps type_spec
+	"export_statement":     true, // JS/TS: export function f() {}
+	"decorated_definition": true, // Python: @decorator def f()
+	"variable_declarator":  true, // JS/TS: const f = () => {}
+	"lexical_declaration":  true,
+	"variable_declaration": true,
+	"template_declaration": true, // C++: template<T> void f()
+	"method_signature":     true, // Dart: class member signatures
+}
+
+// docSkipKinds are siblings allowed between a doc comment and its definition.
+var docSkipKinds = map[string]bool{
+	"attribute_item": true, // Rust: #[derive(...)]
+}
+
+var xmlTagPattern = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+
+// definitionNode climbs from a captured name node to the declaration that owns it.
+// C-family declarators nest the name a few levels below the definition.
+func definitionNode(name *tree_sitter.Node) *tree_sitter.Node {
+	n := name.Parent()
+	for n != nil {
+		switch n.Kind() {
+		case "function_declarator", "pointer_declarator", "reference_declarator",
+			"qualified_identifier", "parenthesized_declarator", "destructor_name", "operator_name":
+			n = n.Parent()
+			continue
+		}
+		break
+	}
+	if n == nil {
+		return name
+	}
+	return n
+}
+
+// extractDoc returns the documentation attached to the definition owning name.
+// Python docstrings win over comments; other languages use the contiguous
+// comment block immediately above the definition.
+func extractDoc(name *tree_sitter.Node, content []byte, lang string) string {
+	def := definitionNode(name)
+
+	if lang == "python" {
+		if doc := pythonDocstring(def, content); doc != "" {
+			return doc
+		}
+	}
+
+	for n := def; n != nil; n = n.Parent() {
+		if doc := precedingComments(n, content, lang); doc != "" {
+			return doc
+		}
+		parent := n.Parent()
+		if parent == nil || !docWrapperKinds[parent.Kind()] {
+			break
+		}
+	}
+	return ""
+}
+
+// precedingComments collects the comment block directly above node.
+// A blank line or a trailing comment on a code line ends the block.
+func precedingComments(node *tree_sitter.Node, content []byte, lang string) string {
+	var blocks []string
+	next := node
+	for prev := node.PrevSibling(); prev != nil; prev = prev.PrevSibling() {
+		if docSkipKinds[prev.Kind()] {
+			next = prev
+			continue
+		}
+		if !strings.Contains(prev.Kind(), "comment") {
+			break
+		}
+		if prev.EndPosition().Row+1 < next.StartPosition().Row {
+			break // Separated by a blank line
+		}
+		if before := prev.PrevSibling(); before != nil && before.EndPosition().Row == prev.StartPosition().Row {
+			break // Trailing comment of the previous statement
+		}
+		blocks = append(blocks, prev.Utf8Text(content))
+		next = prev
+	}
+
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for i := len(blocks) - 1; i >= 0; i-- {
+		lines = append(lines, cleanComment(blocks[i], lang)...)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// cleanComment strips comment markers from a raw comment token.
+func cleanComment(text, lang string) []string {
+	var lines []string
+	text = strings.TrimSpace(text)
+
+	if strings.HasPrefix(text, "/*") {
+		text = strings.TrimSuffix(text, "*/")
+		for _, prefix := range []string{"/**", "/*!", "/*"} {
+			if strings.HasPrefix(text, prefix) {
+				text = text[len(prefix):]
+				break
+			}
+		}
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			line = strings.TrimPrefix(line, "*")
+			lines = append(lines, strings.TrimPrefix(line, " "))
+		}
+	} else {
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			for _, prefix := range []string{"///", "//!", "//", "#'", "##", "#", "--"} {
+				if strings.HasPrefix(line, prefix) {
+					line = line[len(prefix):]
+					break
+				}
+			}
+			// Skip compiler directives such as //go:generate or //nolint
+			if lang == "go" && (strings.HasPrefix(line, "go:") || strings.HasPrefix(line, "nolint")) {
+				continue
+			}
+			lines = append(lines, strings.TrimPrefix(line, " "))
+		}
+	}
+
+	if lang == "c_sharp" {
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace(xmlTagPattern.ReplaceAllString(line, ""))
+		}
+	}
+
+	// Drop leading/trailing empty lines left by comment delimiters
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// pythonDocstring returns the docstring of a function or class definition.
+func pythonDocstring(def *tree_sitter.Node, content []byte) string {
+	body := def.ChildByFieldName("body")
+	if body == nil || body.NamedChildCount() == 0 {
+		return ""
+	}
+	stmt := body.NamedChild(0)
+	if stmt == nil || stmt.Kind() != "expression_statement" || stmt.NamedChildCount() == 0 {
+		return ""
+	}
+	str := stmt.NamedChild(0)
+	if str == nil || str.Kind() != "string" {
+		return ""
+	}
+
+	text := strings.TrimLeft(str.Utf8Text(content), "rRuUbBfF")
+	for _, quote := range []string{`"""`, `'''`, `"`, `'`} {
+		if strings.HasPrefix(text, quote) && strings.HasSuffix(text, quote) && len(text) >= 2*len(quote) {
+			text = text[len(quote) : len(text)-len(quote)]
+			break
+		}
+	}
+	return dedentDocstring(text)
+}
+
+// dedentDocstring removes the common indentation of continuation lines (PEP 257).
+func dedentDocstring(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := 1; i < len(lines); i++ {
+		if indent > 0 && len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = strings.TrimSpace(lines[i])
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,159 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// analyzeSource analyzes src saved as name in a temporary directory,
+// skipping the test when the language's grammar isn't installed.
+func analyzeSource(t *testing.T, name, src string, detail DetailLevel) *FileAnalysis {
+	t.Helper()
+	lang := DetectLanguage(name)
+	loader := NewGrammarLoader()
+	if err := loader.LoadLanguage(lang); err != nil {
+		t.Skipf("grammar for %s not available: %v", lang, err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	analysis, err := loader.AnalyzeFile(path, detail)
+	if err != nil || analysis == nil {
+		t.Fatalf("AnalyzeFile(%s): %v", name, err)
+	}
+	return analysis
+}
+
+func TestCleanComment(t *testing.T) {
+	tests := []struct {
+		name, lang, comment, want string
+	}{
+		{"go line", "go", "// Greet returns a greeting.", "Greet returns a greeting."},
+		{"go directive", "go", "//go:generate stringer -type=Kind", ""},
+		{"rust outer doc", "rust", "/// Parses the input.", "Parses the input."},
+		{"rust inner doc", "rust", "//! Crate docs.", "Crate docs."},
+		{"jsdoc", "javascript", "/**\n * Adds two numbers.\n * @param a first\n */", "Adds two numbers.\n@param a first"},
+		{"javadoc one line", "java", "/** Returns the user. */", "Returns the user."},
+		{"c block", "c", "/* Frees the buffer. */", "Frees the buffer."},
+		{"c# xml", "c_sharp", "/// <summary>Saves the file.</summary>", "Saves the file."},
+		{"r roxygen", "r", "#' Fits the model.", "Fits the model."},
+		{"shell", "bash", "# Deploys the app.", "Deploys the app."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(cleanComment(tt.comment, tt.lang), "\n"); got != tt.want {
+				t.Errorf("cleanComment(%q) = %q, want %q", tt.comment, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedentDocstring(t *testing.T) {
+	got := dedentDocstring("Summary line.\n\n        Details follow\n          indented more.\n    ")
+	want := "Summary line.\n\nDetails follow\n  indented more."
+	if got != want {
+		t.Errorf("dedentDocstring = %q, want %q", got, want)
+	}
+}
+
+func TestExtractDoc(t *testing.T) {
+	tests := []struct {
+		file, src string
+		funcs     map[string]string // Function name -> doc
+		types     map[string]string // Type name -> doc
+	}{
+		{
+			file: "user.go",
+			src: `package user
+
+// User is a registered account.
+type User struct{ Name string }
+
+// Greet returns a greeting
+// for the user.
+//
+//go:noinline
+func (u *User) Greet() string { return "hi " + u.Name }
+
+func undocumented() {}
+
+// Detached by a blank line.
+
+func detached() {}
+`,
+			funcs: map[string]string{"Greet": "Greet returns a greeting\nfor the user.", "undocumented": "", "detached": ""},
+			types: map[string]string{"User": "User is a registered account."},
+		},
+		{
+			file: "user.py",
+			src: `class User:
+    """A registered account."""
+
+    @property
+    def name(self):
+        """The display name.
+
+        Falls back to the email address.
+        """
+        return self._name
+
+
+# Creates a user.
+def create():
+    return User()
+`,
+			funcs: map[string]string{"name": "The display name.\n\nFalls back to the email address.", "create": "Creates a user."},
+			types: map[string]string{"User": "A registered account."},
+		},
+		{
+			file: "User.java",
+			src: `/**
+ * A registered account.
+ */
+public class User {
+    /** Returns a greeting. */
+    @Override
+    public String greet() { return "hi"; }
+
+    // Not Javadoc, still attached.
+    void save() {}
+}
+`,
+			funcs: map[string]string{"greet": "Returns a greeting.", "save": "Not Javadoc, still attached."},
+			types: map[string]string{"User": "A registered account."},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, detail := range []DetailLevel{DetailNone, DetailSignature, DetailFull} {
+			t.Run(tt.file, func(t *testing.T) {
+				analysis := analyzeSource(t, tt.file, tt.src, detail)
+				docs := make(map[string]string)
+				for _, fn := range analysis.Functions {
+					docs[fn.Name] = fn.Doc
+				}
+				for name, want := range tt.funcs {
+					if got, ok := docs[name]; !ok {
+						t.Errorf("detail %d: function %s not found", detail, name)
+					} else if got != want {
+						t.Errorf("detail %d: %s doc = %q, want %q", detail, name, got, want)
+					}
+				}
+				docs = make(map[string]string)
+				for _, typ := range analysis.Types {
+					docs[typ.Name] = typ.Doc
+				}
+				for name, want := range tt.types {
+					if got, ok := docs[name]; !ok {
+						t.Errorf("detail %d: type %s not found", detail, name)
+					} else if got != want {
+						t.Errorf("detail %d: %s doc = %q, want %q", detail, name, got, want)
+					}
+				}
+			})
+		}
+	}
+}
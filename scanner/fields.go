@@ -0,0 +1,159 @@
+package scanner
+
+import (
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// fieldKinds are member declarations that introduce data fields of a type.
+var fieldKinds = map[string]bool{
+	"field_declaration":       true, // Go, Java, C#, C/C++, Rust
+	"public_field_definition": true, // TypeScript
+	"field_definition":        true, // JavaScript
+	"property_signature":      true, // TypeScript interfaces
+	"property_declaration":    true, // Kotlin, Swift, C#, PHP
+	"class_parameter":         true, // Kotlin primary constructor (val x: Int)
+}
+
+// fieldDeclarators wrap the declared names inside a field declaration.
+var fieldDeclarators = map[string]bool{
+	"variable_declaration": true, // C#, Kotlin
+	"variable_declarator":  true,
+	"property_element":     true, // PHP
+	"pattern":              true, // Swift
+}
+
+// typeFields returns the data members of the type definition owning name:
+// struct and class fields and properties, and for Python the class-level
+// assignments and the self.x assignments made in __init__.
+// Nested types and methods keep their own members.
+func typeFields(name *tree_sitter.Node, content []byte) []string {
+	def := definitionNode(name)
+
+	var fields []string
+	seen := make(map[string]bool)
+	add := func(field string) {
+		field = strings.TrimPrefix(field, "$")
+		if field != "" && !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+
+	var walk func(n *tree_sitter.Node)
+	walk = func(n *tree_sitter.Node) {
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			child := n.NamedChild(i)
+			switch {
+			case fieldKinds[child.Kind()]:
+				for _, f := range fieldNames(child, content) {
+					add(f)
+				}
+			case child.Kind() == "assignment":
+				if f := pythonField(child, def, content); f != "" {
+					add(f)
+				}
+			case child.Kind() == "function_definition" && symbolText(child, content) == "__init__":
+				walk(child)
+			case symbolKinds[child.Kind()]:
+				// Nested definitions own their members
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(def)
+	return fields
+}
+
+// fieldNames returns the names declared by a field declaration. Go allows
+// several names per declaration (a, b int); embedded fields have none.
+func fieldNames(field *tree_sitter.Node, content []byte) []string {
+	cursor := field.Walk()
+	defer cursor.Close()
+
+	var names []string
+	for _, fieldName := range []string{"name", "property"} {
+		for _, n := range field.ChildrenByFieldName(fieldName, cursor) {
+			names = append(names, n.Utf8Text(content))
+		}
+		if len(names) > 0 {
+			return names
+		}
+	}
+	for _, d := range field.ChildrenByFieldName("declarator", cursor) {
+		if n := declaratorName(&d); n != nil {
+			names = append(names, n.Utf8Text(content))
+		}
+	}
+	if len(names) > 0 {
+		return names
+	}
+
+	for i := uint(0); i < field.NamedChildCount(); i++ {
+		if child := field.NamedChild(i); fieldDeclarators[child.Kind()] {
+			names = append(names, fieldNames(child, content)...)
+		}
+	}
+	if len(names) > 0 {
+		return names
+	}
+	for i := uint(0); i < field.NamedChildCount(); i++ {
+		switch child := field.NamedChild(i); child.Kind() {
+		case "identifier", "simple_identifier", "variable_name", "property_identifier":
+			return []string{child.Utf8Text(content)}
+		}
+	}
+	return nil
+}
+
+// declaratorName follows a C-style declarator chain (*name, name[4]) or a
+// Java variable_declarator down to the declared identifier.
+func declaratorName(d *tree_sitter.Node) *tree_sitter.Node {
+	for d != nil {
+		switch d.Kind() {
+		case "identifier", "field_identifier":
+			return d
+		}
+		if name := d.ChildByFieldName("name"); name != nil {
+			return name
+		}
+		d = d.ChildByFieldName("declarator")
+	}
+	return nil
+}
+
+// pythonField returns the attribute assigned by a Python class-level
+// assignment (x = 1, x: int) or a self.x assignment, or "".
+func pythonField(assign, class *tree_sitter.Node, content []byte) string {
+	left := assign.ChildByFieldName("left")
+	if left == nil {
+		return ""
+	}
+	switch left.Kind() {
+	case "identifier":
+		// expression_statement -> block -> class_definition
+		stmt := assign.Parent()
+		if stmt == nil || stmt.Parent() == nil || stmt.Parent().Parent() == nil ||
+			stmt.Parent().Parent().Id() != class.Id() {
+			return ""
+		}
+		return left.Utf8Text(content)
+	case "attribute":
+		obj := left.ChildByFieldName("object")
+		attr := left.ChildByFieldName("attribute")
+		if obj != nil && attr != nil && obj.Utf8Text(content) == "self" {
+			return attr.Utf8Text(content)
+		}
+	}
+	return ""
+}
+
+// symbolText returns the name of a definition node, or "".
+func symbolText(def *tree_sitter.Node, content []byte) string {
+	if name := symbolName(def); name != nil {
+		return name.Utf8Text(content)
+	}
+	return ""
+}
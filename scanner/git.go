@@ -2,7 +2,6 @@ package scanner
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
@@ -161,96 +160,53 @@ type ImpactInfo struct {
 	UsedBy int    // number of other files that import/use this file
 }
 
-// AnalyzeImpact checks which changed files are imported by other files
-// Uses tree-sitter to extract actual imports for accuracy
+// AnalyzeImpact checks which changed files are imported by other files.
+// Imports are extracted with tree-sitter and resolved to concrete files, so
+// files that merely share a name are not counted. A changed Go file is
+// reported as its package directory, which importers use as a whole.
 func AnalyzeImpact(root string, changedFiles []FileInfo) []ImpactInfo {
 	if len(changedFiles) == 0 {
 		return nil
 	}
 
-	// Build set of changed file base names and directories
-	changedBases := make(map[string]string) // base name -> full path
-	changedDirs := make(map[string]string)  // dir name -> representative file
+	// Map each changed file to the unit reported for it
+	changed := make(map[string]string)
 	for _, f := range changedFiles {
-		base := strings.TrimSuffix(filepath.Base(f.Path), filepath.Ext(f.Path))
-		changedBases[base] = f.Path
-
-		// Also track directories for Go-style package imports
-		dir := filepath.Dir(f.Path)
-		if dir != "." && dir != "" {
-			dirBase := filepath.Base(dir)
-			if _, exists := changedDirs[dirBase]; !exists {
-				changedDirs[dirBase] = f.Path
-			}
+		changed[f.Path] = f.Path
+		if DetectLanguage(f.Path) == "go" {
+			changed[f.Path] = filepath.Dir(f.Path) + "/"
 		}
 	}
 
-	// Scan all files to get their imports using tree-sitter
-	loader := NewGrammarLoader()
-	usageCounts := make(map[string]int)
-
-	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-
-		// Skip ignored directories
-		if info.IsDir() {
-			if IgnoredDirs[info.Name()] {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Analyze file imports (only need basic info for impact analysis)
-		analysis, err := loader.AnalyzeFile(path, DetailNone)
-		if err != nil || analysis == nil {
-			return nil
-		}
-
-		relPath, _ := filepath.Rel(root, path)
-
-		// Check each import to see if it references a changed file
-		for _, imp := range analysis.Imports {
-			// Extract the last component of the import path
-			// e.g., "./components/Header" -> "Header"
-			// e.g., "codemap/scanner" -> "scanner"
-			impBase := filepath.Base(imp)
-			impBase = strings.TrimSuffix(impBase, filepath.Ext(impBase))
-
-			// Check if this import matches a changed file (by filename)
-			if changedPath, ok := changedBases[impBase]; ok {
-				if relPath != changedPath {
-					usageCounts[changedPath]++
-				}
-			}
+	analyses, err := ScanForDeps(root, LoadGitignore(root), NewGrammarLoader(), DetailNone)
+	if err != nil {
+		return nil
+	}
 
-			// Also check if import matches a changed directory (Go packages)
-			if changedPath, ok := changedDirs[impBase]; ok {
-				changedDir := filepath.Dir(changedPath)
-				// Don't count imports from same directory
-				if filepath.Dir(relPath) != changedDir {
-					usageCounts[changedDir+"/"]++
-				}
+	// Count importing files per unit, once per importer
+	usageCounts := make(map[string]int)
+	for _, a := range analyses {
+		used := make(map[string]bool)
+		for _, imp := range a.ImportedFiles {
+			if unit, ok := changed[imp]; ok && !used[unit] {
+				used[unit] = true
+				usageCounts[unit]++
 			}
 		}
-		return nil
-	})
+	}
 
 	// Build impact info
 	var impacts []ImpactInfo
 	for file, count := range usageCounts {
-		if count > 0 {
-			impacts = append(impacts, ImpactInfo{
-				File:   filepath.Base(file),
-				UsedBy: count,
-			})
-		}
+		impacts = append(impacts, ImpactInfo{File: file, UsedBy: count})
 	}
 
 	// Sort by usage count descending
 	sort.Slice(impacts, func(i, j int) bool {
-		return impacts[i].UsedBy > impacts[j].UsedBy
+		if impacts[i].UsedBy != impacts[j].UsedBy {
+			return impacts[i].UsedBy > impacts[j].UsedBy
+		}
+		return impacts[i].File < impacts[j].File
 	})
 
 	return impacts
@@ -1,12 +1,15 @@
 package scanner
 
 import (
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	tree_sitter "github.com/tree-sitter/go-tree-sitter"
 )
@@ -14,15 +17,22 @@ import (
 //go:embed queries/*.scm
 var queryFiles embed.FS
 
-// LanguageConfig holds dynamically loaded parser and query
+// LanguageConfig holds dynamically loaded parser and queries
 type LanguageConfig struct {
-	Language *tree_sitter.Language
-	Query    *tree_sitter.Query
+	Language  *tree_sitter.Language
+	Query     *tree_sitter.Query
+	CallQuery *tree_sitter.Query // nil if the language has no call query
+	RefQuery  *tree_sitter.Query // nil if the language has no reference query
 }
 
-// GrammarLoader handles dynamic loading of tree-sitter grammars
+// GrammarLoader handles dynamic loading of tree-sitter grammars.
+// It is safe for concurrent use: grammars and compiled queries are shared,
+// while every AnalyzeFile call parses with its own parser.
 type GrammarLoader struct {
+	mu         sync.Mutex // Guards configs, specs and failures
 	configs    map[string]*LanguageConfig
+	specs      map[string]languageSpec // Spec each language was loaded with
+	failures   map[string]error        // Configured languages that failed to load
 	grammarDir string
 }
 
@@ -32,7 +42,8 @@ type LangInfo struct {
 	Full  string // Full name: "JavaScript", "Python"
 }
 
-// LangDisplay maps internal language names to display names
+// LangDisplay maps the built-in languages to display names (see
+// LanguageInfo for configured ones)
 var LangDisplay = map[string]LangInfo{
 	"go":         {"Go", "Go"},
 	"python":     {"Py", "Python"},
@@ -84,7 +95,9 @@ var extToLang = map[string]string{
 // NewGrammarLoader creates a loader that searches for grammars
 func NewGrammarLoader() *GrammarLoader {
 	loader := &GrammarLoader{
-		configs: make(map[string]*LanguageConfig),
+		configs:  make(map[string]*LanguageConfig),
+		specs:    make(map[string]languageSpec),
+		failures: make(map[string]error),
 	}
 
 	// Find grammar directory - check env var first (for Homebrew install)
@@ -125,58 +138,114 @@ func (l *GrammarLoader) GrammarDir() string {
 
 // LoadLanguage dynamically loads a grammar from .so/.dylib
 func (l *GrammarLoader) LoadLanguage(lang string) error {
-	if _, exists := l.configs[lang]; exists {
-		return nil // Already loaded
-	}
+	_, err := l.languageConfig(lang)
+	return err
+}
+
+// languageConfig returns the loaded config for lang, loading it on first use
+// and again once RegisterLanguages changes where it comes from. A
+// configured grammar or query that fails to load is reported on stderr
+// once.
+func (l *GrammarLoader) languageConfig(lang string) (*LanguageConfig, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-	if l.grammarDir == "" {
-		return fmt.Errorf("no grammar directory found")
+	spec := specFor(lang)
+	if config, exists := l.configs[lang]; exists && l.specs[lang] == spec {
+		return config, nil // Already loaded
+	}
+	if err, failed := l.failures[lang]; failed && l.specs[lang] == spec {
+		return nil, err
 	}
+	delete(l.configs, lang)
+	delete(l.failures, lang)
 
-	// OS-specific library extension
-	var libExt string
-	switch runtime.GOOS {
-	case "darwin":
-		libExt = ".dylib"
-	case "windows":
-		libExt = ".dll"
-	default:
-		libExt = ".so"
+	config, err := l.loadLanguage(lang, spec)
+	if err != nil {
+		if spec != (languageSpec{}) {
+			l.specs[lang] = spec
+			l.failures[lang] = err
+			fmt.Fprintf(os.Stderr, "⚠️  Language %s disabled: %v\n", lang, err)
+		}
+		return nil, err
 	}
+	l.specs[lang] = spec
+	l.configs[lang] = config
+	return config, nil
+}
 
+// loadLanguage loads a grammar and compiles its queries. Callers hold l.mu.
+func (l *GrammarLoader) loadLanguage(lang string, spec languageSpec) (*LanguageConfig, error) {
 	// Load shared library
-	libPath := filepath.Join(l.grammarDir, fmt.Sprintf("libtree-sitter-%s%s", lang, libExt))
+	libPath := spec.grammar
+	if libPath == "" {
+		if l.grammarDir == "" {
+			return nil, fmt.Errorf("no grammar directory found")
+		}
+		libPath = filepath.Join(l.grammarDir, fmt.Sprintf("libtree-sitter-%s%s", lang, libraryExt()))
+	}
 	lib, err := loadLibrary(libPath)
 	if err != nil {
-		return fmt.Errorf("load %s: %w", libPath, err)
+		return nil, fmt.Errorf("load %s: %w", libPath, err)
 	}
 
 	// Get language function
-	langFunc, err := getLanguageFunc(lib, lang)
+	symbol := spec.symbol
+	if symbol == "" {
+		symbol = "tree_sitter_" + lang
+	}
+	langFunc, err := getLanguageFunc(lib, symbol)
 	if err != nil {
-		return fmt.Errorf("get func for %s: %w", lang, err)
+		return nil, fmt.Errorf("get func for %s: %w", lang, err)
 	}
 	language := tree_sitter.NewLanguage(langFunc())
 
 	// Load query
-	queryBytes, err := queryFiles.ReadFile(fmt.Sprintf("queries/%s.scm", lang))
-	if err != nil {
-		return fmt.Errorf("no query for %s", lang)
+	var query *tree_sitter.Query
+	if spec.symbols != "" {
+		if query, err = compileQueryFile(language, spec.symbols); err != nil {
+			return nil, err
+		}
+	} else {
+		queryBytes, err := queryFiles.ReadFile(fmt.Sprintf("queries/%s.scm", lang))
+		if err != nil && spec.custom {
+			return nil, fmt.Errorf("no symbol query (set queries.symbols or add %s.scm)", lang)
+		} else if err != nil {
+			return nil, fmt.Errorf("no query for %s", lang)
+		}
+		var qerr *tree_sitter.QueryError
+		if query, qerr = tree_sitter.NewQuery(language, string(queryBytes)); qerr != nil {
+			return nil, fmt.Errorf("bad query for %s: %v", lang, qerr)
+		}
 	}
 
-	query, qerr := tree_sitter.NewQuery(language, string(queryBytes))
-	if qerr != nil {
-		return fmt.Errorf("bad query for %s: %v", lang, qerr)
+	// Built-in call and reference queries are optional: a pattern the
+	// installed grammar rejects only disables that part of the analysis.
+	// Configured ones must compile.
+	config := &LanguageConfig{Language: language, Query: query}
+	if spec.calls != "" {
+		if config.CallQuery, err = compileQueryFile(language, spec.calls); err != nil {
+			return nil, err
+		}
+	} else if pattern, ok := callQueryPatterns[lang]; ok {
+		var qerr *tree_sitter.QueryError
+		if config.CallQuery, qerr = tree_sitter.NewQuery(language, pattern); qerr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Calls disabled for %s: built-in query %d:%d: %s\n",
+				lang, qerr.Row+1, qerr.Column+1, describeQueryError(qerr))
+		}
 	}
-
-	l.configs[lang] = &LanguageConfig{Language: language, Query: query}
-	return nil
-}
-
-// DetectLanguage returns the language name for a file path
-func DetectLanguage(filePath string) string {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	return extToLang[ext]
+	if spec.refs != "" {
+		if config.RefQuery, err = compileQueryFile(language, spec.refs); err != nil {
+			return nil, err
+		}
+	} else if pattern, ok := refQueryPatterns[lang]; ok {
+		var qerr *tree_sitter.QueryError
+		if config.RefQuery, qerr = tree_sitter.NewQuery(language, pattern); qerr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  References disabled for %s: built-in query %d:%d: %s\n",
+				lang, qerr.Row+1, qerr.Column+1, describeQueryError(qerr))
+		}
+	}
+	return config, nil
 }
 
 // AnalyzeFile extracts functions and imports
@@ -187,11 +256,11 @@ func (l *GrammarLoader) AnalyzeFile(filePath string, detailLevel DetailLevel) (*
 		return nil, nil
 	}
 
-	if err := l.LoadLanguage(lang); err != nil {
+	config, err := l.languageConfig(lang)
+	if err != nil {
 		return nil, nil // Skip if grammar unavailable
 	}
 
-	config := l.configs[lang]
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
@@ -207,11 +276,15 @@ func (l *GrammarLoader) AnalyzeFile(filePath string, detailLevel DetailLevel) (*
 	cursor := tree_sitter.NewQueryCursor()
 	defer cursor.Close()
 
-	analysis := &FileAnalysis{Path: filePath, Language: lang}
+	sum := sha256.Sum256(content)
+	analysis := &FileAnalysis{Path: filePath, Language: lang, Size: int64(len(content)), Hash: hex.EncodeToString(sum[:])}
+	analysis.Package = declaredPackage(tree.RootNode(), content)
 
 	// Temporary storage for building composite captures
 	funcBuilder := make(map[uint]*funcCapture)
 	typeBuilder := make(map[uint]*typeCapture)
+	superBuilder := make(map[uint]*superCapture)
+	var funcRanges []funcRange // Function extents for attributing calls
 
 	// Use Matches() API - iterate over query matches
 	matches := cursor.Matches(config.Query, tree.RootNode(), content)
@@ -226,13 +299,36 @@ func (l *GrammarLoader) AnalyzeFile(filePath string, detailLevel DetailLevel) (*
 			switch {
 			case strings.HasPrefix(captureName, "func."):
 				handleFuncCapture(funcBuilder, match.Id(), captureName, text, line)
+				if captureName == "func.name" {
+					funcBuilder[match.Id()].owner = ownerOf(&capture.Node, content)
+					funcBuilder[match.Id()].rng = definitionRange(&capture.Node)
+					funcBuilder[match.Id()].doc = extractDoc(&capture.Node, content, lang)
+					funcRanges = append(funcRanges, newFuncRange(&capture.Node, content))
+				}
 			case strings.HasPrefix(captureName, "type."):
 				handleTypeCapture(typeBuilder, match.Id(), captureName, text, line, detailLevel)
+				if captureName == "type.name" {
+					typeBuilder[match.Id()].rng = definitionRange(&capture.Node)
+					typeBuilder[match.Id()].doc = extractDoc(&capture.Node, content, lang)
+				}
+				if captureName == "type.name" && detailLevel >= DetailSignature {
+					typeBuilder[match.Id()].members = typeFields(&capture.Node, content)
+				}
+			case strings.HasPrefix(captureName, "super.") || strings.HasPrefix(captureName, "impl."):
+				handleSuperCapture(superBuilder, match.Id(), captureName, text, line)
+			case captureName == "var.name" || captureName == "const.name":
+				analysis.Variables = append(analysis.Variables, newVarInfo(&capture.Node, captureName, text, lang))
 			case captureName == "import" || captureName == "module":
-				analysis.Imports = append(analysis.Imports, text)
+				analysis.Imports = append(analysis.Imports, importPaths(&capture.Node, content, text, lang)...)
 			// Legacy support: plain @function/@method capture (current queries)
 			case captureName == "function" || captureName == "method":
-				analysis.Functions = append(analysis.Functions, FuncInfo{Name: text, Line: line})
+				// Parameters are not captured, so arity is unknown
+				fn := FuncInfo{Name: text, Line: line, ParamCount: -1, SourceRange: definitionRange(&capture.Node), Doc: extractDoc(&capture.Node, content, lang)}
+				if detailLevel >= DetailSignature {
+					fn.Owner = ownerOf(&capture.Node, content)
+				}
+				analysis.Functions = append(analysis.Functions, fn)
+				funcRanges = append(funcRanges, newFuncRange(&capture.Node, content))
 			}
 		}
 	}
@@ -249,28 +345,350 @@ func (l *GrammarLoader) AnalyzeFile(filePath string, detailLevel DetailLevel) (*
 		analysis.Types = append(analysis.Types, typeInfo)
 	}
 
+	// Builders are maps; restore source order so output is deterministic
+	sort.SliceStable(analysis.Functions, func(i, j int) bool {
+		return analysis.Functions[i].StartByte < analysis.Functions[j].StartByte
+	})
+	sort.SliceStable(analysis.Types, func(i, j int) bool {
+		return analysis.Types[i].StartByte < analysis.Types[j].StartByte
+	})
+
 	analysis.Functions = dedupeFuncs(analysis.Functions)
 	analysis.Types = dedupeTypes(analysis.Types)
+	analysis.Variables = dedupeVars(analysis.Variables)
 	analysis.Imports = dedupe(analysis.Imports)
+	attachSupertypes(analysis, superBuilder, detailLevel)
+	attachMethods(analysis)
+	if detailLevel >= DetailFull && config.RefQuery != nil {
+		analysis.References = extractReferences(config.RefQuery, tree.RootNode(), content)
+	}
+	if detailLevel >= DetailFull && config.CallQuery != nil {
+		analysis.Calls = extractCalls(config.CallQuery, tree.RootNode(), content, funcRanges, newInferrer(tree.RootNode(), content, lang))
+		if lang == "bash" {
+			analysis.Calls = functionCommands(analysis.Calls, analysis.Functions)
+		}
+	}
 	return analysis, nil
 }
 
+// definitionRange returns the full extent of the definition named by name.
+// Python decorators, TypeScript/JavaScript export statements and the "type"
+// keyword of single Go type specs are included; Dart keeps the body as a
+// sibling of the signature.
+func definitionRange(name *tree_sitter.Node) SourceRange {
+	start := definitionNode(name)
+	end := start
+	if next := start.NextNamedSibling(); next != nil && next.Kind() == "function_body" {
+		end = next
+	}
+	if parent := start.Parent(); parent != nil {
+		switch parent.Kind() {
+		case "decorated_definition", "export_statement":
+			start = parent
+		case "type_declaration":
+			if parent.NamedChildCount() == 1 {
+				start, end = parent, parent
+			}
+		}
+	}
+	return SourceRange{
+		StartLine: int(start.StartPosition().Row) + 1,
+		EndLine:   int(end.EndPosition().Row) + 1,
+		StartByte: int(start.StartByte()),
+		EndByte:   int(end.EndByte()),
+	}
+}
+
+// newVarInfo builds a VarInfo from a @var.name or @const.name capture.
+// Python has no constant declarations; UPPER_CASE names are treated as constants.
+func newVarInfo(name *tree_sitter.Node, captureName, text, lang string) VarInfo {
+	kind := "var"
+	if captureName == "const.name" || (lang == "python" && text == strings.ToUpper(text) && strings.ToLower(text) != text) {
+		kind = "const"
+	}
+	return VarInfo{
+		Name:       text,
+		Kind:       kind,
+		IsExported: IsExportedName(text, lang),
+		Line:       int(name.StartPosition().Row) + 1,
+		EndLine:    int(definitionNode(name).EndPosition().Row) + 1,
+	}
+}
+
+// ownerKinds are declarations that own the methods defined inside them.
+var ownerKinds = map[string]bool{
+	"class_declaration":     true,
+	"class_definition":      true,
+	"class_specifier":       true,
+	"struct_specifier":      true,
+	"interface_declaration": true,
+	"struct_declaration":    true,
+	"record_declaration":    true,
+	"enum_declaration":      true,
+	"protocol_declaration":  true,
+	"object_declaration":    true,
+	"trait_declaration":     true,
+	"trait_item":            true,
+	"impl_item":             true,
+	"class":                 true, // Ruby
+	"module":                true, // Ruby
+	"extension_declaration": true, // Dart
+	"mixin_declaration":     true,
+}
+
+// ownerOf returns the class, struct, trait or impl type enclosing the
+// definition named by name, or "" for free functions. Nested functions
+// (closures) are not owned by the enclosing class. Go methods are owned
+// by their receiver type instead (see funcCapture.Build).
+func ownerOf(name *tree_sitter.Node, content []byte) string {
+	// C++ out-of-line definitions: void Foo::bar() {}
+	if parent := name.Parent(); parent != nil && parent.Kind() == "qualified_identifier" {
+		if scope := parent.ChildByFieldName("scope"); scope != nil {
+			return normalizeTypeRef(scope.Utf8Text(content))
+		}
+	}
+
+	def := definitionNode(name)
+	for n := def.Parent(); n != nil; n = n.Parent() {
+		if ownerKinds[n.Kind()] && n.Parent() != nil { // Python's root is also "module"
+			if n.Kind() == "impl_item" {
+				if t := n.ChildByFieldName("type"); t != nil {
+					return normalizeTypeRef(t.Utf8Text(content))
+				}
+			}
+			if label := ownerLabel(n); label != nil {
+				return label.Utf8Text(content)
+			}
+			return ""
+		}
+		if symbolKinds[n.Kind()] {
+			return "" // Nested inside another function
+		}
+	}
+	return ""
+}
+
+// ownerLabel returns the name node of an owning declaration. Some grammars
+// (Kotlin) don't expose a name field, so fall back to the first identifier.
+func ownerLabel(n *tree_sitter.Node) *tree_sitter.Node {
+	if name := n.ChildByFieldName("name"); name != nil {
+		return name
+	}
+	for i := uint(0); i < n.NamedChildCount(); i++ {
+		child := n.NamedChild(i)
+		switch child.Kind() {
+		case "type_identifier", "identifier", "simple_identifier", "constant":
+			return child
+		}
+	}
+	return nil
+}
+
+// receiverTypeName extracts the type name from a Go receiver such as "(s *Server[T])".
+func receiverTypeName(receiver string) string {
+	receiver = strings.Trim(strings.TrimSpace(receiver), "()")
+	if i := strings.Index(receiver, "["); i >= 0 {
+		receiver = receiver[:i]
+	}
+	fields := strings.Fields(receiver)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimLeft(fields[len(fields)-1], "*")
+}
+
+// superCapture collects inheritance clauses of a type declaration.
+// Captures: super.type (declaring type), super.extends, super.implements,
+// super.method (interface method set), impl.type / impl.trait (Rust impl blocks).
+// Supertypes are kept as written until attachSupertypes qualifies them.
+type superCapture struct {
+	typeName   string
+	line       int
+	extends    []string
+	implements []string
+	methods    []string
+	implType   string
+	implTrait  string
+}
+
+// importPaths returns the modules an import capture names. The package of
+// a Python `from . import x` may have a module x, so it is recorded as ".x"
+// for the resolver to try x.py before the package's __init__.py.
+func importPaths(node *tree_sitter.Node, content []byte, text, lang string) []string {
+	stmt := node.Parent()
+	if lang != "python" || strings.Trim(text, ".") != "" || stmt == nil || stmt.Kind() != "import_from_statement" {
+		return []string{text}
+	}
+	cursor := stmt.Walk()
+	defer cursor.Close()
+
+	var paths []string
+	for _, name := range stmt.ChildrenByFieldName("name", cursor) {
+		if name.Kind() == "aliased_import" {
+			if n := name.ChildByFieldName("name"); n != nil {
+				name = *n
+			}
+		}
+		paths = append(paths, text+name.Utf8Text(content))
+	}
+	if len(paths) == 0 {
+		return []string{text} // from . import *
+	}
+	return paths
+}
+
+// handleSuperCapture routes inheritance captures to builder
+func handleSuperCapture(builders map[uint]*superCapture, matchID uint, name, text string, line int) {
+	if builders[matchID] == nil {
+		builders[matchID] = &superCapture{}
+	}
+	sc := builders[matchID]
+
+	switch name {
+	case "super.type":
+		sc.typeName = text
+		sc.line = line
+	case "super.extends":
+		sc.extends = append(sc.extends, text)
+	case "super.implements":
+		sc.implements = append(sc.implements, text)
+	case "super.method":
+		sc.methods = append(sc.methods, text)
+	case "impl.type":
+		sc.implType = normalizeTypeRef(text)
+		sc.line = line
+	case "impl.trait":
+		sc.implTrait = text
+	}
+}
+
+// attachSupertypes merges inheritance captures into the matching TypeInfo
+// (same name and line) and records standalone impl blocks, with supertypes
+// as supertypeRef names them.
+func attachSupertypes(analysis *FileAnalysis, builders map[uint]*superCapture, detail DetailLevel) {
+	index := make(map[string]int)
+	for i, t := range analysis.Types {
+		index[fmt.Sprintf("%s:%d", t.Name, t.Line)] = i
+	}
+
+	// Visit matches in query order so supertypes keep a stable order
+	ids := make([]uint, 0, len(builders))
+	for id := range builders {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		sc := builders[id]
+		if sc.implType != "" && sc.implTrait != "" {
+			analysis.Impls = append(analysis.Impls, ImplInfo{Type: sc.implType, Trait: supertypeRef(sc.implTrait, analysis.Language), Line: sc.line})
+			continue
+		}
+		for i, ref := range sc.extends {
+			sc.extends[i] = supertypeRef(ref, analysis.Language)
+		}
+		for i, ref := range sc.implements {
+			sc.implements[i] = supertypeRef(ref, analysis.Language)
+		}
+		i, ok := index[fmt.Sprintf("%s:%d", sc.typeName, sc.line)]
+		if !ok {
+			continue
+		}
+		t := &analysis.Types[i]
+		t.Extends = dedupe(append(t.Extends, sc.extends...))
+		t.Implements = dedupe(append(t.Implements, sc.implements...))
+		if detail >= DetailFull {
+			t.Methods = dedupe(append(t.Methods, sc.methods...))
+		}
+	}
+}
+
+// attachMethods lists the functions owned by each type (class methods,
+// Go methods declared in the same file) in its Methods, in source order.
+func attachMethods(analysis *FileAnalysis) {
+	funcs := append([]FuncInfo(nil), analysis.Functions...)
+	sort.SliceStable(funcs, func(i, j int) bool { return funcs[i].Line < funcs[j].Line })
+
+	owned := make(map[string][]string)
+	for _, fn := range funcs {
+		if fn.Owner != "" {
+			owned[fn.Owner] = append(owned[fn.Owner], fn.Name)
+		}
+	}
+	for i := range analysis.Types {
+		t := &analysis.Types[i]
+		if methods := owned[t.Name]; len(methods) > 0 {
+			t.Methods = dedupe(append(t.Methods, methods...))
+		}
+	}
+}
+
+// normalizeTypeRef reduces a type reference to its bare name:
+// generic arguments, constructor calls and qualifiers are dropped
+// ("java.util.List<String>" -> "List", "abc.ABC" -> "ABC").
+func normalizeTypeRef(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if i := strings.IndexAny(ref, "<[("); i > 0 {
+		ref = ref[:i]
+	}
+	ref = strings.TrimSpace(strings.TrimPrefix(ref, "*"))
+	if i := strings.LastIndexAny(ref, `.:\`); i >= 0 {
+		ref = ref[i+1:]
+	}
+	return ref
+}
+
+// supertypeRef reduces a supertype reference to its name, keeping the
+// qualifier that places it in a package: "java.util.List<String>" ->
+// "java.util.List", "fmt::Display" -> "fmt.Display". Rust's crate::,
+// self:: and super:: prefixes are dropped. C and C++ qualifiers name
+// namespaces the graph doesn't record, so those keep the bare name.
+func supertypeRef(ref, lang string) string {
+	name := normalizeTypeRef(ref)
+	if lang == "c" || lang == "cpp" {
+		return name
+	}
+	ref = strings.TrimSpace(ref)
+	if i := strings.IndexAny(ref, "<[("); i > 0 {
+		ref = ref[:i]
+	}
+	ref = strings.TrimSpace(strings.TrimPrefix(ref, "*"))
+	ref = strings.Trim(strings.NewReplacer("::", ".", `\`, ".").Replace(ref), ".")
+	for _, prefix := range []string{"crate.", "self.", "super."} {
+		for strings.HasPrefix(ref, prefix) {
+			ref = ref[len(prefix):]
+		}
+	}
+	if ref == "" || strings.ContainsAny(ref, " \t\n") {
+		return name
+	}
+	return ref
+}
+
 // funcCapture collects components of a function signature
 type funcCapture struct {
 	name     string
 	params   string
 	result   string
 	receiver string
+	owner    string
+	doc      string
 	line     int
+	rng      SourceRange
 }
 
 // Build constructs FuncInfo from captured components
 func (fc *funcCapture) Build(detail DetailLevel, lang string) FuncInfo {
 	info := FuncInfo{
-		Name:       fc.name,
-		IsExported: IsExportedName(fc.name, lang),
-		Line:       fc.line,
-		ParamCount: countParams(fc.params),
+		Name:        fc.name,
+		IsExported:  IsExportedName(fc.name, lang),
+		Line:        fc.line,
+		ParamCount:  countParams(fc.params),
+		SourceRange: fc.rng,
+		Doc:         fc.doc,
+	}
+	if info.ParamCount > 0 && boundFirstParam(fc.params, lang, fc.owner) {
+		info.ParamCount-- // self/cls is passed implicitly
 	}
 
 	if detail >= DetailSignature && fc.params != "" {
@@ -281,6 +699,13 @@ func (fc *funcCapture) Build(detail DetailLevel, lang string) FuncInfo {
 		info.Receiver = fc.receiver
 	}
 
+	if detail >= DetailSignature {
+		info.Owner = fc.owner
+		if lang == "go" && fc.receiver != "" {
+			info.Owner = receiverTypeName(fc.receiver)
+		}
+	}
+
 	return info
 }
 
@@ -326,6 +751,26 @@ func countParams(params string) int {
 	return count
 }
 
+// boundFirstParam reports whether a parameter list starts with the implicit
+// receiver: self or cls of a Python method, or any form of a Rust self
+// parameter (self, &self, &'a mut self, self: Box<Self>).
+func boundFirstParam(params, lang, owner string) bool {
+	first, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(params), "("), ",")
+	first, _, _ = strings.Cut(first, ":")
+	first = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(first), ")"))
+	switch lang {
+	case "python":
+		return owner != "" && (first == "self" || first == "cls")
+	case "rust":
+		if first = strings.TrimPrefix(first, "&"); strings.HasPrefix(first, "'") {
+			_, first, _ = strings.Cut(first, " ") // Lifetime
+		}
+		first = strings.TrimSpace(first)
+		return strings.TrimSpace(strings.TrimPrefix(first, "mut ")) == "self"
+	}
+	return false
+}
+
 // handleFuncCapture routes function-related captures to builder
 func handleFuncCapture(builders map[uint]*funcCapture, matchID uint, name, text string, line int) {
 	if builders[matchID] == nil {
@@ -348,22 +793,30 @@ func handleFuncCapture(builders map[uint]*funcCapture, matchID uint, name, text
 
 // typeCapture collects components of a type definition
 type typeCapture struct {
-	name   string
-	kind   TypeKind
-	fields string
-	line   int
+	name    string
+	kind    TypeKind
+	fields  string   // Raw member block (legacy @type.fields captures)
+	members []string // Field names found by walking the definition
+	doc     string
+	line    int
+	rng     SourceRange
 }
 
 // Build constructs TypeInfo from captured components
 func (tc *typeCapture) Build(detail DetailLevel, lang string) TypeInfo {
 	info := TypeInfo{
-		Name:       tc.name,
-		Kind:       tc.kind,
-		IsExported: IsExportedName(tc.name, lang),
-		Line:       tc.line,
+		Name:        tc.name,
+		Kind:        tc.kind,
+		IsExported:  IsExportedName(tc.name, lang),
+		Line:        tc.line,
+		SourceRange: tc.rng,
+		Doc:         tc.doc,
 	}
 
-	if detail >= DetailFull && tc.fields != "" {
+	if detail >= DetailSignature {
+		info.Fields = tc.members
+	}
+	if len(info.Fields) == 0 && detail >= DetailFull && tc.fields != "" {
 		info.Fields = parseFieldNames(tc.fields)
 	}
 
@@ -488,19 +941,40 @@ func parseFieldNames(fieldsText string) []string {
 	return fields
 }
 
-// dedupeFuncs removes duplicate functions by name
+// dedupeFuncs removes functions matched by more than one pattern.
+// Methods of different owners and overloads share names, so the
+// definition line is part of the identity.
 func dedupeFuncs(funcs []FuncInfo) []FuncInfo {
 	seen := make(map[string]bool)
 	var out []FuncInfo
 	for _, f := range funcs {
-		if !seen[f.Name] {
-			seen[f.Name] = true
+		key := fmt.Sprintf("%s.%s:%d", f.Owner, f.Name, f.Line)
+		if !seen[key] {
+			seen[key] = true
 			out = append(out, f)
 		}
 	}
 	return out
 }
 
+// dedupeVars removes duplicate variables by name, preferring constants
+// (Java "static final" fields match both the static and the final pattern)
+func dedupeVars(vars []VarInfo) []VarInfo {
+	index := make(map[string]int)
+	var out []VarInfo
+	for _, v := range vars {
+		if i, ok := index[v.Name]; ok {
+			if v.Kind == "const" {
+				out[i].Kind = "const"
+			}
+			continue
+		}
+		index[v.Name] = len(out)
+		out = append(out, v)
+	}
+	return out
+}
+
 // dedupeTypes removes duplicate types by name
 func dedupeTypes(types []TypeInfo) []TypeInfo {
 	seen := make(map[string]bool)
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSupertypeRef(t *testing.T) {
+	tests := []struct {
+		ref, lang, want string
+	}{
+		{"Reader", "go", "Reader"},
+		{"io.Reader", "go", "io.Reader"},
+		{"java.util.List<String>", "java", "java.util.List"},
+		{"Base()", "kotlin", "Base"},
+		{"fmt::Display", "rust", "fmt.Display"},
+		{"crate::models::Entity", "rust", "models.Entity"},
+		{"super::Entity", "rust", "Entity"},
+		{`\App\Models\User`, "php", "App.Models.User"},
+		{"React.Component<Props>", "typescript", "React.Component"},
+		{"std::exception", "cpp", "exception"},
+	}
+	for _, tt := range tests {
+		if got := supertypeRef(tt.ref, tt.lang); got != tt.want {
+			t.Errorf("supertypeRef(%q, %s) = %q, want %q", tt.ref, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestGoEmbeddedInterfacesKeepQualifier(t *testing.T) {
+	analysis := analyzeSource(t, "rc.go", `package rc
+
+import "io"
+
+type Closer interface{ Close() error }
+
+type ReadCloser interface {
+	io.Reader
+	Closer
+}
+`, DetailFull)
+	for _, typ := range analysis.Types {
+		if typ.Name == "ReadCloser" {
+			if want := []string{"io.Reader", "Closer"}; !reflect.DeepEqual(typ.Extends, want) {
+				t.Errorf("ReadCloser extends %q, want %q", typ.Extends, want)
+			}
+			return
+		}
+	}
+	t.Error("ReadCloser not found")
+}
+
+func TestBoundFirstParam(t *testing.T) {
+	tests := []struct {
+		params, lang, owner string
+		want                bool
+	}{
+		{"(self, x)", "python", "Server", true},
+		{"(cls)", "python", "Server", true},
+		{"(self: 'Server', x)", "python", "Server", true},
+		{"(self, x)", "python", "", false}, // Free function
+		{"(x, self)", "python", "Server", false},
+		{"(&self, x: i32)", "rust", "", true},
+		{"(&mut self)", "rust", "", true},
+		{"(&'a self)", "rust", "", true},
+		{"(&'a mut self)", "rust", "", true},
+		{"(mut self)", "rust", "", true},
+		{"(self: Box<Self>)", "rust", "", true},
+		{"(selfish: i32)", "rust", "", false},
+		{"(x: &Self)", "rust", "", false},
+		{"(self)", "go", "", false},
+	}
+	for _, tt := range tests {
+		if got := boundFirstParam(tt.params, tt.lang, tt.owner); got != tt.want {
+			t.Errorf("boundFirstParam(%q, %s, %q) = %v, want %v", tt.params, tt.lang, tt.owner, got, tt.want)
+		}
+	}
+}
@@ -18,9 +18,14 @@ func loadLibrary(path string) (uintptr, error) {
 	return lib, nil
 }
 
-// getLanguageFunc gets the tree_sitter_<lang> function from the library
-func getLanguageFunc(lib uintptr, lang string) (func() unsafe.Pointer, error) {
+// getLanguageFunc gets the language function named symbol (tree_sitter_<lang>)
+// from the library
+func getLanguageFunc(lib uintptr, symbol string) (func() unsafe.Pointer, error) {
+	sym, err := purego.Dlsym(lib, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("dlsym %s: %w", symbol, err)
+	}
 	var langFunc func() unsafe.Pointer
-	purego.RegisterLibFunc(&langFunc, lib, fmt.Sprintf("tree_sitter_%s", lang))
+	purego.RegisterFunc(&langFunc, sym)
 	return langFunc, nil
 }
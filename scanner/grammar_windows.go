@@ -17,12 +17,12 @@ func loadLibrary(path string) (uintptr, error) {
 	return uintptr(handle), nil
 }
 
-// getLanguageFunc gets the tree_sitter_<lang> function from the DLL
-func getLanguageFunc(lib uintptr, lang string) (func() unsafe.Pointer, error) {
-	procName := fmt.Sprintf("tree_sitter_%s", lang)
-	proc, err := syscall.GetProcAddress(syscall.Handle(lib), procName)
+// getLanguageFunc gets the language function named symbol (tree_sitter_<lang>)
+// from the DLL
+func getLanguageFunc(lib uintptr, symbol string) (func() unsafe.Pointer, error) {
+	proc, err := syscall.GetProcAddress(syscall.Handle(lib), symbol)
 	if err != nil {
-		return nil, fmt.Errorf("GetProcAddress %s: %w", procName, err)
+		return nil, fmt.Errorf("GetProcAddress %s: %w", symbol, err)
 	}
 
 	// Create a wrapper function that calls the proc
@@ -0,0 +1,417 @@
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"codemap/config"
+)
+
+// IgnoreFile is codemap's own ignore file. It uses .gitignore syntax, may
+// appear in any directory and takes precedence over git's ignore files.
+const IgnoreFile = ".codemapignore"
+
+// IgnoreMatcher decides which paths scanning skips. A path is ignored when
+// any directory on it (or its own name) is one of the ignored directory
+// names, or when git would ignore it: patterns come from .gitignore files
+// in every directory from the repository top down, .git/info/exclude and
+// git's global excludes file, and then .codemapignore files. As in git, a
+// later pattern overrides an earlier one, a file can't be re-included when
+// a parent directory is excluded, and each file's patterns are relative to
+// its directory.
+type IgnoreMatcher struct {
+	dirs   map[string]bool // Ignored directory names
+	top    string          // Worktree top, or the scanned root outside git
+	prefix string          // Scanned root relative to top ("" when the same)
+	global []ignorePattern // Global excludes and .git/info/exclude, relative to top
+	gitDir string          // Git directory ("" outside git)
+
+	// IncludeGenerated keeps generated files in dependency scans (see SkipsAnalysis)
+	IncludeGenerated bool
+
+	mu             sync.Mutex
+	patterns       map[string][2][]ignorePattern // Top-relative dir -> .gitignore, .codemapignore patterns
+	excluded       map[string]bool               // Top-relative dir -> ignored
+	attributes     map[string][]attributeRule    // Top-relative dir -> .gitattributes linguist rules
+	infoAttributes []attributeRule               // .git/info/attributes
+	sources        []string
+}
+
+// ignorePattern is one line of an ignore file.
+type ignorePattern struct {
+	re      *regexp.Regexp
+	base    string // Top-relative directory of the ignore file ("" for top)
+	negate  bool
+	dirOnly bool
+}
+
+// LoadGitignore returns the matcher for the project at root: the default
+// ignored directory names adjusted by the ignore section of the user and
+// project config, and the ignore files that apply under root.
+func LoadGitignore(root string) *IgnoreMatcher {
+	settings := config.LoadIgnore(root)
+	m := NewIgnoreMatcher(root, settings.AddDirs, settings.RemoveDirs)
+	m.IncludeGenerated = settings.IncludeGenerated
+	return m
+}
+
+// NewIgnoreMatcher returns a matcher for root that skips IgnoredDirs plus
+// addDirs, minus removeDirs.
+func NewIgnoreMatcher(root string, addDirs, removeDirs []string) *IgnoreMatcher {
+	m := &IgnoreMatcher{
+		dirs:       make(map[string]bool),
+		top:        root,
+		patterns:   make(map[string][2][]ignorePattern),
+		excluded:   make(map[string]bool),
+		attributes: make(map[string][]attributeRule),
+	}
+	for name := range IgnoredDirs {
+		m.dirs[name] = true
+	}
+	for _, name := range addDirs {
+		m.dirs[name] = true
+	}
+	for _, name := range removeDirs {
+		delete(m.dirs, name)
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return m
+	}
+	top, gitDir := findGitDir(abs)
+	if top == "" {
+		m.top = abs
+		return m
+	}
+	m.top, m.gitDir = top, gitDir
+	if rel, err := filepath.Rel(top, abs); err == nil && rel != "." {
+		m.prefix = filepath.ToSlash(rel)
+	}
+	m.infoAttributes = readAttributesFile(filepath.Join(gitDir, "info", "attributes"), "")
+	for _, file := range []string{globalExcludesFile(top), filepath.Join(gitDir, "info", "exclude")} {
+		if patterns := readIgnoreFile(file, ""); patterns != nil {
+			m.global = append(m.global, patterns...)
+			m.sources = append(m.sources, file)
+		}
+	}
+	// Ignore files from the top down to root are always consulted; load
+	// them now so Sources lists them
+	m.dirPatterns("")
+	if m.prefix != "" {
+		parts := strings.Split(m.prefix, "/")
+		for i := range parts {
+			m.dirPatterns(strings.Join(parts[:i+1], "/"))
+		}
+	}
+	return m
+}
+
+// Sources returns the ignore files loaded so far.
+func (m *IgnoreMatcher) Sources() []string {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.sources...)
+}
+
+// ExcludeFile returns the path of the repository's .git/info/exclude, which
+// may not exist, or "" outside git.
+func (m *IgnoreMatcher) ExcludeFile() string {
+	if m == nil || m.gitDir == "" {
+		return ""
+	}
+	return filepath.Join(m.gitDir, "info", "exclude")
+}
+
+// IsIgnoreFile reports whether name is the base name of a per-directory
+// ignore file: .gitignore or .codemapignore.
+func IsIgnoreFile(name string) bool {
+	return name == ".gitignore" || name == IgnoreFile
+}
+
+// IgnoresName reports whether name is an ignored directory name.
+func (m *IgnoreMatcher) IgnoresName(name string) bool {
+	if m == nil {
+		return IgnoredDirs[name]
+	}
+	return m.dirs[name]
+}
+
+// MatchesPath reports whether the file at relPath (relative to the scanned
+// root) is ignored.
+func (m *IgnoreMatcher) MatchesPath(relPath string) bool {
+	return m.Match(relPath, false)
+}
+
+// Match reports whether relPath (relative to the scanned root) is ignored;
+// isDir says whether it names a directory, which directory-only patterns
+// ("build/") need to know.
+func (m *IgnoreMatcher) Match(relPath string, isDir bool) bool {
+	rel := filepath.ToSlash(filepath.Clean(relPath))
+	if rel == "." || rel == "" {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for _, name := range parts {
+		if m.IgnoresName(name) {
+			return true // Like the walker always did, this also skips files named .DS_Store or .env
+		}
+	}
+	if m == nil {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := 1; i < len(parts); i++ {
+		if m.dirExcluded(m.topPath(strings.Join(parts[:i], "/"))) {
+			return true
+		}
+	}
+	if isDir {
+		return m.dirExcluded(m.topPath(rel))
+	}
+	return m.excludes(m.topPath(rel), false)
+}
+
+// topPath converts a root-relative path to a top-relative one.
+func (m *IgnoreMatcher) topPath(rel string) string {
+	if m.prefix == "" {
+		return rel
+	}
+	return m.prefix + "/" + rel
+}
+
+// dirExcluded reports (and caches) whether the patterns exclude the
+// top-relative directory dir. Callers hold m.mu and check its parents.
+func (m *IgnoreMatcher) dirExcluded(dir string) bool {
+	excluded, ok := m.excluded[dir]
+	if !ok {
+		excluded = m.excludes(dir, true)
+		m.excluded[dir] = excluded
+	}
+	return excluded
+}
+
+// excludes applies every pattern that can see the top-relative path p, in
+// precedence order; the last match decides. Callers hold m.mu.
+func (m *IgnoreMatcher) excludes(p string, isDir bool) bool {
+	var dirs []string // Directories from top down to p's parent
+	dirs = append(dirs, "")
+	if parent := path.Dir(p); parent != "." {
+		parts := strings.Split(parent, "/")
+		for i := range parts {
+			dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+		}
+	}
+
+	excluded := matchPatterns(m.global, p, isDir, false)
+	for kind := 0; kind < 2; kind++ {
+		for _, dir := range dirs {
+			excluded = matchPatterns(m.dirPatterns(dir)[kind], p, isDir, excluded)
+		}
+	}
+	return excluded
+}
+
+// dirPatterns loads the .gitignore and .codemapignore of a top-relative
+// directory. Callers hold m.mu.
+func (m *IgnoreMatcher) dirPatterns(dir string) [2][]ignorePattern {
+	if patterns, ok := m.patterns[dir]; ok {
+		return patterns
+	}
+	var patterns [2][]ignorePattern
+	for i, name := range []string{".gitignore", IgnoreFile} {
+		file := filepath.Join(m.top, filepath.FromSlash(dir), name)
+		if patterns[i] = readIgnoreFile(file, dir); patterns[i] != nil {
+			m.sources = append(m.sources, file)
+		}
+	}
+	m.patterns[dir] = patterns
+	return patterns
+}
+
+// matchPatterns returns whether the top-relative path p is excluded after
+// applying patterns to the verdict so far.
+func matchPatterns(patterns []ignorePattern, p string, isDir, excluded bool) bool {
+	for _, ip := range patterns {
+		if ip.dirOnly && !isDir {
+			continue
+		}
+		rel := p
+		if ip.base != "" {
+			if !strings.HasPrefix(p, ip.base+"/") {
+				continue
+			}
+			rel = p[len(ip.base)+1:]
+		}
+		if ip.re.MatchString(rel) {
+			excluded = !ip.negate
+		}
+	}
+	return excluded
+}
+
+// readIgnoreFile parses an ignore file whose patterns are relative to the
+// top-relative directory base. Returns nil when the file can't be read.
+func readIgnoreFile(file, base string) []ignorePattern {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	patterns := []ignorePattern{}
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		if ip, ok := parseIgnoreLine(lines.Text(), base); ok {
+			patterns = append(patterns, ip)
+		}
+	}
+	return patterns
+}
+
+// parseIgnoreLine compiles one line of gitignore syntax.
+func parseIgnoreLine(line, base string) (ignorePattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+	// Trailing spaces are dropped unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	ip := ignorePattern{base: base}
+	if strings.HasPrefix(line, "!") {
+		ip.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		ip.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	// A slash before the end anchors the pattern to the file's directory;
+	// otherwise it matches a name at any depth
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+
+	re, err := regexp.Compile("^" + globRegexp(line) + "$")
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	ip.re = re
+	return ip, true
+}
+
+// globRegexp translates a gitignore glob into a regular expression: * and
+// ? stay within a path component, "**/" matches any leading directories,
+// "/**/" zero or more directories and a trailing "/**" everything inside.
+func globRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob) && (i == 0 || glob[i-1] == '/'):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// findGitDir returns the worktree top enclosing dir and its git directory,
+// or empty strings outside a git repository. A .git file (worktrees,
+// submodules) points to the git directory; info/exclude lives in the
+// common directory it names, if any.
+func findGitDir(dir string) (top, gitDir string) {
+	for d := dir; ; d = filepath.Dir(d) {
+		dotGit := filepath.Join(d, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return d, dotGit
+			}
+			data, err := os.ReadFile(dotGit)
+			if err != nil || !strings.HasPrefix(string(data), "gitdir:") {
+				return d, dotGit
+			}
+			gitDir = strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(d, gitDir)
+			}
+			if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+				c := strings.TrimSpace(string(common))
+				if !filepath.IsAbs(c) {
+					c = filepath.Join(gitDir, c)
+				}
+				gitDir = c
+			}
+			return d, gitDir
+		}
+		if d == filepath.Dir(d) {
+			return "", ""
+		}
+	}
+}
+
+// globalExcludesFile returns git's core.excludesFile, or its default
+// location ($XDG_CONFIG_HOME/git/ignore, else ~/.config/git/ignore).
+func globalExcludesFile(top string) string {
+	cmd := exec.Command("git", "config", "--path", "--get", "core.excludesFile")
+	cmd.Dir = top
+	if out, err := cmd.Output(); err == nil {
+		if file := strings.TrimSpace(string(out)); file != "" {
+			return file
+		}
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "git", "ignore")
+}
@@ -0,0 +1,181 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree writes files, keyed by slash-separated paths, under root.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		abs := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// isolateGit keeps the user's git configuration and global excludes out of
+// a test.
+func isolateGit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+}
+
+func TestIgnorePatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string // Lines of an ignore file at the top
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		{"name at top", []string{"*.log"}, "debug.log", false, true},
+		{"name at any depth", []string{"*.log"}, "a/b/debug.log", false, true},
+		{"star stays in a component", []string{"*.log"}, "debug.log/x", false, false},
+		{"leading slash anchors", []string{"/out"}, "out", false, true},
+		{"anchored doesn't match deeper", []string{"/out"}, "src/out", false, false},
+		{"inner slash anchors", []string{"docs/*.md"}, "docs/a.md", false, true},
+		{"inner slash not below", []string{"docs/*.md"}, "docs/sub/a.md", false, false},
+		{"inner slash not deeper", []string{"docs/*.md"}, "x/docs/a.md", false, false},
+		{"directory only matches directories", []string{"out/"}, "out", true, true},
+		{"directory only skips files", []string{"out/"}, "out", false, false},
+		{"leading double star", []string{"**/tmp"}, "a/b/tmp", false, true},
+		{"middle double star, no directories", []string{"a/**/b"}, "a/b", false, true},
+		{"middle double star, directories", []string{"a/**/b"}, "a/x/y/b", false, true},
+		{"trailing double star", []string{"logs/**"}, "logs/a/b.txt", false, true},
+		{"trailing double star not the directory", []string{"logs/**"}, "logs", true, false},
+		{"question mark", []string{"?.go"}, "a.go", false, true},
+		{"question mark is one character", []string{"?.go"}, "ab.go", false, false},
+		{"character class", []string{"file[0-9].txt"}, "file7.txt", false, true},
+		{"negated character class", []string{"[!a]b"}, "ab", false, false},
+		{"negated character class matches", []string{"[!a]b"}, "cb", false, true},
+		{"unclosed bracket is literal", []string{"a[b"}, "a[b", false, true},
+		{"negation re-includes", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"negation leaves others", []string{"*.log", "!keep.log"}, "other.log", false, true},
+		{"later pattern wins", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"escaped bang", []string{`\!important`}, "!important", false, true},
+		{"escaped hash", []string{`\#notes`}, "#notes", false, true},
+		{"comment", []string{"#notes"}, "#notes", false, false},
+		{"trailing spaces dropped", []string{"spaced   "}, "spaced", false, true},
+		{"escaped trailing space kept", []string{`spaced\ `}, "spaced ", false, true},
+		{"carriage return dropped", []string{"dos.txt\r"}, "dos.txt", false, true},
+		{"escaped glob character", []string{`\*.txt`}, "a.txt", false, false},
+		{"literal dot", []string{"a.go"}, "abgo", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patterns []ignorePattern
+			for _, line := range tt.lines {
+				if ip, ok := parseIgnoreLine(line, ""); ok {
+					patterns = append(patterns, ip)
+				}
+			}
+			if got := matchPatterns(patterns, tt.path, tt.isDir, false); got != tt.excluded {
+				t.Errorf("%q excludes %q: %v, want %v", tt.lines, tt.path, got, tt.excluded)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	isolateGit(t)
+	top := t.TempDir()
+	writeTree(t, top, map[string]string{
+		".git/info/exclude": "*.swp\n",
+		".gitignore":        "*.log\n!keep.log\n/out\nsecrets/\ncache/\n!cache/keep.txt\n",
+		"app/.gitignore":    "/local.txt\n*.tmp\n!important.tmp\n",
+		".codemapignore":    "fixtures/\n!trace.log\n",
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"main.go", false, false},
+		{"debug.log", false, true},
+		{"keep.log", false, false},
+		{"trace.log", false, false}, // .codemapignore overrides .gitignore
+		{"app/debug.log", false, true},
+		{"file.swp", false, true},                  // .git/info/exclude
+		{"out", true, true},                        // Anchored at the top
+		{"out/bundle.js", false, true},             // Inside an excluded directory
+		{"app/out/bundle.js", false, false},        // Anchored elsewhere
+		{"secrets", true, true},                    // Directory only
+		{"secrets", false, false},                  // ... so not a file named secrets
+		{"app/secrets/key.pem", false, true},       // Unanchored directory at any depth
+		{"cache/keep.txt", false, true},            // Can't re-include under an excluded directory
+		{"app/local.txt", false, true},             // Anchored to app/
+		{"local.txt", false, false},                // ... not the top
+		{"app/sub/local.txt", false, false},        // ... nor deeper
+		{"app/scratch.tmp", false, true},           // Nested ignore file
+		{"app/important.tmp", false, false},        // Nested negation
+		{"scratch.tmp", false, false},              // Nested patterns don't apply above
+		{"test/fixtures/data.json", false, true},   // .codemapignore
+		{"node_modules/lib/index.js", false, true}, // Ignored directory name
+		{".DS_Store", false, true},                 // Ignored name as a file
+		{".", true, false},
+	}
+	m := NewIgnoreMatcher(top, nil, nil)
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+	if got, want := len(m.Sources()), 4; got != want {
+		t.Errorf("Sources = %q, want %d files", m.Sources(), want)
+	}
+
+	// A subdirectory root sees the patterns above it, relative to their files
+	sub := NewIgnoreMatcher(filepath.Join(top, "app"), nil, nil)
+	for path, want := range map[string]bool{
+		"local.txt":     true,
+		"sub/local.txt": false,
+		"scratch.tmp":   true,
+		"debug.log":     true,
+		"keep.log":      false,
+		"out/x.js":      false,
+	} {
+		if got := sub.MatchesPath(path); got != want {
+			t.Errorf("from app/, MatchesPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestIgnoredDirNames(t *testing.T) {
+	isolateGit(t)
+	root := t.TempDir() // Outside git the root's ignore files still apply
+	writeTree(t, root, map[string]string{".gitignore": "*.tmp\n"})
+
+	m := NewIgnoreMatcher(root, []string{"generated"}, []string{"vendor"})
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{"scratch.tmp", true},
+		{"generated/api.go", true},
+		{"vendor/lib/lib.go", false},
+		{"node_modules/x/index.js", true},
+		{"src/.venv/bin/activate", true},
+	}
+	for _, tt := range tests {
+		if got := m.MatchesPath(tt.path); got != tt.want {
+			t.Errorf("MatchesPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	var none *IgnoreMatcher
+	if !none.MatchesPath("vendor/x.go") || none.MatchesPath("src/x.go") {
+		t.Error("nil matcher doesn't fall back to IgnoredDirs")
+	}
+}
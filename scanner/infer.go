@@ -0,0 +1,579 @@
+package scanner
+
+import (
+	"strings"
+	"unicode"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Inference methods recorded in CallInfo.Inference.
+const (
+	InferReceiver = "receiver" // self/this or the Go method receiver
+	InferParam    = "param"    // Declared parameter type
+	InferLocal    = "local"    // Typed local or constructor (x := T{}, new T(), T())
+	InferField    = "field"    // Declared field type of a known type (s.repo)
+	InferStatic   = "static"   // Receiver is a type name (Type.method())
+	InferImport   = "import"   // Receiver is an import alias (pkg.Func())
+)
+
+// namedFunctionKinds are definitions that own a scope of parameters and
+// locals. Closures and lambdas are left out so that their bodies share the
+// scope of the function they appear in.
+var namedFunctionKinds = map[string]bool{
+	"function_declaration":    true,
+	"function_definition":     true,
+	"function_item":           true,
+	"method_declaration":      true,
+	"method_definition":       true,
+	"constructor_declaration": true,
+}
+
+// memberKinds are member accesses (obj.field) whose parts name a variable
+// and one of its fields.
+var memberKinds = map[string][2]string{
+	"selector_expression":      {"operand", "field"},    // Go
+	"attribute":                {"object", "attribute"}, // Python
+	"member_expression":        {"object", "property"},  // JS/TS
+	"field_access":             {"object", "field"},     // Java
+	"member_access_expression": {"expression", "name"},  // C#
+	"field_expression":         {"value", "field"},      // Rust
+}
+
+// binding is the inferred type of a name in scope, with the import path
+// of the package declaring it when that is an imported one.
+type binding struct {
+	typ string
+	pkg string
+	how string
+}
+
+// inferrer resolves call receivers to types or imported packages within
+// one parsed file, caching scopes and field types as it goes.
+type inferrer struct {
+	root     *tree_sitter.Node
+	content  []byte
+	aliases  map[string]string // Import alias -> import path
+	scopes   map[uintptr]map[string]binding
+	typeDefs map[string]*tree_sitter.Node
+	fields   map[string]map[string]binding
+}
+
+func newInferrer(root *tree_sitter.Node, content []byte, lang string) *inferrer {
+	return &inferrer{
+		root:    root,
+		content: content,
+		aliases: importAliases(root, content, lang),
+		scopes:  make(map[uintptr]map[string]binding),
+		fields:  make(map[string]map[string]binding),
+	}
+}
+
+// receiver infers what the receiver of a method call refers to: a type
+// (with how it was inferred, and the imported package declaring it) or an
+// imported package. All are empty when nothing is known.
+func (in *inferrer) receiver(recv *tree_sitter.Node) (typ, pkg, how string) {
+	fn := enclosingFunction(recv)
+	text := recv.Utf8Text(in.content)
+
+	switch recv.Kind() {
+	case "this", "self":
+		return in.owner(fn), "", InferReceiver
+	case "identifier", "simple_identifier", "variable_name", "name":
+		if text == "self" || text == "this" || text == "$this" {
+			return in.owner(fn), "", InferReceiver
+		}
+		if b, ok := in.scope(fn)[text]; ok {
+			return b.typ, b.pkg, b.how
+		}
+		if path, ok := in.aliases[text]; ok {
+			return "", path, InferImport
+		}
+		if ft, ok := in.fieldTypes(in.owner(fn))[text]; ok { // implicit this.field
+			return ft.typ, ft.pkg, InferField
+		}
+		if in.typeDef(text) != nil || isTypeLikeName(text) {
+			return text, "", InferStatic
+		}
+		return "", "", ""
+	}
+
+	if path, ok := in.aliases[text]; ok { // import pkg.mod; pkg.mod.f()
+		return "", path, InferImport
+	}
+	if fields, ok := memberKinds[recv.Kind()]; ok {
+		obj, field := recv.ChildByFieldName(fields[0]), recv.ChildByFieldName(fields[1])
+		if obj == nil || field == nil {
+			return "", "", ""
+		}
+		base, _, _ := in.receiver(obj)
+		if base == "" {
+			return "", "", ""
+		}
+		if ft, ok := in.fieldTypes(base)[field.Utf8Text(in.content)]; ok {
+			return ft.typ, ft.pkg, InferField
+		}
+		return "", "", ""
+	}
+	if b := in.constructed(recv, InferLocal); b.typ != "" { // new Foo().bar()
+		return b.typ, b.pkg, b.how
+	}
+	return "", "", ""
+}
+
+// owner returns the type owning the method fn.
+func (in *inferrer) owner(fn *tree_sitter.Node) string {
+	if fn == nil {
+		return ""
+	}
+	if recv := fn.ChildByFieldName("receiver"); recv != nil { // Go
+		for _, b := range in.params(recv, InferReceiver) {
+			return b.typ
+		}
+	}
+	if name := symbolName(fn); name != nil {
+		return ownerOf(name, in.content)
+	}
+	return ""
+}
+
+// scope returns the typed names visible in fn: the Go receiver, parameters
+// and locals declared with a type or initialized by a constructor.
+func (in *inferrer) scope(fn *tree_sitter.Node) map[string]binding {
+	if fn == nil {
+		return nil
+	}
+	if vars, ok := in.scopes[fn.Id()]; ok {
+		return vars
+	}
+
+	vars := make(map[string]binding)
+	if recv := fn.ChildByFieldName("receiver"); recv != nil {
+		for name, b := range in.params(recv, InferReceiver) {
+			vars[name] = b
+		}
+	}
+	if params := fn.ChildByFieldName("parameters"); params != nil {
+		for name, b := range in.params(params, InferParam) {
+			vars[name] = b
+		}
+	}
+	if body := fn.ChildByFieldName("body"); body != nil {
+		in.locals(body, vars)
+	}
+	in.scopes[fn.Id()] = vars
+	return vars
+}
+
+// params collects the declared types of a parameter list.
+func (in *inferrer) params(list *tree_sitter.Node, how string) map[string]binding {
+	vars := make(map[string]binding)
+	for i := uint(0); i < list.NamedChildCount(); i++ {
+		param := list.NamedChild(i)
+		t := param.ChildByFieldName("type")
+		if t == nil {
+			continue
+		}
+		b := in.typed(t, how)
+		if b.typ == "" {
+			continue
+		}
+		for _, name := range declaredNames(param, in.content) {
+			vars[name] = b
+		}
+	}
+	return vars
+}
+
+// locals walks a function body for declarations whose type is written out
+// or follows from the initializer. The first declaration of a name wins.
+func (in *inferrer) locals(n *tree_sitter.Node, vars map[string]binding) {
+	bind := func(name string, b binding) {
+		if _, ok := vars[name]; !ok && name != "" && b.typ != "" {
+			vars[name] = b
+		}
+	}
+
+	switch n.Kind() {
+	case "short_var_declaration": // Go: a, b := T{}, &U{}
+		left, right := n.ChildByFieldName("left"), n.ChildByFieldName("right")
+		if left != nil && right != nil && left.NamedChildCount() == right.NamedChildCount() {
+			for i := uint(0); i < left.NamedChildCount(); i++ {
+				bind(left.NamedChild(i).Utf8Text(in.content), in.constructed(right.NamedChild(i), InferLocal))
+			}
+		}
+	case "var_spec": // Go: var x T, var x = T{}
+		var b binding
+		if t := n.ChildByFieldName("type"); t != nil {
+			b = in.typed(t, InferLocal)
+		} else if v := n.ChildByFieldName("value"); v != nil && v.NamedChildCount() > 0 {
+			b = in.constructed(v.NamedChild(0), InferLocal)
+		}
+		for _, name := range declaredNames(n, in.content) {
+			bind(name, b)
+		}
+	case "assignment": // Python: x = T(), x: T = ...
+		if left := n.ChildByFieldName("left"); left != nil && left.Kind() == "identifier" {
+			if t := n.ChildByFieldName("type"); t != nil {
+				bind(left.Utf8Text(in.content), in.typed(t, InferLocal))
+			} else if right := n.ChildByFieldName("right"); right != nil {
+				bind(left.Utf8Text(in.content), in.constructed(right, InferLocal))
+			}
+		}
+	case "variable_declarator": // JS/TS, Java, C#
+		var b binding
+		t := n.ChildByFieldName("type")
+		if t == nil && n.Parent() != nil {
+			t = n.Parent().ChildByFieldName("type") // Java/C#: T x = ...
+		}
+		if t != nil {
+			b = in.typed(t, InferLocal)
+		}
+		if b.typ == "" || b.typ == "var" {
+			b = binding{}
+			if v := n.ChildByFieldName("value"); v != nil {
+				b = in.constructed(v, InferLocal)
+			}
+		}
+		if name := n.ChildByFieldName("name"); name != nil {
+			bind(name.Utf8Text(in.content), b)
+		} else if name := declaratorName(n); name != nil {
+			bind(name.Utf8Text(in.content), b)
+		}
+	}
+
+	for i := uint(0); i < n.NamedChildCount(); i++ {
+		in.locals(n.NamedChild(i), vars)
+	}
+}
+
+// typeDef returns the definition of the type named name in this file.
+func (in *inferrer) typeDef(name string) *tree_sitter.Node {
+	if in.typeDefs == nil {
+		in.typeDefs = make(map[string]*tree_sitter.Node)
+		var walk func(n *tree_sitter.Node)
+		walk = func(n *tree_sitter.Node) {
+			for i := uint(0); i < n.NamedChildCount(); i++ {
+				child := n.NamedChild(i)
+				if symbolKinds[child.Kind()] && !namedFunctionKinds[child.Kind()] {
+					if t := symbolText(child, in.content); t != "" && in.typeDefs[t] == nil {
+						in.typeDefs[t] = child
+					}
+				}
+				walk(child)
+			}
+		}
+		walk(in.root)
+	}
+	return in.typeDefs[name]
+}
+
+// fieldTypes returns the declared field types of the type named name,
+// including Python attributes assigned in __init__ from a constructor or
+// with an annotation.
+func (in *inferrer) fieldTypes(name string) map[string]binding {
+	if fields, ok := in.fields[name]; ok {
+		return fields
+	}
+	fields := make(map[string]binding)
+	in.fields[name] = fields
+	def := in.typeDef(name)
+	if def == nil {
+		return fields
+	}
+
+	var walk func(n *tree_sitter.Node)
+	walk = func(n *tree_sitter.Node) {
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			child := n.NamedChild(i)
+			switch {
+			case fieldKinds[child.Kind()]:
+				if t := child.ChildByFieldName("type"); t != nil {
+					for _, f := range fieldNames(child, in.content) {
+						if b := in.typed(t, InferField); b.typ != "" {
+							fields[strings.TrimPrefix(f, "$")] = b
+						}
+					}
+				}
+			case child.Kind() == "assignment":
+				if f := pythonField(child, def, in.content); f != "" {
+					var b binding
+					if t := child.ChildByFieldName("type"); t != nil {
+						b = in.typed(t, InferField)
+					} else if right := child.ChildByFieldName("right"); right != nil {
+						b = in.constructed(right, InferField)
+					}
+					if b.typ != "" {
+						fields[f] = b
+					}
+				}
+			case child.Kind() == "function_definition" && symbolText(child, in.content) == "__init__":
+				walk(child)
+			case symbolKinds[child.Kind()]:
+				// Nested definitions own their members
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(def)
+	return fields
+}
+
+// typed returns the binding of a written type.
+func (in *inferrer) typed(t *tree_sitter.Node, how string) binding {
+	typ := typeName(t, in.content)
+	return binding{typ, in.typePackage(t, typ), how}
+}
+
+// constructed returns the binding of the type an expression creates (see
+// constructedType).
+func (in *inferrer) constructed(expr *tree_sitter.Node, how string) binding {
+	typ := constructedType(expr, in.content)
+	return binding{typ, in.typePackage(expr, typ), how}
+}
+
+// typePackage returns the import path of the package declaring typ as n
+// writes it: the package of a qualifier that is an import alias
+// (*http.Client, requests.Session()), or the module a Python from-import or
+// JS/TS default import of the type names. It is "" for the file's own types.
+func (in *inferrer) typePackage(n *tree_sitter.Node, typ string) string {
+	if typ == "" {
+		return ""
+	}
+	text := n.Utf8Text(in.content)
+	if i := strings.Index(text, "."+typ); i > 0 {
+		j := i
+		for j > 0 && (text[j-1] == '.' || text[j-1] == '_' || isAlnum(text[j-1])) {
+			j--
+		}
+		return in.aliases[text[j:i]]
+	}
+	if path, ok := in.aliases[typ]; ok && !strings.HasPrefix(path, "typing.") {
+		// typing's List, Dict, ... annotate builtin types
+		return strings.TrimSuffix(path, "."+typ)
+	}
+	return ""
+}
+
+// isAlnum reports whether b is an ASCII letter or digit.
+func isAlnum(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
+
+// enclosingFunction returns the innermost named function containing n.
+func enclosingFunction(n *tree_sitter.Node) *tree_sitter.Node {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if namedFunctionKinds[p.Kind()] {
+			return p
+		}
+	}
+	return nil
+}
+
+// declaredNames returns the names a parameter or var spec declares:
+// Go allows several (a, b int); TS uses a pattern, Python a bare identifier.
+func declaredNames(decl *tree_sitter.Node, content []byte) []string {
+	cursor := decl.Walk()
+	defer cursor.Close()
+
+	var names []string
+	for _, n := range decl.ChildrenByFieldName("name", cursor) {
+		names = append(names, n.Utf8Text(content))
+	}
+	if len(names) > 0 {
+		return names
+	}
+	if p := decl.ChildByFieldName("pattern"); p != nil && p.Kind() == "identifier" {
+		return []string{p.Utf8Text(content)}
+	}
+	for i := uint(0); i < decl.NamedChildCount(); i++ {
+		switch child := decl.NamedChild(i); child.Kind() {
+		case "identifier", "simple_identifier", "variable_name":
+			return []string{child.Utf8Text(content)}
+		}
+	}
+	return nil
+}
+
+// constructedType returns the type an expression evidently creates:
+// T{} and &T{} (Go), new(T) (Go), new T() (JS/TS, Java, C#) and T()
+// for capitalized Python callees.
+func constructedType(expr *tree_sitter.Node, content []byte) string {
+	switch expr.Kind() {
+	case "composite_literal":
+		if t := expr.ChildByFieldName("type"); t != nil {
+			return typeName(t, content)
+		}
+	case "unary_expression":
+		if op := expr.ChildByFieldName("operand"); op != nil && op.Kind() == "composite_literal" {
+			return constructedType(op, content)
+		}
+	case "new_expression":
+		if c := expr.ChildByFieldName("constructor"); c != nil {
+			return typeName(c, content)
+		}
+	case "object_creation_expression":
+		if t := expr.ChildByFieldName("type"); t != nil {
+			return typeName(t, content)
+		}
+	case "call_expression":
+		fn, args := expr.ChildByFieldName("function"), expr.ChildByFieldName("arguments")
+		if fn != nil && args != nil && fn.Utf8Text(content) == "new" && args.NamedChildCount() > 0 {
+			return typeName(args.NamedChild(0), content)
+		}
+	case "call":
+		if fn := expr.ChildByFieldName("function"); fn != nil {
+			if name := typeName(fn, content); isTypeLikeName(name) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// typeName reduces a written type to the name of the type it refers to:
+// *pkg.Service, Service<T>, Optional[Service] and ": Service" all give
+// Service. Slices, maps and function types give "".
+func typeName(t *tree_sitter.Node, content []byte) string {
+	s := strings.TrimSpace(t.Utf8Text(content))
+	s = strings.TrimSpace(strings.TrimPrefix(s, ":")) // TS type annotation
+	s = strings.Trim(s, `"'`)                         // Python forward reference
+	for strings.HasPrefix(s, "Optional[") && strings.HasSuffix(s, "]") {
+		s = s[len("Optional[") : len(s)-1]
+	}
+	s = strings.TrimLeft(s, "*&")
+	if i := strings.IndexAny(s, "<[("); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSuffix(s, "?")
+	if i := strings.LastIndexAny(s, ".:\\"); i >= 0 {
+		s = s[i+1:]
+	}
+	if !isIdentifier(s) {
+		return ""
+	}
+	return s
+}
+
+// isIdentifier reports whether s is a plain identifier.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTypeLikeName reports whether name follows the type naming convention
+// of starting with an uppercase letter and containing a lowercase one
+// (User, not MAX_SIZE).
+func isTypeLikeName(name string) bool {
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		return false
+	}
+	return strings.IndexFunc(name, unicode.IsLower) >= 0
+}
+
+// importAliases maps the names an import binds in the file to the import
+// path: Go package names (explicit or the last path element), Python
+// modules (import a.b, import a.b as c, from a import b) and JS/TS
+// namespace and default imports.
+func importAliases(root *tree_sitter.Node, content []byte, lang string) map[string]string {
+	aliases := make(map[string]string)
+	text := func(n *tree_sitter.Node) string { return n.Utf8Text(content) }
+
+	var walk func(n *tree_sitter.Node)
+	walk = func(n *tree_sitter.Node) {
+		switch {
+		case lang == "go" && n.Kind() == "import_spec":
+			path := n.ChildByFieldName("path")
+			if path == nil {
+				return
+			}
+			imp := strings.Trim(text(path), "\"`")
+			name := imp[strings.LastIndex(imp, "/")+1:]
+			if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" && strings.Contains(imp, "/") {
+				// Major version suffix: example.com/mod/v2 is package mod
+				rest := imp[:strings.LastIndex(imp, "/")]
+				name = rest[strings.LastIndex(rest, "/")+1:]
+			}
+			if alias := n.ChildByFieldName("name"); alias != nil {
+				name = text(alias)
+			}
+			if name != "_" && name != "." {
+				aliases[name] = imp
+			}
+			return
+		case lang == "python" && n.Kind() == "import_statement":
+			for i := uint(0); i < n.NamedChildCount(); i++ {
+				child := n.NamedChild(i)
+				switch child.Kind() {
+				case "dotted_name":
+					aliases[text(child)] = text(child)
+				case "aliased_import":
+					if name, alias := child.ChildByFieldName("name"), child.ChildByFieldName("alias"); name != nil && alias != nil {
+						aliases[text(alias)] = text(name)
+					}
+				}
+			}
+			return
+		case lang == "python" && n.Kind() == "import_from_statement":
+			module := n.ChildByFieldName("module_name")
+			if module == nil {
+				return
+			}
+			prefix := text(module)
+			if !strings.HasSuffix(prefix, ".") {
+				prefix += "."
+			}
+			cursor := n.Walk()
+			defer cursor.Close()
+			for _, name := range n.ChildrenByFieldName("name", cursor) {
+				switch name.Kind() {
+				case "dotted_name":
+					aliases[text(&name)] = prefix + text(&name)
+				case "aliased_import":
+					if orig, alias := name.ChildByFieldName("name"), name.ChildByFieldName("alias"); orig != nil && alias != nil {
+						aliases[text(alias)] = prefix + text(orig)
+					}
+				}
+			}
+			return
+		case (lang == "typescript" || lang == "javascript") && n.Kind() == "import_statement":
+			source := n.ChildByFieldName("source")
+			if source == nil {
+				return
+			}
+			imp := strings.Trim(text(source), "\"'`")
+			for i := uint(0); i < n.NamedChildCount(); i++ {
+				clause := n.NamedChild(i)
+				if clause.Kind() != "import_clause" {
+					continue
+				}
+				for j := uint(0); j < clause.NamedChildCount(); j++ {
+					switch c := clause.NamedChild(j); c.Kind() {
+					case "identifier": // import api from "./api"
+						aliases[text(c)] = imp
+					case "namespace_import": // import * as api from "./api"
+						for k := uint(0); k < c.NamedChildCount(); k++ {
+							if id := c.NamedChild(k); id.Kind() == "identifier" {
+								aliases[text(id)] = imp
+							}
+						}
+					}
+				}
+			}
+			return
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+	return aliases
+}
@@ -0,0 +1,293 @@
+package scanner
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package ecosystems, named as in package URLs (purl types).
+const (
+	EcosystemGo    = "golang"
+	EcosystemNPM   = "npm"
+	EcosystemCargo = "cargo"
+	EcosystemPyPI  = "pypi"
+	EcosystemGem   = "gem"
+)
+
+// LockedPackage is a package at the version a lockfile resolved it to.
+type LockedPackage struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Direct       bool     `json:"direct,omitempty"`       // Declared by the project itself
+	Dev          bool     `json:"dev,omitempty"`          // Only needed by development dependencies
+	Checksum     string   `json:"checksum,omitempty"`     // Integrity hash as the lockfile records it
+	Dependencies []string `json:"dependencies,omitempty"` // Keys of the packages it depends on
+}
+
+// Key identifies a package within its lockfile.
+func (p *LockedPackage) Key() string {
+	return p.Name + "@" + p.Version
+}
+
+// Lockfile is the resolved dependency tree of one lockfile.
+type Lockfile struct {
+	Path      string           `json:"path"` // Relative to the scanned root
+	Ecosystem string           `json:"ecosystem"`
+	Packages  []*LockedPackage `json:"packages"` // Sorted by name and version
+
+	byKey map[string]*LockedPackage
+}
+
+// Lookup returns the package with the given key, or nil.
+func (l *Lockfile) Lookup(key string) *LockedPackage {
+	if l.byKey == nil {
+		l.byKey = make(map[string]*LockedPackage, len(l.Packages))
+		for _, p := range l.Packages {
+			l.byKey[p.Key()] = p
+		}
+	}
+	return l.byKey[key]
+}
+
+// Direct returns the packages the project declares itself, the roots of
+// the dependency tree.
+func (l *Lockfile) Direct() []*LockedPackage {
+	var direct []*LockedPackage
+	for _, p := range l.Packages {
+		if p.Direct {
+			direct = append(direct, p)
+		}
+	}
+	return direct
+}
+
+// PURL returns the package URL of a package of the lockfile.
+func (l *Lockfile) PURL(p *LockedPackage) string {
+	name := p.Name
+	switch l.Ecosystem {
+	case EcosystemNPM:
+		name = strings.Replace(name, "@", "%40", 1)
+	case EcosystemPyPI:
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	}
+	return "pkg:" + l.Ecosystem + "/" + name + "@" + url.PathEscape(p.Version)
+}
+
+// Inventory is the set of packages the project's lockfiles pin.
+type Inventory struct {
+	Root      string      `json:"root"`
+	Lockfiles []*Lockfile `json:"lockfiles"`
+}
+
+// lockfileParsers maps lockfile names to their parsers. A parser receives
+// the directory of the lockfile for the manifests next to it.
+var lockfileParsers = map[string]struct {
+	ecosystem string
+	parse     func(dir string, content []byte) *lockGraph
+}{
+	"go.sum":            {EcosystemGo, parseGoSum},
+	"package-lock.json": {EcosystemNPM, parsePackageLock},
+	"pnpm-lock.yaml":    {EcosystemNPM, parsePnpmLock},
+	"yarn.lock":         {EcosystemNPM, parseYarnLock},
+	"Cargo.lock":        {EcosystemCargo, parseCargoLock},
+	"poetry.lock":       {EcosystemPyPI, parsePoetryLock},
+	"uv.lock":           {EcosystemPyPI, parseUvLock},
+	"Gemfile.lock":      {EcosystemGem, parseGemfileLock},
+}
+
+// ReadInventory parses the lockfiles under root: go.sum, package-lock.json,
+// pnpm-lock.yaml, yarn.lock, Cargo.lock, poetry.lock, uv.lock and
+// Gemfile.lock.
+func ReadInventory(root string, ignore *IgnoreMatcher) *Inventory {
+	inv := &Inventory{Root: root}
+	filepath.Walk(root, func(p string, info os.FileInfo, _ error) error {
+		if info == nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if rel != "." && ignore.Match(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parser, ok := lockfileParsers[info.Name()]
+		if !ok || ignore.Match(rel, false) {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		g := parser.parse(filepath.Dir(p), data)
+		if g == nil || len(g.packages) == 0 {
+			return nil
+		}
+		inv.Lockfiles = append(inv.Lockfiles, g.lockfile(filepath.ToSlash(rel), parser.ecosystem))
+		return nil
+	})
+	return inv
+}
+
+// Resolve returns the locked package an import path refers to, and its
+// lockfile: the package of the same name, the one whose name is the
+// longest path prefix of the import (Go modules, npm subpath imports), or
+// the one whose normalized name is the import's top-level module (Python,
+// Rust and Ruby). Direct dependencies win over transitive ones.
+func (inv *Inventory) Resolve(importPath string) (*LockedPackage, *Lockfile) {
+	if inv == nil || importPath == "" {
+		return nil, nil
+	}
+	top := importPath
+	if i := strings.IndexAny(top, "./:"); i > 0 {
+		top = top[:i]
+	}
+	top = normalizePythonName(top)
+
+	var best *LockedPackage
+	var bestFile *Lockfile
+	bestRank := 0
+	for _, l := range inv.Lockfiles {
+		for _, p := range l.Packages {
+			rank := 0
+			switch {
+			case p.Name == importPath:
+				rank = 3 * len(p.Name)
+			case strings.HasPrefix(importPath, p.Name+"/"):
+				rank = 2 * len(p.Name)
+			case normalizePythonName(p.Name) == top:
+				rank = 1
+			}
+			if rank == 0 {
+				continue
+			}
+			if rank > bestRank || rank == bestRank && p.Direct && !best.Direct {
+				best, bestFile, bestRank = p, l, rank
+			}
+		}
+	}
+	return best, bestFile
+}
+
+// DependencyResolver returns a function naming the dependency that provides
+// an import path: the locked package Resolve finds, else the longest
+// dependency a manifest under root declares that is the import path or a
+// path prefix of it. The function returns "" for the standard library and
+// undeclared packages.
+func DependencyResolver(root string, ignore *IgnoreMatcher) func(importPath string) string {
+	inv := ReadInventory(root, ignore)
+	var declared []string
+	for _, names := range DependencyNames(ReadDependencies(root)) {
+		declared = append(declared, names...)
+	}
+	return func(importPath string) string {
+		if p, _ := inv.Resolve(importPath); p != nil {
+			return p.Name
+		}
+		best := ""
+		for _, name := range declared {
+			if (name == importPath || strings.HasPrefix(importPath, name+"/")) && len(name) > len(best) {
+				best = name
+			}
+		}
+		return best
+	}
+}
+
+// normalizePythonName lowercases a package name and folds the separators
+// PEP 503 treats alike ("PyYAML", "typing_extensions", "zope.interface").
+func normalizePythonName(name string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(name))
+}
+
+// lockGraph is what a lockfile parser produces: the packages by key, the
+// keys of the direct dependencies and whether each is a dev dependency.
+type lockGraph struct {
+	packages map[string]*LockedPackage
+	roots    map[string]bool // Key -> dev
+}
+
+func newLockGraph() *lockGraph {
+	return &lockGraph{packages: make(map[string]*LockedPackage), roots: make(map[string]bool)}
+}
+
+// add records a package, returning the one already recorded under its key.
+func (g *lockGraph) add(name, version string) *LockedPackage {
+	key := name + "@" + version
+	if p, ok := g.packages[key]; ok {
+		return p
+	}
+	p := &LockedPackage{Name: name, Version: version}
+	g.packages[key] = p
+	return p
+}
+
+// root marks a package as a direct dependency. A package both the runtime
+// and the development dependencies declare is a runtime one.
+func (g *lockGraph) root(key string, dev bool) {
+	if _, ok := g.packages[key]; !ok {
+		return
+	}
+	if existing, ok := g.roots[key]; ok {
+		dev = dev && existing
+	}
+	g.roots[key] = dev
+}
+
+// lockfile finishes the graph: it sets the Direct and Dev flags, dropping
+// dependencies on packages the lockfile doesn't list, and sorts the
+// packages. Packages only reachable from development roots are Dev.
+func (g *lockGraph) lockfile(rel, ecosystem string) *Lockfile {
+	runtime := make(map[string]bool)
+	dev := make(map[string]bool)
+	var visit func(key string, seen map[string]bool)
+	visit = func(key string, seen map[string]bool) {
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		p, ok := g.packages[key]
+		if !ok {
+			return
+		}
+		for _, dep := range p.Dependencies {
+			visit(dep, seen)
+		}
+	}
+	for key, isDev := range g.roots {
+		if isDev {
+			visit(key, dev)
+		} else {
+			visit(key, runtime)
+		}
+	}
+
+	l := &Lockfile{Path: rel, Ecosystem: ecosystem}
+	for key, p := range g.packages {
+		_, p.Direct = g.roots[key]
+		p.Dev = dev[key] && !runtime[key]
+		var deps []string
+		for _, dep := range p.Dependencies {
+			if _, ok := g.packages[dep]; ok && dep != key {
+				deps = append(deps, dep)
+			}
+		}
+		sort.Strings(deps)
+		p.Dependencies = dedupe(deps)
+		l.Packages = append(l.Packages, p)
+	}
+	sort.Slice(l.Packages, func(i, j int) bool {
+		a, b := l.Packages[i], l.Packages[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Version < b.Version
+	})
+	return l
+}
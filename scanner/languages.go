@@ -0,0 +1,298 @@
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"codemap/config"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// languageSpec is where a language's grammar and queries come from when
+// configuration adds or adjusts it. Empty fields select the built-in ones.
+type languageSpec struct {
+	custom  bool   // Not a built-in language
+	grammar string // Shared library (default: the grammar directory's)
+	symbol  string // Language function it exports (default: tree_sitter_<lang>)
+	symbols string // Query files
+	calls   string
+	refs    string
+}
+
+// registry holds the languages RegisterLanguages configured. It is
+// consulted before the built-in tables.
+var registry struct {
+	sync.RWMutex
+	exts     map[string]string // Lowercase extension -> language
+	shebangs map[string]string // Interpreter -> language
+	display  map[string]LangInfo
+	specs    map[string]languageSpec
+}
+
+// shebangToLang maps the interpreters of #! lines to the built-in
+// languages, for scripts without an extension.
+var shebangToLang = map[string]string{
+	"python":  "python",
+	"python2": "python",
+	"python3": "python",
+	"node":    "javascript",
+	"nodejs":  "javascript",
+	"ruby":    "ruby",
+	"bash":    "bash",
+	"sh":      "bash",
+	"php":     "php",
+	"Rscript": "r",
+	"swift":   "swift",
+}
+
+// RegisterLanguages configures the languages of the project at root, as
+// config.LoadLanguages reads them: new languages with their extensions,
+// shebangs, grammars and queries, and adjustments of built-in ones. Query
+// files in <root>/.codemap/queries and then config.LanguagesDir, named
+// <lang>.scm, <lang>.calls.scm and <lang>.refs.scm, override the built-in
+// queries of any language. It replaces the previous registration; invalid
+// definitions are reported in the error and left out.
+func RegisterLanguages(root string) error {
+	defs, err := config.LoadLanguages(root)
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	exts := make(map[string]string)
+	shebangs := make(map[string]string)
+	display := make(map[string]LangInfo)
+	specs := make(map[string]languageSpec)
+
+	userDir, _ := config.LanguagesDir()
+	projectDir := filepath.Join(root, ".codemap", "queries")
+	inDir := func(dir, file string) string {
+		if dir == "" {
+			return ""
+		}
+		path := filepath.Join(dir, file)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+		return ""
+	}
+	// Project query files win over definitions, which win over the user's
+	pick := func(lang, suffix, defined string) string {
+		if path := inDir(projectDir, lang+suffix); path != "" {
+			return path
+		}
+		if defined != "" {
+			return defined
+		}
+		return inDir(userDir, lang+suffix)
+	}
+
+	defined := make(map[string]config.Language)
+	for _, def := range defs {
+		_, builtin := LangDisplay[def.Name]
+		if !builtin && len(def.Extensions) == 0 && len(def.Shebangs) == 0 {
+			errs = append(errs, fmt.Errorf("language %s (%s): no extensions or shebangs to detect it by", def.Name, def.Dir))
+			continue
+		}
+		defined[def.Name] = def
+		for _, ext := range def.Extensions {
+			exts[strings.ToLower(ext)] = def.Name
+		}
+		for _, interpreter := range def.Shebangs {
+			shebangs[interpreter] = def.Name
+		}
+		if !builtin || def.Display != def.Name {
+			display[def.Name] = LangInfo{Short: def.Short, Full: def.Display}
+		}
+	}
+
+	names := make([]string, 0, len(LangDisplay)+len(defined))
+	for lang := range LangDisplay {
+		names = append(names, lang)
+	}
+	for lang := range defined {
+		if _, builtin := LangDisplay[lang]; !builtin {
+			names = append(names, lang)
+		}
+	}
+	for _, lang := range names {
+		var spec languageSpec
+		def, ok := defined[lang]
+		if ok {
+			_, builtin := LangDisplay[lang]
+			spec.custom = !builtin
+			spec.grammar = def.Path(def.Grammar)
+			spec.symbol = def.Symbol
+			if spec.grammar == "" && spec.custom {
+				spec.grammar = inDir(userDir, "libtree-sitter-"+lang+libraryExt())
+			}
+		}
+		spec.symbols = pick(lang, ".scm", def.Path(def.Queries.Symbols))
+		spec.calls = pick(lang, ".calls.scm", def.Path(def.Queries.Calls))
+		spec.refs = pick(lang, ".refs.scm", def.Path(def.Queries.Refs))
+		if spec != (languageSpec{}) {
+			specs[lang] = spec
+		}
+	}
+
+	registry.Lock()
+	registry.exts, registry.shebangs, registry.display, registry.specs = exts, shebangs, display, specs
+	registry.Unlock()
+	return errors.Join(errs...)
+}
+
+// specFor returns the configured spec of lang, zero for a built-in
+// language used as is.
+func specFor(lang string) languageSpec {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.specs[lang]
+}
+
+// LanguageInfo returns the display names of a language, built-in or
+// configured; both are empty for unknown languages.
+func LanguageInfo(lang string) LangInfo {
+	registry.RLock()
+	info, ok := registry.display[lang]
+	registry.RUnlock()
+	if ok {
+		return info
+	}
+	return LangDisplay[lang]
+}
+
+// DetectLanguage returns the language name for a file path: by its
+// extension, or for executable files without one by the interpreter of a
+// #! line. Files named like Makefile or LICENSE aren't opened.
+func DetectLanguage(filePath string) string {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	registry.RLock()
+	lang, ok := registry.exts[ext]
+	registry.RUnlock()
+	if ok {
+		return lang
+	}
+	if lang := extToLang[ext]; lang != "" || ext != "" {
+		return lang
+	}
+	if knownFiles[filepath.Base(filePath)] || !executable(filePath) {
+		return ""
+	}
+	return shebangLanguage(filePath)
+}
+
+// knownFiles are names of extensionless files that aren't scripts.
+var knownFiles = map[string]bool{
+	"AUTHORS": true, "CHANGELOG": true, "CODEOWNERS": true, "CONTRIBUTORS": true,
+	"COPYING": true, "Containerfile": true, "Dockerfile": true, "GNUmakefile": true,
+	"Gemfile": true, "Jenkinsfile": true, "LICENCE": true, "LICENSE": true,
+	"Makefile": true, "NOTICE": true, "OWNERS": true, "Procfile": true,
+	"README": true, "Rakefile": true, "VERSION": true, "Vagrantfile": true,
+	"makefile": true,
+}
+
+// executable reports whether the file at path may be run; scripts started
+// by their #! line must be. Windows has no execute bits, so any file may.
+func executable(path string) bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode()&0o111 != 0
+}
+
+// shebangLanguage returns the language of the script at path named by its
+// #! line ("#!/usr/bin/env python3"), or "".
+func shebangLanguage(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	line, err := bufio.NewReaderSize(f, 128).ReadSlice('\n')
+	if (err != nil && len(line) == 0) || !strings.HasPrefix(string(line), "#!") {
+		return ""
+	}
+
+	fields := strings.Fields(string(line[2:]))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, arg := range fields[1:] {
+			if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+				interpreter = filepath.Base(arg)
+				break
+			}
+		}
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+	// python3.12 is python3, which is python
+	versioned, _, _ := strings.Cut(interpreter, ".")
+	for _, name := range []string{interpreter, versioned, strings.TrimRight(versioned, "0123456789")} {
+		if lang, ok := registry.shebangs[name]; ok {
+			return lang
+		}
+		if lang, ok := shebangToLang[name]; ok {
+			return lang
+		}
+	}
+	return ""
+}
+
+// libraryExt returns the file extension of shared libraries on this OS.
+func libraryExt() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return ".dylib"
+	case "windows":
+		return ".dll"
+	default:
+		return ".so"
+	}
+}
+
+// compileQueryFile compiles a user-supplied query, reporting an error at its
+// position in the file.
+func compileQueryFile(language *tree_sitter.Language, path string) (*tree_sitter.Query, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	query, qerr := tree_sitter.NewQuery(language, string(source))
+	if qerr != nil {
+		return nil, fmt.Errorf("%s:%d:%d: %s", path, qerr.Row+1, qerr.Column+1, describeQueryError(qerr))
+	}
+	return query, nil
+}
+
+// describeQueryError explains a query error without its position.
+func describeQueryError(qerr *tree_sitter.QueryError) string {
+	message := strings.TrimSpace(qerr.Message)
+	switch qerr.Kind {
+	case tree_sitter.QueryErrorSyntax:
+		return "invalid syntax" // The message quotes the source line
+	case tree_sitter.QueryErrorNodeType:
+		return fmt.Sprintf("invalid node type %q", message)
+	case tree_sitter.QueryErrorField:
+		return fmt.Sprintf("invalid field name %q", message)
+	case tree_sitter.QueryErrorCapture:
+		return fmt.Sprintf("invalid capture name %q", message)
+	case tree_sitter.QueryErrorPredicate:
+		return "invalid predicate: " + message
+	case tree_sitter.QueryErrorStructure:
+		return "impossible pattern"
+	}
+	return message
+}
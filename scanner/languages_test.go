@@ -0,0 +1,202 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// isolateLanguages points the user config at an empty directory and
+// restores the built-in languages when the test ends.
+func isolateLanguages(t *testing.T) (userDir string) {
+	t.Helper()
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Cleanup(func() {
+		registry.Lock()
+		registry.exts, registry.shebangs, registry.display, registry.specs = nil, nil, nil, nil
+		registry.Unlock()
+	})
+	return filepath.Join(config, "codemap")
+}
+
+// writeScript writes an executable file.
+func writeScript(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShebangLanguage(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"#!/usr/bin/env python3\n", "python"},
+		{"#!/usr/bin/python3.12\n", "python"},
+		{"#!/bin/sh -e\n", "bash"},
+		{"#!/usr/bin/env -S node --no-warnings\n", "javascript"},
+		{"#!/usr/bin/env RUBYOPT=-w ruby\n", "ruby"},
+		{"#!/usr/bin/env Rscript", "r"}, // Nothing but the line
+		{"#!/usr/bin/perl\n", ""},
+		{"#!\n", ""},
+		{"# python3\n", ""},
+		{"", ""},
+	}
+	dir := t.TempDir()
+	for i, tt := range tests {
+		path := filepath.Join(dir, "script"+string(rune('a'+i)))
+		writeScript(t, path, tt.line)
+		if got := shebangLanguage(path); got != tt.want {
+			t.Errorf("shebangLanguage(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+	if got := shebangLanguage(filepath.Join(dir, "missing")); got != "" {
+		t.Errorf("shebangLanguage(missing) = %q, want \"\"", got)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"main.go":   "package main\n",
+		"APP.PY":    "",
+		"notes":     "#!/usr/bin/env python3\n", // Not executable
+		"data.json": "{}",
+	})
+	writeScript(t, filepath.Join(dir, "deploy"), "#!/bin/bash\n")
+	writeScript(t, filepath.Join(dir, "Makefile"), "#!/usr/bin/make -f\n")
+	writeScript(t, filepath.Join(dir, "tool"), "\x7fELF")
+
+	tests := []struct {
+		file, want string
+	}{
+		{"main.go", "go"},
+		{"APP.PY", "python"},
+		{"data.json", ""},
+		{"deploy", "bash"},
+		{"Makefile", ""}, // Known name, never opened
+		{"tool", ""},     // Executable without a #! line
+		{"missing", ""},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct{ file, want string }{"notes", ""})
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(filepath.Join(dir, tt.file)); got != tt.want {
+			t.Errorf("DetectLanguage(%s) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterLanguages(t *testing.T) {
+	userDir := isolateLanguages(t)
+	root := t.TempDir()
+	writeTree(t, userDir, map[string]string{
+		"config.yaml": `languages:
+  elixir:
+    display: Elixir
+    extensions: [.ex, exs]
+    shebangs: [elixir]
+    grammar: grammars/libtree-sitter-elixir.so
+`,
+		"languages/nim.yaml":         "extensions: [.nim]\nsymbol: tree_sitter_nim2\n",
+		"languages/broken.yaml":      "extensions: [.b\n",
+		"languages/go.scm":           "",
+		"languages/python.calls.scm": "",
+		"languages/python.refs.scm":  "",
+	})
+	writeTree(t, root, map[string]string{
+		".codemap/config.yaml": `languages:
+  python:
+    extensions: [.pyw]
+    queries:
+      calls: queries/calls.scm
+  bogus:
+    display: Bogus
+`,
+		".codemap/queries/go.scm": "",
+	})
+
+	err := RegisterLanguages(root)
+	if err == nil {
+		t.Fatal("RegisterLanguages reported no error for broken.yaml and bogus")
+	}
+	for _, want := range []string{"broken.yaml", "language bogus", "no extensions or shebangs"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't mention %q", err, want)
+		}
+	}
+
+	script := filepath.Join(root, "run")
+	writeScript(t, script, "#!/usr/bin/env elixir\n")
+	for file, want := range map[string]string{
+		"lib/app.ex": "elixir", "lib/app.EXS": "elixir", "app.nim": "nim",
+		"gui.pyw": "python", "app.py": "python", "app.b": "", "run": "elixir",
+	} {
+		if got := DetectLanguage(filepath.Join(root, file)); got != want {
+			t.Errorf("DetectLanguage(%s) = %q, want %q", file, got, want)
+		}
+	}
+	if info := LanguageInfo("elixir"); info.Full != "Elixir" || info.Short != "Elixir" {
+		t.Errorf("LanguageInfo(elixir) = %+v, want Elixir", info)
+	}
+	if info := LanguageInfo("python"); info != LangDisplay["python"] {
+		t.Errorf("LanguageInfo(python) = %+v, want the built-in %+v", info, LangDisplay["python"])
+	}
+
+	tests := []struct {
+		lang string
+		want languageSpec
+	}{
+		{"elixir", languageSpec{custom: true, grammar: filepath.Join(userDir, "grammars", "libtree-sitter-elixir.so")}},
+		{"nim", languageSpec{custom: true, symbol: "tree_sitter_nim2"}},
+		// Project query files win over the user's
+		{"go", languageSpec{symbols: filepath.Join(root, ".codemap", "queries", "go.scm")}},
+		// Defined queries win over the user's query files
+		{"python", languageSpec{
+			calls: filepath.Join(root, ".codemap", "queries", "calls.scm"),
+			refs:  filepath.Join(userDir, "languages", "python.refs.scm"),
+		}},
+		{"bogus", languageSpec{}},
+		{"java", languageSpec{}},
+	}
+	for _, tt := range tests {
+		if got := specFor(tt.lang); got != tt.want {
+			t.Errorf("specFor(%s) = %+v, want %+v", tt.lang, got, tt.want)
+		}
+	}
+
+	// Registering again replaces the previous registration
+	if err := RegisterLanguages(t.TempDir()); err == nil || !strings.Contains(err.Error(), "broken.yaml") {
+		t.Errorf("second RegisterLanguages error = %v, want broken.yaml", err)
+	}
+	if got := DetectLanguage(filepath.Join(root, "gui.pyw")); got != "" {
+		t.Errorf("after re-registering, gui.pyw is %q, want \"\"", got)
+	}
+}
+
+func TestInvalidQueryOverride(t *testing.T) {
+	if err := NewGrammarLoader().LoadLanguage("go"); err != nil {
+		t.Skipf("grammar for go not available: %v", err)
+	}
+	isolateLanguages(t)
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".codemap/queries/go.calls.scm": "; Calls\n(call_expression function: (no_such_node) @call.name)\n",
+	})
+	if err := RegisterLanguages(root); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewGrammarLoader().LoadLanguage("go")
+	if err == nil {
+		t.Fatal("LoadLanguage succeeded with an invalid call query")
+	}
+	want := filepath.Join(root, ".codemap", "queries", "go.calls.scm") + `:2:`
+	if !strings.Contains(err.Error(), want) || !strings.Contains(err.Error(), `invalid node type "no_such_node"`) {
+		t.Errorf("error = %q, want the position in go.calls.scm and the node type", err)
+	}
+}
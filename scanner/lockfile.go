@@ -0,0 +1,773 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// nameIndex finds the package a lockfile means by a bare name, for formats
+// whose dependency lists leave out versions.
+type nameIndex map[string]string // Name -> key
+
+func (idx nameIndex) add(name, key string) {
+	if _, ok := idx[name]; !ok {
+		idx[name] = key
+	}
+}
+
+// parseGoSum reads the versions the go.mod next to go.sum selects, with
+// the checksums go.sum records. The dependency tree comes from the go.mod
+// files of the dependencies in the module cache, when they are there.
+// Without a go.mod, the highest version go.sum lists is taken.
+func parseGoSum(dir string, content []byte) *lockGraph {
+	g := newLockGraph()
+	sums := make(map[string]string) // path@version -> h1: hash
+	latest := make(map[string]string)
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		sums[fields[0]+"@"+fields[1]] = fields[2]
+		if v, ok := latest[fields[0]]; !ok || semver.Compare(fields[1], v) > 0 {
+			latest[fields[0]] = fields[1]
+		}
+	}
+
+	selected := make(map[string]string) // Module path -> version
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		if f, err := modfile.ParseLax("go.mod", data, nil); err == nil {
+			for _, req := range f.Require {
+				selected[req.Mod.Path] = req.Mod.Version
+				p := g.add(req.Mod.Path, req.Mod.Version)
+				p.Checksum = sums[p.Key()]
+				if !req.Indirect {
+					g.root(p.Key(), false)
+				}
+			}
+		}
+	}
+	if len(selected) == 0 {
+		for path, version := range latest {
+			selected[path] = version
+			g.add(path, version).Checksum = sums[path+"@"+version]
+		}
+	}
+
+	cache := goModCache()
+	for path, version := range selected {
+		p := g.packages[path+"@"+version]
+		for _, dep := range cachedRequirements(cache, path, version) {
+			if v, ok := selected[dep]; ok {
+				p.Dependencies = append(p.Dependencies, dep+"@"+v)
+			}
+		}
+	}
+	return g
+}
+
+// goModCache returns the module cache directory.
+func goModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+// cachedRequirements returns the modules a module version requires,
+// according to its go.mod in the module cache.
+func cachedRequirements(cache, path, version string) []string {
+	escPath, err := module.EscapePath(path)
+	if err != nil || cache == "" {
+		return nil
+	}
+	escVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(cache, "cache", "download", filepath.FromSlash(escPath), "@v", escVersion+".mod"))
+	if err != nil {
+		return nil
+	}
+	f, err := modfile.ParseLax(path+"@"+version+"/go.mod", data, nil)
+	if err != nil {
+		return nil
+	}
+	var deps []string
+	for _, req := range f.Require {
+		deps = append(deps, req.Mod.Path)
+	}
+	return deps
+}
+
+// npmManifest is the part of package.json the lockfile parsers read.
+type npmManifest struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	Workspaces           json.RawMessage   `json:"workspaces"`
+}
+
+// npmProjects reads the package.json in dir and those of the workspaces
+// it lists.
+func npmProjects(dir string) []npmManifest {
+	var root npmManifest
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil || json.Unmarshal(data, &root) != nil {
+		return nil
+	}
+	projects := []npmManifest{root}
+
+	var globs []string
+	if json.Unmarshal(root.Workspaces, &globs) != nil {
+		var ws struct {
+			Packages []string `json:"packages"`
+		}
+		json.Unmarshal(root.Workspaces, &ws)
+		globs = ws.Packages
+	}
+	for _, glob := range globs {
+		matches, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(glob), "package.json"))
+		for _, match := range matches {
+			var member npmManifest
+			if data, err := os.ReadFile(match); err == nil && json.Unmarshal(data, &member) == nil {
+				projects = append(projects, member)
+			}
+		}
+	}
+	return projects
+}
+
+// packageLockEntry is a package of package-lock.json: an entry of
+// "packages" (lockfile version 2 and 3) or of "dependencies" (version 1).
+type packageLockEntry struct {
+	Version              string            `json:"version"`
+	Link                 bool              `json:"link"`
+	Integrity            string            `json:"integrity"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+func parsePackageLock(dir string, content []byte) *lockGraph {
+	var lock struct {
+		Packages     map[string]packageLockEntry `json:"packages"`
+		Dependencies map[string]json.RawMessage  `json:"dependencies"`
+	}
+	if json.Unmarshal(content, &lock) != nil {
+		return nil
+	}
+	entries := lock.Packages
+	if len(entries) == 0 {
+		// Version 1 nests the tree: flatten it into node_modules paths
+		entries = make(map[string]packageLockEntry)
+		flattenPackageLockV1(lock.Dependencies, "", entries)
+		root := packageLockEntry{}
+		for _, project := range npmProjects(dir) {
+			root.Dependencies = mergeVersions(root.Dependencies, project.Dependencies, project.OptionalDependencies)
+			root.DevDependencies = mergeVersions(root.DevDependencies, project.DevDependencies)
+		}
+		entries[""] = root
+	}
+
+	// Packages installed into node_modules; the other locations are the
+	// project and its workspaces
+	g := newLockGraph()
+	keys := make(map[string]string) // Location -> key
+	for loc, e := range entries {
+		i := strings.LastIndex(loc, "node_modules/")
+		if i < 0 || e.Link || e.Version == "" {
+			continue
+		}
+		p := g.add(loc[i+len("node_modules/"):], e.Version)
+		p.Checksum = e.Integrity
+		keys[loc] = p.Key()
+	}
+
+	// Node's resolution: the nearest node_modules up the tree
+	resolve := func(from, name string) string {
+		loc := from
+		for {
+			candidate := "node_modules/" + name
+			if loc != "" {
+				candidate = loc + "/" + candidate
+			}
+			if key, ok := keys[candidate]; ok {
+				return key
+			}
+			if loc == "" {
+				return ""
+			}
+			if i := strings.LastIndex(loc, "/node_modules/"); i >= 0 {
+				loc = loc[:i]
+			} else {
+				loc = ""
+			}
+		}
+	}
+
+	for loc, e := range entries {
+		deps := sortedKeys(mergeVersions(nil, e.Dependencies, e.OptionalDependencies, e.PeerDependencies))
+		if key, ok := keys[loc]; ok {
+			p := g.packages[key]
+			for _, name := range deps {
+				if dep := resolve(loc, name); dep != "" {
+					p.Dependencies = append(p.Dependencies, dep)
+				}
+			}
+			continue
+		}
+		if e.Link || strings.Contains(loc, "node_modules/") {
+			continue
+		}
+		for _, name := range deps {
+			g.root(resolve(loc, name), false)
+		}
+		for _, name := range sortedKeys(e.DevDependencies) {
+			g.root(resolve(loc, name), true)
+		}
+	}
+	return g
+}
+
+// flattenPackageLockV1 turns the nested "dependencies" of a version 1
+// package-lock.json into entries by node_modules path, their "requires"
+// becoming dependencies.
+func flattenPackageLockV1(deps map[string]json.RawMessage, parent string, entries map[string]packageLockEntry) {
+	for name, raw := range deps {
+		var e struct {
+			Version      string                     `json:"version"`
+			Integrity    string                     `json:"integrity"`
+			Requires     map[string]string          `json:"requires"`
+			Dependencies map[string]json.RawMessage `json:"dependencies"`
+		}
+		if json.Unmarshal(raw, &e) != nil {
+			continue
+		}
+		loc := "node_modules/" + name
+		if parent != "" {
+			loc = parent + "/" + loc
+		}
+		entries[loc] = packageLockEntry{Version: e.Version, Integrity: e.Integrity, Dependencies: e.Requires}
+		flattenPackageLockV1(e.Dependencies, loc, entries)
+	}
+}
+
+// mergeVersions adds the entries of name -> version maps to dst.
+func mergeVersions(dst map[string]string, maps ...map[string]string) map[string]string {
+	for _, m := range maps {
+		for name, version := range m {
+			if dst == nil {
+				dst = make(map[string]string)
+			}
+			dst[name] = version
+		}
+	}
+	return dst
+}
+
+// pnpmPackage is an entry of pnpm-lock.yaml's packages or snapshots.
+type pnpmPackage struct {
+	Resolution struct {
+		Integrity string `yaml:"integrity"`
+	} `yaml:"resolution"`
+	Dependencies         map[string]string `yaml:"dependencies"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies"`
+}
+
+// pnpmImporter lists the dependencies of a project of the workspace. The
+// values are versions (lockfile version 5) or {specifier, version} maps.
+type pnpmImporter struct {
+	Dependencies         map[string]interface{} `yaml:"dependencies"`
+	DevDependencies      map[string]interface{} `yaml:"devDependencies"`
+	OptionalDependencies map[string]interface{} `yaml:"optionalDependencies"`
+}
+
+func parsePnpmLock(dir string, content []byte) *lockGraph {
+	var lock struct {
+		pnpmImporter `yaml:",inline"`        // Single-project lockfiles of version 5
+		Importers    map[string]pnpmImporter `yaml:"importers"`
+		Packages     map[string]pnpmPackage  `yaml:"packages"`
+		Snapshots    map[string]pnpmPackage  `yaml:"snapshots"` // Version 9
+	}
+	if yaml.Unmarshal(content, &lock) != nil {
+		return nil
+	}
+
+	g := newLockGraph()
+	for key, pkg := range lock.Packages {
+		if name, version, ok := pnpmPackageKey(key); ok {
+			g.add(name, version).Checksum = pkg.Resolution.Integrity
+		}
+	}
+	graph := lock.Snapshots
+	if graph == nil {
+		graph = lock.Packages
+	}
+	for key, pkg := range graph {
+		name, version, ok := pnpmPackageKey(key)
+		if !ok {
+			continue
+		}
+		p := g.add(name, version)
+		for _, deps := range []map[string]string{pkg.Dependencies, pkg.OptionalDependencies} {
+			for _, dep := range sortedKeys(deps) {
+				if ref := pnpmReference(dep, deps[dep]); ref != "" {
+					p.Dependencies = append(p.Dependencies, ref)
+				}
+			}
+		}
+	}
+
+	importers := lock.Importers
+	if importers == nil {
+		importers = map[string]pnpmImporter{".": lock.pnpmImporter}
+	}
+	for _, importer := range importers {
+		for _, deps := range []map[string]interface{}{importer.Dependencies, importer.OptionalDependencies} {
+			for _, name := range sortedKeys(deps) {
+				g.root(pnpmReference(name, pnpmImporterVersion(deps[name])), false)
+			}
+		}
+		for _, name := range sortedKeys(importer.DevDependencies) {
+			g.root(pnpmReference(name, pnpmImporterVersion(importer.DevDependencies[name])), true)
+		}
+	}
+	return g
+}
+
+// pnpmImporterVersion returns the version of an importer's dependency.
+func pnpmImporterVersion(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		s, _ := v["version"].(string)
+		return s
+	}
+	return ""
+}
+
+// pnpmPackageKey splits a package key of pnpm-lock.yaml: "/name/1.0.0"
+// (version 5), "/name@1.0.0(peer@2.0.0)" (version 6) or "@scope/name@1.0.0"
+// (version 9).
+func pnpmPackageKey(key string) (name, version string, ok bool) {
+	key = strings.TrimPrefix(key, "/")
+	if i := strings.Index(key, "("); i > 0 {
+		key = key[:i] // Peer dependency suffix
+	}
+	// Version 5 puts the version in the segment after the name, whose
+	// peer suffix may hold more @s ("/name/1.0.0_@types+node@20.0.0")
+	segments := 1
+	if strings.HasPrefix(key, "@") {
+		segments = 2 // Scoped name
+	}
+	if parts := strings.SplitN(key, "/", segments+1); len(parts) > segments {
+		version = parts[segments]
+		if j := strings.Index(version, "_"); j > 0 {
+			version = version[:j] // Version 5 peer suffix
+		}
+		return strings.Join(parts[:segments], "/"), version, true
+	}
+	if i := strings.LastIndex(key, "@"); i > 0 {
+		return key[:i], key[i+1:], true
+	}
+	return "", "", false
+}
+
+// pnpmReference returns the key of the package a dependency refers to.
+// The reference is a version, possibly with a peer suffix, or the key of
+// another package for aliases; links to workspace projects have no key.
+func pnpmReference(name, ref string) string {
+	if ref == "" || strings.HasPrefix(ref, "link:") || strings.HasPrefix(ref, "file:") {
+		return ""
+	}
+	// Versions start with a digit; their peer suffixes may contain @s
+	isVersion := ref[0] >= '0' && ref[0] <= '9'
+	if !isVersion && (strings.HasPrefix(ref, "/") || strings.LastIndex(ref, "@") > 0) {
+		if n, v, ok := pnpmPackageKey(ref); ok {
+			return n + "@" + v
+		}
+	}
+	if i := strings.IndexAny(ref, "(_"); i > 0 {
+		ref = ref[:i]
+	}
+	return name + "@" + ref
+}
+
+// yarnEntry is a resolved package of yarn.lock.
+type yarnEntry struct {
+	name, version, checksum string
+	deps                    map[string]string
+}
+
+func parseYarnLock(dir string, content []byte) *lockGraph {
+	// Both the classic format (name "range") and Berry's YAML (name: range)
+	// are indented blocks under their comma-separated descriptors
+	descriptors := make(map[string]*yarnEntry)
+	var entries []*yarnEntry
+	var current *yarnEntry
+	inDeps := false
+	lines := bufio.NewScanner(bytes.NewReader(content))
+	lines.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lines.Scan() {
+		line := lines.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 0:
+			current = &yarnEntry{deps: make(map[string]string)}
+			inDeps = false
+			for _, d := range strings.Split(strings.TrimSuffix(trimmed, ":"), ",") {
+				name, rng := splitYarnDescriptor(unquote(strings.TrimSpace(d)))
+				if name == "" || strings.HasPrefix(rng, "workspace:") {
+					continue
+				}
+				current.name = name
+				descriptors[name+"@"+rng] = current
+			}
+			if current.name != "" {
+				entries = append(entries, current)
+			}
+		case current == nil:
+		case indent <= 2:
+			key, value := splitYarnField(trimmed)
+			inDeps = value == "" && (key == "dependencies" || key == "optionalDependencies")
+			switch key {
+			case "version":
+				current.version = value
+			case "integrity", "checksum":
+				current.checksum = value
+			}
+		case inDeps:
+			if key, value := splitYarnField(trimmed); key != "" {
+				current.deps[key] = strings.TrimPrefix(value, "npm:")
+			}
+		}
+	}
+
+	g := newLockGraph()
+	for _, e := range entries {
+		if e.version == "" || strings.HasPrefix(e.version, "0.0.0-use.local") {
+			continue
+		}
+		g.add(e.name, e.version).Checksum = e.checksum
+	}
+	key := func(name, rng string) string {
+		if e, ok := descriptors[name+"@"+strings.TrimPrefix(rng, "npm:")]; ok && e.version != "" {
+			return e.name + "@" + e.version
+		}
+		return ""
+	}
+	for _, e := range entries {
+		p, ok := g.packages[e.name+"@"+e.version]
+		if !ok {
+			continue
+		}
+		for _, name := range sortedKeys(e.deps) {
+			if dep := key(name, e.deps[name]); dep != "" {
+				p.Dependencies = append(p.Dependencies, dep)
+			}
+		}
+	}
+	for _, project := range npmProjects(dir) {
+		for _, deps := range []map[string]string{project.Dependencies, project.OptionalDependencies} {
+			for _, name := range sortedKeys(deps) {
+				g.root(key(name, deps[name]), false)
+			}
+		}
+		for _, name := range sortedKeys(project.DevDependencies) {
+			g.root(key(name, project.DevDependencies[name]), true)
+		}
+	}
+	return g
+}
+
+// splitYarnDescriptor splits "name@range" ("@scope/name@npm:^1.0.0") and
+// drops Berry's npm: protocol from the range.
+func splitYarnDescriptor(d string) (name, rng string) {
+	i := strings.Index(d[min(1, len(d)):], "@")
+	if i < 0 {
+		return "", ""
+	}
+	i++
+	return d[:i], strings.TrimPrefix(d[i+1:], "npm:")
+}
+
+// splitYarnField splits a classic (key "value") or Berry (key: value)
+// field line, unquoting both parts.
+func splitYarnField(line string) (key, value string) {
+	if strings.HasPrefix(line, `"`) {
+		if end := strings.Index(line[1:], `"`); end >= 0 {
+			key, value = line[1:end+1], line[end+2:]
+		}
+	} else if i := strings.IndexAny(line, ": "); i >= 0 {
+		key, value = line[:i], line[i:]
+	} else {
+		key = line
+	}
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), ":"))
+	return strings.TrimSuffix(key, ":"), unquote(value)
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func parseCargoLock(dir string, content []byte) *lockGraph {
+	var lock struct {
+		Packages []struct {
+			Name         string   `toml:"name"`
+			Version      string   `toml:"version"`
+			Source       string   `toml:"source"`
+			Checksum     string   `toml:"checksum"`
+			Dependencies []string `toml:"dependencies"`
+		} `toml:"package"`
+	}
+	if _, err := toml.Decode(string(content), &lock); err != nil {
+		return nil
+	}
+
+	g := newLockGraph()
+	names := make(nameIndex)
+	for _, pkg := range lock.Packages {
+		names.add(pkg.Name, pkg.Name+"@"+pkg.Version)
+		if pkg.Source != "" {
+			g.add(pkg.Name, pkg.Version).Checksum = pkg.Checksum
+		}
+	}
+	// A dependency is "name", or "name version" when several versions are
+	// locked, optionally followed by its source
+	key := func(dep string) string {
+		fields := strings.Fields(dep)
+		if len(fields) >= 2 {
+			return fields[0] + "@" + fields[1]
+		}
+		return names[dep]
+	}
+	for _, pkg := range lock.Packages {
+		// Crates without a source are the workspace's own
+		p, ok := g.packages[pkg.Name+"@"+pkg.Version]
+		for _, dep := range pkg.Dependencies {
+			if !ok {
+				g.root(key(dep), false)
+			} else if k := key(dep); k != "" {
+				p.Dependencies = append(p.Dependencies, k)
+			}
+		}
+	}
+	return g
+}
+
+func parsePoetryLock(dir string, content []byte) *lockGraph {
+	var lock struct {
+		Packages []struct {
+			Name         string                 `toml:"name"`
+			Version      string                 `toml:"version"`
+			Dependencies map[string]interface{} `toml:"dependencies"`
+			Files        []struct {
+				Hash string `toml:"hash"`
+			} `toml:"files"`
+		} `toml:"package"`
+	}
+	if _, err := toml.Decode(string(content), &lock); err != nil {
+		return nil
+	}
+
+	g := newLockGraph()
+	names := make(nameIndex)
+	for _, pkg := range lock.Packages {
+		p := g.add(pkg.Name, pkg.Version)
+		if len(pkg.Files) > 0 {
+			p.Checksum = pkg.Files[0].Hash
+		}
+		names.add(normalizePythonName(pkg.Name), p.Key())
+	}
+	for _, pkg := range lock.Packages {
+		p := g.packages[pkg.Name+"@"+pkg.Version]
+		for _, dep := range sortedKeys(pkg.Dependencies) {
+			if key, ok := names[normalizePythonName(dep)]; ok {
+				p.Dependencies = append(p.Dependencies, key)
+			}
+		}
+	}
+	pythonRoots(g, dir, names)
+	return g
+}
+
+// pythonRoots marks the dependencies pyproject.toml declares as direct.
+func pythonRoots(g *lockGraph, dir string, names nameIndex) {
+	data, err := os.ReadFile(filepath.Join(dir, "pyproject.toml"))
+	if err != nil {
+		return
+	}
+	for _, dep := range parsePyproject(string(data)) {
+		if dep.Scope != ScopeBuild {
+			g.root(names[normalizePythonName(dep.Name)], dep.Scope == ScopeDev || dep.Scope == ScopeTest)
+		}
+	}
+}
+
+// uvDependency is a dependency of a uv.lock package. The version is only
+// given when several versions of the package are locked.
+type uvDependency struct {
+	Name    string `toml:"name"`
+	Version string `toml:"version"`
+}
+
+func parseUvLock(dir string, content []byte) *lockGraph {
+	var lock struct {
+		Packages []struct {
+			Name                 string                    `toml:"name"`
+			Version              string                    `toml:"version"`
+			Source               map[string]interface{}    `toml:"source"`
+			Dependencies         []uvDependency            `toml:"dependencies"`
+			OptionalDependencies map[string][]uvDependency `toml:"optional-dependencies"`
+			DevDependencies      map[string][]uvDependency `toml:"dev-dependencies"`
+			Sdist                struct {
+				Hash string `toml:"hash"`
+			} `toml:"sdist"`
+		} `toml:"package"`
+	}
+	if _, err := toml.Decode(string(content), &lock); err != nil {
+		return nil
+	}
+
+	g := newLockGraph()
+	names := make(nameIndex)
+	local := func(source map[string]interface{}) bool {
+		_, editable := source["editable"]
+		_, virtual := source["virtual"]
+		return editable || virtual
+	}
+	for _, pkg := range lock.Packages {
+		names.add(normalizePythonName(pkg.Name), pkg.Name+"@"+pkg.Version)
+		if !local(pkg.Source) {
+			g.add(pkg.Name, pkg.Version).Checksum = pkg.Sdist.Hash
+		}
+	}
+	key := func(dep uvDependency) string {
+		if dep.Version != "" {
+			return dep.Name + "@" + dep.Version
+		}
+		return names[normalizePythonName(dep.Name)]
+	}
+	for _, pkg := range lock.Packages {
+		deps := pkg.Dependencies
+		for _, extra := range sortedKeys(pkg.OptionalDependencies) {
+			deps = append(deps, pkg.OptionalDependencies[extra]...)
+		}
+		// The project and its workspace members declare the direct ones
+		if local(pkg.Source) {
+			for _, dep := range deps {
+				g.root(key(dep), false)
+			}
+			for _, group := range sortedKeys(pkg.DevDependencies) {
+				for _, dep := range pkg.DevDependencies[group] {
+					g.root(key(dep), true)
+				}
+			}
+			continue
+		}
+		p := g.packages[pkg.Name+"@"+pkg.Version]
+		for _, dep := range deps {
+			if k := key(dep); k != "" {
+				p.Dependencies = append(p.Dependencies, k)
+			}
+		}
+	}
+	return g
+}
+
+// gemSpec matches a gem of a Gemfile.lock specs list, "name (version)" at
+// four spaces or one of its dependencies at six.
+var gemSpec = regexp.MustCompile(`^( {4}| {6})([^ (]+)(?: \(([^)]*)\))?$`)
+
+func parseGemfileLock(dir string, content []byte) *lockGraph {
+	g := newLockGraph()
+	names := make(nameIndex)
+	deps := make(map[string][]string) // Key -> dependency names
+	var localDeps, direct []string    // Dependencies of PATH gems and of the Gemfile
+	section := ""
+	var current string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line != "" && line[0] != ' ' {
+			section = line
+			current = ""
+			continue
+		}
+		if section == "DEPENDENCIES" {
+			if name := strings.Fields(line); len(name) > 0 {
+				direct = append(direct, strings.TrimSuffix(name[0], "!"))
+			}
+			continue
+		}
+		m := gemSpec.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if m[1] == "    " {
+			current = m[2] + "@" + m[3]
+			if section == "PATH" {
+				current = "" // The project's own gems
+				continue
+			}
+			g.add(m[2], m[3])
+			names.add(m[2], current)
+		} else if current != "" {
+			deps[current] = append(deps[current], m[2])
+		} else if section == "PATH" {
+			localDeps = append(localDeps, m[2])
+		}
+	}
+
+	for key, list := range deps {
+		p := g.packages[key]
+		for _, name := range list {
+			if dep, ok := names[name]; ok {
+				p.Dependencies = append(p.Dependencies, dep)
+			}
+		}
+	}
+
+	// Gemfile groups tell development gems apart
+	scopes := make(map[string]string)
+	if data, err := os.ReadFile(filepath.Join(dir, "Gemfile")); err == nil {
+		for _, dep := range parseGemfile(string(data)) {
+			scopes[dep.Name] = dep.Scope
+		}
+	}
+	sort.Strings(direct)
+	for _, name := range append(direct, localDeps...) {
+		scope := scopes[name]
+		g.root(names[name], scope == ScopeDev || scope == ScopeTest)
+	}
+	return g
+}
@@ -0,0 +1,568 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLockfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		lockfile string
+		files    map[string]string // The lockfile, the manifests next to it and module cache files
+		want     []*LockedPackage  // nil when the lockfile yields no packages
+	}{
+		{
+			name:     "go.sum with go.mod",
+			lockfile: "go.sum",
+			files: map[string]string{
+				"go.mod": "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgithub.com/acme/x v1.2.0\n\tgithub.com/acme/y v0.3.0 // indirect\n)\n",
+				"go.sum": "github.com/acme/x v1.1.0 h1:old=\ngithub.com/acme/x v1.1.0/go.mod h1:oldmod=\ngithub.com/acme/x v1.2.0 h1:new=\ngithub.com/acme/x v1.2.0/go.mod h1:newmod=\ngithub.com/acme/y v0.3.0 h1:y=\nnot a checksum line\n",
+				"modcache/cache/download/github.com/acme/x/@v/v1.2.0.mod": "module github.com/acme/x\n\nrequire github.com/acme/y v0.2.0\n",
+			},
+			want: []*LockedPackage{
+				{Name: "github.com/acme/x", Version: "v1.2.0", Direct: true, Checksum: "h1:new=", Dependencies: []string{"github.com/acme/y@v0.3.0"}},
+				{Name: "github.com/acme/y", Version: "v0.3.0", Checksum: "h1:y="},
+			},
+		},
+		{
+			name:     "go.sum alone",
+			lockfile: "go.sum",
+			files: map[string]string{
+				"go.sum": "github.com/acme/x v1.1.0 h1:old=\ngithub.com/acme/x v1.10.0 h1:new=\ngithub.com/acme/x v1.9.0 h1:mid=\n",
+			},
+			want: []*LockedPackage{
+				{Name: "github.com/acme/x", Version: "v1.10.0", Checksum: "h1:new="},
+			},
+		},
+		{
+			name:     "package-lock.json, version 3",
+			lockfile: "package-lock.json",
+			files: map[string]string{
+				"package-lock.json": `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "dependencies": {"react": "^18"}, "devDependencies": {"vite": "^5"}},
+    "node_modules/react": {"version": "18.2.0", "integrity": "sha512-r", "dependencies": {"loose-envify": "^1.1.0"}},
+    "node_modules/loose-envify": {"version": "1.4.0", "integrity": "sha512-l"},
+    "node_modules/vite": {"version": "5.0.0", "dependencies": {"esbuild": "^0.19", "loose-envify": "*"}},
+    "node_modules/vite/node_modules/esbuild": {"version": "0.19.0"},
+    "node_modules/esbuild": {"version": "0.18.0"},
+    "node_modules/@app/ui": {"resolved": "packages/ui", "link": true},
+    "packages/ui": {"name": "@app/ui", "dependencies": {"esbuild": "*"}}
+  }
+}`,
+			},
+			want: []*LockedPackage{
+				{Name: "esbuild", Version: "0.18.0", Direct: true}, // Through the workspace
+				{Name: "esbuild", Version: "0.19.0", Dev: true},
+				{Name: "loose-envify", Version: "1.4.0", Checksum: "sha512-l"},
+				{Name: "react", Version: "18.2.0", Direct: true, Checksum: "sha512-r", Dependencies: []string{"loose-envify@1.4.0"}},
+				{Name: "vite", Version: "5.0.0", Direct: true, Dev: true, Dependencies: []string{"esbuild@0.19.0", "loose-envify@1.4.0"}},
+			},
+		},
+		{
+			name:     "package-lock.json, version 1",
+			lockfile: "package-lock.json",
+			files: map[string]string{
+				"package.json": `{"dependencies": {"a": "^1"}, "devDependencies": {"b": "^2"}}`,
+				"package-lock.json": `{
+  "lockfileVersion": 1,
+  "dependencies": {
+    "a": {"version": "1.0.0", "integrity": "sha1-a", "requires": {"c": "^1"}},
+    "b": {"version": "2.0.0", "requires": {"c": "^2"}, "dependencies": {"c": {"version": "2.0.0"}}},
+    "c": {"version": "1.0.0"}
+  }
+}`,
+			},
+			want: []*LockedPackage{
+				{Name: "a", Version: "1.0.0", Direct: true, Checksum: "sha1-a", Dependencies: []string{"c@1.0.0"}},
+				{Name: "b", Version: "2.0.0", Direct: true, Dev: true, Dependencies: []string{"c@2.0.0"}},
+				{Name: "c", Version: "1.0.0"},
+				{Name: "c", Version: "2.0.0", Dev: true},
+			},
+		},
+		{
+			name:     "pnpm-lock.yaml, version 9",
+			lockfile: "pnpm-lock.yaml",
+			files: map[string]string{
+				"pnpm-lock.yaml": `lockfileVersion: '9.0'
+
+importers:
+  .:
+    dependencies:
+      react:
+        specifier: ^18
+        version: 18.2.0
+      ui:
+        specifier: workspace:*
+        version: link:packages/ui
+    devDependencies:
+      typescript:
+        specifier: ^5
+        version: 5.3.3
+  packages/ui:
+    dependencies:
+      '@types/react':
+        specifier: ^18
+        version: 18.2.0
+
+packages:
+  react@18.2.0:
+    resolution: {integrity: sha512-r}
+  loose-envify@1.4.0:
+    resolution: {integrity: sha512-l}
+  typescript@5.3.3:
+    resolution: {integrity: sha512-t}
+  '@types/react@18.2.0':
+    resolution: {integrity: sha512-tr}
+
+snapshots:
+  react@18.2.0:
+    dependencies:
+      loose-envify: 1.4.0
+  loose-envify@1.4.0: {}
+  typescript@5.3.3: {}
+  '@types/react@18.2.0': {}
+`,
+			},
+			want: []*LockedPackage{
+				{Name: "@types/react", Version: "18.2.0", Direct: true, Checksum: "sha512-tr"},
+				{Name: "loose-envify", Version: "1.4.0", Checksum: "sha512-l"},
+				{Name: "react", Version: "18.2.0", Direct: true, Checksum: "sha512-r", Dependencies: []string{"loose-envify@1.4.0"}},
+				{Name: "typescript", Version: "5.3.3", Direct: true, Dev: true, Checksum: "sha512-t"},
+			},
+		},
+		{
+			name:     "pnpm-lock.yaml, version 6",
+			lockfile: "pnpm-lock.yaml",
+			files: map[string]string{
+				"pnpm-lock.yaml": `lockfileVersion: '6.0'
+
+dependencies:
+  react-dom:
+    specifier: ^18
+    version: 18.2.0(react@18.2.0)
+  string-width-cjs:
+    specifier: npm:string-width@^4
+    version: /string-width@4.2.3
+
+packages:
+  /react-dom@18.2.0(react@18.2.0):
+    resolution: {integrity: sha512-d}
+    dependencies:
+      react: 18.2.0
+  /react@18.2.0:
+    resolution: {integrity: sha512-r}
+  /string-width@4.2.3:
+    resolution: {integrity: sha512-s}
+`,
+			},
+			want: []*LockedPackage{
+				{Name: "react", Version: "18.2.0", Checksum: "sha512-r"},
+				{Name: "react-dom", Version: "18.2.0", Direct: true, Checksum: "sha512-d", Dependencies: []string{"react@18.2.0"}},
+				{Name: "string-width", Version: "4.2.3", Direct: true, Checksum: "sha512-s"},
+			},
+		},
+		{
+			name:     "pnpm-lock.yaml, version 5",
+			lockfile: "pnpm-lock.yaml",
+			files: map[string]string{
+				"pnpm-lock.yaml": `lockfileVersion: 5.4
+
+specifiers:
+  ts-node: ^10
+  '@babel/core': ^7
+
+dependencies:
+  '@babel/core': 7.23.0
+
+devDependencies:
+  ts-node: 10.9.1_@types+node@20.10.0
+
+packages:
+
+  /@babel/core/7.23.0:
+    resolution: {integrity: sha512-b}
+
+  /@types/node/20.10.0:
+    resolution: {integrity: sha512-n}
+
+  /ts-node/10.9.1_@types+node@20.10.0:
+    resolution: {integrity: sha512-t}
+    dependencies:
+      '@types/node': 20.10.0
+`,
+			},
+			want: []*LockedPackage{
+				{Name: "@babel/core", Version: "7.23.0", Direct: true, Checksum: "sha512-b"},
+				{Name: "@types/node", Version: "20.10.0", Dev: true, Checksum: "sha512-n"},
+				{Name: "ts-node", Version: "10.9.1", Direct: true, Dev: true, Checksum: "sha512-t", Dependencies: []string{"@types/node@20.10.0"}},
+			},
+		},
+		{
+			name:     "yarn.lock, classic",
+			lockfile: "yarn.lock",
+			files: map[string]string{
+				"package.json": `{"dependencies": {"@babel/core": "^7.1.0"}, "devDependencies": {"ms": "2.1.2"}}`,
+				"yarn.lock": `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/core@^7.0.0", "@babel/core@^7.1.0":
+  version "7.23.0"
+  resolved "https://registry.yarnpkg.com/@babel/core/-/core-7.23.0.tgz"
+  integrity sha512-b
+  dependencies:
+    debug "^4.1.0"
+
+debug@^4.1.0:
+  version "4.3.4"
+  integrity sha512-d
+  dependencies:
+    ms "2.1.2"
+
+ms@2.1.2:
+  version "2.1.2"
+  integrity sha512-m
+`,
+			},
+			want: []*LockedPackage{
+				{Name: "@babel/core", Version: "7.23.0", Direct: true, Checksum: "sha512-b", Dependencies: []string{"debug@4.3.4"}},
+				{Name: "debug", Version: "4.3.4", Checksum: "sha512-d", Dependencies: []string{"ms@2.1.2"}},
+				{Name: "ms", Version: "2.1.2", Direct: true, Checksum: "sha512-m"}, // Also a runtime dependency's
+			},
+		},
+		{
+			name:     "yarn.lock, berry",
+			lockfile: "yarn.lock",
+			files: map[string]string{
+				"package.json": `{"dependencies": {"lodash": "^4.17.0"}}`,
+				"yarn.lock": `__metadata:
+  version: 6
+  cacheKey: 8
+
+"app@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:."
+  dependencies:
+    lodash: "npm:^4.17.0"
+  languageName: unknown
+  linkType: soft
+
+"lodash@npm:^4.17.0":
+  version: 4.17.21
+  resolution: "lodash@npm:4.17.21"
+  checksum: abc123
+  languageName: node
+  linkType: hard
+`,
+			},
+			want: []*LockedPackage{
+				{Name: "lodash", Version: "4.17.21", Direct: true, Checksum: "abc123"},
+			},
+		},
+		{
+			name:     "Cargo.lock",
+			lockfile: "Cargo.lock",
+			files: map[string]string{
+				"Cargo.lock": `version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = ["serde", "rand 0.8.5"]
+
+[[package]]
+name = "rand"
+version = "0.7.3"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "r7"
+
+[[package]]
+name = "rand"
+version = "0.8.5"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "r8"
+dependencies = ["rand_core 0.6.4 (registry+https://github.com/rust-lang/crates.io-index)"]
+
+[[package]]
+name = "rand_core"
+version = "0.6.4"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "rc"
+
+[[package]]
+name = "serde"
+version = "1.0.190"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "s"
+dependencies = ["serde_derive"]
+
+[[package]]
+name = "serde_derive"
+version = "1.0.190"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+checksum = "sd"
+`,
+			},
+			want: []*LockedPackage{
+				{Name: "rand", Version: "0.7.3", Checksum: "r7"},
+				{Name: "rand", Version: "0.8.5", Direct: true, Checksum: "r8", Dependencies: []string{"rand_core@0.6.4"}},
+				{Name: "rand_core", Version: "0.6.4", Checksum: "rc"},
+				{Name: "serde", Version: "1.0.190", Direct: true, Checksum: "s", Dependencies: []string{"serde_derive@1.0.190"}},
+				{Name: "serde_derive", Version: "1.0.190", Checksum: "sd"},
+			},
+		},
+		{
+			name:     "poetry.lock",
+			lockfile: "poetry.lock",
+			files: map[string]string{
+				"pyproject.toml": "[tool.poetry.dependencies]\npython = \"^3.11\"\nrequests = \"^2.31\"\n\n[tool.poetry.group.test.dependencies]\npytest = \"^8\"\n",
+				"poetry.lock": `[[package]]
+name = "charset_normalizer"
+version = "3.3.2"
+files = []
+
+[[package]]
+name = "pytest"
+version = "8.0.0"
+files = [{file = "pytest-8.0.0.tar.gz", hash = "sha256:p"}]
+
+[[package]]
+name = "Requests"
+version = "2.31.0"
+files = [
+    {file = "requests-2.31.0-py3-none-any.whl", hash = "sha256:r"},
+    {file = "requests-2.31.0.tar.gz", hash = "sha256:rs"},
+]
+
+[package.dependencies]
+Charset-Normalizer = ">=2,<4"
+urllib3 = ">=1.21.1,<3"
+`,
+			},
+			want: []*LockedPackage{
+				{Name: "Requests", Version: "2.31.0", Direct: true, Checksum: "sha256:r", Dependencies: []string{"charset_normalizer@3.3.2"}},
+				{Name: "charset_normalizer", Version: "3.3.2"},
+				{Name: "pytest", Version: "8.0.0", Direct: true, Dev: true, Checksum: "sha256:p"},
+			},
+		},
+		{
+			name:     "uv.lock",
+			lockfile: "uv.lock",
+			files: map[string]string{
+				"uv.lock": `version = 1
+
+[[package]]
+name = "app"
+version = "0.1.0"
+source = { editable = "." }
+dependencies = [{ name = "httpx" }]
+
+[package.optional-dependencies]
+cli = [{ name = "click" }]
+
+[package.dev-dependencies]
+dev = [{ name = "pytest" }]
+
+[[package]]
+name = "click"
+version = "8.1.7"
+source = { registry = "https://pypi.org/simple" }
+
+[[package]]
+name = "httpx"
+version = "0.27.0"
+source = { registry = "https://pypi.org/simple" }
+dependencies = [{ name = "idna" }, { name = "sniffio", version = "1.3.0" }]
+sdist = { url = "https://files.pythonhosted.org/httpx-0.27.0.tar.gz", hash = "sha256:h" }
+
+[[package]]
+name = "idna"
+version = "3.6"
+source = { registry = "https://pypi.org/simple" }
+
+[[package]]
+name = "pytest"
+version = "8.0.0"
+source = { registry = "https://pypi.org/simple" }
+
+[[package]]
+name = "sniffio"
+version = "1.3.0"
+source = { registry = "https://pypi.org/simple" }
+`,
+			},
+			want: []*LockedPackage{
+				{Name: "click", Version: "8.1.7", Direct: true},
+				{Name: "httpx", Version: "0.27.0", Direct: true, Checksum: "sha256:h", Dependencies: []string{"idna@3.6", "sniffio@1.3.0"}},
+				{Name: "idna", Version: "3.6"},
+				{Name: "pytest", Version: "8.0.0", Direct: true, Dev: true},
+				{Name: "sniffio", Version: "1.3.0"},
+			},
+		},
+		{
+			name:     "Gemfile.lock",
+			lockfile: "Gemfile.lock",
+			files: map[string]string{
+				"Gemfile": "source \"https://rubygems.org\"\ngemspec\ngem \"rspec\", group: :test\n",
+				"Gemfile.lock": `PATH
+  remote: .
+  specs:
+    myapp (0.1.0)
+      rack (~> 3.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (3.0.8)
+    rspec (3.12.0)
+      rspec-core (~> 3.12.0)
+      rspec-mocks (~> 3.12.0)
+    rspec-core (3.12.2)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  myapp!
+  rspec
+
+BUNDLED WITH
+   2.5.3
+`,
+			},
+			want: []*LockedPackage{
+				{Name: "rack", Version: "3.0.8", Direct: true}, // Through the gemspec
+				{Name: "rspec", Version: "3.12.0", Direct: true, Dev: true, Dependencies: []string{"rspec-core@3.12.2"}},
+				{Name: "rspec-core", Version: "3.12.2", Dev: true},
+			},
+		},
+
+		// Malformed lockfiles
+		{name: "malformed go.sum", lockfile: "go.sum", files: map[string]string{"go.sum": "garbage\n\x00\x01\n"}},
+		{name: "malformed package-lock.json", lockfile: "package-lock.json", files: map[string]string{"package-lock.json": `{"packages": {"node_modules/a": `}},
+		{name: "malformed pnpm-lock.yaml", lockfile: "pnpm-lock.yaml", files: map[string]string{"pnpm-lock.yaml": "packages: [\n  /a@1.0.0\n"}},
+		{name: "malformed yarn.lock", lockfile: "yarn.lock", files: map[string]string{"yarn.lock": "not a lockfile\n    version \"1.0.0\"\n"}},
+		{name: "malformed Cargo.lock", lockfile: "Cargo.lock", files: map[string]string{"Cargo.lock": "[[package]\nname = \"a\"\n"}},
+		{name: "malformed poetry.lock", lockfile: "poetry.lock", files: map[string]string{"poetry.lock": "[[package]]\nname = \n"}},
+		{name: "malformed uv.lock", lockfile: "uv.lock", files: map[string]string{"uv.lock": "[[package]]\nname = \"a\"\nsource = {\n"}},
+		{name: "malformed Gemfile.lock", lockfile: "Gemfile.lock", files: map[string]string{"Gemfile.lock": "GEM\n  specs:\n    broken (\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTree(t, dir, tt.files)
+			t.Setenv("GOMODCACHE", filepath.Join(dir, "modcache"))
+
+			parser := lockfileParsers[tt.lockfile]
+			g := parser.parse(dir, []byte(tt.files[tt.lockfile]))
+			if tt.want == nil {
+				if g != nil && len(g.packages) > 0 {
+					t.Errorf("malformed %s yields %d packages", tt.lockfile, len(g.packages))
+				}
+				return
+			}
+			if g == nil {
+				t.Fatalf("%s didn't parse", tt.lockfile)
+			}
+			if got := g.lockfile(tt.lockfile, parser.ecosystem).Packages; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("packages:\n got %s\nwant %s", describePackages(got), describePackages(tt.want))
+			}
+		})
+	}
+}
+
+func TestPnpmPackageKey(t *testing.T) {
+	tests := []struct {
+		key, name, version string
+	}{
+		{"/lodash/4.17.21", "lodash", "4.17.21"},
+		{"/@babel/core/7.23.0", "@babel/core", "7.23.0"},
+		{"/ts-node/10.9.1_typescript@5.3.3", "ts-node", "10.9.1"},
+		{"/@swc/jest/0.2.29_@swc+core@1.3.100", "@swc/jest", "0.2.29"},
+		{"/react-dom@18.2.0(react@18.2.0)", "react-dom", "18.2.0"},
+		{"/@tanstack/query@5.0.0(@types/react@18.2.0)", "@tanstack/query", "5.0.0"},
+		{"@types/react@18.2.0", "@types/react", "18.2.0"},
+		{"lodash@4.17.21", "lodash", "4.17.21"},
+	}
+	for _, tt := range tests {
+		name, version, ok := pnpmPackageKey(tt.key)
+		if !ok || name != tt.name || version != tt.version {
+			t.Errorf("pnpmPackageKey(%q) = %q, %q, %v, want %q, %q", tt.key, name, version, ok, tt.name, tt.version)
+		}
+	}
+	if _, _, ok := pnpmPackageKey("lodash"); ok {
+		t.Error("pnpmPackageKey accepted a bare name")
+	}
+}
+
+func TestPnpmReference(t *testing.T) {
+	tests := []struct {
+		name, ref, want string
+	}{
+		{"react", "18.2.0", "react@18.2.0"},
+		{"react-dom", "18.2.0(react@18.2.0)", "react-dom@18.2.0"},
+		{"ts-node", "10.9.1_@types+node@20.10.0", "ts-node@10.9.1"},
+		{"string-width-cjs", "/string-width@4.2.3", "string-width@4.2.3"},
+		{"string-width-cjs", "string-width@4.2.3", "string-width@4.2.3"},
+		{"ui", "link:packages/ui", ""},
+		{"local", "file:../local", ""},
+		{"missing", "", ""},
+	}
+	for _, tt := range tests {
+		if got := pnpmReference(tt.name, tt.ref); got != tt.want {
+			t.Errorf("pnpmReference(%q, %q) = %q, want %q", tt.name, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestReadInventory(t *testing.T) {
+	isolateGit(t)
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"Cargo.lock":                   "[[package]]\nname = \"serde\"\nversion = \"1.0.0\"\nsource = \"registry\"\n",
+		"web/yarn.lock":                "ms@2.1.2:\n  version \"2.1.2\"\n",
+		"web/node_modules/x/yarn.lock": "debug@4.0.0:\n  version \"4.0.0\"\n",
+		"empty/package-lock.json":      `{"lockfileVersion": 3, "packages": {"": {}}}`,
+		"docs/requirements.txt":        "mkdocs\n",
+	})
+	if err := os.WriteFile(filepath.Join(root, "broken.lock"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	inv := ReadInventory(root, NewIgnoreMatcher(root, nil, nil))
+	var got []string
+	for _, l := range inv.Lockfiles {
+		got = append(got, l.Path+" "+l.Ecosystem)
+	}
+	// Lockfiles without packages and those in ignored directories are left out
+	if want := []string{"Cargo.lock cargo", "web/yarn.lock npm"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lockfiles = %q, want %q", got, want)
+	}
+}
+
+// describePackages formats packages for failure messages.
+func describePackages(packages []*LockedPackage) string {
+	s := ""
+	for _, p := range packages {
+		s += "\n\t" + p.Key()
+		if p.Direct {
+			s += " direct"
+		}
+		if p.Dev {
+			s += " dev"
+		}
+		if p.Checksum != "" {
+			s += " " + p.Checksum
+		}
+		if len(p.Dependencies) > 0 {
+			s += " -> " + strings.Join(p.Dependencies, ", ")
+		}
+	}
+	return s
+}
@@ -0,0 +1,538 @@
+package scanner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// cargoDependencies is the part of Cargo.toml that declares dependencies.
+type cargoDependencies struct {
+	Dependencies      map[string]interface{} `toml:"dependencies"`
+	DevDependencies   map[string]interface{} `toml:"dev-dependencies"`
+	BuildDependencies map[string]interface{} `toml:"build-dependencies"`
+	Target            map[string]struct {
+		Dependencies      map[string]interface{} `toml:"dependencies"`
+		DevDependencies   map[string]interface{} `toml:"dev-dependencies"`
+		BuildDependencies map[string]interface{} `toml:"build-dependencies"`
+	} `toml:"target"`
+}
+
+func parseCargoToml(c string) (deps []Dependency) {
+	var manifest cargoDependencies
+	if _, err := toml.Decode(c, &manifest); err != nil {
+		return nil
+	}
+	deps = append(deps, cargoTable(manifest.Dependencies, ScopeRuntime)...)
+	deps = append(deps, cargoTable(manifest.DevDependencies, ScopeDev)...)
+	deps = append(deps, cargoTable(manifest.BuildDependencies, ScopeBuild)...)
+	targets := make([]string, 0, len(manifest.Target))
+	for target := range manifest.Target {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		t := manifest.Target[target]
+		deps = append(deps, cargoTable(t.Dependencies, ScopeRuntime)...)
+		deps = append(deps, cargoTable(t.DevDependencies, ScopeDev)...)
+		deps = append(deps, cargoTable(t.BuildDependencies, ScopeBuild)...)
+	}
+	return
+}
+
+// cargoTable reads a Cargo dependency table, whose entries are either a
+// version ("1.0") or a table ({ version = "1.0", optional = true,
+// package = "real-name" }).
+func cargoTable(table map[string]interface{}, scope string) []Dependency {
+	var deps []Dependency
+	for name, spec := range table {
+		dep := Dependency{Name: name, Scope: scope}
+		switch spec := spec.(type) {
+		case string:
+			dep.Version = spec
+		case map[string]interface{}:
+			dep.Version, _ = spec["version"].(string)
+			if pkg, ok := spec["package"].(string); ok {
+				dep.Name = pkg // Renamed dependency
+			}
+			if optional, _ := spec["optional"].(bool); optional && scope == ScopeRuntime {
+				dep.Scope = ScopeOptional
+			}
+		}
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps
+}
+
+// pyproject is the part of pyproject.toml that declares dependencies, in
+// both the PEP 621 [project] table and Poetry's [tool.poetry].
+type pyproject struct {
+	Project struct {
+		Dependencies         []string            `toml:"dependencies"`
+		OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+	} `toml:"project"`
+	DependencyGroups map[string][]interface{} `toml:"dependency-groups"` // PEP 735
+	BuildSystem      struct {
+		Requires []string `toml:"requires"`
+	} `toml:"build-system"`
+	Tool struct {
+		Poetry struct {
+			Dependencies    map[string]interface{} `toml:"dependencies"`
+			DevDependencies map[string]interface{} `toml:"dev-dependencies"`
+			Group           map[string]struct {
+				Dependencies map[string]interface{} `toml:"dependencies"`
+			} `toml:"group"`
+		} `toml:"poetry"`
+	} `toml:"tool"`
+}
+
+func parsePyproject(c string) (deps []Dependency) {
+	var manifest pyproject
+	if _, err := toml.Decode(c, &manifest); err != nil {
+		return nil
+	}
+	deps = append(deps, pep508List(manifest.Project.Dependencies, ScopeRuntime)...)
+	for _, group := range sortedKeys(manifest.Project.OptionalDependencies) {
+		deps = append(deps, pep508List(manifest.Project.OptionalDependencies[group], ScopeOptional)...)
+	}
+	for _, group := range sortedKeys(manifest.DependencyGroups) {
+		var reqs []string
+		for _, req := range manifest.DependencyGroups[group] {
+			if s, ok := req.(string); ok {
+				reqs = append(reqs, s) // Skips {include-group = "..."}
+			}
+		}
+		deps = append(deps, pep508List(reqs, groupScope(group))...)
+	}
+	deps = append(deps, pep508List(manifest.BuildSystem.Requires, ScopeBuild)...)
+
+	poetry := manifest.Tool.Poetry
+	deps = append(deps, poetryTable(poetry.Dependencies, ScopeRuntime)...)
+	deps = append(deps, poetryTable(poetry.DevDependencies, ScopeDev)...)
+	for _, group := range sortedKeys(poetry.Group) {
+		deps = append(deps, poetryTable(poetry.Group[group].Dependencies, groupScope(group))...)
+	}
+	return
+}
+
+// groupScope returns the scope of a named dependency group: test groups
+// are test dependencies, the others development ones.
+func groupScope(group string) string {
+	if strings.Contains(strings.ToLower(group), "test") {
+		return ScopeTest
+	}
+	return ScopeDev
+}
+
+// pep508List parses a list of PEP 508 requirements.
+func pep508List(reqs []string, scope string) []Dependency {
+	var deps []Dependency
+	for _, req := range reqs {
+		if name, version, ok := parsePEP508(req); ok {
+			deps = append(deps, Dependency{Name: name, Version: version, Scope: scope})
+		}
+	}
+	return deps
+}
+
+// poetryTable reads a Poetry or Pipfile dependency table, whose entries are
+// either a constraint ("^2.0", "*") or a table ({ version = "^2.0",
+// optional = true }). The python entry is the interpreter, not a package.
+func poetryTable(table map[string]interface{}, scope string) []Dependency {
+	var deps []Dependency
+	for name, spec := range table {
+		if strings.EqualFold(name, "python") {
+			continue
+		}
+		dep := Dependency{Name: name, Scope: scope}
+		switch spec := spec.(type) {
+		case string:
+			dep.Version = spec
+		case map[string]interface{}:
+			dep.Version, _ = spec["version"].(string)
+			if optional, _ := spec["optional"].(bool); optional && scope == ScopeRuntime {
+				dep.Scope = ScopeOptional
+			}
+		}
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps
+}
+
+func parsePipfile(c string) (deps []Dependency) {
+	var manifest struct {
+		Packages    map[string]interface{} `toml:"packages"`
+		DevPackages map[string]interface{} `toml:"dev-packages"`
+	}
+	if _, err := toml.Decode(c, &manifest); err != nil {
+		return nil
+	}
+	deps = append(deps, poetryTable(manifest.Packages, ScopeRuntime)...)
+	deps = append(deps, poetryTable(manifest.DevPackages, ScopeDev)...)
+	return
+}
+
+var (
+	// gemLine matches gem 'name', '~> 1.0', '>= 1.0.1', group: :test
+	gemLine     = regexp.MustCompile(`^gem\s*\(?\s*['"]([^'"]+)['"]((?:\s*,\s*['"][^'"]*['"])*)(.*)$`)
+	gemVersion  = regexp.MustCompile(`['"]([^'"]*)['"]`)
+	gemGroupOpt = regexp.MustCompile(`(?:group|groups)\s*(?::|=>)\s*\[?([^\]]*)`)
+	gemGroup    = regexp.MustCompile(`^group\s*\(?([^)]*?)\)?\s+do\b`)
+	rubySymbol  = regexp.MustCompile(`:(\w+)|['"](\w+)['"]`)
+)
+
+func parseGemfile(c string) (deps []Dependency) {
+	var blocks []string // Scopes of the enclosing do ... end blocks
+	scope := func() string {
+		if len(blocks) > 0 {
+			return blocks[len(blocks)-1]
+		}
+		return ScopeRuntime
+	}
+	for _, line := range strings.Split(c, "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, " #"); i != -1 {
+			line = line[:i]
+		}
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case gemGroup.MatchString(line):
+			blocks = append(blocks, gemScope(gemGroup.FindStringSubmatch(line)[1]))
+			continue
+		case strings.HasSuffix(line, " do") || strings.Contains(line, " do |"):
+			blocks = append(blocks, scope()) // platforms, source, ...
+			continue
+		case line == "end":
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		}
+
+		m := gemLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var versions []string
+		for _, v := range gemVersion.FindAllStringSubmatch(m[2], -1) {
+			versions = append(versions, v[1])
+		}
+		dep := Dependency{Name: m[1], Version: strings.Join(versions, ", "), Scope: scope()}
+		if opt := gemGroupOpt.FindStringSubmatch(m[3]); opt != nil {
+			dep.Scope = gemScope(opt[1])
+		}
+		deps = append(deps, dep)
+	}
+	return
+}
+
+// gemScope returns the scope of a Bundler group list (":development,
+// :test"). Gems in the default group alongside others are still runtime
+// dependencies.
+func gemScope(list string) string {
+	scope := ""
+	for _, m := range rubySymbol.FindAllStringSubmatch(list, -1) {
+		group := m[1] + m[2]
+		switch group {
+		case "default", "production":
+			return ScopeRuntime
+		case "test":
+			if scope == "" {
+				scope = ScopeTest
+			}
+		default:
+			scope = ScopeDev
+		}
+	}
+	if scope == "" {
+		return ScopeRuntime
+	}
+	return scope
+}
+
+// pomProject is the part of a Maven pom.xml that declares dependencies.
+type pomProject struct {
+	GroupID string `xml:"groupId"`
+	Version string `xml:"version"`
+	Parent  struct {
+		GroupID string `xml:"groupId"`
+		Version string `xml:"version"`
+	} `xml:"parent"`
+	Properties struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Dependencies []pomDependency `xml:"dependencies>dependency"`
+	Profiles     []struct {
+		Dependencies []pomDependency `xml:"dependencies>dependency"`
+	} `xml:"profiles>profile"`
+}
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+	Optional   string `xml:"optional"`
+}
+
+// pomProperty matches a ${property} reference.
+var pomProperty = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+func parsePom(c string) (deps []Dependency) {
+	var project pomProject
+	if xml.Unmarshal([]byte(c), &project) != nil {
+		return nil
+	}
+	props := map[string]string{
+		"project.groupId":        firstNonEmpty(project.GroupID, project.Parent.GroupID),
+		"project.version":        firstNonEmpty(project.Version, project.Parent.Version),
+		"project.parent.version": project.Parent.Version,
+	}
+	for _, p := range project.Properties.Entries {
+		props[p.XMLName.Local] = strings.TrimSpace(p.Value)
+	}
+	resolve := func(s string) string {
+		return pomProperty.ReplaceAllStringFunc(strings.TrimSpace(s), func(ref string) string {
+			if v, ok := props[ref[2:len(ref)-1]]; ok && v != "" {
+				return v
+			}
+			return ref
+		})
+	}
+
+	all := project.Dependencies
+	for _, profile := range project.Profiles {
+		all = append(all, profile.Dependencies...)
+	}
+	for _, d := range all {
+		dep := Dependency{Name: resolve(d.GroupID) + ":" + resolve(d.ArtifactID), Version: resolve(d.Version)}
+		switch strings.TrimSpace(d.Scope) {
+		case "test":
+			dep.Scope = ScopeTest
+		case "provided", "system":
+			dep.Scope = ScopeBuild // Supplied by the runtime environment, not packaged
+		default:
+			dep.Scope = ScopeRuntime
+		}
+		if strings.TrimSpace(d.Optional) == "true" && dep.Scope == ScopeRuntime {
+			dep.Scope = ScopeOptional
+		}
+		deps = append(deps, dep)
+	}
+	return
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+var (
+	// gradleDependency matches a configuration with a string notation:
+	// implementation "g:a:1.0" and implementation("g:a:1.0")
+	gradleDependency = regexp.MustCompile(`(?m)^\s*(\w+)\s*\(?\s*(?:platform\(\s*)?["']([^"':\s]+):([^"':\s]+)(?::([^"'\s]*))?["']`)
+
+	// gradleMapDependency matches the map notation:
+	// implementation group: 'g', name: 'a', version: '1.0'
+	gradleMapDependency = regexp.MustCompile(`(?m)^\s*(\w+)\s*\(?\s*group\s*[:=]\s*["']([^"']+)["']\s*,\s*name\s*[:=]\s*["']([^"']+)["'](?:\s*,\s*version\s*[:=]\s*["']([^"']+)["'])?`)
+)
+
+// gradleScopes maps Gradle configurations to dependency scopes.
+var gradleScopes = map[string]string{
+	"implementation":            ScopeRuntime,
+	"api":                       ScopeRuntime,
+	"compile":                   ScopeRuntime,
+	"runtimeOnly":               ScopeRuntime,
+	"runtime":                   ScopeRuntime,
+	"compileOnly":               ScopeBuild,
+	"compileOnlyApi":            ScopeBuild,
+	"annotationProcessor":       ScopeBuild,
+	"kapt":                      ScopeBuild,
+	"ksp":                       ScopeBuild,
+	"classpath":                 ScopeBuild,
+	"developmentOnly":           ScopeDev,
+	"testImplementation":        ScopeTest,
+	"testCompileOnly":           ScopeTest,
+	"testRuntimeOnly":           ScopeTest,
+	"testCompile":               ScopeTest,
+	"testRuntime":               ScopeTest,
+	"androidTestImplementation": ScopeTest,
+	"debugImplementation":       ScopeDev,
+}
+
+func parseGradle(c string) (deps []Dependency) {
+	add := func(config, group, artifact, version string) {
+		scope, ok := gradleScopes[config]
+		if !ok {
+			return // Not a dependency configuration
+		}
+		deps = append(deps, Dependency{Name: group + ":" + artifact, Version: version, Scope: scope})
+	}
+	for _, m := range gradleDependency.FindAllStringSubmatch(c, -1) {
+		add(m[1], m[2], m[3], m[4])
+	}
+	for _, m := range gradleMapDependency.FindAllStringSubmatch(c, -1) {
+		add(m[1], m[2], m[3], m[4])
+	}
+	return
+}
+
+func parseComposer(c string) (deps []Dependency) {
+	var manifest struct {
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if json.Unmarshal([]byte(c), &manifest) != nil {
+		return nil
+	}
+	for _, d := range append(versionMap(manifest.Require, ScopeRuntime), versionMap(manifest.RequireDev, ScopeDev)...) {
+		if d.Name == "php" || strings.HasPrefix(d.Name, "ext-") || strings.HasPrefix(d.Name, "lib-") {
+			continue // Platform requirements
+		}
+		deps = append(deps, d)
+	}
+	return
+}
+
+func parsePubspec(c string) (deps []Dependency) {
+	var manifest struct {
+		Dependencies    map[string]interface{} `yaml:"dependencies"`
+		DevDependencies map[string]interface{} `yaml:"dev_dependencies"`
+	}
+	if yaml.Unmarshal([]byte(c), &manifest) != nil {
+		return nil
+	}
+	deps = append(deps, pubspecTable(manifest.Dependencies, ScopeRuntime)...)
+	deps = append(deps, pubspecTable(manifest.DevDependencies, ScopeDev)...)
+	return
+}
+
+// pubspecTable reads pubspec dependencies, whose entries are a constraint
+// ("^1.2.0"), empty (any version) or a source map ({ version: ..., git:
+// ..., sdk: flutter }).
+func pubspecTable(table map[string]interface{}, scope string) []Dependency {
+	var deps []Dependency
+	for name, spec := range table {
+		dep := Dependency{Name: name, Scope: scope}
+		switch spec := spec.(type) {
+		case string:
+			dep.Version = spec
+		case map[string]interface{}:
+			if v, ok := spec["version"]; ok {
+				dep.Version = fmt.Sprint(v)
+			}
+		}
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps
+}
+
+func parseCsproj(c string) (deps []Dependency) {
+	var project struct {
+		ItemGroups []struct {
+			References []struct {
+				Include        string `xml:"Include,attr"`
+				Update         string `xml:"Update,attr"`
+				Version        string `xml:"Version,attr"`
+				VersionElement string `xml:"Version"`
+				PrivateAssets  string `xml:"PrivateAssets,attr"`
+				PrivateElement string `xml:"PrivateAssets"`
+			} `xml:"PackageReference"`
+		} `xml:"ItemGroup"`
+	}
+	if xml.Unmarshal([]byte(c), &project) != nil {
+		return nil
+	}
+	for _, group := range project.ItemGroups {
+		for _, ref := range group.References {
+			name := firstNonEmpty(ref.Include, ref.Update)
+			if name == "" {
+				continue
+			}
+			dep := Dependency{Name: name, Version: firstNonEmpty(ref.Version, ref.VersionElement), Scope: ScopeRuntime}
+			// Analyzers and build tools don't flow to consumers
+			if strings.EqualFold(firstNonEmpty(ref.PrivateAssets, ref.PrivateElement), "all") {
+				dep.Scope = ScopeDev
+			}
+			deps = append(deps, dep)
+		}
+	}
+	return
+}
+
+// descriptionScopes maps the dependency fields of an R DESCRIPTION file to
+// scopes.
+var descriptionScopes = map[string]string{
+	"Depends":   ScopeRuntime,
+	"Imports":   ScopeRuntime,
+	"LinkingTo": ScopeBuild,
+	"Suggests":  ScopeOptional,
+	"Enhances":  ScopeOptional,
+}
+
+// rPackage matches "pkg (>= 1.0)" in a DESCRIPTION dependency field.
+var rPackage = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9.]*)\s*(?:\(\s*([^)]*?)\s*\))?$`)
+
+func parseDescription(c string) (deps []Dependency) {
+	// DESCRIPTION is a Debian control file: "Field: value", continued on
+	// indented lines
+	fields := make(map[string]string)
+	var order []string
+	field := ""
+	for _, line := range strings.Split(c, "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && field != "" {
+			fields[field] += " " + strings.TrimSpace(line)
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			field = ""
+			continue
+		}
+		field = strings.TrimSpace(name)
+		fields[field] = strings.TrimSpace(value)
+		order = append(order, field)
+	}
+
+	for _, field := range order {
+		scope, ok := descriptionScopes[field]
+		if !ok {
+			continue
+		}
+		for _, entry := range strings.Split(fields[field], ",") {
+			m := rPackage.FindStringSubmatch(strings.TrimSpace(entry))
+			if m == nil || m[1] == "R" {
+				continue // R itself is the interpreter
+			}
+			deps = append(deps, Dependency{Name: m[1], Version: m[2], Scope: scope})
+		}
+	}
+	return
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,362 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseManifests(t *testing.T) {
+	tests := []struct {
+		name, file, lang, content string
+		want                      []Dependency
+	}{
+		{
+			name: "go.mod",
+			file: "go.mod", lang: "go",
+			content: "module example.com/app\n\ngo 1.22\n\nrequire (\n\tgithub.com/pkg/errors v0.9.1\n\tgolang.org/x/text v0.14.0 // indirect\n)\n\nfuture directive\n",
+			want: []Dependency{
+				{Name: "github.com/pkg/errors", Version: "v0.9.1"},
+				{Name: "golang.org/x/text", Version: "v0.14.0"},
+			},
+		},
+		{
+			name: "requirements.txt",
+			file: "requirements.txt", lang: "python",
+			content: "# Web\n-r base.txt\n--index-url https://pypi.example.com\nrequests[socks] >=2.8 ; python_version < '3.12'\nflask==2.0 # pinned\nDjango\n\n",
+			want: []Dependency{
+				{Name: "requests", Version: ">=2.8"},
+				{Name: "flask", Version: "==2.0"},
+				{Name: "Django"},
+			},
+		},
+		{
+			name: "pyproject.toml, PEP 621",
+			file: "pyproject.toml", lang: "python",
+			content: `[project]
+name = "app"
+dependencies = ["httpx>=0.27", "rich (>=13)"]
+
+[project.optional-dependencies]
+cli = ["click"]
+
+[dependency-groups]
+test = ["pytest>=8", {include-group = "lint"}]
+lint = ["ruff"]
+
+[build-system]
+requires = ["hatchling"]
+`,
+			want: []Dependency{
+				{Name: "httpx", Version: ">=0.27", Scope: ScopeRuntime},
+				{Name: "rich", Version: ">=13", Scope: ScopeRuntime},
+				{Name: "click", Scope: ScopeOptional},
+				{Name: "ruff", Scope: ScopeDev},
+				{Name: "pytest", Version: ">=8", Scope: ScopeTest},
+				{Name: "hatchling", Scope: ScopeBuild},
+			},
+		},
+		{
+			name: "pyproject.toml, Poetry",
+			file: "pyproject.toml", lang: "python",
+			content: `[tool.poetry.dependencies]
+python = "^3.11"
+requests = "^2.31"
+uvloop = { version = "^0.19", optional = true }
+
+[tool.poetry.dev-dependencies]
+black = "*"
+
+[tool.poetry.group.test.dependencies]
+pytest = "^8"
+
+[tool.poetry.group.docs.dependencies]
+mkdocs = { version = "^1.5" }
+`,
+			want: []Dependency{
+				{Name: "requests", Version: "^2.31", Scope: ScopeRuntime},
+				{Name: "uvloop", Version: "^0.19", Scope: ScopeOptional},
+				{Name: "black", Version: "*", Scope: ScopeDev},
+				{Name: "mkdocs", Version: "^1.5", Scope: ScopeDev},
+				{Name: "pytest", Version: "^8", Scope: ScopeTest},
+			},
+		},
+		{
+			name: "Pipfile",
+			file: "Pipfile", lang: "python",
+			content: "[packages]\nrequests = \"*\"\ndjango = { version = \">=4\" }\n\n[dev-packages]\npytest = \"*\"\n\n[requires]\npython_version = \"3.12\"\n",
+			want: []Dependency{
+				{Name: "django", Version: ">=4", Scope: ScopeRuntime},
+				{Name: "requests", Version: "*", Scope: ScopeRuntime},
+				{Name: "pytest", Version: "*", Scope: ScopeDev},
+			},
+		},
+		{
+			name: "package.json",
+			file: "package.json", lang: "javascript",
+			content: `{"name": "web", "dependencies": {"react": "^18.2.0", "axios": "1.6.0"}, "devDependencies": {"vite": "^5"}, "peerDependencies": {"react-dom": ">=18"}, "optionalDependencies": {"fsevents": "^2"}}`,
+			want: []Dependency{
+				{Name: "axios", Version: "1.6.0", Scope: ScopeRuntime},
+				{Name: "react", Version: "^18.2.0", Scope: ScopeRuntime},
+				{Name: "vite", Version: "^5", Scope: ScopeDev},
+				{Name: "react-dom", Version: ">=18", Scope: ScopePeer},
+				{Name: "fsevents", Version: "^2", Scope: ScopeOptional},
+			},
+		},
+		{
+			name: "Podfile",
+			file: "Podfile", lang: "swift",
+			content: "platform :ios, '15.0'\ntarget 'App' do\n  pod 'Alamofire', '~> 5.8'\n  pod \"SwiftyJSON\"\nend\n",
+			want: []Dependency{
+				{Name: "Alamofire", Version: "~> 5.8"},
+				{Name: "SwiftyJSON"},
+			},
+		},
+		{
+			name: "Package.swift",
+			file: "Package.swift", lang: "swift",
+			content: `let package = Package(
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-nio.git", from: "2.62.0"),
+        .package(url: "https://github.com/vapor/vapor", "4.0.0"..<"5.0.0"),
+        .package(url: "https://github.com/apple/swift-log", branch: "main"),
+    ]
+)
+`,
+			want: []Dependency{
+				{Name: "swift-nio", Version: "2.62.0"},
+				{Name: "vapor", Version: "4.0.0..<5.0.0"},
+				{Name: "swift-log", Version: "main"},
+			},
+		},
+		{
+			name: "Cargo.toml",
+			file: "Cargo.toml", lang: "rust",
+			content: `[package]
+name = "app"
+
+[dependencies]
+serde = { version = "1", features = ["derive"] }
+tokio = "1"
+openssl = { version = "0.10", optional = true }
+log = { package = "tracing", version = "0.1" }
+
+[dev-dependencies]
+insta = "1"
+
+[build-dependencies]
+cc = "1"
+
+[target.'cfg(windows)'.dependencies]
+winapi = "0.3"
+
+[target.'cfg(unix)'.dev-dependencies]
+nix = "0.27"
+`,
+			want: []Dependency{
+				{Name: "openssl", Version: "0.10", Scope: ScopeOptional},
+				{Name: "serde", Version: "1", Scope: ScopeRuntime},
+				{Name: "tokio", Version: "1", Scope: ScopeRuntime},
+				{Name: "tracing", Version: "0.1", Scope: ScopeRuntime},
+				{Name: "insta", Version: "1", Scope: ScopeDev},
+				{Name: "cc", Version: "1", Scope: ScopeBuild},
+				{Name: "nix", Version: "0.27", Scope: ScopeDev},
+				{Name: "winapi", Version: "0.3", Scope: ScopeRuntime},
+			},
+		},
+		{
+			name: "Gemfile",
+			file: "Gemfile", lang: "ruby",
+			content: `source "https://rubygems.org"
+
+gem "rails", "~> 7.1", ">= 7.1.2"
+gem 'pg' # database
+gem "rspec-rails", group: :test
+
+group :development, :test do
+  gem "rubocop", require: false
+end
+
+group :test do
+  gem "capybara"
+end
+
+gem "bootsnap", groups: [:default, :development]
+
+platforms :jruby do
+  gem "jdbc"
+end
+`,
+			want: []Dependency{
+				{Name: "rails", Version: "~> 7.1, >= 7.1.2", Scope: ScopeRuntime},
+				{Name: "pg", Scope: ScopeRuntime},
+				{Name: "rspec-rails", Scope: ScopeTest},
+				{Name: "rubocop", Scope: ScopeDev},
+				{Name: "capybara", Scope: ScopeTest},
+				{Name: "bootsnap", Scope: ScopeRuntime},
+				{Name: "jdbc", Scope: ScopeRuntime},
+			},
+		},
+		{
+			name: "pom.xml",
+			file: "pom.xml", lang: "java",
+			content: `<project>
+  <parent><groupId>com.acme</groupId><version>2.0.0</version></parent>
+  <properties><junit.version>5.10.1</junit.version></properties>
+  <dependencies>
+    <dependency><groupId>${project.groupId}</groupId><artifactId>core</artifactId><version>${project.version}</version></dependency>
+    <dependency><groupId>org.junit.jupiter</groupId><artifactId>junit-jupiter</artifactId><version>${junit.version}</version><scope>test</scope></dependency>
+    <dependency><groupId>jakarta.servlet</groupId><artifactId>jakarta.servlet-api</artifactId><scope>provided</scope></dependency>
+    <dependency><groupId>com.h2database</groupId><artifactId>h2</artifactId><version>${h2.version}</version><optional>true</optional></dependency>
+  </dependencies>
+  <profiles><profile><dependencies>
+    <dependency><groupId>org.postgresql</groupId><artifactId>postgresql</artifactId><version>42.7.1</version></dependency>
+  </dependencies></profile></profiles>
+</project>
+`,
+			want: []Dependency{
+				{Name: "com.acme:core", Version: "2.0.0", Scope: ScopeRuntime},
+				{Name: "org.junit.jupiter:junit-jupiter", Version: "5.10.1", Scope: ScopeTest},
+				{Name: "jakarta.servlet:jakarta.servlet-api", Scope: ScopeBuild},
+				{Name: "com.h2database:h2", Version: "${h2.version}", Scope: ScopeOptional},
+				{Name: "org.postgresql:postgresql", Version: "42.7.1", Scope: ScopeRuntime},
+			},
+		},
+		{
+			name: "build.gradle",
+			file: "build.gradle", lang: "java",
+			content: `dependencies {
+    implementation 'com.google.guava:guava:32.1.0-jre'
+    implementation platform('org.springframework.boot:spring-boot-dependencies:3.2.0')
+    testImplementation "junit:junit:4.13.2"
+    custom 'com.acme:plugin:1.0'
+    compileOnly group: 'org.projectlombok', name: 'lombok', version: '1.18.30'
+}
+`,
+			want: []Dependency{
+				{Name: "com.google.guava:guava", Version: "32.1.0-jre", Scope: ScopeRuntime},
+				{Name: "org.springframework.boot:spring-boot-dependencies", Version: "3.2.0", Scope: ScopeRuntime},
+				{Name: "junit:junit", Version: "4.13.2", Scope: ScopeTest},
+				{Name: "org.projectlombok:lombok", Version: "1.18.30", Scope: ScopeBuild},
+			},
+		},
+		{
+			name: "build.gradle.kts",
+			file: "build.gradle.kts", lang: "kotlin",
+			content: `dependencies {
+    implementation("io.ktor:ktor-server-core:2.3.7")
+    ksp("com.google.dagger:dagger-compiler:2.50")
+    testRuntimeOnly("org.junit.platform:junit-platform-launcher")
+}
+`,
+			want: []Dependency{
+				{Name: "io.ktor:ktor-server-core", Version: "2.3.7", Scope: ScopeRuntime},
+				{Name: "com.google.dagger:dagger-compiler", Version: "2.50", Scope: ScopeBuild},
+				{Name: "org.junit.platform:junit-platform-launcher", Scope: ScopeTest},
+			},
+		},
+		{
+			name: "composer.json",
+			file: "composer.json", lang: "php",
+			content: `{"require": {"php": ">=8.1", "ext-json": "*", "lib-curl": "*", "monolog/monolog": "^3.0"}, "require-dev": {"phpunit/phpunit": "^10"}}`,
+			want: []Dependency{
+				{Name: "monolog/monolog", Version: "^3.0", Scope: ScopeRuntime},
+				{Name: "phpunit/phpunit", Version: "^10", Scope: ScopeDev},
+			},
+		},
+		{
+			name: "pubspec.yaml",
+			file: "pubspec.yaml", lang: "dart",
+			content: "name: app\ndependencies:\n  flutter:\n    sdk: flutter\n  http: ^1.1.0\n  path:\n  collection:\n    version: 1.18\ndev_dependencies:\n  lints: ^3.0.0\n",
+			want: []Dependency{
+				{Name: "collection", Version: "1.18", Scope: ScopeRuntime},
+				{Name: "flutter", Scope: ScopeRuntime},
+				{Name: "http", Version: "^1.1.0", Scope: ScopeRuntime},
+				{Name: "path", Scope: ScopeRuntime},
+				{Name: "lints", Version: "^3.0.0", Scope: ScopeDev},
+			},
+		},
+		{
+			name: "csproj",
+			file: "App.csproj", lang: "c_sharp",
+			content: `<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" Version="13.0.3" />
+    <PackageReference Update="Serilog"><Version>3.1.1</Version></PackageReference>
+    <PackageReference Include="StyleCop.Analyzers" Version="1.1.118" PrivateAssets="all" />
+  </ItemGroup>
+  <ItemGroup>
+    <PackageReference Include="coverlet.collector" Version="6.0.0"><PrivateAssets>All</PrivateAssets></PackageReference>
+    <PackageReference Version="1.0.0" />
+  </ItemGroup>
+</Project>
+`,
+			want: []Dependency{
+				{Name: "Newtonsoft.Json", Version: "13.0.3", Scope: ScopeRuntime},
+				{Name: "Serilog", Version: "3.1.1", Scope: ScopeRuntime},
+				{Name: "StyleCop.Analyzers", Version: "1.1.118", Scope: ScopeDev},
+				{Name: "coverlet.collector", Version: "6.0.0", Scope: ScopeDev},
+			},
+		},
+		{
+			name: "DESCRIPTION",
+			file: "DESCRIPTION", lang: "r",
+			content: "Package: tidyish\nVersion: 0.1.0\nDepends: R (>= 4.1), methods\nImports:\n    dplyr (>= 1.0.0),\n    rlang\nLinkingTo: Rcpp\nSuggests: testthat (>= 3.0.0)\n",
+			want: []Dependency{
+				{Name: "methods", Scope: ScopeRuntime},
+				{Name: "dplyr", Version: ">= 1.0.0", Scope: ScopeRuntime},
+				{Name: "rlang", Scope: ScopeRuntime},
+				{Name: "Rcpp", Scope: ScopeBuild},
+				{Name: "testthat", Version: ">= 3.0.0", Scope: ScopeOptional},
+			},
+		},
+
+		// Malformed manifests declare nothing
+		{name: "malformed go.mod", file: "go.mod", lang: "go", content: "module\nrequire (\n"},
+		{name: "malformed pyproject.toml", file: "pyproject.toml", lang: "python", content: "[project\ndependencies = ["},
+		{name: "malformed Pipfile", file: "Pipfile", lang: "python", content: "[packages]\nrequests = "},
+		{name: "malformed package.json", file: "package.json", lang: "javascript", content: `{"dependencies": {"react": `},
+		{name: "malformed Cargo.toml", file: "Cargo.toml", lang: "rust", content: "[dependencies\nserde = 1"},
+		{name: "malformed pom.xml", file: "pom.xml", lang: "java", content: "<project><dependencies>"},
+		{name: "malformed composer.json", file: "composer.json", lang: "php", content: "{require}"},
+		{name: "malformed pubspec.yaml", file: "pubspec.yaml", lang: "dart", content: "dependencies: [http\n"},
+		{name: "malformed csproj", file: "Lib.csproj", lang: "c_sharp", content: "<Project><ItemGroup>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, ok := manifestFor(tt.file)
+			if !ok {
+				t.Fatalf("no parser for %s", tt.file)
+			}
+			if parser.lang != tt.lang {
+				t.Errorf("%s is %s, want %s", tt.file, parser.lang, tt.lang)
+			}
+			if got := parser.parse(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dependencies:\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := manifestFor("setup.py"); ok {
+		t.Error("setup.py has a parser")
+	}
+}
+
+func TestReadDependencies(t *testing.T) {
+	isolateGit(t)
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"go.mod":                          "module example.com/app\n\nrequire github.com/pkg/errors v0.9.1\n",
+		"web/package.json":                `{"dependencies": {"react": "^18"}}`,
+		"web/node_modules/x/package.json": `{"dependencies": {"left-pad": "1"}}`,
+		"tools/requirements.txt":          "requests\n",
+	})
+
+	want := map[string][]Dependency{
+		"go":         {{Name: "github.com/pkg/errors", Version: "v0.9.1", Scope: ScopeRuntime, Manifest: "go.mod"}},
+		"javascript": {{Name: "react", Version: "^18", Scope: ScopeRuntime, Manifest: "web/package.json"}},
+		"python":     {{Name: "requests", Scope: ScopeRuntime, Manifest: "tools/requirements.txt"}},
+	}
+	if got := ReadDependencies(root); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadDependencies:\n got %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package scanner
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// packageKinds are the top-level declarations naming a file's package or
+// namespace.
+var packageKinds = map[string]bool{
+	"package_clause":                    true, // Go
+	"package_declaration":               true, // Java
+	"package_header":                    true, // Kotlin
+	"namespace_declaration":             true, // C#
+	"file_scoped_namespace_declaration": true, // C# 10
+	"namespace_definition":              true, // PHP
+}
+
+// packageNameKinds are the nodes holding the name inside a package declaration.
+var packageNameKinds = map[string]bool{
+	"package_identifier": true,
+	"identifier":         true,
+	"scoped_identifier":  true,
+	"qualified_name":     true,
+	"namespace_name":     true,
+}
+
+// declaredPackage returns the package or namespace declared at the top of a
+// file (package foo, package com.acme;, namespace Acme.Core), or "".
+func declaredPackage(root *tree_sitter.Node, content []byte) string {
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		decl := root.NamedChild(i)
+		if !packageKinds[decl.Kind()] {
+			continue
+		}
+		if name := decl.ChildByFieldName("name"); name != nil {
+			return name.Utf8Text(content)
+		}
+		for j := uint(0); j < decl.NamedChildCount(); j++ {
+			if child := decl.NamedChild(j); packageNameKinds[child.Kind()] {
+				return child.Utf8Text(content)
+			}
+		}
+	}
+	return ""
+}
+
+// QualifyPackages replaces each file's declared package with its fully
+// qualified form: the import path for Go, the dotted module path for
+// Python and the extensionless module path for TS/JS. Java, Kotlin, C# and
+// PHP keep their declared package or namespace; other languages use the
+// file's directory. Call receivers naming an in-tree import get the
+// imported file's package.
+func (r *ImportResolver) QualifyPackages(analyses []FileAnalysis) {
+	for i := range analyses {
+		a := &analyses[i]
+		file := filepath.ToSlash(a.Path)
+		switch a.Language {
+		case "go":
+			a.Package = r.goImportPath(file, a.Package)
+		case "python":
+			a.Package = r.pythonModulePath(file)
+		case "typescript", "javascript":
+			a.Package = scriptModulePath(file)
+		case "java", "kotlin", "c_sharp", "php":
+			// Declared in source
+		default:
+			if dir := path.Dir(file); dir != "." {
+				a.Package = dir
+			}
+		}
+	}
+
+	// Import aliases used as call receivers, and the imported types of typed
+	// receivers, name the imported module's package
+	pkgOf := make(map[string]string, len(analyses))
+	for _, a := range analyses {
+		pkgOf[filepath.ToSlash(a.Path)] = a.Package
+	}
+	for i := range analyses {
+		a := &analyses[i]
+		for j := range a.Calls {
+			call := &a.Calls[j]
+			if call.ReceiverPackage == "" || a.Language == "go" {
+				continue // Go import paths are already qualified
+			}
+			files := r.Resolve(a.Path, a.Language, call.ReceiverPackage)
+			if len(files) == 0 {
+				continue
+			}
+			pkg, ok := pkgOf[files[0]]
+			if !ok {
+				// Imported file left out of this scan: derive its module path
+				switch a.Language {
+				case "python":
+					pkg = r.pythonModulePath(files[0])
+				case "typescript", "javascript":
+					pkg = scriptModulePath(files[0])
+				}
+			}
+			call.ReceiverPackage = pkg
+		}
+	}
+}
+
+// goImportPath returns the import path of the package holding file, or the
+// declared package name outside any module. External test packages
+// (package foo_test) get a _test suffix.
+func (r *ImportResolver) goImportPath(file, declared string) string {
+	dir := path.Dir(file)
+	var best *goModule
+	for i := range r.goModules {
+		mod := &r.goModules[i]
+		if !withinDir(dir, mod.dir) {
+			continue
+		}
+		if best == nil || (mod.dir != "." && (best.dir == "." || len(mod.dir) > len(best.dir))) {
+			best = mod
+		}
+	}
+	if best == nil {
+		return declared
+	}
+
+	importPath := best.path
+	if dir != best.dir {
+		importPath += "/" + strings.TrimPrefix(dir, best.dir+"/")
+	}
+	if strings.HasSuffix(declared, "_test") {
+		importPath += "_test"
+	}
+	return importPath
+}
+
+// withinDir reports whether dir is parent or one of its subdirectories.
+func withinDir(dir, parent string) bool {
+	return parent == "." || dir == parent || strings.HasPrefix(dir, parent+"/")
+}
+
+// pythonModulePath returns the dotted module path of a Python file, climbing
+// through the enclosing directories that are packages (have __init__.py).
+func (r *ImportResolver) pythonModulePath(file string) string {
+	mod := strings.TrimSuffix(file, path.Ext(file))
+	if path.Base(mod) == "__init__" {
+		mod = path.Dir(mod)
+	}
+	if mod == "." {
+		return ""
+	}
+
+	parts := []string{path.Base(mod)}
+	for dir := path.Dir(mod); dir != "." && r.files[path.Join(dir, "__init__.py")]; dir = path.Dir(dir) {
+		parts = append([]string{path.Base(dir)}, parts...)
+	}
+	return strings.Join(parts, ".")
+}
+
+// scriptModulePath returns the specifier-style path of a TS/JS module:
+// the file path without extension, or the directory for index files.
+func scriptModulePath(file string) string {
+	mod := strings.TrimSuffix(file, path.Ext(file))
+	mod = strings.TrimSuffix(mod, ".d")
+	if path.Base(mod) == "index" {
+		mod = path.Dir(mod)
+	}
+	if mod == "." {
+		return ""
+	}
+	return mod
+}
@@ -0,0 +1,75 @@
+package scanner
+
+import "testing"
+
+func TestDeclaredPackage(t *testing.T) {
+	tests := []struct {
+		name, file, src, want string
+	}{
+		{"go", "server.go", "// Package app serves.\npackage app\n\nfunc Run() {}\n", "app"},
+		{"go external test", "server_test.go", "package app_test\n", "app_test"},
+		{"java", "User.java", "package com.acme.core;\n\npublic class User {}\n", "com.acme.core"},
+		{"java default package", "Main.java", "public class Main {}\n", ""},
+		{"python", "views.py", "package = 'not a declaration'\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := analyzeSource(t, tt.file, tt.src, DetailNone).Package; got != tt.want {
+				t.Errorf("Package = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQualifyPackages(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"go.mod":       "module example.com/app\n",
+		"tools/go.mod": "module example.com/tools\n",
+	})
+	files := []string{
+		"main.go", "api/handler.go", "api/handler_test.go", "tools/gen/gen.go",
+		"app/__init__.py", "app/api/__init__.py", "app/api/routes.py", "scripts/run.py", "setup.py",
+		"web/src/index.ts", "web/src/util.d.ts", "web/src/app.tsx",
+		"src/main/java/com/acme/User.java",
+		"lib/build.sh", "deploy.sh",
+	}
+	r := NewImportResolver(root, files, []string{"go.mod", "tools/go.mod"})
+
+	tests := []struct {
+		file, lang, declared, want string
+	}{
+		{"main.go", "go", "main", "example.com/app"},
+		{"api/handler.go", "go", "api", "example.com/app/api"},
+		{"api/handler_test.go", "go", "api_test", "example.com/app/api_test"},
+		{"tools/gen/gen.go", "go", "gen", "example.com/tools/gen"}, // Nested module wins
+		{"app/api/routes.py", "python", "", "app.api.routes"},
+		{"app/api/__init__.py", "python", "", "app.api"},
+		{"scripts/run.py", "python", "", "run"}, // scripts/ isn't a package
+		{"setup.py", "python", "", "setup"},
+		{"web/src/index.ts", "typescript", "", "web/src"},
+		{"web/src/util.d.ts", "typescript", "", "web/src/util"},
+		{"web/src/app.tsx", "typescript", "", "web/src/app"},
+		{"src/main/java/com/acme/User.java", "java", "com.acme", "com.acme"},
+		{"lib/build.sh", "bash", "", "lib"},
+		{"deploy.sh", "bash", "", ""},
+	}
+	analyses := make([]FileAnalysis, len(tests))
+	for i, tt := range tests {
+		analyses[i] = FileAnalysis{Path: tt.file, Language: tt.lang, Package: tt.declared}
+	}
+	r.QualifyPackages(analyses)
+	for i, tt := range tests {
+		if got := analyses[i].Package; got != tt.want {
+			t.Errorf("%s: Package = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+
+	// Outside any module, Go files keep the declared name
+	bare := NewImportResolver(t.TempDir(), []string{"main.go"}, nil)
+	outside := []FileAnalysis{{Path: "main.go", Language: "go", Package: "main"}}
+	bare.QualifyPackages(outside)
+	if outside[0].Package != "main" {
+		t.Errorf("outside a module, Package = %q, want main", outside[0].Package)
+	}
+}
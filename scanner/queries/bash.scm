@@ -4,6 +4,11 @@
 (function_definition
   name: (word) @function)
 
+; Top-level variables: NAME=value
+(program
+  (variable_assignment
+    name: (variable_name) @var.name))
+
 ; source or . commands (imports)
 (command
   name: (command_name) @_cmd
@@ -5,11 +5,46 @@
   declarator: (function_declarator
     declarator: (identifier) @function))
 
+; Functions returning pointers: char *name(void), char **names(void)
+(function_definition
+  declarator: (pointer_declarator
+    declarator: (function_declarator
+      declarator: (identifier) @function)))
+
+(function_definition
+  declarator: (pointer_declarator
+    declarator: (pointer_declarator
+      declarator: (function_declarator
+        declarator: (identifier) @function))))
+
 ; Function declarations (prototypes)
 (declaration
   declarator: (function_declarator
     declarator: (identifier) @function))
 
+(declaration
+  declarator: (pointer_declarator
+    declarator: (function_declarator
+      declarator: (identifier) @function)))
+
+; File-scope variables: int x = 1; int y;
+(translation_unit
+  (declaration
+    declarator: (init_declarator
+      declarator: (identifier) @var.name)))
+
+(translation_unit
+  (declaration
+    declarator: (identifier) @var.name))
+
+; Macro constants: #define MAX 10
+(preproc_def
+  name: (identifier) @const.name)
+
+; Enum members
+(enumerator
+  name: (identifier) @const.name)
+
 ; #include directives
 (preproc_include
   path: (string_literal) @import)
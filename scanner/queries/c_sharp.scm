@@ -26,6 +26,38 @@
 (enum_declaration
   name: (identifier) @type.name) @type.enum
 
+; Base types: class A : B, IC (interfaces are told apart at graph build time)
+(class_declaration
+  name: (identifier) @super.type
+  (base_list (_) @super.extends))
+
+(struct_declaration
+  name: (identifier) @super.type
+  (base_list (_) @super.extends))
+
+(interface_declaration
+  name: (identifier) @super.type
+  (base_list (_) @super.extends))
+
+; Constants and static fields
+(field_declaration
+  (modifier) @_mod
+  (variable_declaration
+    (variable_declarator
+      (identifier) @const.name))
+  (#eq? @_mod "const"))
+
+(field_declaration
+  (modifier) @_mod
+  (variable_declaration
+    (variable_declarator
+      (identifier) @var.name))
+  (#eq? @_mod "static"))
+
+; Enum members
+(enum_member_declaration
+  name: (identifier) @const.name)
+
 ; Using directives
 (using_directive
   (qualified_name) @import)
@@ -11,6 +11,49 @@
     declarator: (qualified_identifier
       name: (identifier) @function)))
 
+; Functions returning pointers or references: char *name(), T &get()
+(function_definition
+  declarator: (pointer_declarator
+    declarator: (function_declarator
+      declarator: [
+        (identifier) @function
+        (qualified_identifier
+          name: (identifier) @function)
+      ])))
+
+(function_definition
+  declarator: (pointer_declarator
+    declarator: (pointer_declarator
+      declarator: (function_declarator
+        declarator: (identifier) @function))))
+
+(function_definition
+  declarator: (reference_declarator
+    (function_declarator
+      declarator: [
+        (identifier) @function
+        (qualified_identifier
+          name: (identifier) @function)
+      ])))
+
+; File-scope variables: int x = 1; int y;
+(translation_unit
+  (declaration
+    declarator: (init_declarator
+      declarator: (identifier) @var.name)))
+
+(translation_unit
+  (declaration
+    declarator: (identifier) @var.name))
+
+; Macro constants: #define MAX 10
+(preproc_def
+  name: (identifier) @const.name)
+
+; Enum members
+(enumerator
+  name: (identifier) @const.name)
+
 ; #include directives
 (preproc_include
   path: (string_literal) @import)
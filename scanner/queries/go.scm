@@ -23,6 +23,38 @@
     name: (type_identifier) @type.name
     type: (interface_type))) @type.interface
 
+; Interface method sets (used for structural satisfaction)
+(type_spec
+  name: (type_identifier) @super.type
+  type: (interface_type
+    (method_elem
+      name: (field_identifier) @super.method)))
+
+; Embedded interfaces
+(type_spec
+  name: (type_identifier) @super.type
+  type: (interface_type
+    (type_elem
+      [(type_identifier) (qualified_type)] @super.extends)))
+
+; Package-level variables: var x = 1, var ( ... )
+(source_file
+  (var_declaration
+    (var_spec
+      name: (identifier) @var.name)))
+
+(source_file
+  (var_declaration
+    (var_spec_list
+      (var_spec
+        name: (identifier) @var.name))))
+
+; Package-level constants
+(source_file
+  (const_declaration
+    (const_spec
+      name: (identifier) @const.name)))
+
 ; Import paths
 (import_spec
   path: (interpreted_string_literal) @import)
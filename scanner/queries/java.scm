@@ -22,6 +22,43 @@
 (enum_declaration
   name: (identifier) @type.name) @type.enum
 
+; Superclass: class A extends B
+(class_declaration
+  name: (identifier) @super.type
+  superclass: (superclass (_) @super.extends))
+
+; Implemented interfaces: class A implements B, C
+(class_declaration
+  name: (identifier) @super.type
+  interfaces: (super_interfaces
+    (type_list (_) @super.implements)))
+
+(enum_declaration
+  name: (identifier) @super.type
+  interfaces: (super_interfaces
+    (type_list (_) @super.implements)))
+
+; Parent interfaces: interface A extends B, C
+(interface_declaration
+  name: (identifier) @super.type
+  (extends_interfaces
+    (type_list (_) @super.extends)))
+
+; Static fields and constants
+(field_declaration
+  (modifiers "static" "final")
+  declarator: (variable_declarator
+    name: (identifier) @const.name))
+
+(field_declaration
+  (modifiers "static")
+  declarator: (variable_declarator
+    name: (identifier) @var.name))
+
+; Enum members
+(enum_constant
+  name: (identifier) @const.name)
+
 ; Import declarations
 (import_declaration
   (scoped_identifier) @import)
@@ -20,6 +20,43 @@
 (class_declaration
   name: (identifier) @type.name) @type.class
 
+; Class heritage: class A extends B
+(class_declaration
+  name: (identifier) @super.type
+  (class_heritage (_) @super.extends))
+
+; Top-level variables and constants (also when exported)
+(program
+  (lexical_declaration
+    "const"
+    (variable_declarator
+      name: (identifier) @const.name)))
+
+(program
+  (lexical_declaration
+    "let"
+    (variable_declarator
+      name: (identifier) @var.name)))
+
+(program
+  (variable_declaration
+    (variable_declarator
+      name: (identifier) @var.name)))
+
+(program
+  (export_statement
+    (lexical_declaration
+      "const"
+      (variable_declarator
+        name: (identifier) @const.name))))
+
+(program
+  (export_statement
+    (lexical_declaration
+      "let"
+      (variable_declarator
+        name: (identifier) @var.name))))
+
 ; ES6 imports: import x from 'y'
 (import_statement
   source: (string) @import)
@@ -4,6 +4,29 @@
 (function_declaration
   (simple_identifier) @function)
 
+; Class and interface declarations
+(class_declaration
+  (type_identifier) @type.name) @type.class
+
+; Superclass: class A : B()
+(class_declaration
+  (type_identifier) @super.type
+  (delegation_specifier
+    (constructor_invocation
+      (user_type) @super.extends)))
+
+; Interfaces: class A : B
+(class_declaration
+  (type_identifier) @super.type
+  (delegation_specifier
+    (user_type) @super.implements))
+
+; Top-level properties
+(source_file
+  (property_declaration
+    (variable_declaration
+      (simple_identifier) @var.name)))
+
 ; Import statements
 (import_header
   (identifier) @import)
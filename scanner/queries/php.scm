@@ -8,6 +8,54 @@
 (method_declaration
   name: (name) @function)
 
+; Class, interface, trait and enum declarations
+(class_declaration
+  name: (name) @type.name) @type.class
+
+(interface_declaration
+  name: (name) @type.name) @type.interface
+
+(trait_declaration
+  name: (name) @type.name) @type.trait
+
+(enum_declaration
+  name: (name) @type.name) @type.enum
+
+; Superclass: class A extends B
+(class_declaration
+  name: (name) @super.type
+  (base_clause
+    [(name) (qualified_name)] @super.extends))
+
+; Parent interfaces: interface A extends B
+(interface_declaration
+  name: (name) @super.type
+  (base_clause
+    [(name) (qualified_name)] @super.extends))
+
+; Implemented interfaces: class A implements B
+(class_declaration
+  name: (name) @super.type
+  (class_interface_clause
+    [(name) (qualified_name)] @super.implements))
+
+(enum_declaration
+  name: (name) @super.type
+  (class_interface_clause
+    [(name) (qualified_name)] @super.implements))
+
+; Constants: const MAX = 10;
+(program
+  (const_declaration
+    (const_element
+      (name) @const.name)))
+
+(class_declaration
+  body: (declaration_list
+    (const_declaration
+      (const_element
+        (name) @const.name))))
+
 ; Use statements (imports)
 (namespace_use_clause
   (qualified_name) @import)
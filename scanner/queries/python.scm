@@ -9,6 +9,24 @@
 (class_definition
   name: (identifier) @type.name) @type.class
 
+; Base classes: class A(B, mod.C)
+(class_definition
+  name: (identifier) @super.type
+  superclasses: (argument_list
+    [(identifier) (attribute)] @super.extends))
+
+; Module-level assignments (UPPER_CASE names are constants)
+(module
+  (expression_statement
+    (assignment
+      left: (identifier) @var.name)))
+
+(module
+  (expression_statement
+    (assignment
+      left: (pattern_list
+        (identifier) @var.name))))
+
 ; import x, import x.y.z
 (import_statement
   name: (dotted_name) @import)
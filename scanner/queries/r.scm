@@ -12,6 +12,13 @@
   operator: "="
   rhs: (function_definition))
 
+; Top-level assignments that are not functions
+(program
+  (binary_operator
+    lhs: (identifier) @var.name
+    operator: "<-"
+    rhs: [(float) (integer) (string) (true) (false) (null) (call)]))
+
 ; library() calls
 (call
   function: (identifier) @_fn
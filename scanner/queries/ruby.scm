@@ -8,6 +8,11 @@
 (singleton_method
   name: (identifier) @function)
 
+; Top-level constants: MAX = 10
+(program
+  (assignment
+    left: (constant) @const.name))
+
 ; require 'x'
 (call
   method: (identifier) @_req (#match? @_req "^require")
@@ -17,6 +17,30 @@
 (trait_item
   name: (type_identifier) @type.name) @type.trait
 
+; Supertraits: trait A: B + C
+(trait_item
+  name: (type_identifier) @super.type
+  bounds: (trait_bounds
+    [(type_identifier) (scoped_type_identifier) (generic_type)] @super.extends))
+
+; Trait implementations: impl Trait for Type
+(impl_item
+  trait: (_) @impl.trait
+  type: (_) @impl.type)
+
+; Constants and statics
+(source_file
+  (const_item
+    name: (identifier) @const.name))
+
+(source_file
+  (static_item
+    name: (identifier) @var.name))
+
+; Enum variants
+(enum_variant
+  name: (identifier) @const.name)
+
 ; use statements
 (use_declaration
   argument: (scoped_identifier) @import)
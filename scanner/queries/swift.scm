@@ -7,6 +7,37 @@
 ; Init declarations - no name field, so we skip them
 ; (they show as "init" which isn't very useful)
 
+; Class, struct and enum declarations (extensions name a user_type instead)
+(class_declaration
+  name: (type_identifier) @type.name) @type.class
+
+; Protocol declarations
+(protocol_declaration
+  name: (type_identifier) @type.name) @type.protocol
+
+; Inheritance and protocol conformance: class A: B, P
+(class_declaration
+  name: (type_identifier) @super.type
+  (inheritance_specifier
+    (user_type) @super.extends))
+
+(protocol_declaration
+  name: (type_identifier) @super.type
+  (inheritance_specifier
+    (user_type) @super.extends))
+
+; Conformance added by an extension: extension A: P
+(class_declaration
+  name: (user_type) @impl.type
+  (inheritance_specifier
+    (user_type) @impl.trait))
+
+; Top-level properties: let x = 1, var y = 2
+(source_file
+  (property_declaration
+    name: (pattern
+      (simple_identifier) @var.name)))
+
 ; Import statements - capture the identifier
 (import_declaration
   (identifier
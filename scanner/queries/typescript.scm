@@ -32,6 +32,63 @@
 (enum_declaration
   name: (identifier) @type.name) @type.enum
 
+; Class heritage: class A extends B implements C
+(class_declaration
+  name: (type_identifier) @super.type
+  (class_heritage
+    (extends_clause
+      value: (_) @super.extends)))
+
+(class_declaration
+  name: (type_identifier) @super.type
+  (class_heritage
+    (implements_clause (_) @super.implements)))
+
+; Parent interfaces: interface A extends B
+(interface_declaration
+  name: (type_identifier) @super.type
+  (extends_type_clause
+    type: (_) @super.extends))
+
+; Top-level variables and constants (also when exported)
+(program
+  (lexical_declaration
+    "const"
+    (variable_declarator
+      name: (identifier) @const.name)))
+
+(program
+  (lexical_declaration
+    "let"
+    (variable_declarator
+      name: (identifier) @var.name)))
+
+(program
+  (variable_declaration
+    (variable_declarator
+      name: (identifier) @var.name)))
+
+(program
+  (export_statement
+    (lexical_declaration
+      "const"
+      (variable_declarator
+        name: (identifier) @const.name))))
+
+(program
+  (export_statement
+    (lexical_declaration
+      "let"
+      (variable_declarator
+        name: (identifier) @var.name))))
+
+; Enum members
+(enum_body
+  name: (property_identifier) @const.name)
+
+(enum_assignment
+  name: (property_identifier) @const.name)
+
 ; ES6 imports
 (import_statement
   source: (string) @import)
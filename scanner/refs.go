@@ -0,0 +1,230 @@
+package scanner
+
+import (
+	"fmt"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// RefInfo represents a use of a named type or package-level value inside a symbol.
+type RefInfo struct {
+	From     string `json:"from,omitempty"`      // Enclosing function/type ("" for file scope)
+	FromLine int    `json:"from_line,omitempty"` // Definition line of From
+	Name     string `json:"name"`                // Referenced type or variable name
+	Kind     string `json:"kind"`                // "type" or "value"
+	Line     int    `json:"line"`                // Line of the first use within From
+}
+
+// refQueryPatterns maps languages to queries capturing type usages (@ref.type)
+// in parameter lists, return types, fields and local declarations, and
+// identifiers that may read or write package-level variables (@ref.value).
+// They are kept apart from the symbol queries so that a pattern the installed
+// grammar rejects only disables reference extraction.
+var refQueryPatterns = map[string]string{
+	"go": `
+(type_identifier) @ref.type
+(identifier) @ref.value
+(selector_expression
+  operand: (identifier)
+  field: (field_identifier) @ref.value)
+`,
+	"python": `
+; Annotations: x: User, -> Optional[User], mod.Config
+(type (identifier) @ref.type)
+(type (generic_type (identifier) @ref.type))
+(type (attribute attribute: (identifier) @ref.type))
+(identifier) @ref.value
+`,
+	"javascript": `
+(identifier) @ref.value
+`,
+	"typescript": `
+(type_identifier) @ref.type
+(identifier) @ref.value
+`,
+	"rust": `
+(type_identifier) @ref.type
+(identifier) @ref.value
+`,
+	"java": `
+(type_identifier) @ref.type
+(identifier) @ref.value
+`,
+	"c": `
+(type_identifier) @ref.type
+(identifier) @ref.value
+`,
+	"cpp": `
+(type_identifier) @ref.type
+(identifier) @ref.value
+`,
+	"c_sharp": `
+; C# types are plain identifiers in type positions
+(_ type: (identifier) @ref.type)
+(_ returns: (identifier) @ref.type)
+(generic_name (identifier) @ref.type)
+(type_argument_list (identifier) @ref.type)
+(identifier) @ref.value
+`,
+	"kotlin": `
+(user_type (type_identifier) @ref.type)
+(simple_identifier) @ref.value
+`,
+	"swift": `
+(user_type (type_identifier) @ref.type)
+(simple_identifier) @ref.value
+`,
+	"php": `
+(named_type (name) @ref.type)
+(name) @ref.value
+`,
+	"ruby": `
+(constant) @ref.value
+`,
+	"bash": `
+(variable_name) @ref.value
+`,
+	"dart": `
+(type_identifier) @ref.type
+`,
+}
+
+// symbolKinds are definitions that own the references nested inside them.
+var symbolKinds = map[string]bool{
+	"function_declaration":    true,
+	"function_definition":     true,
+	"function_item":           true,
+	"method_declaration":      true,
+	"method_definition":       true,
+	"constructor_declaration": true,
+	"type_spec":               true, // Go
+	"class_declaration":       true,
+	"class_definition":        true,
+	"interface_declaration":   true,
+	"struct_declaration":      true,
+	"enum_declaration":        true,
+	"record_declaration":      true,
+	"protocol_declaration":    true,
+	"trait_declaration":       true,
+	"type_alias_declaration":  true,
+	"struct_item":             true,
+	"enum_item":               true,
+	"trait_item":              true,
+	"type_item":               true,
+	"struct_specifier":        true,
+	"class_specifier":         true,
+	"union_specifier":         true,
+	"enum_specifier":          true,
+	"function_signature":      true, // Dart
+	"method_signature":        true,
+	"object_declaration":      true, // Kotlin
+	"type_alias":              true,
+}
+
+// extractReferences collects type and value usages per enclosing symbol,
+// keeping the first occurrence of each (symbol, name) pair. Value usages
+// at file scope are dropped: only functions and types read or write variables.
+func extractReferences(query *tree_sitter.Query, root *tree_sitter.Node, content []byte) []RefInfo {
+	cursor := tree_sitter.NewQueryCursor()
+	defer cursor.Close()
+
+	var refs []RefInfo
+	seen := make(map[string]bool)
+
+	matches := cursor.Matches(query, root, content)
+	for match := matches.Next(); match != nil; match = matches.Next() {
+		for _, capture := range match.Captures {
+			node := capture.Node
+			if isDefinitionName(&node) {
+				continue
+			}
+
+			kind := "type"
+			if query.CaptureNames()[capture.Index] == "ref.value" {
+				kind = "value"
+				if isAttributeName(&node) {
+					continue
+				}
+			}
+
+			from, fromLine := enclosingSymbol(&node, content)
+			if kind == "value" && from == "" {
+				continue
+			}
+			name := node.Utf8Text(content)
+			key := fmt.Sprintf("%s>%s:%d>%s", kind, from, fromLine, name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			refs = append(refs, RefInfo{
+				From:     from,
+				FromLine: fromLine,
+				Name:     name,
+				Kind:     kind,
+				Line:     int(node.StartPosition().Row) + 1,
+			})
+		}
+	}
+	return refs
+}
+
+// isDefinitionName reports whether node names the definition it belongs to
+// (type Foo struct, class Foo) rather than referring to another type.
+// A C struct specifier without a body is a reference.
+func isDefinitionName(node *tree_sitter.Node) bool {
+	parent := node.Parent()
+	if parent == nil || !symbolKinds[parent.Kind()] {
+		return false
+	}
+	name := parent.ChildByFieldName("name")
+	if name == nil || name.Id() != node.Id() {
+		return false
+	}
+	if kind := parent.Kind(); kind == "struct_specifier" || kind == "class_specifier" ||
+		kind == "union_specifier" || kind == "enum_specifier" {
+		return parent.ChildByFieldName("body") != nil
+	}
+	return true
+}
+
+// isAttributeName reports whether node is the member name of a Python
+// attribute access (obj.name), which never refers to a module variable.
+func isAttributeName(node *tree_sitter.Node) bool {
+	parent := node.Parent()
+	if parent == nil || parent.Kind() != "attribute" {
+		return false
+	}
+	attr := parent.ChildByFieldName("attribute")
+	return attr != nil && attr.Id() == node.Id()
+}
+
+// enclosingSymbol returns the name and definition line of the innermost
+// function or type containing node, or "" at file scope.
+func enclosingSymbol(node *tree_sitter.Node, content []byte) (string, int) {
+	for n := node.Parent(); n != nil; n = n.Parent() {
+		if !symbolKinds[n.Kind()] {
+			continue
+		}
+		if name := symbolName(n); name != nil && name.Id() != node.Id() {
+			return name.Utf8Text(content), int(name.StartPosition().Row) + 1
+		}
+	}
+	return "", 0
+}
+
+// symbolName finds the name node of a definition. C-family functions keep
+// the name inside nested declarators.
+func symbolName(def *tree_sitter.Node) *tree_sitter.Node {
+	if name := def.ChildByFieldName("name"); name != nil {
+		return name
+	}
+	for d := def.ChildByFieldName("declarator"); d != nil; d = d.ChildByFieldName("declarator") {
+		switch d.Kind() {
+		case "identifier", "field_identifier":
+			return d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package scanner
+
+import (
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+func TestBuiltinRefQueriesCompile(t *testing.T) {
+	loader := NewGrammarLoader()
+	for lang, pattern := range refQueryPatterns {
+		t.Run(lang, func(t *testing.T) {
+			config, err := loader.languageConfig(lang)
+			if err != nil {
+				t.Skipf("grammar for %s not available: %v", lang, err)
+			}
+			query, qerr := tree_sitter.NewQuery(config.Language, pattern)
+			if qerr != nil {
+				t.Fatalf("%d:%d: %s", qerr.Row+1, qerr.Column+1, describeQueryError(qerr))
+			}
+			query.Close()
+		})
+	}
+}
@@ -0,0 +1,520 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// importConfigFiles are the project files that change how imports resolve.
+var importConfigFiles = map[string]bool{
+	"go.mod":         true,
+	"tsconfig.json":  true,
+	"jsconfig.json":  true,
+	"pyproject.toml": true,
+	"setup.py":       true,
+	"setup.cfg":      true,
+}
+
+// scriptExts are tried, in order, for extensionless TS/JS imports.
+var scriptExts = []string{".ts", ".tsx", ".d.ts", ".js", ".jsx", ".mjs", ".cjs"}
+
+// ImportResolver maps import strings to the scanned files they refer to,
+// following each language's module rules: Go module paths from go.mod,
+// TS/JS relative paths and tsconfig paths/baseUrl, Python packages and
+// relative imports, Rust mod/use paths and Java packages. Other languages
+// resolve only imports that name a file relative to the importer.
+// All paths are slash-separated and relative to the scan root.
+type ImportResolver struct {
+	files     map[string]bool     // Scanned files
+	dirs      map[string][]string // Directory -> scanned files in it, sorted
+	byBase    map[string][]string // File name -> scanned files with that name
+	goModules []goModule          // Longest module path first
+	tsConfigs map[string]*tsConfig
+	pyRoots   []string // Python project directories, deepest first, then "."
+}
+
+type goModule struct {
+	path string // Module path from the module directive
+	dir  string // Directory holding go.mod
+}
+
+// tsConfig holds the resolution settings of a tsconfig.json/jsconfig.json,
+// with baseUrl and path targets already made relative to the scan root.
+type tsConfig struct {
+	baseURL string
+	paths   map[string][]string
+}
+
+// NewImportResolver indexes the scanned files and reads the module configs
+// (go.mod, tsconfig.json, jsconfig.json) found at configs, given relative
+// to root. Python project files (pyproject.toml, setup.py, setup.cfg) mark
+// source roots.
+func NewImportResolver(root string, files []string, configs []string) *ImportResolver {
+	r := &ImportResolver{
+		files:     make(map[string]bool),
+		dirs:      make(map[string][]string),
+		byBase:    make(map[string][]string),
+		tsConfigs: make(map[string]*tsConfig),
+	}
+	for _, f := range files {
+		f = filepath.ToSlash(f)
+		r.files[f] = true
+		r.dirs[path.Dir(f)] = append(r.dirs[path.Dir(f)], f)
+		r.byBase[path.Base(f)] = append(r.byBase[path.Base(f)], f)
+	}
+	for _, fs := range r.dirs {
+		sort.Strings(fs)
+	}
+
+	pyRoots := map[string]bool{".": true}
+	for _, c := range configs {
+		c = filepath.ToSlash(c)
+		dir := path.Dir(c)
+		switch path.Base(c) {
+		case "pyproject.toml", "setup.py", "setup.cfg":
+			pyRoots[dir] = true
+		case "go.mod":
+			if mod := readModulePath(filepath.Join(root, filepath.FromSlash(c))); mod != "" {
+				r.goModules = append(r.goModules, goModule{path: mod, dir: dir})
+			}
+		default:
+			// tsconfig.json wins over jsconfig.json in the same directory
+			if _, ok := r.tsConfigs[dir]; ok && path.Base(c) == "jsconfig.json" {
+				continue
+			}
+			if cfg := readTSConfig(root, c, 0); cfg != nil {
+				r.tsConfigs[dir] = cfg
+			}
+		}
+	}
+	sort.Slice(r.goModules, func(i, j int) bool {
+		return len(r.goModules[i].path) > len(r.goModules[j].path)
+	})
+	delete(pyRoots, ".")
+	for dir := range pyRoots {
+		r.pyRoots = append(r.pyRoots, dir)
+	}
+	sort.Slice(r.pyRoots, func(i, j int) bool {
+		if len(r.pyRoots[i]) != len(r.pyRoots[j]) {
+			return len(r.pyRoots[i]) > len(r.pyRoots[j])
+		}
+		return r.pyRoots[i] < r.pyRoots[j]
+	})
+	r.pyRoots = append(r.pyRoots, ".")
+	return r
+}
+
+// ResolveImports fills ImportedFiles for every analysis.
+func (r *ImportResolver) ResolveImports(analyses []FileAnalysis) {
+	for i := range analyses {
+		a := &analyses[i]
+		var files []string
+		for _, imp := range a.Imports {
+			files = append(files, r.Resolve(a.Path, a.Language, imp)...)
+		}
+		a.ImportedFiles = nil
+		for _, f := range dedupe(files) {
+			if f != filepath.ToSlash(a.Path) {
+				a.ImportedFiles = append(a.ImportedFiles, filepath.FromSlash(f))
+			}
+		}
+		sort.Strings(a.ImportedFiles)
+	}
+}
+
+// Resolve returns the scanned files that import imp of file from refers to.
+// A Go package import resolves to the non-test files of the package.
+// Imports of the standard library or third-party packages resolve to nothing.
+func (r *ImportResolver) Resolve(from, lang, imp string) []string {
+	from = filepath.ToSlash(from)
+	switch lang {
+	case "go":
+		return r.resolveGo(imp)
+	case "typescript", "javascript":
+		return r.resolveScript(from, imp)
+	case "python":
+		return r.resolvePython(from, imp)
+	case "rust":
+		return r.resolveRust(from, imp)
+	case "java":
+		return r.resolveJava(imp)
+	}
+	return r.resolveRelative(from, imp)
+}
+
+func (r *ImportResolver) resolveGo(imp string) []string {
+	for _, mod := range r.goModules {
+		if imp != mod.path && !strings.HasPrefix(imp, mod.path+"/") {
+			continue
+		}
+		dir := path.Join(mod.dir, strings.TrimPrefix(imp, mod.path))
+		var files []string
+		for _, f := range r.dirs[dir] {
+			if strings.HasSuffix(f, ".go") && !strings.HasSuffix(f, "_test.go") {
+				files = append(files, f)
+			}
+		}
+		return files
+	}
+	return nil
+}
+
+func (r *ImportResolver) resolveScript(from, imp string) []string {
+	if strings.HasPrefix(imp, "./") || strings.HasPrefix(imp, "../") || imp == "." || imp == ".." {
+		return r.scriptFile(path.Join(path.Dir(from), imp))
+	}
+
+	cfg := r.nearestTSConfig(path.Dir(from))
+	if cfg == nil {
+		return nil
+	}
+	for _, target := range cfg.match(imp) {
+		if f := r.scriptFile(target); f != nil {
+			return f
+		}
+	}
+	if cfg.baseURL != "" {
+		return r.scriptFile(path.Join(cfg.baseURL, imp))
+	}
+	return nil
+}
+
+// scriptFile resolves a TS/JS module path the way bundlers do: the exact
+// file, the file with a script extension, or the directory's index file.
+// A .js specifier may name the .ts source it compiles from.
+func (r *ImportResolver) scriptFile(p string) []string {
+	if r.files[p] {
+		return []string{p}
+	}
+	stem := p
+	if ext := path.Ext(p); ext == ".js" || ext == ".jsx" || ext == ".mjs" || ext == ".cjs" {
+		stem = strings.TrimSuffix(p, ext)
+	}
+	for _, base := range []string{stem, stem + "/index"} {
+		for _, ext := range scriptExts {
+			if r.files[base+ext] {
+				return []string{base + ext}
+			}
+		}
+	}
+	return nil
+}
+
+// nearestTSConfig returns the config in dir or its closest ancestor.
+func (r *ImportResolver) nearestTSConfig(dir string) *tsConfig {
+	for {
+		if cfg, ok := r.tsConfigs[dir]; ok {
+			return cfg
+		}
+		if dir == "." || dir == "/" || dir == "" {
+			return nil
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// match returns the targets of the paths patterns matching imp, the most
+// specific (longest prefix) pattern first.
+func (c *tsConfig) match(imp string) []string {
+	var patterns []string
+	for pattern := range c.paths {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	var targets []string
+	for _, pattern := range patterns {
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		if !wildcard {
+			if imp == pattern {
+				targets = append(targets, c.paths[pattern]...)
+			}
+			continue
+		}
+		if len(imp) < len(prefix)+len(suffix) || !strings.HasPrefix(imp, prefix) || !strings.HasSuffix(imp, suffix) {
+			continue
+		}
+		star := imp[len(prefix) : len(imp)-len(suffix)]
+		for _, t := range c.paths[pattern] {
+			targets = append(targets, strings.Replace(t, "*", star, 1))
+		}
+	}
+	return targets
+}
+
+// resolvePython maps dotted module names to module.py or package/__init__.py.
+// Relative imports start from the importer's package: `from . import x`,
+// recorded as ".x", is the module x.py when there is one and otherwise a
+// name the package's __init__.py defines. Absolute imports are tried
+// against the source roots: the Python projects enclosing the importer,
+// the scan root and their src/ directories.
+func (r *ImportResolver) resolvePython(from, imp string) []string {
+	if strings.HasPrefix(imp, ".") {
+		rest := strings.TrimLeft(imp, ".")
+		dir := path.Dir(from)
+		for i := 1; i < len(imp)-len(rest); i++ {
+			dir = path.Dir(dir)
+		}
+		if rest != "" {
+			if f := r.pythonModule(path.Join(dir, strings.ReplaceAll(rest, ".", "/"))); f != nil {
+				return f
+			}
+		}
+		if init := path.Join(dir, "__init__.py"); !strings.Contains(rest, ".") && r.files[init] {
+			return []string{init}
+		}
+		return nil
+	}
+
+	rel := strings.ReplaceAll(imp, ".", "/")
+	for _, root := range r.pyRoots {
+		if root != "." && !strings.HasPrefix(from, root+"/") {
+			continue
+		}
+		for _, dir := range []string{root, path.Join(root, "src")} {
+			if f := r.pythonModule(path.Join(dir, rel)); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+func (r *ImportResolver) pythonModule(p string) []string {
+	for _, f := range []string{p + ".py", path.Join(p, "__init__.py")} {
+		if r.files[f] {
+			return []string{f}
+		}
+	}
+	return nil
+}
+
+// resolveRust follows `mod name` declarations and crate::, self:: and
+// super:: use paths to the file defining the longest matching module.
+// A bare name is looked up as a child module of the importer.
+func (r *ImportResolver) resolveRust(from, imp string) []string {
+	segs := strings.Split(imp, "::")
+	var dir string
+	switch segs[0] {
+	case "crate":
+		dir, segs = r.rustCrateDir(from), segs[1:]
+	case "self":
+		dir, segs = rustModuleDir(from), segs[1:]
+	case "super":
+		dir = rustModuleDir(from)
+		for len(segs) > 0 && segs[0] == "super" {
+			dir, segs = path.Dir(dir), segs[1:]
+		}
+	default:
+		dir = rustModuleDir(from)
+	}
+	if dir == "" {
+		return nil
+	}
+	for n := len(segs); n > 0; n-- {
+		mod := path.Join(dir, path.Join(segs[:n]...))
+		for _, f := range []string{mod + ".rs", mod + "/mod.rs"} {
+			if r.files[f] {
+				return []string{f}
+			}
+		}
+	}
+	return nil
+}
+
+// rustModuleDir returns the directory holding the child modules of the
+// module defined by file: its own directory for mod.rs and crate roots,
+// otherwise a directory named after the file.
+func rustModuleDir(file string) string {
+	switch path.Base(file) {
+	case "mod.rs", "lib.rs", "main.rs":
+		return path.Dir(file)
+	}
+	return strings.TrimSuffix(file, ".rs")
+}
+
+// rustCrateDir returns the directory of the crate root (lib.rs or main.rs)
+// closest above file, or "".
+func (r *ImportResolver) rustCrateDir(file string) string {
+	for dir := path.Dir(file); ; dir = path.Dir(dir) {
+		if r.files[path.Join(dir, "lib.rs")] || r.files[path.Join(dir, "main.rs")] {
+			return dir
+		}
+		if dir == "." || dir == "/" {
+			return ""
+		}
+	}
+}
+
+// resolveJava maps a single-type, static or on-demand (package.*) import to
+// the class file or package directory whose path ends with the qualified name.
+func (r *ImportResolver) resolveJava(imp string) []string {
+	segs := strings.Split(strings.TrimSuffix(imp, ".*"), ".")
+	// Longest class path first: a.b.C.m (static) is the file a/b/C.java
+	for n := len(segs); n >= 2; n-- {
+		suffix := path.Join(segs[:n]...) + ".java"
+		var files []string
+		for _, f := range r.byBase[path.Base(suffix)] {
+			if f == suffix || strings.HasSuffix(f, "/"+suffix) {
+				files = append(files, f)
+			}
+		}
+		if len(files) > 0 {
+			sort.Strings(files)
+			return files
+		}
+	}
+
+	pkg := path.Join(segs...)
+	var files []string
+	for dir, fs := range r.dirs {
+		if dir != pkg && !strings.HasSuffix(dir, "/"+pkg) {
+			continue
+		}
+		for _, f := range fs {
+			if strings.HasSuffix(f, ".java") {
+				files = append(files, f)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// resolveRelative handles includes and requires that name a file relative
+// to the importer (#include "util.h", require_relative "helper").
+func (r *ImportResolver) resolveRelative(from, imp string) []string {
+	p := path.Join(path.Dir(from), imp)
+	if r.files[p] {
+		return []string{p}
+	}
+	if ext := path.Ext(from); path.Ext(imp) == "" && r.files[p+ext] {
+		return []string{p + ext}
+	}
+	return nil
+}
+
+// readModulePath returns the module path declared by a go.mod file.
+func readModulePath(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			if i := strings.Index(rest, "//"); i >= 0 {
+				rest = rest[:i]
+			}
+			return strings.Trim(strings.TrimSpace(rest), `"`+"`")
+		}
+	}
+	return ""
+}
+
+// readTSConfig reads compilerOptions.baseUrl and paths from a tsconfig.json,
+// following relative "extends" chains for settings the file leaves unset.
+func readTSConfig(root, file string, depth int) *tsConfig {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil || depth > 8 {
+		return nil
+	}
+	var raw struct {
+		Extends         string `json:"extends"`
+		CompilerOptions struct {
+			BaseURL *string             `json:"baseUrl"`
+			Paths   map[string][]string `json:"paths"`
+		} `json:"compilerOptions"`
+	}
+	if err := json.Unmarshal(stripJSONC(data), &raw); err != nil {
+		return nil
+	}
+
+	dir := path.Dir(file)
+	cfg := &tsConfig{}
+	if strings.HasPrefix(raw.Extends, ".") {
+		ext := path.Join(dir, raw.Extends)
+		if path.Ext(ext) != ".json" {
+			ext += ".json"
+		}
+		if parent := readTSConfig(root, ext, depth+1); parent != nil {
+			*cfg = *parent
+		}
+	}
+	if raw.CompilerOptions.BaseURL != nil {
+		cfg.baseURL = path.Join(dir, *raw.CompilerOptions.BaseURL)
+	}
+	if raw.CompilerOptions.Paths != nil {
+		// Path targets are relative to baseUrl, or to the config without one
+		base := cfg.baseURL
+		if raw.CompilerOptions.BaseURL == nil {
+			base = dir
+		}
+		cfg.paths = make(map[string][]string)
+		for pattern, targets := range raw.CompilerOptions.Paths {
+			for _, t := range targets {
+				cfg.paths[pattern] = append(cfg.paths[pattern], path.Join(base, t))
+			}
+		}
+	}
+	return cfg
+}
+
+// stripJSONC removes the comments and trailing commas tsconfig files allow.
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out = append(out, '\n')
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == ']' || c == '}':
+			// Drop a comma left before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolvePython(t *testing.T) {
+	files := []string{
+		"app/__init__.py",
+		"app/models.py",
+		"app/views.py",
+		"app/api/__init__.py",
+		"app/api/routes.py",
+		"utils.py",
+		"src/lib/__init__.py",
+		"services/billing/pyproject.toml",
+		"services/billing/src/billing/__init__.py",
+		"services/billing/src/billing/invoice.py",
+		"services/billing/tests/test_invoice.py",
+		"tools/helpers.py",
+		"tools/run.py",
+	}
+	configs := []string{"services/billing/pyproject.toml"}
+	r := NewImportResolver("/repo", files, configs)
+
+	tests := []struct {
+		name, from, imp string
+		want            []string
+	}{
+		{"from . import module", "app/views.py", ".models", []string{"app/models.py"}},
+		{"from . import subpackage", "app/views.py", ".api", []string{"app/api/__init__.py"}},
+		{"from . import name in __init__", "app/views.py", ".settings", []string{"app/__init__.py"}},
+		{"from . import *", "app/views.py", ".", []string{"app/__init__.py"}},
+		{"from .. import module", "app/api/routes.py", "..models", []string{"app/models.py"}},
+		{"missing dotted relative", "app/views.py", ".missing.deep", nil},
+		{"absolute from scan root", "app/api/routes.py", "app.models", []string{"app/models.py"}},
+		{"absolute from src", "app/views.py", "lib", []string{"src/lib/__init__.py"}},
+		{"absolute from project src", "services/billing/tests/test_invoice.py", "billing.invoice", []string{"services/billing/src/billing/invoice.py"}},
+		{"project src outside the project", "app/views.py", "billing.invoice", nil},
+		{"not from ancestor directories", "app/api/routes.py", "api.routes", nil},
+		{"not from the importer's directory", "tools/run.py", "helpers", nil},
+		{"third party", "app/views.py", "requests", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Resolve(tt.from, "python", tt.imp); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve(%s, %q) = %v, want %v", tt.from, tt.imp, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPythonRelativeImportNames(t *testing.T) {
+	analysis := analyzeSource(t, "views.py", `from . import models, api as routes
+from .. import settings
+from .forms import Form
+from . import *
+import os.path
+`, DetailNone)
+
+	want := []string{".models", ".api", "..settings", ".forms", ".", "os.path"}
+	if !reflect.DeepEqual(analysis.Imports, want) {
+		t.Errorf("Imports = %q, want %q", analysis.Imports, want)
+	}
+}
@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CycloneDX is a CycloneDX 1.5 software bill of materials, in its JSON
+// encoding.
+type CycloneDX struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cdxComponent `json:"components"`
+	} `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type"`
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	Scope      string        `json:"scope,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Hashes     []cdxHash     `json:"hashes,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// SBOM returns the inventory as a CycloneDX bill of materials. Components
+// are identified by package URL; a package several lockfiles pin appears
+// once. Development dependencies have the optional scope.
+func (inv *Inventory) SBOM() *CycloneDX {
+	bom := &CycloneDX{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Components:   []cdxComponent{},
+		Dependencies: []cdxDependency{},
+	}
+	bom.Metadata.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	bom.Metadata.Tools.Components = []cdxComponent{{Type: "application", Name: "codemap"}}
+	rootRef := "root"
+	bom.Metadata.Component = cdxComponent{Type: "application", BOMRef: rootRef, Name: filepath.Base(inv.Root)}
+
+	components := make(map[string]*cdxComponent)
+	dependsOn := map[string]map[string]bool{rootRef: {}}
+	for _, l := range inv.Lockfiles {
+		for _, p := range l.Packages {
+			ref := l.PURL(p)
+			c, ok := components[ref]
+			if !ok {
+				c = &cdxComponent{Type: "library", BOMRef: ref, Name: p.Name, Version: p.Version, PURL: ref, Scope: "optional"}
+				if hash, ok := cdxChecksum(p.Checksum); ok {
+					c.Hashes = []cdxHash{hash}
+				}
+				components[ref] = c
+				dependsOn[ref] = make(map[string]bool)
+			}
+			if !p.Dev {
+				c.Scope = "required"
+			}
+			c.Properties = append(c.Properties, cdxProperty{Name: "codemap:lockfile", Value: l.Path})
+			if p.Direct {
+				dependsOn[rootRef][ref] = true
+			}
+			for _, key := range p.Dependencies {
+				if dep := l.Lookup(key); dep != nil {
+					dependsOn[ref][l.PURL(dep)] = true
+				}
+			}
+		}
+	}
+
+	for _, ref := range sortedKeys(components) {
+		bom.Components = append(bom.Components, *components[ref])
+	}
+	for _, ref := range sortedKeys(dependsOn) {
+		bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: ref, DependsOn: sortedKeys(dependsOn[ref])})
+	}
+	return bom
+}
+
+// cdxChecksum converts a lockfile checksum to a CycloneDX hash: npm's
+// Subresource Integrity ("sha512-<base64>"), "sha256:<hex>" from Python
+// lockfiles and Cargo's bare SHA-256. Go's h1: hashes cover a module's file
+// tree rather than an archive and aren't included.
+func cdxChecksum(checksum string) (cdxHash, bool) {
+	algs := map[string]string{"sha1": "SHA-1", "sha256": "SHA-256", "sha384": "SHA-384", "sha512": "SHA-512"}
+	if alg, value, ok := strings.Cut(checksum, "-"); ok && algs[alg] != "" {
+		data, err := base64.StdEncoding.DecodeString(strings.Fields(value + " ")[0])
+		if err != nil {
+			return cdxHash{}, false
+		}
+		return cdxHash{Alg: algs[alg], Content: hex.EncodeToString(data)}, true
+	}
+	if alg, value, ok := strings.Cut(checksum, ":"); ok && algs[alg] != "" {
+		return cdxHash{Alg: algs[alg], Content: strings.ToLower(value)}, true
+	}
+	if len(checksum) == 64 {
+		if _, err := hex.DecodeString(checksum); err == nil {
+			return cdxHash{Alg: "SHA-256", Content: strings.ToLower(checksum)}, true
+		}
+	}
+	return cdxHash{}, false
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
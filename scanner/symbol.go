@@ -1,31 +1,36 @@
 package scanner
 
 import (
+	"path/filepath"
 	"strings"
 )
 
 // SymbolQuery represents the filters for symbol search
 type SymbolQuery struct {
 	Name string // Substring match (case-insensitive)
-	Kind string // "function", "type", "all"
+	Kind string // "function", "type", "variable", "all"
 	File string // Filter by specific file (optional)
 }
 
 // SymbolMatch represents a found symbol
 type SymbolMatch struct {
-	Name      string `json:"name"`
-	Kind      string `json:"kind"`      // "function" or "type"
-	Signature string `json:"signature"` // For functions
-	TypeKind  string `json:"type_kind"` // For types (struct, class, etc.)
-	File      string `json:"file"`
-	Line      int    `json:"line"`
-	Exported  bool   `json:"exported"`
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`      // "function", "type" or "variable"
+	Signature string   `json:"signature"` // For functions
+	TypeKind  string   `json:"type_kind"` // For types (struct, class, etc.) and variables (var, const)
+	File      string   `json:"file"`
+	Package   string   `json:"package,omitempty"` // Fully qualified package of File
+	Line      int      `json:"line"`
+	Exported  bool     `json:"exported"`
+	Methods   []string `json:"methods,omitempty"` // For types: owned methods
+	Fields    []string `json:"fields,omitempty"`  // For types: fields and properties
 }
 
 // SearchSymbols searches for symbols in the analyzed files
 func SearchSymbols(analyses []FileAnalysis, query SymbolQuery) []SymbolMatch {
 	var matches []SymbolMatch
 	searchName := strings.ToLower(query.Name)
+	methods := methodsByOwner(analyses)
 
 	for _, analysis := range analyses {
 		// Skip if file filter is set and doesn't match
@@ -42,6 +47,7 @@ func SearchSymbols(analyses []FileAnalysis, query SymbolQuery) []SymbolMatch {
 						Kind:      "function",
 						Signature: fn.Signature,
 						File:      analysis.Path,
+						Package:   analysis.Package,
 						Line:      fn.Line,
 						Exported:  fn.IsExported,
 					})
@@ -58,8 +64,28 @@ func SearchSymbols(analyses []FileAnalysis, query SymbolQuery) []SymbolMatch {
 						Kind:     "type",
 						TypeKind: string(t.Kind),
 						File:     analysis.Path,
+						Package:  analysis.Package,
 						Line:     t.Line,
 						Exported: t.IsExported,
+						Methods:  dedupe(append(append([]string(nil), t.Methods...), methods[ownerKey(analysis, t.Name)]...)),
+						Fields:   t.Fields,
+					})
+				}
+			}
+		}
+
+		// Search package-level variables and constants
+		if query.Kind == "" || query.Kind == "all" || query.Kind == "variable" {
+			for _, v := range analysis.Variables {
+				if searchName == "" || strings.Contains(strings.ToLower(v.Name), searchName) {
+					matches = append(matches, SymbolMatch{
+						Name:     v.Name,
+						Kind:     "variable",
+						TypeKind: v.Kind,
+						File:     analysis.Path,
+						Package:  analysis.Package,
+						Line:     v.Line,
+						Exported: v.IsExported,
 					})
 				}
 			}
@@ -68,3 +94,29 @@ func SearchSymbols(analyses []FileAnalysis, query SymbolQuery) []SymbolMatch {
 
 	return matches
 }
+
+// methodsByOwner indexes method names by owning type. Go methods may be
+// declared in any file of the package, so Go owners are keyed by directory.
+func methodsByOwner(analyses []FileAnalysis) map[string][]string {
+	methods := make(map[string][]string)
+	for _, analysis := range analyses {
+		if analysis.Language != "go" {
+			continue // Other languages declare methods inside the type (TypeInfo.Methods)
+		}
+		for _, fn := range analysis.Functions {
+			if fn.Owner != "" {
+				key := ownerKey(analysis, fn.Owner)
+				methods[key] = append(methods[key], fn.Name)
+			}
+		}
+	}
+	return methods
+}
+
+// ownerKey identifies a type for method lookup.
+func ownerKey(analysis FileAnalysis, typeName string) string {
+	if analysis.Language == "go" {
+		return filepath.Dir(analysis.Path) + ":" + typeName
+	}
+	return analysis.Path + ":" + typeName
+}
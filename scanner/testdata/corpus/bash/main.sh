@@ -0,0 +1,30 @@
+#!/usr/bin/env bash
+# Test corpus for validating Bash call graph extraction.
+
+hello() {
+  echo "Hello, $1"
+}
+
+add() {
+  echo $(($1 + $2))
+}
+
+nested() {
+  echo "nested called"
+}
+
+helper() {
+  nested
+}
+
+process() {
+  helper
+}
+
+main() {
+  hello "World"
+  add 1 2
+  process
+}
+
+main "$@"
@@ -0,0 +1,46 @@
+/* Test corpus for validating C call graph extraction. */
+#include <stdio.h>
+
+struct ops {
+    int (*apply)(int, int);
+};
+
+const char *hello(const char *name);
+int add(int a, int b);
+void process(void);
+void helper(void);
+void nested(void);
+
+int main(void) {
+    const char *greeting = hello("World");
+    printf("%s\n", greeting);
+
+    int result = add(1, 2);
+    printf("Result: %d\n", result);
+
+    process();
+
+    struct ops o = {add};
+    o.apply(3, 4);
+    return 0;
+}
+
+const char *hello(const char *name) {
+    return name;
+}
+
+int add(int a, int b) {
+    return a + b;
+}
+
+void process(void) {
+    helper();
+}
+
+void helper(void) {
+    nested();
+}
+
+void nested(void) {
+    printf("nested called\n");
+}
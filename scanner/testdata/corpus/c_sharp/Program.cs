@@ -0,0 +1,52 @@
+// Test corpus for validating C# call graph extraction.
+using System;
+
+public class Program
+{
+    public static void Main(string[] args)
+    {
+        string greeting = Hello("World");
+        Console.WriteLine(greeting);
+
+        int result = Add(1, 2);
+        Console.WriteLine($"Result: {result}");
+
+        Process();
+
+        var g = new Greeter();
+        g.Greet();
+    }
+
+    public static string Hello(string name)
+    {
+        return "Hello, " + name;
+    }
+
+    public static int Add(int a, int b)
+    {
+        return a + b;
+    }
+
+    static void Process()
+    {
+        Helper();
+    }
+
+    static void Helper()
+    {
+        Nested();
+    }
+
+    static void Nested()
+    {
+        Console.WriteLine("nested called");
+    }
+}
+
+public class Greeter
+{
+    public string Greet()
+    {
+        return Program.Hello("Greeter");
+    }
+}
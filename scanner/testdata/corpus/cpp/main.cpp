@@ -0,0 +1,46 @@
+// Test corpus for validating C++ call graph extraction.
+#include <iostream>
+#include <string>
+
+std::string hello(const std::string &name) {
+    return "Hello, " + name;
+}
+
+int add(int a, int b) {
+    return a + b;
+}
+
+void nested() {
+    std::cout << "nested called" << std::endl;
+}
+
+void helper() {
+    nested();
+}
+
+void process() {
+    helper();
+}
+
+class Greeter {
+public:
+    std::string greet();
+};
+
+std::string Greeter::greet() {
+    return hello("Greeter");
+}
+
+int main() {
+    std::string greeting = hello("World");
+    std::cout << greeting << std::endl;
+
+    int result = add(1, 2);
+    std::cout << "Result: " << result << std::endl;
+
+    process();
+
+    Greeter g;
+    g.greet();
+    return 0;
+}
@@ -0,0 +1,40 @@
+// Test corpus for validating Dart call graph extraction.
+
+class Greeter {
+  String greet() {
+    return hello('Greeter');
+  }
+}
+
+void main() {
+  var greeting = hello('World');
+  print(greeting);
+
+  var result = add(1, 2);
+  print('Result: $result');
+
+  process();
+
+  var g = Greeter();
+  g.greet();
+}
+
+String hello(String name) {
+  return 'Hello, $name';
+}
+
+int add(int a, int b) {
+  return a + b;
+}
+
+void process() {
+  helper();
+}
+
+void helper() {
+  nested();
+}
+
+void nested() {
+  print('nested called');
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 )
 
+// greetingPrefix is prepended to every greeting.
+const greetingPrefix = "Hello, "
+
 // main is the entry point - calls multiple functions.
 func main() {
 	greeting := hello("World")
@@ -18,7 +21,7 @@ func main() {
 
 // hello returns a greeting string.
 func hello(name string) string {
-	return "Hello, " + name
+	return greetingPrefix + name
 }
 
 // add returns the sum of two integers.
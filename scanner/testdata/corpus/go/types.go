@@ -17,6 +17,11 @@ func (u *User) Greet() string {
 	return hello(u.Name)
 }
 
+// Greeter is satisfied by any type with a Greet method.
+type Greeter interface {
+	Greet() string
+}
+
 // Service handles user operations.
 type Service struct {
 	users []*User
@@ -33,3 +38,8 @@ func (s *Service) ProcessAll(opts ...string) {
 		_ = u.Greet()
 	}
 }
+
+// Greet shares its name with User.Greet and must get its own node.
+func (s *Service) Greet() string {
+	return "service"
+}
@@ -0,0 +1,49 @@
+// Test corpus for validating Java call graph extraction.
+public class Main {
+    public static void main(String[] args) {
+        String greeting = hello("World");
+        System.out.println(greeting);
+
+        int result = add(1, 2);
+        System.out.println("Result: " + result);
+
+        process();
+
+        Greeter g = new Greeter();
+        g.greet();
+    }
+
+    static String hello(String name) {
+        return "Hello, " + name;
+    }
+
+    static int add(int a, int b) {
+        return a + b;
+    }
+
+    /**
+     * Runs the helper chain.
+     */
+    static void process() {
+        helper();
+    }
+
+    static void helper() {
+        nested();
+    }
+
+    static void nested() {
+        System.out.println("nested called");
+    }
+}
+
+/** Something that can greet. */
+interface Greeting {
+    String greet();
+}
+
+class Greeter implements Greeting {
+    String greet() {
+        return Main.hello("Greeter");
+    }
+}
@@ -0,0 +1,40 @@
+// Test corpus for validating JavaScript call graph extraction.
+
+class Greeter {
+  greet() {
+    return hello("Greeter");
+  }
+}
+
+function main() {
+  const greeting = hello("World");
+  console.log(greeting);
+
+  const result = add(1, 2);
+  console.log(`Result: ${result}`);
+
+  process();
+  new Greeter().greet();
+}
+
+function hello(name) {
+  return "Hello, " + name;
+}
+
+function add(a, b) {
+  return a + b;
+}
+
+function process() {
+  helper();
+}
+
+const helper = () => {
+  nested();
+};
+
+function nested() {
+  console.log("nested called");
+}
+
+main();
@@ -0,0 +1,40 @@
+// Test corpus for validating Kotlin call graph extraction.
+
+class Greeter {
+    fun greet(): String {
+        return hello("Greeter")
+    }
+}
+
+fun main() {
+    val greeting = hello("World")
+    println(greeting)
+
+    val result = add(1, 2)
+    println("Result: $result")
+
+    process()
+
+    val g = Greeter()
+    g.greet()
+}
+
+fun hello(name: String): String {
+    return "Hello, $name"
+}
+
+fun add(a: Int, b: Int): Int {
+    return a + b
+}
+
+fun process() {
+    helper()
+}
+
+fun helper() {
+    nested()
+}
+
+fun nested() {
+    println("nested called")
+}
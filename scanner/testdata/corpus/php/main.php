@@ -0,0 +1,56 @@
+<?php
+// Test corpus for validating PHP call graph extraction.
+
+class Greeter
+{
+    public function greet()
+    {
+        return hello("Greeter");
+    }
+
+    public static function create()
+    {
+        return new Greeter();
+    }
+}
+
+function main()
+{
+    $greeting = hello("World");
+    echo $greeting;
+
+    $result = add(1, 2);
+    echo "Result: $result";
+
+    process();
+
+    $g = Greeter::create();
+    $g->greet();
+}
+
+function hello($name)
+{
+    return "Hello, " . $name;
+}
+
+function add($a, $b)
+{
+    return $a + $b;
+}
+
+function process()
+{
+    helper();
+}
+
+function helper()
+{
+    nested();
+}
+
+function nested()
+{
+    echo "nested called";
+}
+
+main();
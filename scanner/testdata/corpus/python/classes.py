@@ -17,6 +17,10 @@ class User:
         return hello(self.name)
 
 
+class Admin(User):
+    """A user with elevated permissions."""
+
+
 class Service:
     """Handles user operations."""
 
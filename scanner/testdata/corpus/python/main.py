@@ -2,6 +2,7 @@
 
 from typing import List
 
+GREETING_PREFIX = "Hello, "
 
 def main():
     """Entry point - calls multiple functions."""
@@ -16,7 +17,7 @@ def main():
 
 def hello(name: str) -> str:
     """Returns a greeting string."""
-    return f"Hello, {name}"
+    return f"{GREETING_PREFIX}{name}"
 
 
 def add(a: int, b: int) -> int:
@@ -0,0 +1,40 @@
+# Test corpus for validating Ruby call graph extraction.
+
+class Greeter
+  def greet
+    hello("Greeter")
+  end
+end
+
+def main
+  greeting = hello("World")
+  puts greeting
+
+  result = add(1, 2)
+  puts "Result: #{result}"
+
+  process()
+  Greeter.new.greet
+end
+
+def hello(name)
+  "Hello, #{name}"
+end
+
+def add(a, b)
+  a + b
+end
+
+def process
+  helper()
+end
+
+def helper
+  nested()
+end
+
+def nested
+  puts "nested called"
+end
+
+main()
@@ -0,0 +1,44 @@
+// Test corpus for validating Rust call graph extraction.
+
+struct Greeter {
+    name: String,
+}
+
+impl Greeter {
+    fn greet(&self) -> String {
+        hello(&self.name)
+    }
+}
+
+fn main() {
+    let greeting = hello("World");
+    println!("{}", greeting);
+
+    let result = add(1, 2);
+    println!("Result: {}", result);
+
+    process();
+
+    let g = Greeter { name: String::from("Rust") };
+    g.greet();
+}
+
+fn hello(name: &str) -> String {
+    format!("Hello, {}", name)
+}
+
+fn add(a: i32, b: i32) -> i32 {
+    a + b
+}
+
+fn process() {
+    helper();
+}
+
+fn helper() {
+    nested();
+}
+
+fn nested() {
+    println!("nested called");
+}
@@ -0,0 +1,42 @@
+// Test corpus for validating Swift call graph extraction.
+
+class Greeter {
+    func greet() -> String {
+        return hello(name: "Greeter")
+    }
+}
+
+func main() {
+    let greeting = hello(name: "World")
+    print(greeting)
+
+    let result = add(a: 1, b: 2)
+    print("Result: \(result)")
+
+    process()
+
+    let g = Greeter()
+    g.greet()
+}
+
+func hello(name: String) -> String {
+    return "Hello, " + name
+}
+
+func add(a: Int, b: Int) -> Int {
+    return a + b
+}
+
+func process() {
+    helper()
+}
+
+func helper() {
+    nested()
+}
+
+func nested() {
+    print("nested called")
+}
+
+main()
@@ -35,6 +35,7 @@ type FileInfo struct {
 	IsNew   bool   `json:"is_new,omitempty"`
 	Added   int    `json:"added,omitempty"`
 	Removed int    `json:"removed,omitempty"`
+	Class   string `json:"class,omitempty"` // ClassGenerated, ClassVendored, ClassMinified or ClassBinary; "" for source
 }
 
 // Project represents the root of the codebase for tree/skyline mode.
@@ -52,15 +53,20 @@ type FuncInfo struct {
 	Name       string `json:"name"`
 	Signature  string `json:"signature,omitempty"`   // Full signature when detail >= 1
 	Receiver   string `json:"receiver,omitempty"`    // For methods (Go, Rust, etc.)
+	Owner      string `json:"owner,omitempty"`       // Enclosing class/struct/trait or Go receiver type
 	IsExported bool   `json:"exported,omitempty"`    // Public visibility
 	Line       int    `json:"line,omitempty"`        // Line number of definition (1-indexed)
 	ParamCount int    `json:"param_count,omitempty"` // Number of parameters (-1 for variadic)
+	SourceRange
+	Doc string `json:"doc,omitempty"` // Doc comment or docstring
 }
 
 // MarshalJSON customizes JSON output for backward compatibility
-// When no extended info is present, serialize as plain string
+// When nothing but the name is known, serialize as plain string; any other
+// field, including the source range and doc, selects the object form so
+// that nothing is dropped.
 func (f FuncInfo) MarshalJSON() ([]byte, error) {
-	if f.Signature == "" && f.Receiver == "" && !f.IsExported && f.Line == 0 {
+	if f == (FuncInfo{Name: f.Name}) {
 		return json.Marshal(f.Name)
 	}
 	type Alias FuncInfo
@@ -85,6 +91,16 @@ func (f *FuncInfo) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// SourceRange is the full extent of a definition in its file, including
+// decorators and export/annotation wrappers. Line numbers are 1-indexed;
+// byte offsets are 0-indexed with EndByte exclusive.
+type SourceRange struct {
+	StartLine int `json:"start_line,omitempty"`
+	EndLine   int `json:"end_line,omitempty"`
+	StartByte int `json:"start_byte,omitempty"`
+	EndByte   int `json:"end_byte,omitempty"`
+}
+
 // TypeKind represents normalized type categories across languages
 type TypeKind string
 
@@ -102,29 +118,60 @@ const (
 type TypeInfo struct {
 	Name       string   `json:"name"`
 	Kind       TypeKind `json:"kind"`
-	Fields     []string `json:"fields,omitempty"`  // Field names when detail = 2
-	Methods    []string `json:"methods,omitempty"` // Method names (for classes)
+	Fields     []string `json:"fields,omitempty"`  // Field and property names when detail >= 1
+	Methods    []string `json:"methods,omitempty"` // Method names (owned methods; interface method sets)
 	IsExported bool     `json:"exported,omitempty"`
 	Line       int      `json:"line,omitempty"` // Line number of definition (1-indexed)
+	Doc        string   `json:"doc,omitempty"`  // Doc comment or docstring
+	SourceRange
+	Extends    []string `json:"extends,omitempty"`    // Superclasses / parent interfaces
+	Implements []string `json:"implements,omitempty"` // Implemented interfaces / protocols
+}
+
+// VarInfo represents a package-level variable or constant
+type VarInfo struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"` // "var" or "const"
+	IsExported bool   `json:"exported,omitempty"`
+	Line       int    `json:"line,omitempty"`     // Line of the declared name (1-indexed)
+	EndLine    int    `json:"end_line,omitempty"` // Last line of the declaration
+}
+
+// ImplInfo records a conformance declared apart from the type itself,
+// such as a Rust `impl Trait for Type` block.
+type ImplInfo struct {
+	Type  string `json:"type"`
+	Trait string `json:"trait"`
+	Line  int    `json:"line,omitempty"`
 }
 
 // FileAnalysis holds extracted info about a single file for deps mode.
 type FileAnalysis struct {
-	Path      string     `json:"path"`
-	Language  string     `json:"language"`
-	Functions []FuncInfo `json:"functions"`
-	Types     []TypeInfo `json:"types,omitempty"`
-	Imports   []string   `json:"imports"`
+	Path          string     `json:"path"`
+	Language      string     `json:"language"`
+	Package       string     `json:"package,omitempty"` // Fully qualified package, namespace or module
+	Functions     []FuncInfo `json:"functions"`
+	Types         []TypeInfo `json:"types,omitempty"`
+	Variables     []VarInfo  `json:"variables,omitempty"`
+	Imports       []string   `json:"imports"`
+	ImportedFiles []string   `json:"imported_files,omitempty"` // Scanned files the imports resolve to
+	Impls         []ImplInfo `json:"impls,omitempty"`
+	References    []RefInfo  `json:"references,omitempty"` // Type usages when detail = 2
+	Calls         []CallInfo `json:"calls,omitempty"`      // Call sites when detail = 2
+	Size          int64      `json:"-"`                    // Content size in bytes
+	Hash          string     `json:"-"`                    // Hex SHA-256 of the content
 }
 
 // DepsProject is the JSON output for --deps mode.
 type DepsProject struct {
-	Root         string              `json:"root"`
-	Mode         string              `json:"mode"`
-	Files        []FileAnalysis      `json:"files"`
-	ExternalDeps map[string][]string `json:"external_deps"`
-	DiffRef      string              `json:"diff_ref,omitempty"`
-	DetailLevel  int                 `json:"detail_level,omitempty"`
+	Root         string                  `json:"root"`
+	Mode         string                  `json:"mode"`
+	Files        []FileAnalysis          `json:"files"`
+	ExternalDeps map[string][]string     `json:"external_deps"`
+	Dependencies map[string][]Dependency `json:"dependencies,omitempty"` // Declared versions and scopes of ExternalDeps
+	Modules      []Module                `json:"modules,omitempty"`      // Workspace modules (go.mod, package.json, ...)
+	DiffRef      string                  `json:"diff_ref,omitempty"`
+	DetailLevel  int                     `json:"detail_level,omitempty"`
 }
 
 // IsExportedName checks if a symbol name is exported based on language conventions
@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFuncInfoJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   FuncInfo
+		want string
+	}{
+		{"name only", FuncInfo{Name: "run"}, `"run"`},
+		{
+			"line",
+			FuncInfo{Name: "run", Line: 3},
+			`{"name":"run","line":3}`,
+		},
+		{
+			"doc",
+			FuncInfo{Name: "run", Doc: "Run runs."},
+			`{"name":"run","doc":"Run runs."}`,
+		},
+		{
+			"source range",
+			FuncInfo{Name: "run", SourceRange: SourceRange{StartLine: 2, EndLine: 4, StartByte: 10, EndByte: 42}},
+			`{"name":"run","start_line":2,"end_line":4,"start_byte":10,"end_byte":42}`,
+		},
+		{
+			"parameter count",
+			FuncInfo{Name: "run", ParamCount: 2},
+			`{"name":"run","param_count":2}`,
+		},
+		{
+			"full",
+			FuncInfo{Name: "Run", Signature: "func (s *Server) Run() error", Receiver: "(s *Server)", Owner: "Server", IsExported: true, Line: 3, SourceRange: SourceRange{StartLine: 2, EndLine: 5}, Doc: "Run serves."},
+			`{"name":"Run","signature":"func (s *Server) Run() error","receiver":"(s *Server)","owner":"Server","exported":true,"line":3,"start_line":2,"end_line":5,"doc":"Run serves."}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal = %s, want %s", data, tt.want)
+			}
+
+			var back FuncInfo
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(back, tt.fn) {
+				t.Errorf("round trip = %+v, want %+v", back, tt.fn)
+			}
+		})
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"os"
 	"path/filepath"
 
-	ignore "github.com/sabhiram/go-gitignore"
+	"codemap/internal/parallel"
 )
 
 // IgnoredDirs are directories to skip during scanning
@@ -40,8 +40,8 @@ var IgnoredDirs = map[string]bool{
 
 // WalkOptions configures the file walking behavior.
 type WalkOptions struct {
-	// Gitignore patterns to apply (can be nil)
-	Gitignore *ignore.GitIgnore
+	// Ignore decides which paths to skip (nil skips IgnoredDirs only)
+	Ignore *IgnoreMatcher
 
 	// LanguageFilter if true, only visits files with supported languages
 	LanguageFilter bool
@@ -66,19 +66,8 @@ func WalkFiles(root string, opts WalkOptions, fn WalkFunc) error {
 			return err
 		}
 
-		// Skip if matched by common ignore patterns
-		if info.IsDir() {
-			if IgnoredDirs[info.Name()] {
-				return filepath.SkipDir
-			}
-		} else {
-			if IgnoredDirs[info.Name()] {
-				return nil
-			}
-		}
-
-		// Skip if matched by .gitignore
-		if opts.Gitignore != nil && opts.Gitignore.MatchesPath(relPath) {
+		// Skip ignored directory names and ignore file matches
+		if opts.Ignore.Match(relPath, info.IsDir()) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
@@ -100,65 +89,122 @@ func WalkFiles(root string, opts WalkOptions, fn WalkFunc) error {
 	})
 }
 
-// LoadGitignore loads .gitignore from root if it exists
-func LoadGitignore(root string) *ignore.GitIgnore {
-	gitignorePath := filepath.Join(root, ".gitignore")
-
-	if _, err := os.Stat(gitignorePath); err == nil {
-		if gitignore, err := ignore.CompileIgnoreFile(gitignorePath); err == nil {
-			return gitignore
-		}
-	}
-
-	return nil
-}
-
 // ScanFiles walks the directory tree and returns all files.
 // This is a convenience wrapper around WalkFiles for collecting FileInfo.
-func ScanFiles(root string, gitignore *ignore.GitIgnore) ([]FileInfo, error) {
+func ScanFiles(root string, ignore *IgnoreMatcher) ([]FileInfo, error) {
 	var files []FileInfo
 
 	opts := WalkOptions{
-		Gitignore: gitignore,
+		Ignore: ignore,
 	}
 
 	err := WalkFiles(root, opts, func(absPath, relPath string, info os.FileInfo) error {
-		files = append(files, FileInfo{
-			Path:   relPath,
-			Size:   info.Size(),
-			Ext:    filepath.Ext(absPath),
-			Tokens: EstimateTokens(info.Size()),
-		})
+		file := FileInfo{
+			Path:  relPath,
+			Size:  info.Size(),
+			Ext:   filepath.Ext(absPath),
+			Class: ignore.Classify(absPath, relPath),
+		}
+		// Generated, vendored and binary files don't count towards the token budget
+		if file.Class == "" {
+			file.Tokens = EstimateTokens(info.Size())
+		}
+		files = append(files, file)
 		return nil
 	})
 
 	return files, err
 }
 
+// SourceFiles returns the relative paths of the files ScanForDeps analyzes:
+// those in a language whose grammar the loader can load, except the
+// classified files the matcher skips (see IgnoreMatcher.SkipsAnalysis).
+func SourceFiles(root string, ignore *IgnoreMatcher, loader *GrammarLoader) ([]string, error) {
+	var paths []string
+	loadable := make(map[string]bool)
+
+	err := WalkFiles(root, WalkOptions{Ignore: ignore}, func(absPath, relPath string, info os.FileInfo) error {
+		lang := DetectLanguage(absPath)
+		if lang == "" || ignore.SkipsAnalysis(absPath, relPath) {
+			return nil
+		}
+		ok, seen := loadable[lang]
+		if !seen {
+			ok = loader.LoadLanguage(lang) == nil
+			loadable[lang] = ok
+		}
+		if ok {
+			paths = append(paths, relPath)
+		}
+		return nil
+	})
+
+	return paths, err
+}
+
 // ScanForDeps walks the directory tree and analyzes files for dependencies.
 // This is a convenience wrapper around WalkFiles for collecting FileAnalysis.
 // detailLevel controls the depth of extraction (0=names, 1=signatures, 2=full)
-func ScanForDeps(root string, gitignore *ignore.GitIgnore, loader *GrammarLoader, detailLevel DetailLevel) ([]FileAnalysis, error) {
-	var analyses []FileAnalysis
+func ScanForDeps(root string, ignore *IgnoreMatcher, loader *GrammarLoader, detailLevel DetailLevel) ([]FileAnalysis, error) {
+	return ScanForDepsWithWorkers(root, ignore, loader, detailLevel, 0)
+}
 
-	opts := WalkOptions{
-		Gitignore:      gitignore,
-		LanguageFilter: true, // Only analyze supported languages
-	}
+// ScanForDepsWithWorkers is ScanForDeps with files analyzed on up to workers
+// goroutines (GOMAXPROCS when workers <= 0). Results keep the walk order.
+func ScanForDepsWithWorkers(root string, ignore *IgnoreMatcher, loader *GrammarLoader, detailLevel DetailLevel, workers int) ([]FileAnalysis, error) {
+	return ScanForDepsIn(root, ignore, loader, detailLevel, workers, nil)
+}
+
+// ScanForDepsIn is ScanForDepsWithWorkers analyzing only the files whose
+// relative paths are in only (every file when only is nil). Imports still
+// resolve against all source files under root.
+func ScanForDepsIn(root string, ignore *IgnoreMatcher, loader *GrammarLoader, detailLevel DetailLevel, workers int, only map[string]bool) ([]FileAnalysis, error) {
+	type file struct{ absPath, relPath string }
+	var files []file
+	var configs []string
+
+	opts := WalkOptions{Ignore: ignore}
 
 	err := WalkFiles(root, opts, func(absPath, relPath string, info os.FileInfo) error {
+		if importConfigFiles[info.Name()] {
+			configs = append(configs, relPath)
+		}
+		// Only analyze supported languages, leaving out generated and vendored code
+		if DetectLanguage(absPath) != "" && !ignore.SkipsAnalysis(absPath, relPath) {
+			files = append(files, file{absPath, relPath})
+		}
+		return nil
+	})
+
+	results := make([]*FileAnalysis, len(files))
+	parallel.For(len(files), workers, func(i int) {
+		if only != nil && !only[files[i].relPath] {
+			return
+		}
 		// Analyze file with the specified detail level
-		analysis, err := loader.AnalyzeFile(absPath, detailLevel)
+		analysis, err := loader.AnalyzeFile(files[i].absPath, detailLevel)
 		if err != nil || analysis == nil {
-			return nil // Skip files that can't be analyzed
+			return // Skip files that can't be analyzed
 		}
 
 		// Use relative path in output
-		analysis.Path = relPath
-		analyses = append(analyses, *analysis)
-
-		return nil
+		analysis.Path = files[i].relPath
+		results[i] = analysis
 	})
 
+	var analyses []FileAnalysis
+	var paths []string
+	for i, analysis := range results {
+		if analysis != nil {
+			analyses = append(analyses, *analysis)
+			paths = append(paths, analysis.Path)
+		} else if only != nil && !only[files[i].relPath] {
+			paths = append(paths, files[i].relPath) // Not analyzed, but imports may resolve to it
+		}
+	}
+	resolver := NewImportResolver(root, paths, configs)
+	resolver.ResolveImports(analyses)
+	resolver.QualifyPackages(analyses)
+
 	return analyses, err
 }
@@ -0,0 +1,570 @@
+package scanner
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/mod/modfile"
+	"gopkg.in/yaml.v3"
+)
+
+// Module kinds recorded in Module.Kind.
+const (
+	ModuleGo     = "go"
+	ModuleNPM    = "npm"
+	ModuleCargo  = "cargo"
+	ModuleGradle = "gradle"
+)
+
+// Module is one unit of a multi-module repository: a Go module, an npm
+// package, a Cargo crate or a Gradle project.
+type Module struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`               // Directory relative to the root ("." for the root)
+	Kind     string   `json:"kind"`               // Module* kind
+	Manifest string   `json:"manifest,omitempty"` // Manifest file relative to the root
+	Deps     []string `json:"deps,omitempty"`     // External dependencies
+	Requires []string `json:"requires,omitempty"` // Names of the workspace modules it depends on
+}
+
+// Workspace is the set of modules found under a project root.
+type Workspace struct {
+	Modules []Module `json:"modules"`
+}
+
+// DiscoverWorkspace finds the modules under root: every go.mod, package.json,
+// Cargo.toml with a [package] and Gradle build file outside ignored paths,
+// plus the members declared by go.work, npm/yarn "workspaces",
+// pnpm-workspace.yaml, Cargo [workspace] and settings.gradle. A module's
+// dependencies on other modules of the workspace become Requires; the rest
+// are its external Deps.
+func DiscoverWorkspace(root string, ignore *IgnoreMatcher) *Workspace {
+	d := &discovery{root: root, ignore: ignore, modules: make(map[string]*Module), visited: make(map[string]bool)}
+
+	WalkFiles(root, WalkOptions{Ignore: ignore}, func(absPath, relPath string, info os.FileInfo) error {
+		dir := filepath.ToSlash(filepath.Dir(relPath))
+		switch info.Name() {
+		case "go.mod":
+			d.goModule(dir)
+		case "go.work":
+			d.goWork(dir)
+		case "package.json":
+			d.npmPackage(dir)
+		case "pnpm-workspace.yaml":
+			d.pnpmWorkspace(dir)
+		case "Cargo.toml":
+			d.cargoManifest(dir)
+		case "settings.gradle", "settings.gradle.kts":
+			d.gradleSettings(dir, info.Name())
+		case "build.gradle", "build.gradle.kts":
+			d.gradleProject(dir, "")
+		}
+		return nil
+	})
+
+	ws := &Workspace{}
+	for _, m := range d.modules {
+		ws.Modules = append(ws.Modules, *m)
+	}
+	sort.Slice(ws.Modules, func(i, j int) bool { return ws.Modules[i].Path < ws.Modules[j].Path })
+	ws.link(d)
+	return ws
+}
+
+// ModuleOf returns the innermost module containing the root-relative path,
+// or nil when no module does.
+func (w *Workspace) ModuleOf(relPath string) *Module {
+	if w == nil {
+		return nil
+	}
+	p := filepath.ToSlash(filepath.Clean(relPath))
+	var best *Module
+	for i := range w.Modules {
+		m := &w.Modules[i]
+		if containsPath(m.Path, p) && (best == nil || len(m.Path) > len(best.Path)) {
+			best = m
+		}
+	}
+	return best
+}
+
+// Lookup returns the module with the given name or directory, or nil.
+func (w *Workspace) Lookup(name string) *Module {
+	if w == nil {
+		return nil
+	}
+	dir := filepath.ToSlash(filepath.Clean(name))
+	for i := range w.Modules {
+		if w.Modules[i].Name == name {
+			return &w.Modules[i]
+		}
+	}
+	for i := range w.Modules {
+		if w.Modules[i].Path == dir {
+			return &w.Modules[i]
+		}
+	}
+	return nil
+}
+
+// HasModule reports whether a module of the workspace is named name.
+func (w *Workspace) HasModule(name string) bool {
+	if w == nil {
+		return false
+	}
+	for _, m := range w.Modules {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the module names in path order.
+func (w *Workspace) Names() []string {
+	if w == nil {
+		return nil
+	}
+	names := make([]string, len(w.Modules))
+	for i, m := range w.Modules {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// Contains reports whether the root-relative path belongs to module m
+// rather than to a module nested inside it.
+func (w *Workspace) Contains(m *Module, relPath string) bool {
+	return m != nil && w.ModuleOf(relPath) == m
+}
+
+// containsPath reports whether the slash-separated path p is dir or inside it.
+func containsPath(dir, p string) bool {
+	return dir == "." || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// discovery collects modules by directory while the tree is walked.
+type discovery struct {
+	root    string
+	ignore  *IgnoreMatcher
+	modules map[string]*Module // Root-relative directory -> module
+	visited map[string]bool    // Manifests read, found by the walk or a workspace file
+
+	goReplaces map[string][]string // Module dir -> directories of local replace targets
+	cargoPaths map[string][]string // Module dir -> directories of path dependencies
+	gradleDeps map[string][]string // Module dir -> Gradle project paths it depends on
+	gradleDirs map[string]string   // Gradle project path (":a:b") -> directory
+}
+
+// add records a module, keeping the first manifest found for a directory.
+func (d *discovery) add(m *Module) *Module {
+	if existing := d.modules[m.Path]; existing != nil {
+		return existing
+	}
+	if m.Name == "" {
+		m.Name = path.Base(m.Path)
+		if m.Path == "." {
+			m.Name = filepath.Base(d.root)
+		}
+	}
+	d.modules[m.Path] = m
+	return m
+}
+
+func (d *discovery) read(dir, name string) ([]byte, bool) {
+	data, err := os.ReadFile(filepath.Join(d.root, filepath.FromSlash(dir), name))
+	return data, err == nil
+}
+
+// readOnce reads a manifest the first time it is asked for; workspace
+// files may list members the walk finds too, or themselves.
+func (d *discovery) readOnce(dir, name string) ([]byte, bool) {
+	key := path.Join(dir, name)
+	if d.visited[key] {
+		return nil, false
+	}
+	d.visited[key] = true
+	return d.read(dir, name)
+}
+
+// goModule records the Go module of dir/go.mod with its requirements.
+func (d *discovery) goModule(dir string) {
+	data, ok := d.readOnce(dir, "go.mod")
+	if !ok {
+		return
+	}
+	// ParseLax drops replace directives; it is only the fallback for files
+	// with directives newer than this parser
+	f, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		f, err = modfile.ParseLax("go.mod", data, nil)
+	}
+	if err != nil || f.Module == nil {
+		return
+	}
+	m := &Module{Name: f.Module.Mod.Path, Path: dir, Kind: ModuleGo, Manifest: path.Join(dir, "go.mod")}
+	for _, r := range f.Require {
+		m.Deps = append(m.Deps, r.Mod.Path)
+	}
+	for _, r := range f.Replace {
+		if modfile.IsDirectoryPath(r.New.Path) {
+			if d.goReplaces == nil {
+				d.goReplaces = make(map[string][]string)
+			}
+			d.goReplaces[dir] = append(d.goReplaces[dir], path.Join(dir, filepath.ToSlash(r.New.Path)))
+		}
+	}
+	d.add(m)
+}
+
+// goWork records the modules a go.work file uses.
+func (d *discovery) goWork(dir string) {
+	data, ok := d.read(dir, "go.work")
+	if !ok {
+		return
+	}
+	f, err := modfile.ParseWork("go.work", data, nil)
+	if err != nil {
+		return
+	}
+	for _, use := range f.Use {
+		d.goModule(path.Join(dir, filepath.ToSlash(use.Path)))
+	}
+}
+
+// packageJSON is the part of package.json that discovery reads.
+type packageJSON struct {
+	Name                 string            `json:"name"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	Workspaces           json.RawMessage   `json:"workspaces"`
+}
+
+// npmPackage records dir/package.json and the members of its workspaces.
+func (d *discovery) npmPackage(dir string) {
+	data, ok := d.readOnce(dir, "package.json")
+	if !ok {
+		return
+	}
+	var pkg packageJSON
+	if json.Unmarshal(data, &pkg) != nil {
+		return
+	}
+	m := &Module{Name: pkg.Name, Path: dir, Kind: ModuleNPM, Manifest: path.Join(dir, "package.json")}
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDependencies, pkg.OptionalDependencies} {
+		for name := range deps {
+			m.Deps = append(m.Deps, name)
+		}
+	}
+	sort.Strings(m.Deps)
+	m.Deps = dedupe(m.Deps)
+	d.add(m)
+
+	// "workspaces": ["packages/*"] (npm, yarn) or {"packages": [...]} (yarn classic)
+	var globs []string
+	if json.Unmarshal(pkg.Workspaces, &globs) != nil {
+		var ws struct {
+			Packages []string `json:"packages"`
+		}
+		json.Unmarshal(pkg.Workspaces, &ws)
+		globs = ws.Packages
+	}
+	for _, member := range d.expandMembers(dir, globs) {
+		d.npmPackage(member)
+	}
+}
+
+// pnpmWorkspace records the packages listed by pnpm-workspace.yaml.
+func (d *discovery) pnpmWorkspace(dir string) {
+	data, ok := d.read(dir, "pnpm-workspace.yaml")
+	if !ok {
+		return
+	}
+	var ws struct {
+		Packages []string `yaml:"packages"`
+	}
+	if yaml.Unmarshal(data, &ws) != nil {
+		return
+	}
+	for _, member := range d.expandMembers(dir, ws.Packages) {
+		d.npmPackage(member)
+	}
+}
+
+// cargoToml is the part of Cargo.toml that discovery reads.
+type cargoToml struct {
+	Package *struct {
+		Name string `toml:"name"`
+	} `toml:"package"`
+	Workspace *struct {
+		Members []string `toml:"members"`
+		Exclude []string `toml:"exclude"`
+	} `toml:"workspace"`
+	Dependencies      map[string]interface{} `toml:"dependencies"`
+	DevDependencies   map[string]interface{} `toml:"dev-dependencies"`
+	BuildDependencies map[string]interface{} `toml:"build-dependencies"`
+	Target            map[string]struct {
+		Dependencies    map[string]interface{} `toml:"dependencies"`
+		DevDependencies map[string]interface{} `toml:"dev-dependencies"`
+	} `toml:"target"`
+}
+
+// cargoManifest records the crate of dir/Cargo.toml, if it has a
+// [package], and the members of its [workspace].
+func (d *discovery) cargoManifest(dir string) {
+	data, ok := d.readOnce(dir, "Cargo.toml")
+	if !ok {
+		return
+	}
+	var manifest cargoToml
+	if _, err := toml.Decode(string(data), &manifest); err != nil {
+		return
+	}
+
+	if manifest.Package != nil {
+		m := &Module{Name: manifest.Package.Name, Path: dir, Kind: ModuleCargo, Manifest: path.Join(dir, "Cargo.toml")}
+		tables := []map[string]interface{}{manifest.Dependencies, manifest.DevDependencies, manifest.BuildDependencies}
+		for _, target := range manifest.Target {
+			tables = append(tables, target.Dependencies, target.DevDependencies)
+		}
+		for _, deps := range tables {
+			for name, spec := range deps {
+				// A dependency on a local crate: { path = "../core" }
+				if table, ok := spec.(map[string]interface{}); ok {
+					if p, ok := table["path"].(string); ok {
+						if d.cargoPaths == nil {
+							d.cargoPaths = make(map[string][]string)
+						}
+						d.cargoPaths[dir] = append(d.cargoPaths[dir], path.Join(dir, filepath.ToSlash(p)))
+					}
+					if pkg, ok := table["package"].(string); ok {
+						name = pkg // Renamed dependency
+					}
+				}
+				m.Deps = append(m.Deps, name)
+			}
+		}
+		sort.Strings(m.Deps)
+		m.Deps = dedupe(m.Deps)
+		d.add(m)
+	}
+
+	if manifest.Workspace != nil {
+		excluded := make(map[string]bool)
+		for _, member := range d.expandMembers(dir, manifest.Workspace.Exclude) {
+			excluded[member] = true
+		}
+		for _, member := range d.expandMembers(dir, manifest.Workspace.Members) {
+			if !excluded[member] {
+				d.cargoManifest(member)
+			}
+		}
+	}
+}
+
+var (
+	gradleRootName = regexp.MustCompile(`rootProject\.name\s*=\s*["']([^"']+)["']`)
+	gradleInclude  = regexp.MustCompile(`(?m)^\s*include\b(.*)$`)
+	gradleQuoted   = regexp.MustCompile(`["']([^"']+)["']`)
+	gradleProject  = regexp.MustCompile(`project\(\s*(?:path\s*[:=]\s*)?["'](:[^"']*)["']`)
+	gradleCoords   = regexp.MustCompile(`(?m)^\s*(?:implementation|api|compileOnly|runtimeOnly|testImplementation|testRuntimeOnly|annotationProcessor|kapt|ksp|compile|testCompile)\s*\(?\s*["']([^"':]+):([^"':]+)(?::[^"']*)?["']`)
+)
+
+// gradleSettings records the root project and the projects a settings
+// file includes. include(":a:b") is the project in directory a/b.
+func (d *discovery) gradleSettings(dir, name string) {
+	data, ok := d.read(dir, name)
+	if !ok {
+		return
+	}
+	content := string(data)
+	if d.gradleDirs == nil {
+		d.gradleDirs = make(map[string]string)
+	}
+
+	rootName := ""
+	if match := gradleRootName.FindStringSubmatch(content); match != nil {
+		rootName = match[1]
+	}
+	d.gradleDirs[":"] = dir
+	d.gradleProject(dir, rootName)
+
+	for _, line := range gradleInclude.FindAllStringSubmatch(content, -1) {
+		for _, quoted := range gradleQuoted.FindAllStringSubmatch(line[1], -1) {
+			project := quoted[1]
+			if !strings.HasPrefix(project, ":") {
+				project = ":" + project
+			}
+			memberDir := path.Join(dir, strings.ReplaceAll(strings.TrimPrefix(project, ":"), ":", "/"))
+			d.gradleDirs[project] = memberDir
+			d.gradleProject(memberDir, "")
+		}
+	}
+}
+
+// gradleProject records the Gradle project in dir with the dependencies of
+// its build file.
+func (d *discovery) gradleProject(dir, name string) {
+	m := &Module{Name: name, Path: dir, Kind: ModuleGradle}
+	for _, file := range []string{"build.gradle", "build.gradle.kts"} {
+		data, ok := d.read(dir, file)
+		if !ok {
+			continue
+		}
+		m.Manifest = path.Join(dir, file)
+		content := string(data)
+		for _, match := range gradleCoords.FindAllStringSubmatch(content, -1) {
+			m.Deps = append(m.Deps, match[1]+":"+match[2])
+		}
+		for _, match := range gradleProject.FindAllStringSubmatch(content, -1) {
+			if d.gradleDeps == nil {
+				d.gradleDeps = make(map[string][]string)
+			}
+			d.gradleDeps[dir] = append(d.gradleDeps[dir], match[1])
+		}
+		break
+	}
+	if m.Manifest == "" && d.gradleDirs[":"] == "" {
+		return // Neither a build file nor part of a settings file
+	}
+	sort.Strings(m.Deps)
+	m.Deps = dedupe(m.Deps)
+
+	existing := d.modules[dir]
+	if existing != nil && existing.Kind == ModuleGradle {
+		// Found again through settings.gradle: keep the build file's findings
+		if name != "" {
+			existing.Name = name
+		}
+		return
+	}
+	if name == "" && dir != "." {
+		m.Name = path.Base(dir)
+	}
+	d.add(m)
+}
+
+// expandMembers returns the directories under dir matching workspace member
+// globs ("packages/*", "crates/**"). Patterns starting with "!" are
+// exclusions.
+func (d *discovery) expandMembers(dir string, globs []string) []string {
+	matched := make(map[string]bool)
+	for _, glob := range globs {
+		exclude := strings.HasPrefix(glob, "!")
+		glob = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(glob, "!"), "./"), "/")
+		for _, member := range d.globDirs(path.Join(dir, glob)) {
+			if exclude {
+				delete(matched, member)
+			} else {
+				matched[member] = true
+			}
+		}
+	}
+	members := make([]string, 0, len(matched))
+	for member := range matched {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// globDirs returns the root-relative directories matching a slash
+// pattern, where a "**" component matches any number of directories.
+func (d *discovery) globDirs(pattern string) []string {
+	if !strings.Contains(pattern, "**") {
+		matches, _ := filepath.Glob(filepath.Join(d.root, filepath.FromSlash(pattern)))
+		var dirs []string
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				if rel, err := filepath.Rel(d.root, match); err == nil && !d.ignore.Match(rel, true) {
+					dirs = append(dirs, filepath.ToSlash(rel))
+				}
+			}
+		}
+		return dirs
+	}
+
+	// Expand "**" by walking below the part of the pattern before it
+	prefix := pattern[:strings.Index(pattern, "**")]
+	re := regexp.MustCompile("^" + globRegexp(pattern) + "$")
+	var dirs []string
+	base := filepath.Join(d.root, filepath.FromSlash(prefix))
+	filepath.Walk(base, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(d.root, p)
+		if err != nil {
+			return nil
+		}
+		if d.ignore.Match(rel, true) {
+			return filepath.SkipDir
+		}
+		if rel = filepath.ToSlash(rel); re.MatchString(rel) {
+			dirs = append(dirs, rel)
+		}
+		return nil
+	})
+	return dirs
+}
+
+// link splits each module's dependencies into Requires (other modules of
+// the workspace) and external Deps.
+func (w *Workspace) link(d *discovery) {
+	byName := make(map[string]map[string]string) // Kind -> name -> module name
+	byDir := make(map[string]string)             // Directory -> module name
+	for _, m := range w.Modules {
+		if byName[m.Kind] == nil {
+			byName[m.Kind] = make(map[string]string)
+		}
+		byName[m.Kind][m.Name] = m.Name
+		byDir[m.Path] = m.Name
+	}
+
+	for i := range w.Modules {
+		m := &w.Modules[i]
+		requires := make(map[string]bool)
+		var external []string
+		for _, dep := range m.Deps {
+			if name, ok := byName[m.Kind][dep]; ok && name != m.Name {
+				requires[name] = true
+			} else {
+				external = append(external, dep)
+			}
+		}
+
+		var localDirs []string
+		switch m.Kind {
+		case ModuleGo:
+			localDirs = d.goReplaces[m.Path]
+		case ModuleCargo:
+			localDirs = d.cargoPaths[m.Path]
+		case ModuleGradle:
+			for _, project := range d.gradleDeps[m.Path] {
+				if dir, ok := d.gradleDirs[project]; ok {
+					localDirs = append(localDirs, dir)
+				}
+			}
+		}
+		for _, dir := range localDirs {
+			if name, ok := byDir[path.Clean(dir)]; ok && name != m.Name {
+				requires[name] = true
+			}
+		}
+
+		m.Deps = external
+		m.Requires = nil
+		for name := range requires {
+			m.Requires = append(m.Requires, name)
+		}
+		sort.Strings(m.Requires)
+	}
+}
@@ -0,0 +1,171 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiscoverWorkspace(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []Module
+	}{
+		{
+			name: "go.work",
+			files: map[string]string{
+				"go.work":          "go 1.22\n\nuse (\n\t./api\n\t./cmd\n\t./build/gen\n)\n",
+				"api/go.mod":       "module example.com/api\n\ngo 1.22\n\nrequire github.com/pkg/errors v0.9.1\n",
+				"cmd/go.mod":       "module example.com/cmd\n\ngo 1.22\n\nrequire (\n\texample.com/api v0.0.0\n\tgithub.com/spf13/cobra v1.8.0\n)\n\nreplace example.com/lib => ../lib\n",
+				"lib/go.mod":       "module example.com/lib\n\ngo 1.22\n",
+				"build/gen/go.mod": "module example.com/gen\n\ngo 1.22\n",
+				"vendor/x/go.mod":  "module example.com/x\n",
+				"bad/go.mod":       "this is not a go.mod\n",
+				"next/go.mod":      "module example.com/next\n\ngo 1.22\n\nfuture directive\n\nrequire golang.org/x/text v0.14.0\n",
+			},
+			want: []Module{
+				{Name: "example.com/api", Path: "api", Kind: ModuleGo, Manifest: "api/go.mod", Deps: []string{"github.com/pkg/errors"}},
+				{Name: "example.com/gen", Path: "build/gen", Kind: ModuleGo, Manifest: "build/gen/go.mod"}, // Used, though in an ignored directory
+				{Name: "example.com/cmd", Path: "cmd", Kind: ModuleGo, Manifest: "cmd/go.mod", Deps: []string{"github.com/spf13/cobra"}, Requires: []string{"example.com/api", "example.com/lib"}},
+				{Name: "example.com/lib", Path: "lib", Kind: ModuleGo, Manifest: "lib/go.mod"},
+				{Name: "example.com/next", Path: "next", Kind: ModuleGo, Manifest: "next/go.mod", Deps: []string{"golang.org/x/text"}}, // Unknown directives are skipped
+			},
+		},
+		{
+			name: "npm workspaces",
+			files: map[string]string{
+				"package.json":                    `{"name": "mono", "private": true, "workspaces": ["packages/*", "!packages/legacy"], "devDependencies": {"typescript": "^5"}}`,
+				"packages/ui/package.json":        `{"name": "@mono/ui", "dependencies": {"react": "^18", "@mono/core": "*"}, "peerDependencies": {"react": "^18"}}`,
+				"packages/core/package.json":      `{"name": "@mono/core", "optionalDependencies": {"fsevents": "^2"}}`,
+				"packages/broken/package.json":    `{"name": `,
+				"node_modules/react/package.json": `{"name": "react"}`,
+			},
+			want: []Module{
+				{Name: "mono", Path: ".", Kind: ModuleNPM, Manifest: "package.json", Deps: []string{"typescript"}},
+				{Name: "@mono/core", Path: "packages/core", Kind: ModuleNPM, Manifest: "packages/core/package.json", Deps: []string{"fsevents"}},
+				{Name: "@mono/ui", Path: "packages/ui", Kind: ModuleNPM, Manifest: "packages/ui/package.json", Deps: []string{"react"}, Requires: []string{"@mono/core"}},
+			},
+		},
+		{
+			name: "yarn classic workspaces",
+			files: map[string]string{
+				"package.json":                 `{"name": "root", "workspaces": {"packages": ["apps/**"], "nohoist": ["**/expo"]}}`,
+				"apps/web/package.json":        `{"name": "web", "dependencies": {"shared": "1.0.0"}}`,
+				"apps/lib/shared/package.json": `{"name": "shared"}`,
+			},
+			want: []Module{
+				{Name: "root", Path: ".", Kind: ModuleNPM, Manifest: "package.json"},
+				{Name: "shared", Path: "apps/lib/shared", Kind: ModuleNPM, Manifest: "apps/lib/shared/package.json"},
+				{Name: "web", Path: "apps/web", Kind: ModuleNPM, Manifest: "apps/web/package.json", Requires: []string{"shared"}},
+			},
+		},
+		{
+			name: "pnpm workspace",
+			files: map[string]string{
+				"pnpm-workspace.yaml":     "packages:\n  - 'tools/*'\n  - '!tools/skip'\n",
+				"tools/lint/package.json": `{"name": "lint", "devDependencies": {"eslint": "^9", "fmt": "workspace:*"}}`,
+				"tools/fmt/package.json":  `{"name": "fmt"}`,
+			},
+			want: []Module{
+				{Name: "fmt", Path: "tools/fmt", Kind: ModuleNPM, Manifest: "tools/fmt/package.json"},
+				{Name: "lint", Path: "tools/lint", Kind: ModuleNPM, Manifest: "tools/lint/package.json", Deps: []string{"eslint"}, Requires: []string{"fmt"}},
+			},
+		},
+		{
+			name: "cargo workspace",
+			files: map[string]string{
+				"Cargo.toml":                     "[workspace]\nmembers = [\"crates/*\"]\nexclude = [\"crates/experimental\"]\n",
+				"crates/core/Cargo.toml":         "[package]\nname = \"core\"\n\n[dependencies]\nserde = { version = \"1\", features = [\"derive\"] }\n",
+				"crates/cli/Cargo.toml":          "[package]\nname = \"cli\"\n\n[dependencies]\nclap = \"4\"\nengine = { path = \"../core\" }\nlog = { package = \"tracing\", version = \"0.1\" }\n\n[dev-dependencies]\ninsta = \"1\"\n\n[build-dependencies]\ncc = \"1\"\n\n[target.'cfg(unix)'.dependencies]\nlibc = \"0.2\"\n",
+				"crates/experimental/Cargo.toml": "[package]\nname = \"experimental\"\n",
+				"crates/broken/Cargo.toml":       "[package\nname = ",
+			},
+			want: []Module{
+				{Name: "cli", Path: "crates/cli", Kind: ModuleCargo, Manifest: "crates/cli/Cargo.toml", Deps: []string{"cc", "clap", "engine", "insta", "libc", "tracing"}, Requires: []string{"core"}},
+				{Name: "core", Path: "crates/core", Kind: ModuleCargo, Manifest: "crates/core/Cargo.toml", Deps: []string{"serde"}},
+				{Name: "experimental", Path: "crates/experimental", Kind: ModuleCargo, Manifest: "crates/experimental/Cargo.toml"}, // Excluded, but found by the walk
+			},
+		},
+		{
+			name: "gradle settings",
+			files: map[string]string{
+				"settings.gradle":           "rootProject.name = 'shop'\ninclude ':app', 'lib:util'\ninclude(\":docs\")\n",
+				"app/build.gradle":          "dependencies {\n    implementation project(':lib:util')\n    implementation 'com.google.guava:guava:32.1.0-jre'\n    testImplementation \"junit:junit:4.13.2\"\n}\n",
+				"lib/util/build.gradle.kts": "dependencies {\n    api(\"org.slf4j:slf4j-api:2.0.9\")\n    implementation(project(path = \":docs\"))\n}\n",
+			},
+			want: []Module{
+				{Name: "shop", Path: ".", Kind: ModuleGradle},
+				{Name: "app", Path: "app", Kind: ModuleGradle, Manifest: "app/build.gradle", Deps: []string{"com.google.guava:guava", "junit:junit"}, Requires: []string{"util"}},
+				{Name: "docs", Path: "docs", Kind: ModuleGradle},
+				{Name: "util", Path: "lib/util", Kind: ModuleGradle, Manifest: "lib/util/build.gradle.kts", Deps: []string{"org.slf4j:slf4j-api"}, Requires: []string{"docs"}},
+			},
+		},
+		{
+			name: "standalone gradle build",
+			files: map[string]string{
+				"tool/build.gradle.kts": "dependencies {\n    implementation(\"com.squareup.okio:okio:3.6.0\")\n}\n",
+			},
+			want: []Module{
+				{Name: "tool", Path: "tool", Kind: ModuleGradle, Manifest: "tool/build.gradle.kts", Deps: []string{"com.squareup.okio:okio"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateGit(t)
+			root := t.TempDir()
+			writeTree(t, root, tt.files)
+
+			ws := DiscoverWorkspace(root, NewIgnoreMatcher(root, nil, nil))
+			if !reflect.DeepEqual(ws.Modules, tt.want) {
+				t.Errorf("modules:\n got %+v\nwant %+v", ws.Modules, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspaceLookups(t *testing.T) {
+	ws := &Workspace{Modules: []Module{
+		{Name: "root", Path: ".", Kind: ModuleNPM},
+		{Name: "@app/web", Path: "apps/web", Kind: ModuleNPM},
+		{Name: "@app/web-admin", Path: "apps/web/admin", Kind: ModuleNPM},
+	}}
+
+	tests := []struct {
+		path, want string
+	}{
+		{"README.md", "root"},
+		{"apps/web/src/index.ts", "@app/web"},
+		{"apps/web", "@app/web"},
+		{"apps/webapp/index.ts", "root"},
+		{"apps/web/admin/main.ts", "@app/web-admin"},
+		{"./apps/web/../web/admin/x.ts", "@app/web-admin"},
+	}
+	for _, tt := range tests {
+		if m := ws.ModuleOf(tt.path); m == nil || m.Name != tt.want {
+			t.Errorf("ModuleOf(%q) = %+v, want %s", tt.path, m, tt.want)
+		}
+	}
+
+	web := ws.Lookup("@app/web")
+	if web == nil || ws.Lookup("apps/web/") != web {
+		t.Errorf("Lookup by name and directory disagree: %+v", web)
+	}
+	if ws.Lookup("missing") != nil {
+		t.Error("Lookup(missing) found a module")
+	}
+	if !ws.Contains(web, "apps/web/index.ts") || ws.Contains(web, "apps/web/admin/index.ts") {
+		t.Error("Contains doesn't stop at nested modules")
+	}
+	if !ws.HasModule("root") || ws.HasModule("apps/web") {
+		t.Error("HasModule doesn't match names only")
+	}
+	if want := []string{"root", "@app/web", "@app/web-admin"}; !reflect.DeepEqual(ws.Names(), want) {
+		t.Errorf("Names = %v, want %v", ws.Names(), want)
+	}
+
+	var none *Workspace
+	if none.ModuleOf("a.go") != nil || none.Lookup("a") != nil || none.HasModule("a") || none.Names() != nil {
+		t.Error("nil workspace has modules")
+	}
+}
@@ -0,0 +1,148 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"codemap/graph"
+)
+
+// StatusFile is the lock file a running watcher keeps in the graph directory.
+const StatusFile = "watch.json"
+
+// Status is the content of the lock file: which process keeps the index
+// fresh and when it last updated it.
+type Status struct {
+	PID     int       `json:"pid"`
+	Root    string    `json:"root"`
+	Graph   string    `json:"graph"` // Graph file kept fresh
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"` // Last index update; zero before the first
+	Pending bool      `json:"pending"` // Changes seen but not yet indexed
+	Nodes   int       `json:"nodes"`
+	Edges   int       `json:"edges"`
+	Vectors int       `json:"vectors,omitempty"`
+}
+
+// Maintains reports whether the watcher keeps the graph file at graphPath
+// fresh. Lock files of watchers predating Graph name the default path.
+func (s *Status) Maintains(graphPath string) bool {
+	if abs, err := filepath.Abs(graphPath); err == nil {
+		graphPath = abs
+	}
+	if s.Graph == "" {
+		return graphPath == graph.GraphPath(s.Root)
+	}
+	return graphPath == s.Graph
+}
+
+// Fresh reports whether the index on disk reflects every change the
+// watcher has seen.
+func (s *Status) Fresh() bool {
+	return !s.Pending
+}
+
+// StatusPath returns the lock file path for a project root.
+func StatusPath(root string) string {
+	return filepath.Join(root, graph.DefaultGraphDir, StatusFile)
+}
+
+// Lock is held by the watcher of a project while it runs.
+type Lock struct {
+	path   string
+	status Status
+}
+
+// Acquire takes the watch lock of root for a watcher keeping the graph
+// file at graphPath fresh, replacing the lock of a watcher that died
+// without releasing it. It fails when another live process holds the lock.
+func Acquire(root, graphPath string) (*Lock, error) {
+	if err := graph.EnsureDir(root); err != nil {
+		return nil, err
+	}
+	l := &Lock{
+		path:   StatusPath(root),
+		status: Status{PID: os.Getpid(), Root: root, Graph: graphPath, Started: time.Now()},
+	}
+	data, err := json.MarshalIndent(l.status, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(data)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(l.path)
+				return nil, err
+			}
+			return l, nil
+		}
+		if !errors.Is(err, os.ErrExist) || attempt > 0 {
+			return nil, err
+		}
+		if s := Running(root); s != nil {
+			return nil, fmt.Errorf("already watched by process %d", s.PID)
+		}
+		os.Remove(l.path) // Left behind by a watcher that is gone
+	}
+}
+
+// Update applies fn to the lock's status and writes it.
+func (l *Lock) Update(fn func(s *Status)) error {
+	fn(&l.status)
+	data, err := json.MarshalIndent(l.status, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// Release removes the lock file.
+func (l *Lock) Release() error {
+	return os.Remove(l.path)
+}
+
+// Running returns the status of the watcher keeping root's index fresh, or
+// nil when no live watcher holds the lock.
+func Running(root string) *Status {
+	data, err := os.ReadFile(StatusPath(root))
+	if err != nil {
+		return nil
+	}
+	var s Status
+	if err := json.Unmarshal(data, &s); err != nil || !processAlive(s.PID) {
+		return nil
+	}
+	return &s
+}
+
+// processAlive reports whether a process with the given ID exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true // FindProcess fails for processes that have exited
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,83 @@
+package watch
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"codemap/graph"
+)
+
+func TestLock(t *testing.T) {
+	root := t.TempDir()
+	graphPath := filepath.Join(t.TempDir(), "graph.gob")
+	if Running(root) != nil {
+		t.Fatal("Running before Acquire")
+	}
+
+	lock, err := Acquire(root, graphPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(root, graphPath); err == nil {
+		t.Error("second Acquire succeeded while the lock is held")
+	}
+	if err := lock.Update(func(s *Status) { s.Pending, s.Nodes = true, 7 }); err != nil {
+		t.Fatal(err)
+	}
+	s := Running(root)
+	if s == nil {
+		t.Fatal("Running = nil while the lock is held")
+	}
+	if s.PID != os.Getpid() || s.Nodes != 7 || s.Fresh() {
+		t.Errorf("status = %+v, want this process, 7 nodes, pending", s)
+	}
+	if !s.Maintains(graphPath) || s.Maintains(graph.GraphPath(root)) {
+		t.Errorf("status maintains %s, want only %s", s.Graph, graphPath)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if Running(root) != nil {
+		t.Error("Running after Release")
+	}
+}
+
+func TestLockReplacesStaleWatcher(t *testing.T) {
+	// The lock of a watcher that exited without releasing it
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := graph.EnsureDir(root); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(Status{PID: cmd.Process.Pid, Root: root})
+	if err := os.WriteFile(StatusPath(root), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := Running(root); s != nil {
+		t.Fatalf("Running = %+v for an exited process", s)
+	}
+	lock, err := Acquire(root, graph.GraphPath(root))
+	if err != nil {
+		t.Fatalf("Acquire over a stale lock: %v", err)
+	}
+	defer lock.Release()
+	if s := Running(root); s == nil || s.PID != os.Getpid() {
+		t.Errorf("Running = %+v, want this process", s)
+	}
+}
+
+func TestStatusMaintainsDefaultGraph(t *testing.T) {
+	// Lock files written before Graph was recorded
+	s := &Status{Root: "/repo"}
+	if !s.Maintains(graph.GraphPath("/repo")) || s.Maintains("/tmp/graph.gob") {
+		t.Error("a status without Graph should maintain the default graph only")
+	}
+}
@@ -0,0 +1,228 @@
+// Package watch keeps a project's knowledge graph fresh. A Watcher reports
+// debounced batches of files changed under a project root, and a Lock
+// records the running daemon in .codemap/watch.json so that the CLI and the
+// MCP server can tell that the index on disk is being kept up to date.
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"codemap/graph"
+	"codemap/scanner"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is how long a burst of changes must stay quiet before it
+// is reported.
+const DefaultDebounce = 500 * time.Millisecond
+
+// Options configures a Watcher.
+type Options struct {
+	// Ignore decides which paths scanning skips (nil skips scanner.IgnoredDirs only)
+	Ignore *scanner.IgnoreMatcher
+
+	// Debounce is the quiet period before a batch is reported (DefaultDebounce when zero)
+	Debounce time.Duration
+
+	// OnPending, if set, is called when the first change of a batch arrives
+	OnPending func()
+
+	// Reload, if set, returns the rules replacing Ignore once an ignore
+	// file (.gitignore, .codemapignore or .git/info/exclude) has changed
+	Reload func() *scanner.IgnoreMatcher
+}
+
+// Watcher watches every directory under a root that scanning doesn't skip:
+// ignored directory names, ignore file matches and the .codemap directory.
+type Watcher struct {
+	root    string
+	opts    Options
+	fs      *fsnotify.Watcher
+	exclude string // .git/info/exclude, watched outside the skipped .git
+}
+
+// New starts watching the tree under root.
+func New(root string, opts Options) (*Watcher, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{root: root, opts: opts, fs: fsw}
+	if err := w.addTree(root, nil); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	w.watchExclude()
+	return w, nil
+}
+
+// watchExclude watches the directory of the ignore rules' .git/info/exclude,
+// if it exists.
+func (w *Watcher) watchExclude() {
+	w.exclude = w.opts.Ignore.ExcludeFile()
+	if w.exclude != "" {
+		w.fs.Add(filepath.Dir(w.exclude)) // An error means there is no info directory
+	}
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+// Run calls changed with the sorted relative paths touched in each burst
+// of changes until ctx is done. A touched directory stands for everything
+// under it; files in new directories are reported individually. When the
+// kernel drops events, or an ignore file changes, changed is called with
+// nil: anything may have changed. The ignore rules are reloaded before
+// such a call. changed runs on Run's goroutine, so events arriving
+// meanwhile form the next batch.
+func (w *Watcher) Run(ctx context.Context, changed func(paths []string)) error {
+	pending := make(map[string]bool)
+	overflow := false // Rescan: events were dropped
+	reload := false   // Rescan with new ignore rules
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	touch := func(rel string) {
+		if len(pending) == 0 && !overflow && !reload && w.opts.OnPending != nil {
+			w.opts.OnPending()
+		}
+		if rel != "" {
+			pending[rel] = true
+		}
+		timer.Reset(w.opts.Debounce)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return nil
+			}
+			if event.Name == w.exclude {
+				touch("")
+				reload = true
+				continue
+			}
+			rel, ok := w.relPath(event.Name)
+			info, err := os.Stat(event.Name) // Fails for removed paths, which are reported as files
+			isDir := err == nil && info.IsDir()
+			if !ok || rel == "." || w.ignored(rel, isDir) {
+				continue
+			}
+			if !isDir && scanner.IsIgnoreFile(filepath.Base(rel)) {
+				reload = true
+			}
+			if event.Has(fsnotify.Create) && isDir {
+				// Files may land in a new directory before it is watched
+				w.addTree(event.Name, func(file string) { touch(file) })
+			}
+			if event.Has(fsnotify.Rename) || event.Has(fsnotify.Remove) {
+				w.fs.Remove(event.Name) // Drop the watch of a moved directory; an error means there was none
+			}
+			touch(rel)
+
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return nil
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				touch("")
+				overflow = true
+			}
+
+		case <-timer.C:
+			if reload && w.opts.Reload != nil {
+				w.opts.Ignore = w.opts.Reload()
+				w.addTree(w.root, nil) // Directories the old rules skipped
+				w.watchExclude()
+			}
+			if overflow || reload {
+				changed(nil)
+			} else if len(pending) > 0 {
+				paths := make([]string, 0, len(pending))
+				for p := range pending {
+					paths = append(paths, p)
+				}
+				sort.Strings(paths)
+				changed(paths)
+			}
+			pending = make(map[string]bool)
+			overflow, reload = false, false
+		}
+	}
+}
+
+// addTree watches dir and the directories under it, calling file (when
+// set) with the relative path of every file found.
+func (w *Watcher) addTree(dir string, file func(rel string)) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil // Vanished or unreadable; nothing to watch
+		}
+		rel, ok := w.relPath(path)
+		if !ok {
+			return nil
+		}
+		if w.ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			if file != nil {
+				file(rel)
+			}
+			return nil
+		}
+		if err := w.fs.Add(path); err != nil && path == w.root {
+			return err
+		}
+		return nil
+	})
+}
+
+// ignored reports whether scanning skips rel: the graph directory anywhere
+// in the path or a match of the ignore rules. isDir matters to
+// directory-only patterns.
+func (w *Watcher) ignored(rel string, isDir bool) bool {
+	if rel == "." {
+		return false
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == graph.DefaultGraphDir {
+			return true
+		}
+	}
+	return w.opts.Ignore.Match(rel, isDir)
+}
+
+// relPath returns path relative to the watched root.
+func (w *Watcher) relPath(path string) (string, bool) {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
@@ -0,0 +1,153 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"codemap/scanner"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// startWatcher runs a watcher over root and returns the channel of the
+// batches it reports.
+func startWatcher(t *testing.T, root string, opts Options) (*Watcher, <-chan []string) {
+	t.Helper()
+	if opts.Debounce == 0 {
+		opts.Debounce = 50 * time.Millisecond
+	}
+	w, err := New(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	batches := make(chan []string, 10)
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx, func(paths []string) { batches <- paths })
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		w.Close()
+	})
+	return w, batches
+}
+
+func nextBatch(t *testing.T, batches <-chan []string) []string {
+	t.Helper()
+	select {
+	case paths := <-batches:
+		return paths
+	case <-time.After(5 * time.Second):
+		t.Fatal("no batch reported")
+		return nil
+	}
+}
+
+func noBatch(t *testing.T, batches <-chan []string) {
+	t.Helper()
+	select {
+	case paths := <-batches:
+		t.Fatalf("unexpected batch %q", paths)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherDebounce(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "pkg", "a.go"), "package pkg\n")
+	var pending atomic.Int32
+	_, batches := startWatcher(t, root, Options{
+		Ignore:    scanner.NewIgnoreMatcher(root, nil, nil),
+		Debounce:  200 * time.Millisecond,
+		OnPending: func() { pending.Add(1) },
+	})
+
+	// A burst of writes, including ones in skipped directories, is one batch
+	write(t, filepath.Join(root, "pkg", "a.go"), "package pkg\n\nfunc A() {}\n")
+	write(t, filepath.Join(root, "b.go"), "package main\n")
+	write(t, filepath.Join(root, "node_modules", "x.js"), "")
+	write(t, filepath.Join(root, ".codemap", "graph.gob"), "")
+	write(t, filepath.Join(root, "b.go"), "package main\n\nfunc main() {}\n")
+
+	if got, want := nextBatch(t, batches), []string{"b.go", filepath.Join("pkg", "a.go")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("batch = %q, want %q", got, want)
+	}
+	if n := pending.Load(); n != 1 {
+		t.Errorf("OnPending called %d times, want 1", n)
+	}
+	noBatch(t, batches)
+
+	// Files landing in a new directory are reported individually, whether
+	// or not the directories below it were watched in time
+	write(t, filepath.Join(root, "cmd", "tool", "main.go"), "package main\n")
+	batch := nextBatch(t, batches)
+	if !slices.Contains(batch, "cmd") || !slices.Contains(batch, filepath.Join("cmd", "tool", "main.go")) {
+		t.Errorf("batch = %q, want cmd and cmd/tool/main.go", batch)
+	}
+}
+
+func TestWatcherOverflowRescans(t *testing.T) {
+	root := t.TempDir()
+	w, batches := startWatcher(t, root, Options{})
+
+	w.fs.Errors <- fsnotify.ErrEventOverflow
+	if got := nextBatch(t, batches); got != nil {
+		t.Errorf("batch after overflow = %q, want nil", got)
+	}
+}
+
+func TestWatcherReloadsIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, ".git", "info", "exclude"), "")
+	write(t, filepath.Join(root, "gen", "a.go"), "package gen\n")
+	var reloads atomic.Int32
+	ignore := scanner.NewIgnoreMatcher(root, nil, nil)
+	_, batches := startWatcher(t, root, Options{
+		Ignore: ignore,
+		Reload: func() *scanner.IgnoreMatcher {
+			reloads.Add(1)
+			return scanner.NewIgnoreMatcher(root, nil, nil)
+		},
+	})
+
+	// A changed .gitignore asks for a rescan under the new rules
+	write(t, filepath.Join(root, ".gitignore"), "gen/\n")
+	if got := nextBatch(t, batches); got != nil {
+		t.Errorf("batch after .gitignore change = %q, want nil", got)
+	}
+	if n := reloads.Load(); n != 1 {
+		t.Errorf("Reload called %d times, want 1", n)
+	}
+	write(t, filepath.Join(root, "gen", "a.go"), "package gen\n\nfunc A() {}\n")
+	noBatch(t, batches)
+
+	// So does .git/info/exclude, though .git is skipped
+	write(t, filepath.Join(root, ".git", "info", "exclude"), "*.tmp\n")
+	if got := nextBatch(t, batches); got != nil {
+		t.Errorf("batch after exclude change = %q, want nil", got)
+	}
+	if n := reloads.Load(); n != 2 {
+		t.Errorf("Reload called %d times, want 2", n)
+	}
+	write(t, filepath.Join(root, "a.tmp"), "")
+	noBatch(t, batches)
+}